@@ -0,0 +1,236 @@
+// Command ptouchgo-cups is a CUPS backend and driver printing with ptouchgo, so "lp -d ptouch label.png" prints a label.
+// Install it as the backend and the driver of the ptouchgo scheme and add a queue with a discovered device or address:
+//
+//	install -m 0700 ptouchgo-cups /usr/lib/cups/backend/ptouchgo
+//	install -m 0755 ptouchgo-cups /usr/lib/cups/driver/ptouchgo
+//	lpadmin -p ptouch -E -v ptouchgo:usb -m ptouchgo:PT-P710BT.ppd
+//
+// The device URI is the scheme followed by the address of "ptouchgo -d". The PPD has CUPS convert jobs into
+// raster pages of the tape or media, each page is printed as a label. Connections reading the printer status
+// wait while the cover is open or the tape is out, the ones without it print on the tape of the page size.
+//
+// Run without arguments it lists the printers found like other backends, "ptouchgo-cups list" lists the PPDs
+// and "ptouchgo-cups cat ptouchgo:PT-P710BT.ppd" writes one like CUPS drivers.
+package main
+
+import (
+	"fmt"
+	"image"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ka2n/ptouchgo"
+	"github.com/ka2n/ptouchgo/conn"
+	_ "github.com/ka2n/ptouchgo/conn/ble"
+	_ "github.com/ka2n/ptouchgo/conn/ssh"
+	_ "github.com/ka2n/ptouchgo/conn/usb"
+	"github.com/ka2n/ptouchgo/cups"
+)
+
+// retryInterval is the interval the status is read at while the printer is not ready
+const retryInterval = 5 * time.Second
+
+func main() {
+	scheme := filepath.Base(os.Args[0])
+	args := os.Args[1:]
+	switch {
+	case len(args) == 0:
+		os.Exit(discover(scheme))
+	case len(args) == 1 && args[0] == "list":
+		os.Exit(listPPDs(scheme))
+	case len(args) == 2 && args[0] == "cat":
+		os.Exit(catPPD(scheme, args[1]))
+	case len(args) == 5 || len(args) == 6:
+		os.Exit(backend(scheme, args))
+	}
+	fmt.Fprintf(os.Stderr, "Usage: %s job-id user title copies options [file]\n       %s list\n       %s cat %s:MODEL.ppd\n", scheme, scheme, scheme, scheme)
+	os.Exit(cups.BackendFailed)
+}
+
+// discover lists the printers of conn.List for lpinfo
+func discover(scheme string) int {
+	devices, err := conn.List()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "DEBUG: %v\n", err)
+	}
+	for _, d := range devices {
+		fmt.Println(cups.DeviceLine(scheme, d))
+	}
+	return cups.BackendOK
+}
+
+// listPPDs lists the PPDs of the supported models for the driver interface of CUPS
+func listPPDs(scheme string) int {
+	for _, m := range ptouchgo.Models() {
+		if !m.Supported() {
+			continue
+		}
+		fmt.Printf("%q en \"Brother\" %q %q\n", scheme+":"+cups.PPDName(m), cups.MakeAndModel(m), cups.DeviceID(m))
+	}
+	return cups.BackendOK
+}
+
+// catPPD writes the PPD named like "ptouchgo:PT-P710BT.ppd"
+func catPPD(scheme, name string) int {
+	model := strings.TrimSuffix(strings.TrimPrefix(name, scheme+":"), ".ppd")
+	m, err := ptouchgo.ParseModel(model)
+	if err == nil {
+		err = cups.WritePPD(os.Stdout, m)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %s: %v\n", name, err)
+		return cups.BackendFailed
+	}
+	return cups.BackendOK
+}
+
+// backend prints the raster pages of a job on the printer of $DEVICE_URI
+func backend(scheme string, args []string) int {
+	uri := os.Getenv("DEVICE_URI")
+	address := strings.TrimPrefix(uri, scheme+":")
+	if uri == "" || address == uri {
+		fmt.Fprintf(os.Stderr, "ERROR: the device URI %q is not %s:ADDRESS\n", uri, scheme)
+		return cups.BackendFailed
+	}
+	copies, err := strconv.Atoi(args[3])
+	if err != nil || copies < 1 {
+		copies = 1
+	}
+	in := io.Reader(os.Stdin)
+	if len(args) == 6 {
+		f, err := os.Open(args[5])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+			return cups.BackendFailed
+		}
+		defer f.Close()
+		in = f
+	}
+
+	headers, pages, err := readPages(in)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		return cups.BackendFailed
+	}
+	if len(pages) == 0 {
+		fmt.Fprintln(os.Stderr, "INFO: the job has no pages")
+		return cups.BackendOK
+	}
+
+	ser, err := ptouchgo.Open(address, 0, false)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %s: %v\n", address, err)
+		return cups.BackendRetry
+	}
+	defer ser.Close()
+	if ser.Capabilities.StatusReadback {
+		if err := waitReady(&ser); err != nil {
+			fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+			return cups.BackendRetry
+		}
+	} else {
+		tw := cups.PageTape(headers[0])
+		ser.TapeWidthMM = uint(tw)
+		fmt.Fprintf(os.Stderr, "INFO: the connection can not read the printer status, printing on %s\n", tw)
+	}
+
+	imgs := make([]image.Image, len(pages))
+	for i, page := range pages {
+		imgs[i] = cups.FitPage(ser, headers[i], page)
+	}
+	opts := cups.Options(ser, headers[0], len(imgs)*copies)
+	opts.Copies = copies
+	opts.WaitPrinted = ser.Capabilities.StatusReadback
+	opts.Progress = func(p ptouchgo.Progress) {
+		if p.Waiting {
+			fmt.Fprintln(os.Stderr, "INFO: printing")
+		} else if p.Total > 0 {
+			fmt.Fprintf(os.Stderr, "INFO: sending %d%%\n", 100*p.Sent/p.Total)
+		}
+	}
+	fmt.Fprintf(os.Stderr, "INFO: printing %d labels on %s\n", len(imgs)*copies, address)
+	if err := ser.PrintImages(imgs, opts); err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %v\n", err)
+		return cups.BackendFailed
+	}
+	fmt.Fprintln(os.Stderr, "INFO: ready to print")
+	return cups.BackendOK
+}
+
+// readPages reads the raster pages of a job
+func readPages(in io.Reader) ([]*cups.PageHeader, []image.Image, error) {
+	rr, err := cups.NewRasterReader(in)
+	if err != nil {
+		return nil, nil, err
+	}
+	var headers []*cups.PageHeader
+	var pages []image.Image
+	for {
+		h, img, err := rr.NextPage()
+		if err == io.EOF {
+			return headers, pages, nil
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		headers = append(headers, h)
+		pages = append(pages, img)
+	}
+}
+
+// waitReady reads the status until the printer has no errors and can print on its tape, reporting
+// the printer-state-reasons of the errors meanwhile, and sets the tape on ser. Warnings stay reported.
+func waitReady(ser *ptouchgo.Serial) (err error) {
+	var reported []string
+	defer func() {
+		if err != nil && len(reported) > 0 {
+			fmt.Fprintf(os.Stderr, "STATE: -%s\n", strings.Join(reported, ","))
+		}
+	}()
+	for {
+		if err := ser.Reset(); err != nil {
+			return err
+		}
+		if err := ser.RequestStatus(); err != nil {
+			return err
+		}
+		st, err := ser.ReadStatus()
+		if err != nil {
+			return fmt.Errorf("read status: %w", err)
+		}
+		reasons := cups.StateReasons(st)
+		var useErr error
+		if !cups.Blocking(reasons) {
+			if useErr = ser.UseStatus(st); useErr != nil {
+				reasons = append(reasons, "media-needed-error")
+			}
+		}
+		if changed(reported, reasons) {
+			if len(reported) > 0 {
+				fmt.Fprintf(os.Stderr, "STATE: -%s\n", strings.Join(reported, ","))
+			}
+			if len(reasons) > 0 {
+				fmt.Fprintf(os.Stderr, "STATE: +%s\n", strings.Join(reasons, ","))
+			}
+			reported = reasons
+		}
+		if !cups.Blocking(reasons) {
+			return nil
+		}
+		msg := strings.Join(st.Errors(), ", ")
+		if useErr != nil {
+			msg = useErr.Error()
+		}
+		fmt.Fprintf(os.Stderr, "INFO: waiting for the printer: %s\n", msg)
+		time.Sleep(retryInterval)
+	}
+}
+
+// changed reports whether the reasons differ
+func changed(a, b []string) bool {
+	return strings.Join(a, ",") != strings.Join(b, ",")
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/ka2n/ptouchgo"
+	"github.com/ka2n/ptouchgo/label"
+)
+
+// batchCLI runs "ptouchgo batch", printing a label of the template for each row of a CSV file as one job.
+// The header row names the fields, "{name}" in the template is replaced by the column of the row.
+func batchCLI(args []string) error {
+	fs := newFlagSet("batch", "")
+	templatePath := fs.String("template", "", "Label design file(.yaml, .yml or .json)")
+	csvPath := fs.String("csv", "", `CSV file with a header row naming the fields, "-" reads stdin`)
+	rowRange := fs.String("range", "", `Rows to print like "3", "2-10", "5-" or "-4", counted from 1 after the header`)
+	previewDir := fs.String("dry-preview-dir", "", "Write the raster of each label as PNG into this directory instead of printing")
+	device := addDeviceFlags(fs)
+	modes := addModeFlags(fs)
+	cutEvery := fs.Int("cut-every", defaultCutEvery(), "Cut after every N labels, a value of at least the number of labels cuts after the last one only")
+	fs.Parse(args)
+
+	if *templatePath == "" || *csvPath == "" {
+		return usageError("template and csv required")
+	}
+	if *cutEvery < 1 {
+		return usageError("cut-every must be at least 1")
+	}
+	mode, err := modes.mode()
+	if err != nil {
+		return err
+	}
+	l, err := label.Load(*templatePath)
+	if err != nil {
+		return withExit(exitConvert, err)
+	}
+	rows, err := readRows(*csvPath)
+	if err != nil {
+		return withExit(exitConvert, err)
+	}
+	first, rows, err := selectRows(rows, *rowRange)
+	if err != nil {
+		return err
+	}
+
+	if *previewDir != "" {
+		return previewRows(l, rows, first, *previewDir, device, mode.convert)
+	}
+
+	ser, tw, err := device.connect()
+	if err != nil {
+		return err
+	}
+	defer ser.Close()
+	if l.Tape == 0 {
+		l.Tape = int(tw)
+	} else if ptouchgo.TapeWidth(l.Tape) != tw {
+		return withExit(exitTape, fmt.Errorf("%s is designed for %s tape, %s is used", *templatePath, ptouchgo.TapeWidth(l.Tape), tw))
+	}
+
+	// the rows take their numbers before printing, other jobs printing meanwhile take the next ones
+	seq, err := l.ReserveSequence(len(rows))
+	if err != nil {
+		return err
+	}
+	imgs, err := label.RenderRowsSequence(l, rows, seq)
+	if err != nil {
+		return withExit(exitConvert, err)
+	}
+	names := make([]string, len(rows))
+	for i := range names {
+		names[i] = fmt.Sprintf("row %d", first+i)
+	}
+	j := job{images: imgs, names: names, copies: 1, cutEvery: *cutEvery, mode: mode, progress: showProgress()}
+	res, err := j.print(ser, tw, false)
+	if err != nil {
+		return err
+	}
+	if jsonOutput {
+		res.Device = *device.devicePath
+		return writeJSON(res)
+	}
+	return nil
+}
+
+// previewRows writes the raster of the labels of rows into dir as row-N.png, first is the number of the first row
+func previewRows(l *label.Layout, rows []map[string]string, first int, dir string, device deviceFlags, conv ptouchgo.ConvertOptions) error {
+	if l.Tape == 0 {
+		tw, auto, err := device.tape()
+		if err != nil {
+			return err
+		}
+		if auto {
+			tw = fallbackTape
+		}
+		l.Tape = int(tw)
+	}
+	imgs, err := label.RenderRows(l, rows)
+	if err != nil {
+		return withExit(exitConvert, err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	paths := make([]string, len(imgs))
+	for i, img := range imgs {
+		data, bytesWidth, err := ptouchgo.ConvertImage(img, ptouchgo.TapeWidth(l.Tape), conv)
+		if err != nil {
+			return withExit(exitConvert, fmt.Errorf("row %d: convert label: %w", first+i, err))
+		}
+		paths[i] = filepath.Join(dir, fmt.Sprintf("row-%d.png", first+i))
+		if err := writePNG(paths[i], ptouchgo.RasterImage(data, bytesWidth)); err != nil {
+			return err
+		}
+	}
+
+	if jsonOutput {
+		return writeJSON(struct {
+			Previews []string `json:"previews"`
+		}{paths})
+	}
+	for _, path := range paths {
+		fmt.Println(path)
+	}
+	return nil
+}
+
+// readRows reads the CSV at path, "-" reads stdin, into one map of header names to values for each row
+func readRows(path string) ([]map[string]string, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(records) < 2 {
+		return nil, fmt.Errorf("%s: no rows after the header", path)
+	}
+	header := records[0]
+	rows := make([]map[string]string, len(records)-1)
+	for i, record := range records[1:] {
+		rows[i] = make(map[string]string, len(header))
+		for j, name := range header {
+			rows[i][strings.TrimSpace(name)] = record[j]
+		}
+	}
+	return rows, nil
+}
+
+// selectRows returns the rows of spec like "2-10" and the number of the first one, empty spec selects all
+func selectRows(rows []map[string]string, spec string) (int, []map[string]string, error) {
+	if spec == "" {
+		return 1, rows, nil
+	}
+	from, to := spec, spec
+	if i := strings.Index(spec, "-"); i >= 0 {
+		from, to = spec[:i], spec[i+1:]
+	}
+	first, last := 1, len(rows)
+	var err error
+	if from != "" {
+		if first, err = strconv.Atoi(from); err != nil {
+			return 0, nil, usageError("range %q: %w", spec, err)
+		}
+	}
+	if to != "" {
+		if last, err = strconv.Atoi(to); err != nil {
+			return 0, nil, usageError("range %q: %w", spec, err)
+		}
+	}
+	if first < 1 || last > len(rows) || first > last {
+		return 0, nil, usageError("range %q: only rows 1-%d exist", spec, len(rows))
+	}
+	return first, rows[first-1 : last], nil
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/ka2n/ptouchgo/conn"
+	"github.com/ka2n/ptouchgo/label"
+)
+
+// completeCommand is the hidden command the completion scripts call for the words to offer
+const completeCommand = "__complete"
+
+// completionCLI runs "ptouchgo completion", printing the completion script of a shell
+func completionCLI(args []string) error {
+	fs := newFlagSet("completion", " bash|zsh|fish")
+	fs.Parse(args)
+
+	scripts := map[string]string{"bash": bashCompletion, "zsh": zshCompletion, "fish": fishCompletion}
+	script, ok := scripts[fs.Arg(0)]
+	if fs.NArg() != 1 || !ok {
+		return usageError("shell required: bash, zsh or fish")
+	}
+	fmt.Print(script)
+	return nil
+}
+
+// completeCLI runs "ptouchgo __complete commands|flags <command>|devices|fonts",
+// printing one word per line followed by a tab and its description
+func completeCLI(args []string) error {
+	if len(args) == 0 {
+		return usageError("commands, flags, devices or fonts required")
+	}
+	switch args[0] {
+	case "commands":
+		for _, c := range commands {
+			fmt.Printf("%s\t%s\n", c.name, c.usage)
+		}
+	case "flags":
+		run := printCLI
+		if len(args) > 1 {
+			for _, c := range commands {
+				if c.name == args[1] {
+					run = c.run
+				}
+			}
+		}
+		// newFlagSet prints the flags instead of the usage, the flag package exits after it
+		completingFlags = true
+		return run([]string{"-h"})
+	case "devices":
+		devices, _ := conn.List()
+		for _, d := range devices {
+			fmt.Printf("%s:%s\t%s\n", d.Driver, d.Address, d.Name)
+		}
+	case "fonts":
+		for _, f := range label.Fonts() {
+			fmt.Printf("%s\t%s\n", f.Name, f.Path)
+		}
+	default:
+		return usageError("unknown completion %q", args[0])
+	}
+	return nil
+}
+
+// completingFlags is set by "__complete flags", -h lists the flags for the completion scripts
+var completingFlags bool
+
+func printFlagNames(fs *flag.FlagSet) {
+	fs.VisitAll(func(f *flag.Flag) {
+		desc := f.Usage
+		if i := strings.IndexByte(desc, '\n'); i >= 0 {
+			desc = desc[:i]
+		}
+		fmt.Fprintf(os.Stdout, "-%s\t%s\n", f.Name, desc)
+	})
+}
+
+const bashCompletion = `# bash completion for ptouchgo, load with: source <(ptouchgo completion bash)
+_ptouchgo() {
+	local cur prev words cword
+	if declare -F _init_completion >/dev/null; then
+		_init_completion -n : || return
+	else
+		cur=${COMP_WORDS[COMP_CWORD]}
+		prev=${COMP_WORDS[COMP_CWORD-1]}
+		words=("${COMP_WORDS[@]}")
+		cword=$COMP_CWORD
+	fi
+	local IFS=$'\n'
+	case $prev in
+	-d|--d)
+		COMPREPLY=($(compgen -W "$(ptouchgo __complete devices 2>/dev/null | cut -f1)" -- "$cur"))
+		declare -F __ltrim_colon_completions >/dev/null && __ltrim_colon_completions "$cur"
+		return
+		;;
+	-font|--font)
+		COMPREPLY=($(compgen -W "$(ptouchgo __complete fonts 2>/dev/null | cut -f1)" -- "$cur"))
+		COMPREPLY=("${COMPREPLY[@]// /\\ }")
+		return
+		;;
+	esac
+	if [[ $cword -eq 1 && $cur != -* ]]; then
+		COMPREPLY=($(compgen -W "$(ptouchgo __complete commands 2>/dev/null | cut -f1)" -- "$cur"))
+		return
+	fi
+	if [[ $cur == -* ]]; then
+		COMPREPLY=($(compgen -W "$(ptouchgo __complete flags "${words[1]}" 2>/dev/null | cut -f1)" -- "$cur"))
+	fi
+}
+complete -o default -F _ptouchgo ptouchgo
+`
+
+const zshCompletion = `#compdef ptouchgo
+# zsh completion for ptouchgo, load with: source <(ptouchgo completion zsh)
+_ptouchgo() {
+	local -a items
+	case ${words[CURRENT-1]} in
+	-d|--d)
+		items=(${(f)"$(ptouchgo __complete devices 2>/dev/null)"})
+		compadd -- ${items%%$'\t'*}
+		return
+		;;
+	-font|--font)
+		items=(${(f)"$(ptouchgo __complete fonts 2>/dev/null)"})
+		compadd -- ${items%%$'\t'*}
+		return
+		;;
+	esac
+	if [[ ${words[CURRENT]} == -* ]]; then
+		items=(${(f)"$(ptouchgo __complete flags ${words[2]} 2>/dev/null)"})
+		items=("${items[@]/$'\t'/:}")
+		_describe flag items
+		return
+	fi
+	if (( CURRENT == 2 )); then
+		items=(${(f)"$(ptouchgo __complete commands 2>/dev/null)"})
+		items=("${items[@]/$'\t'/:}")
+		_describe command items
+	fi
+	_files
+}
+if [[ $funcstack[1] == _ptouchgo ]]; then
+	_ptouchgo "$@"
+else
+	compdef _ptouchgo ptouchgo
+fi
+`
+
+const fishCompletion = `# fish completion for ptouchgo, load with: ptouchgo completion fish | source
+function __ptouchgo_prev
+	set -l tokens (commandline -opc)
+	contains -- $tokens[-1] $argv
+end
+function __ptouchgo_command
+	set -l tokens (commandline -opc)
+	echo $tokens[2]
+end
+complete -c ptouchgo -f -n '__fish_use_subcommand; and not string match -q -- "-*" (commandline -ct)' -a '(ptouchgo __complete commands 2>/dev/null)'
+complete -c ptouchgo -f -n 'string match -q -- "-*" (commandline -ct)' -a '(ptouchgo __complete flags (__ptouchgo_command) 2>/dev/null)'
+complete -c ptouchgo -f -n '__ptouchgo_prev -d --d' -a '(ptouchgo __complete devices 2>/dev/null)'
+complete -c ptouchgo -f -n '__ptouchgo_prev -font --font' -a '(ptouchgo __complete fonts 2>/dev/null)'
+`
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// config holds the defaults of flags, flags given on the command line override them.
+// Each setting can be given in the environment as well, see envPrefix.
+type config struct {
+	Device   string `yaml:"device"`    // -d
+	Tape     string `yaml:"tape"`      // -t, tape width in mm or auto
+	CutEvery int    `yaml:"cut_every"` // -cut-every
+	USBDebug int    `yaml:"usb_debug"` // -usb-debug
+	Font     string `yaml:"font"`      // -font
+	Model    string `yaml:"model"`     // -model
+	APIKeys  string `yaml:"api_keys"`  // -api-keys of serve
+
+	Dither    string  `yaml:"dither"`     // -dither
+	Threshold float64 `yaml:"threshold"`  // -threshold
+	NoCut     bool    `yaml:"no_cut"`     // -no-cut
+	CutAtEnd  bool    `yaml:"cut_at_end"` // -cut-at-end
+	HalfCut   bool    `yaml:"half_cut"`   // -half-cut
+	HiRes     bool    `yaml:"hires"`      // -hires
+
+	Webhooks      string `yaml:"webhooks"`       // -webhook of serve
+	WebhookSecret string `yaml:"webhook_secret"` // -webhook-secret of serve
+	Queue         string `yaml:"queue"`          // -queue of serve
+	SlackSecret   string `yaml:"slack_secret"`   // -slack-secret of serve
+	SlackToken    string `yaml:"slack_token"`    // -slack-token of serve
+	SlackURL      string `yaml:"slack_url"`      // -slack-url of serve
+
+	Clients []clientConfig `yaml:"clients"` // API keys of serve with their limits, only in the file
+
+	Server string `yaml:"server"`  // -server of queue
+	APIKey string `yaml:"api_key"` // -api-key of queue
+
+	path string
+}
+
+// clientConfig is an API key of serve for a server.Client
+type clientConfig struct {
+	Name     string  `yaml:"name"`
+	Key      string  `yaml:"key"`
+	Priority int     `yaml:"priority"`
+	Rate     float64 `yaml:"rate"` // jobs per minute
+	Burst    int     `yaml:"burst"`
+	DailyMM  float64 `yaml:"daily_mm"`
+}
+
+// cfg is the loaded configuration, zero values keep the built-in defaults
+var cfg config
+
+// configPaths returns the config files looked up in order, the first existing one is used.
+// The file in the user config directory like ~/.config/ptouchgo/config.yaml comes first,
+// machines shared by several users can have /etc/ptouchgo/config.yaml.
+func configPaths() []string {
+	var paths []string
+	if dir, err := os.UserConfigDir(); err == nil {
+		paths = append(paths, filepath.Join(dir, "ptouchgo", "config.yaml"))
+	}
+	if runtime.GOOS != "windows" {
+		paths = append(paths, "/etc/ptouchgo/config.yaml")
+	}
+	return paths
+}
+
+// envPrefix starts the environment variables overriding the config file, the yaml key of a
+// setting in upper case follows like PTOUCHGO_DEVICE or PTOUCHGO_CUT_EVERY.
+// PTOUCHGO_CONFIG is the config file used without -config.
+const envPrefix = "PTOUCHGO_"
+
+// loadConfig reads the config file given by -config in args, $PTOUCHGO_CONFIG or the first of configPaths,
+// then the environment. Flags override both, the environment overrides the file.
+func loadConfig(args []string) (config, error) {
+	c, err := loadConfigFile(args)
+	if err != nil {
+		return c, err
+	}
+	return c, c.applyEnv()
+}
+
+func loadConfigFile(args []string) (config, error) {
+	if path := configFlag(args); path != "" {
+		return readConfig(path)
+	}
+	if path := os.Getenv(envPrefix + "CONFIG"); path != "" {
+		return readConfig(path)
+	}
+	for _, path := range configPaths() {
+		c, err := readConfig(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		return c, err
+	}
+	return config{}, nil
+}
+
+// applyEnv sets the settings given in the environment
+func (c *config) applyEnv() error {
+	v := reflect.ValueOf(c).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		key := v.Type().Field(i).Tag.Get("yaml")
+		if key == "" {
+			continue
+		}
+		name := envPrefix + strings.ToUpper(key)
+		value, ok := os.LookupEnv(name)
+		if !ok || value == "" {
+			continue
+		}
+		switch f := v.Field(i); f.Kind() {
+		case reflect.String:
+			f.SetString(value)
+		case reflect.Int:
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			f.SetInt(int64(n))
+		case reflect.Float64:
+			n, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			f.SetFloat(n)
+		case reflect.Bool:
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			f.SetBool(b)
+		}
+	}
+	return nil
+}
+
+// envNames lists the environment variables of the settings
+func envNames() []string {
+	names := []string{envPrefix + "CONFIG"}
+	t := reflect.TypeOf(config{})
+	for i := 0; i < t.NumField(); i++ {
+		switch t.Field(i).Type.Kind() {
+		case reflect.String, reflect.Int, reflect.Float64, reflect.Bool:
+		default:
+			continue
+		}
+		if key := t.Field(i).Tag.Get("yaml"); key != "" {
+			names = append(names, envPrefix+strings.ToUpper(key))
+		}
+	}
+	return names
+}
+
+func readConfig(path string) (config, error) {
+	var c config
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return c, err
+	}
+	if err := yaml.UnmarshalStrict(b, &c); err != nil {
+		return c, fmt.Errorf("%s: %w", path, err)
+	}
+	c.path = path
+	return c, nil
+}
+
+// configFlag returns the value of -config in args, which has to be known before the flags are defined
+func configFlag(args []string) string {
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		name := strings.TrimLeft(arg, "-")
+		if name == arg {
+			continue
+		}
+		switch {
+		case name == "config" && i+1 < len(args):
+			return args[i+1]
+		case strings.HasPrefix(name, "config="):
+			return strings.TrimPrefix(name, "config=")
+		}
+	}
+	return ""
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+
+	"github.com/ka2n/ptouchgo"
+	"github.com/ka2n/ptouchgo/conn"
+)
+
+// deviceJSON is a printer in the output of "ptouchgo discover -json" and "ptouchgo devices -json"
+type deviceJSON struct {
+	Address string `json:"address"` // for -d
+	Driver  string `json:"driver"`
+	Model   string `json:"model,omitempty"`
+	Name    string `json:"name"`
+
+	// read from the printer by devices
+	TapeWidthMM int    `json:"tape_width_mm,omitempty"`
+	Tape        string `json:"tape,omitempty"`
+	MediaType   string `json:"media_type,omitempty"`
+	StatusError string `json:"status_error,omitempty"`
+}
+
+// discoverCLI runs "ptouchgo discover", listing printers with the address to pass to -d
+func discoverCLI(args []string) error {
+	fs := newFlagSet("discover", "")
+	fs.Parse(args)
+	return listDevices(false)
+}
+
+// devicesCLI runs "ptouchgo devices", listing printers with the loaded tape read from each printer
+func devicesCLI(args []string) error {
+	fs := newFlagSet("devices", "")
+	noStatus := fs.Bool("no-status", false, "Do not connect to the printers to read the model and tape")
+	fs.Parse(args)
+	return listDevices(!*noStatus)
+}
+
+func listDevices(status bool) error {
+	// drivers failing to list, like Bluetooth without BlueZ, do not hide the printers of others
+	devices, err := conn.List()
+	if err != nil {
+		log.Println(err)
+	}
+	out := make([]deviceJSON, len(devices))
+	for i, d := range devices {
+		out[i] = deviceJSON{Address: d.Driver + ":" + d.Address, Driver: d.Driver, Model: d.Model, Name: d.Name}
+		if status {
+			readDeviceStatus(&out[i])
+		}
+	}
+	if jsonOutput {
+		return writeJSON(out)
+	}
+	if len(devices) == 0 {
+		return fmt.Errorf("no printer found")
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	if status {
+		fmt.Fprintln(w, "ADDRESS\tMODEL\tTAPE\tNAME")
+	} else {
+		fmt.Fprintln(w, "ADDRESS\tMODEL\tNAME")
+	}
+	for _, d := range out {
+		if status {
+			tape := d.Tape
+			switch {
+			case d.StatusError != "":
+				tape = "?"
+			case d.MediaType != "":
+				tape += " " + d.MediaType
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", d.Address, d.Model, tape, d.Name)
+		} else {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", d.Address, d.Model, d.Name)
+		}
+	}
+	return w.Flush()
+}
+
+// readDeviceStatus fills the model and tape of d from the printer, failures are kept in StatusError
+func readDeviceStatus(d *deviceJSON) {
+	ser, err := ptouchgo.Open(d.Address, 0, false)
+	if err != nil {
+		d.StatusError = err.Error()
+		return
+	}
+	defer ser.Close()
+	st, err := readStatus(ser)
+	if err != nil {
+		d.StatusError = err.Error()
+		return
+	}
+	d.Model = st.Model.String()
+	d.TapeWidthMM = int(st.TapeWidth)
+	d.Tape = st.TapeWidth.String()
+	d.MediaType = st.MediaType.String()
+}
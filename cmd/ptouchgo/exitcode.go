@@ -0,0 +1,95 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ka2n/ptouchgo"
+	"github.com/ka2n/ptouchgo/conn"
+)
+
+// Exit statuses of ptouchgo, scripts can tell the failures apart by them
+const (
+	exitFailure   = 1 // any other failure
+	exitUsage     = 2 // invalid flags or arguments
+	exitDevice    = 3 // the printer can not be found or opened
+	exitTape      = 4 // the loaded tape is missing the layout or not supported
+	exitCoverOpen = 5 // the cover of the printer is open
+	exitNoMedia   = 6 // no tape is loaded
+	exitTransfer  = 7 // sending to or reading from the printer failed
+	exitConvert   = 8 // an image or label could not be loaded or converted
+)
+
+// exitCodes describes the exit statuses for usage
+var exitCodes = []struct {
+	code int
+	desc string
+}{
+	{exitFailure, "other failures"},
+	{exitUsage, "invalid flags or arguments"},
+	{exitDevice, "the printer can not be found or opened"},
+	{exitTape, "wrong or unsupported tape"},
+	{exitCoverOpen, "the cover is open"},
+	{exitNoMedia, "no tape is loaded"},
+	{exitTransfer, "sending to or reading from the printer failed"},
+	{exitConvert, "an image or label could not be loaded or converted"},
+}
+
+// exitError sets the exit status of err
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string {
+	return e.err.Error()
+}
+
+func (e *exitError) Unwrap() error {
+	return e.err
+}
+
+// withExit returns err exiting with code, nil stays nil
+func withExit(code int, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &exitError{code: code, err: err}
+}
+
+// exitCode returns the exit status for err, errors reported by the printer take precedence
+func exitCode(err error) int {
+	var se *ptouchgo.StatusError
+	if errors.As(err, &se) {
+		switch {
+		case se.Status.CoverOpen():
+			return exitCoverOpen
+		case se.Status.NoMedia():
+			return exitNoMedia
+		case se.Status.InvalidMedia():
+			return exitTape
+		default:
+			return exitFailure
+		}
+	}
+	var ue *ptouchgo.UnsupportedError
+	if errors.As(err, &ue) {
+		if ue.Media {
+			return exitTape
+		}
+		return exitUsage
+	}
+	var ee *exitError
+	if errors.As(err, &ee) {
+		return ee.code
+	}
+	if conn.IsTimeout(err) || errors.Is(err, conn.ErrLinkLost) || errors.Is(err, conn.ErrReconnected) {
+		return exitTransfer
+	}
+	return exitFailure
+}
+
+// usageError is an error of invalid flags or arguments
+func usageError(format string, args ...interface{}) error {
+	return withExit(exitUsage, fmt.Errorf(format, args...))
+}
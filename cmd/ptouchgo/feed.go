@@ -0,0 +1,61 @@
+package main
+
+import (
+	"github.com/ka2n/ptouchgo"
+)
+
+// feedLength is how far feed advances the tape by default
+const feedLength = 10 // mm
+
+// feedCLI runs "ptouchgo feed", printing a blank label of -mm without cutting
+func feedCLI(args []string) error {
+	fs := newFlagSet("feed", "")
+	device := addDeviceFlags(fs)
+	mm := fs.Float64("mm", feedLength, "Length of tape to feed in mm")
+	fs.Parse(args)
+
+	rasterLines := ptouchgo.MMToDots(*mm)
+	if rasterLines < 1 {
+		return usageError("feed length must be at least %.2fmm", ptouchgo.DotsToMM(1))
+	}
+	return printBlank(device, rasterLines, false)
+}
+
+// cutCLI runs "ptouchgo cut", cutting off the tape of a chain printed batch or a partially fed label
+func cutCLI(args []string) error {
+	fs := newFlagSet("cut", "")
+	device := addDeviceFlags(fs)
+	fs.Parse(args)
+	return printBlank(device, 1, true)
+}
+
+func printBlank(device deviceFlags, rasterLines int, cut bool) error {
+	ser, tw, err := device.connect()
+	if err != nil {
+		return err
+	}
+	defer ser.Close()
+
+	if cut {
+		err = ser.Cut()
+	} else {
+		err = ser.Feed(rasterLines)
+	}
+	if err != nil {
+		return err
+	}
+
+	if jsonOutput {
+		return writeJSON(jobResult{
+			Device:      *device.devicePath,
+			TapeWidthMM: int(tw),
+			Pages:       1,
+			Copies:      1,
+			Labels:      1,
+			RasterLines: rasterLines,
+			LengthMM:    ptouchgo.DotsToMM(rasterLines),
+			Cut:         cut,
+		})
+	}
+	return nil
+}
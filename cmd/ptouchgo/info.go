@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/ka2n/ptouchgo"
+)
+
+// infoJSON is the output of "ptouchgo info -json"
+type infoJSON struct {
+	Model          string   `json:"model,omitempty"`
+	Firmware       string   `json:"firmware,omitempty"`        // read from the printer
+	ModelSupported *bool    `json:"model_supported,omitempty"` // with model
+	HalfCut        *bool    `json:"half_cut,omitempty"`        // with model
+	TapeWidthMM    int      `json:"tape_width_mm"`
+	TapeWidth      string   `json:"tape_width"`
+	SupportedTapes []string `json:"supported_tapes"`
+	PrintableDots  int      `json:"printable_dots"`
+	HeadPins       int      `json:"head_pins"`
+	DPI            int      `json:"dpi"`
+	StatusReadback bool     `json:"status_readback"`
+	FullDuplex     bool     `json:"full_duplex"`
+	WriteChunk     int      `json:"write_chunk,omitempty"`
+	WriteInterval  string   `json:"write_interval,omitempty"`
+
+	// read from the printer status
+	MediaType string        `json:"media_type,omitempty"`
+	TapeColor string        `json:"tape_color,omitempty"`
+	TextColor string        `json:"text_color,omitempty"`
+	Battery   string        `json:"battery,omitempty"`
+	Printer   *infoSettings `json:"printer_settings,omitempty"`
+
+	// defaults of this command line from the config file
+	Defaults infoDefaults `json:"defaults"`
+}
+
+// infoSettings is the print mode last set on the printer
+type infoSettings struct {
+	AutoCut bool `json:"auto_cut"`
+	Mirror  bool `json:"mirror"`
+}
+
+type infoDefaults struct {
+	Device   string `json:"device"`
+	Tape     string `json:"tape"`
+	Font     string `json:"font,omitempty"`
+	CutEvery int    `json:"cut_every"`
+	Config   string `json:"config,omitempty"`
+}
+
+// infoCLI runs "ptouchgo info", the model and loaded tape are read from the printer when the transport can
+func infoCLI(args []string) error {
+	fs := newFlagSet("info", "")
+	device := addDeviceFlags(fs)
+	fs.Parse(args)
+
+	tw, auto, err := device.tape()
+	if err != nil {
+		return err
+	}
+	if auto {
+		tw = fallbackTape
+	}
+	ser, err := device.open()
+	if err != nil {
+		return err
+	}
+	defer ser.Close()
+
+	var out infoJSON
+	if ser.Capabilities.StatusReadback {
+		st, err := readStatus(ser)
+		if err != nil {
+			log.Printf("status: %v, using %s\n", err, tw)
+		} else {
+			out.Model = st.Model.String()
+			supported, halfCut := st.Model.Supported(), st.Model.HalfCut()
+			out.ModelSupported, out.HalfCut = &supported, &halfCut
+			if st.TapeWidth.Valid() {
+				tw = st.TapeWidth
+			}
+			if st.Model.QL() {
+				if err := ser.UseStatus(st); err != nil {
+					log.Println(err)
+				}
+			} else {
+				ser.Model, ser.HeatShrink = st.Model, st.HeatShrink()
+			}
+			out.MediaType = st.MediaType.String()
+			out.TapeColor = st.TapeColor.String()
+			out.TextColor = st.FontColor.String()
+			out.Battery = st.Battery.String()
+			out.Printer = &infoSettings{AutoCut: st.AutoCut(), Mirror: st.Mirror()}
+			if fw, err := ser.ReadFirmware(); err == nil {
+				out.Firmware = fw
+			} else if ser.Debug {
+				log.Printf("firmware: %v\n", err)
+			}
+		}
+	}
+	caps := ser.Capabilities
+	if media := ser.Media; media.Dots != 0 {
+		out.TapeWidthMM = media.WidthMM
+		out.TapeWidth = media.String()
+		for _, m := range ptouchgo.QLMediaList() {
+			out.SupportedTapes = append(out.SupportedTapes, m.Name)
+		}
+		out.PrintableDots = media.Dots
+	} else {
+		out.TapeWidthMM = int(tw)
+		out.TapeWidth = tw.String()
+		for _, w := range ser.DetectedModel().TapeWidths() {
+			out.SupportedTapes = append(out.SupportedTapes, w.String())
+		}
+		out.PrintableDots = ser.Head().PrintableDots(tw)
+	}
+	head := ser.Head()
+	out.HeadPins, out.DPI = head.Pins, head.DPI
+	out.StatusReadback = caps.StatusReadback
+	out.FullDuplex = caps.FullDuplex
+	if caps.MaxWriteChunk > 0 {
+		out.WriteChunk = caps.MaxWriteChunk
+		out.WriteInterval = caps.WriteInterval.String()
+	}
+	out.Defaults = infoDefaults{Device: defaultDevice(), Tape: defaultTape(), Font: cfg.Font, CutEvery: defaultCutEvery(), Config: cfg.path}
+	if jsonOutput {
+		return writeJSON(out)
+	}
+
+	if out.Model != "" {
+		supported := ""
+		if !*out.ModelSupported {
+			supported = " (not supported)"
+		}
+		fmt.Printf("Model:           %s%s\n", out.Model, supported)
+		fmt.Printf("Half cutter:     %t\n", *out.HalfCut)
+	}
+	firmware := out.Firmware
+	if firmware == "" {
+		firmware = "not reported by the printer"
+	}
+	fmt.Printf("Firmware:        %s\n", firmware)
+	fmt.Printf("Tape:            %s\n", out.TapeWidth)
+	if out.MediaType != "" {
+		fmt.Printf("Media:           %s, %s tape with %s text\n", out.MediaType, out.TapeColor, out.TextColor)
+		fmt.Printf("Battery:         %s\n", out.Battery)
+	}
+	fmt.Printf("Supported tapes: %s\n", strings.Join(out.SupportedTapes, ", "))
+	fmt.Printf("Printable dots:  %d of %d\n", out.PrintableDots, out.HeadPins)
+	fmt.Printf("Resolution:      %d dpi\n", out.DPI)
+	fmt.Printf("Status readback: %t\n", out.StatusReadback)
+	fmt.Printf("Full duplex:     %t\n", out.FullDuplex)
+	if out.WriteChunk > 0 {
+		fmt.Printf("Write chunk:     %d bytes every %s\n", out.WriteChunk, out.WriteInterval)
+	}
+	if out.Printer != nil {
+		fmt.Printf("Auto cut:        %t\n", out.Printer.AutoCut)
+		fmt.Printf("Mirror:          %t\n", out.Printer.Mirror)
+	}
+	d := out.Defaults
+	fmt.Printf("Default device:  %s\n", d.Device)
+	fmt.Printf("Default tape:    %s\n", d.Tape)
+	if d.Font != "" {
+		fmt.Printf("Default font:    %s\n", d.Font)
+	}
+	fmt.Printf("Cut every:       %d\n", d.CutEvery)
+	if d.Config != "" {
+		fmt.Printf("Config:          %s\n", d.Config)
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+// interactiveCLI runs "ptouchgo interactive", printing a text label for each line read from stdin
+// over one connection. Empty lines are skipped, "quit" or the end of input stops.
+func interactiveCLI(args []string) error {
+	fs := newFlagSet("interactive", "")
+	device := addDeviceFlags(fs)
+	font := fs.String("font", cfg.Font, `Font name like "Noto Sans JP Bold" or font file, empty uses the bundled Go font`)
+	size := fs.Float64("size", 0, "Font size in pt, 0 fits the text to the tape")
+	modes := addModeFlags(fs)
+	fs.Parse(args)
+
+	mode, err := modes.mode()
+	if err != nil {
+		return err
+	}
+
+	tw, auto, err := device.tape()
+	if err != nil {
+		return err
+	}
+	ser, err := device.open()
+	if err != nil {
+		return err
+	}
+	defer ser.Close()
+
+	prompt := func() {
+		if !jsonOutput {
+			fmt.Fprint(os.Stderr, "> ")
+		}
+	}
+	if !jsonOutput {
+		fmt.Fprintln(os.Stderr, `Type the text of a label and press enter to print it, \n starts a new line, "quit" exits`)
+	}
+	enc := json.NewEncoder(os.Stdout)
+	sc := bufio.NewScanner(os.Stdin)
+	for prompt(); sc.Scan(); prompt() {
+		text := strings.TrimSpace(sc.Text())
+		if text == "" {
+			continue
+		}
+		if text == "quit" || text == "exit" {
+			break
+		}
+
+		// the tape may be swapped between labels
+		if auto {
+			if tw, err = detectTape(&ser); err != nil {
+				log.Println(err)
+				continue
+			}
+		}
+		j := job{label: labelSpec{text: text, font: *font, size: *size, flagLength: defaultFlagLength}, copies: 1, cutEvery: 1, mode: mode, progress: showProgress()}
+		res, err := j.print(ser, tw, false)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+		if jsonOutput {
+			res.Device = *device.devicePath
+			enc.Encode(res)
+		}
+	}
+	return sc.Err()
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image"
+	"strings"
+
+	"github.com/ka2n/ptouchgo"
+	"github.com/ka2n/ptouchgo/label"
+)
+
+// labelFlags are the flags of print giving a label, printed after the images
+type labelFlags struct {
+	text    *string
+	font    *string
+	size    *float64
+	qr      *string
+	barcode *string
+
+	cableFlag  *float64
+	flagLength *float64
+	cableWrap  *float64
+}
+
+func addLabelFlags(fs *flag.FlagSet) labelFlags {
+	return labelFlags{
+		text:    fs.String("text", "", `Print a label with this text, \n starts a new line. With -qr or -barcode it is the caption`),
+		font:    fs.String("font", cfg.Font, `Font name like "Noto Sans JP Bold" or font file for -text and captions, empty uses the bundled Go font`),
+		size:    fs.Float64("size", 0, "Font size in pt for -text and captions, 0 fits the text to the tape"),
+		qr:      fs.String("qr", "", "Print a QR code of this data"),
+		barcode: fs.String("barcode", "", `Print a barcode like "code128:DATA", the symbology is code128, code39 or ean and defaults to code128`),
+
+		cableFlag:  fs.Float64("cable-flag", 0, "Lay -text out as a flag label for a cable of this diameter in mm, printed on both halves"),
+		flagLength: fs.Float64("flag-length", defaultFlagLength, "Length of each half of a -cable-flag label in mm"),
+		cableWrap:  fs.Float64("cable-wrap", 0, "Lay -text out as a wrap label for a cable of this diameter in mm, repeated around it"),
+	}
+}
+
+// defaultFlagLength is the length of each half of a cable flag label in mm
+const defaultFlagLength = 25
+
+// labelSpec describes a label rendered from text, a QR code or a barcode
+type labelSpec struct {
+	text    string // \n starts a new line, the caption with qr or barcode
+	font    string
+	size    float64
+	qr      string
+	barcode string // [symbology:]data
+
+	cableFlag  float64 // mm cable diameter of a flag label
+	flagLength float64 // mm of each flag half
+	cableWrap  float64 // mm cable diameter of a wrap label
+}
+
+func (f labelFlags) spec() labelSpec {
+	return labelSpec{text: *f.text, font: *f.font, size: *f.size, qr: *f.qr, barcode: *f.barcode,
+		cableFlag: *f.cableFlag, flagLength: *f.flagLength, cableWrap: *f.cableWrap}
+}
+
+// requested reports whether a label is given
+func (s labelSpec) requested() bool {
+	return s.text != "" || s.qr != "" || s.barcode != ""
+}
+
+// render renders the label, nil when no label is requested
+func (s labelSpec) render(tw ptouchgo.TapeWidth) (image.Image, error) {
+	if !s.requested() {
+		return nil, nil
+	}
+	if s.size < 0 {
+		return nil, usageError("size must not be negative")
+	}
+	text := strings.ReplaceAll(s.text, `\n`, "\n")
+
+	cable := s.cableFlag != 0 || s.cableWrap != 0
+	var l *label.Layout
+	switch {
+	case s.qr != "" && s.barcode != "":
+		return nil, usageError("qr and barcode cannot be combined")
+	case cable && (s.qr != "" || s.barcode != ""):
+		return nil, usageError("cable-flag and cable-wrap lay out -text, they cannot be combined with qr or barcode")
+	case s.cableFlag != 0 && s.cableWrap != 0:
+		return nil, usageError("cable-flag and cable-wrap cannot be combined")
+	case s.cableFlag < 0 || s.cableWrap < 0 || s.flagLength <= 0:
+		return nil, usageError("cable diameters must not be negative and flag-length must be positive")
+	case s.cableFlag != 0:
+		l = label.CableFlag(int(tw), text, s.cableFlag, s.flagLength)
+	case s.cableWrap != 0:
+		l = label.CableWrap(int(tw), text, s.cableWrap)
+	case s.qr != "":
+		l = label.QR(int(tw), s.qr, text, 0)
+	case s.barcode != "":
+		symbology, data := "code128", s.barcode
+		if i := strings.Index(data, ":"); i >= 0 {
+			symbology, data = strings.ToLower(data[:i]), data[i+1:]
+		}
+		l = label.Barcode(int(tw), symbology, data, text, 0)
+	default:
+		l = label.Text(int(tw), text, 0)
+	}
+	for i := range l.Elements {
+		if t := l.Elements[i].Type; t == label.TypeText || t == label.TypeBarcode {
+			l.Elements[i].Font = s.font
+			l.Elements[i].Size = s.size
+		}
+	}
+
+	img, err := label.Render(l, nil)
+	if err != nil {
+		return nil, fmt.Errorf("render label: %w", err)
+	}
+	return img, nil
+}
@@ -1,145 +1,361 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/ka2n/ptouchgo"
-	_ "github.com/ka2n/ptouchgo/conn/usb"
+	"github.com/ka2n/ptouchgo/conn"
+	_ "github.com/ka2n/ptouchgo/conn/ble"
+	_ "github.com/ka2n/ptouchgo/conn/ssh"
+	"github.com/ka2n/ptouchgo/conn/usb"
 )
 
-var (
-	imagePath  = flag.String("i", "", "Image path")
-	devicePath = flag.String("d", "/dev/rfcomm0", `Device path(RFCOMM device path or "usb" or "usb://0x0000" or "tcp://192.168.100.1:9100")`)
-	tapeWidth  = flag.Uint("t", 24, "Tape width")
-	debugMode  = flag.Bool("debug", false, "Debug decoded image")
-	dryRunMode = flag.Bool("dry", false, "not printing")
-)
+// devicePathUsage lists the address forms accepted by -d
+const devicePathUsage = `Device path(RFCOMM device path or "serial:/dev/ttyUSB0" or "usb" or "usb://0x0000" or "usblp:/dev/usb/lp0" or "net:192.168.100.1" or "wlan:192.168.118.1" or "nfc:" or "bt:AA:BB:CC:DD:EE:FF" or "ble:AA:BB:CC:DD:EE:FF" or "tcp://192.168.100.1:9100" or "ssh://pi@raspberrypi/dev/usb/lp0" or "file:job.prn")`
 
-var (
-	ser ptouchgo.Serial
-)
+type command struct {
+	name  string
+	usage string
+	run   func(args []string) error
+}
+
+var commands = []command{
+	{"print", "Print images or text", printCLI},
+	{"batch", "Print a label design for each row of a CSV file", batchCLI},
+	{"status", "Show the printer status", statusCLI},
+	{"preview", "Show the raster of an image without printing", previewCLI},
+	{"discover", "List connected and paired printers", discoverCLI},
+	{"devices", "List printers with their loaded tape", devicesCLI},
+	{"feed", "Feed tape without printing", feedCLI},
+	{"cut", "Cut the tape", cutCLI},
+	{"testpage", "Print test patterns checking the print head, alignment and feed", testpageCLI},
+	{"info", "Show the printer and transport parameters", infoCLI},
+	{"power", "Show the battery level and auto power-off times of a PT-P710BT and set them", powerCLI},
+	{"wlan", "Show the wireless LAN settings of a PT-P750W connected over USB and its address", wlanCLI},
+	{"nfc", "Read the Wi-Fi and Bluetooth parameters from the NFC tag of a printer touched to a USB reader", nfcCLI},
+	{"pair", "Pair a Bluetooth printer (Linux)", pairCLI},
+	{"interactive", "Print a text label for each line typed", interactiveCLI},
+	{"serve", "Print images and text sent over HTTP", serveCLI},
+	{"queue", "List the jobs of a running serve", queueCLI},
+	{"completion", "Print the shell completion script of bash, zsh or fish", completionCLI},
+}
 
 func main() {
 	log.SetPrefix("ptouchgo: ")
 	log.SetFlags(0)
-	flag.Parse()
 
-	err := mainCLI()
-	if err != nil {
-		log.Fatalln(err)
+	args := os.Args[1:]
+	run := printCLI
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		if args[0] == completeCommand {
+			if err := completeCLI(args[1:]); err != nil {
+				fail(err)
+			}
+			return
+		}
+		if args[0] == "help" {
+			if len(args) == 1 {
+				usage()
+				return
+			}
+			// "help <command>" shows the flags of the command like "<command> -h"
+			args = []string{args[1], "-h"}
+		}
+		run = nil
+		for _, c := range commands {
+			if c.name == args[0] {
+				run = c.run
+			}
+		}
+		if run == nil {
+			fmt.Fprintf(os.Stderr, "unknown command %q\n\n", args[0])
+			usage()
+			os.Exit(exitUsage)
+		}
+		args = args[1:]
+	}
+	// without a command the flags of print are accepted, like before commands existed
+
+	var err error
+	if cfg, err = loadConfig(args); err != nil {
+		fail(withExit(exitUsage, err))
+	}
+	if err := run(args); err != nil {
+		fail(err)
 	}
 }
 
-func mainCLI() error {
+// fail reports err and exits, as {"error": "..."} on stdout with -json
+func fail(err error) {
+	code := exitCode(err)
+	if jsonOutput {
+		writeJSON(struct {
+			Error    string `json:"error"`
+			ExitCode int    `json:"exit_code"`
+		}{err.Error(), code})
+	} else {
+		log.Println(err)
+	}
+	os.Exit(code)
+}
 
-	var err error
-	if *imagePath == "" || *devicePath == "" {
-		return fmt.Errorf("image file path and device path required")
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage: %s <command> [flags]\n\nCommands:\n", os.Args[0])
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %-10s %s\n", c.name, c.usage)
 	}
+	fmt.Fprintf(os.Stderr, "\nRun \"%s help <command>\" for the flags of a command.\n\nExit status:\n", os.Args[0])
+	for _, c := range exitCodes {
+		fmt.Fprintf(os.Stderr, "  %d  %s\n", c.code, c.desc)
+	}
+	fmt.Fprintf(os.Stderr, "\nFlag defaults come from the environment, then the config file:\n  %s\n", strings.Join(envNames(), " "))
+}
 
-	tw := ptouchgo.TapeWidth(*tapeWidth)
-	if !tw.Valid() {
-		return fmt.Errorf("tapeWith only accespts 3.5,6,9,12,18,24")
+// newFlagSet returns the flags of command name, usage describes its arguments
+func newFlagSet(name, usage string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	fs.Usage = func() {
+		if completingFlags {
+			printFlagNames(fs)
+			return
+		}
+		fmt.Fprintf(fs.Output(), "Usage: %s %s [flags]%s\n", os.Args[0], name, usage)
+		fs.PrintDefaults()
 	}
+	fs.BoolVar(&jsonOutput, "json", false, "Print the result as JSON")
+	fs.String("config", cfg.path, "Config file with flag defaults, looked up at "+strings.Join(configPaths(), " and ")+" when not given")
+	return fs
+}
 
-	// prepare data
-	imgFile, err := os.Open(*imagePath)
-	if err != nil {
-		return err
+// deviceFlags are the flags of commands talking to a printer
+type deviceFlags struct {
+	devicePath *string
+	tapeWidth  *string
+	model      *string
+	debugMode  *bool
+	usbDebug   *int
+	trace      *string
+}
+
+// tapeUsage describes -t
+const tapeUsage = `Tape width in mm(3.5,6,9,12,18,24 or 36 on the PT-P900 series), QL media like "62", "62red" or "29x90", "auto" reads it from the printer`
+
+// tapeAuto is the value of -t reading the tape width from the printer
+const tapeAuto = "auto"
+
+func addDeviceFlags(fs *flag.FlagSet) deviceFlags {
+	return deviceFlags{
+		devicePath: fs.String("d", defaultDevice(), devicePathUsage),
+		tapeWidth:  addTapeFlag(fs, tapeUsage),
+		model:      fs.String("model", cfg.Model, `Printer model like "PT-P300BT" for connections without status readback, -t auto reads it from the printer`),
+		debugMode:  fs.Bool("debug", false, "Log the commands sent to the printer, see preview for the decoded image"),
+		usbDebug:   fs.Int("usb-debug", cfg.USBDebug, "libusb log level(0-4)"),
+		trace:      fs.String("trace", "", "Write a timestamped hex dump of the bytes sent to and read from the printer into this file"),
 	}
-	defer imgFile.Close()
+}
 
-	data, bytesWidth, err := ptouchgo.LoadPNGImage(imgFile, tw)
-	if err != nil {
-		return fmt.Errorf("load image: %w", err)
+func defaultDevice() string {
+	if cfg.Device != "" {
+		return cfg.Device
 	}
-	rasterLines := len(data) / bytesWidth
+	return "/dev/rfcomm0"
+}
 
-	debug := *debugMode
-	if debug {
-		for i := 0; i < len(data); i += bytesWidth {
-			to := i + bytesWidth
-			if to > len(data) {
-				to = len(data)
-			}
-			chunk := data[i:to]
-			for _, c := range chunk {
-				fmt.Printf("%08b", c)
-			}
-			fmt.Println()
-		}
+// addTapeFlag defines -t and its long form -tape
+func addTapeFlag(fs *flag.FlagSet, usage string) *string {
+	tape := fs.String("t", defaultTape(), usage)
+	fs.StringVar(tape, "tape", defaultTape(), "Same as -t")
+	return tape
+}
+
+func defaultTape() string {
+	if cfg.Tape != "" {
+		return cfg.Tape
 	}
+	return tapeAuto
+}
 
-	// Compless data
-	packedData, err := ptouchgo.CompressImage(data, bytesWidth)
-	if err != nil {
-		return fmt.Errorf("convert image: %w", err)
+// fallbackTape is used by -t auto when the connection can not read the printer status
+const fallbackTape = 24
+
+// tape returns the tape width given by -t, auto is true for "auto"
+func (f deviceFlags) tape() (tw ptouchgo.TapeWidth, auto bool, err error) {
+	if strings.EqualFold(*f.tapeWidth, tapeAuto) {
+		return 0, true, nil
+	}
+	if media, ok := f.qlMedia(); ok {
+		return ptouchgo.TapeWidth(media.WidthMM), false, nil
 	}
+	tw, err = parseTapeWidth(*f.tapeWidth)
+	return tw, false, err
+}
 
-	if debug {
-		log.Println("Image loaded")
+// qlMedia returns the QL media given by -t, "12" is the PT tape
+func (f deviceFlags) qlMedia() (ptouchgo.QLMedia, bool) {
+	if _, err := parseTapeWidth(*f.tapeWidth); err == nil {
+		return ptouchgo.QLMedia{}, false
 	}
+	media, err := ptouchgo.ParseQLMedia(*f.tapeWidth)
+	return media, err == nil
+}
 
-	// Open printer
-	ser, err = ptouchgo.Open(*devicePath, *tapeWidth, debug)
-	if err != nil {
-		return fmt.Errorf("%s, %w", *devicePath, err)
+func parseTapeWidth(mm string) (ptouchgo.TapeWidth, error) {
+	if mm == "3.5" {
+		mm = "4" // the 3.5mm tape is reported as 4
 	}
-	defer ser.Close()
+	n, err := strconv.ParseUint(mm, 10, 8)
+	tw := ptouchgo.TapeWidth(n)
+	if err != nil || !tw.Valid() {
+		return 0, usageError("tapeWith only accespts 3.5,6,9,12,18,24,36, QL media like 62 or 29x90, or auto")
+	}
+	return tw, nil
+}
 
-	err = ser.Reset()
-	if err != nil {
-		return err
+func (f deviceFlags) open() (ptouchgo.Serial, error) {
+	if *f.devicePath == "" {
+		return ptouchgo.Serial{}, usageError("device path required")
 	}
+	return f.openAt(*f.devicePath)
+}
 
-	err = ser.SetRasterMode()
+// openAt opens address with the settings of the flags like open, the spool file of a dry run is opened as "file:PATH"
+func (f deviceFlags) openAt(address string) (ptouchgo.Serial, error) {
+	tw, _, err := f.tape()
 	if err != nil {
-		return err
+		return ptouchgo.Serial{}, err
 	}
-
-	// Set property
-	err = ser.SetPrintProperty(rasterLines)
+	usb.SetDebug(*f.usbDebug)
+	ser, err := ptouchgo.Open(address, uint(tw), *f.debugMode)
 	if err != nil {
-		return err
+		return ptouchgo.Serial{}, withExit(exitDevice, fmt.Errorf("%s, %w", address, err))
 	}
+	ser.Media, _ = f.qlMedia()
+	if *f.model != "" {
+		if ser.Model, err = ptouchgo.ParseModel(*f.model); err != nil {
+			ser.Close()
+			return ptouchgo.Serial{}, usageError("%v, use one of %s", err, modelNames())
+		}
+	}
+	if *f.trace != "" {
+		if ser.Conn, err = openTrace(*f.trace, address, ser.Conn); err != nil {
+			ser.Close()
+			return ptouchgo.Serial{}, err
+		}
+	}
+	return ser, nil
+}
 
-	err = ser.SetPrintMode(true, false)
+// connect opens the printer and sets the tape width of -t, "auto" asks the printer
+// for the loaded tape and fails when no supported tape is loaded
+func (f deviceFlags) connect() (ptouchgo.Serial, ptouchgo.TapeWidth, error) {
+	tw, auto, err := f.tape()
 	if err != nil {
-		return err
+		return ptouchgo.Serial{}, 0, err
 	}
-
-	err = ser.SetExtendedMode(false, true, false, false, false)
+	ser, err := f.open()
 	if err != nil {
-		return err
+		return ptouchgo.Serial{}, 0, err
+	}
+	if auto {
+		if tw, err = detectTape(&ser); err != nil {
+			ser.Close()
+			return ptouchgo.Serial{}, 0, err
+		}
 	}
+	return ser, tw, nil
+}
 
-	err = ser.SetFeedAmount(10)
+// modelNames lists the models accepted by -model
+func modelNames() string {
+	var names []string
+	for _, m := range ptouchgo.Models() {
+		names = append(names, m.String())
+	}
+	return strings.Join(names, ", ")
+}
+
+// detectTape reads the loaded tape or QL media from the printer for -t auto and sets it on ser
+func detectTape(ser *ptouchgo.Serial) (ptouchgo.TapeWidth, error) {
+	if !ser.Capabilities.StatusReadback {
+		log.Printf("the connection can not read the tape width, using %dmm, set -t to choose another\n", fallbackTape)
+		ser.TapeWidthMM = fallbackTape
+		return fallbackTape, nil
+	}
+	st, err := readStatus(*ser)
 	if err != nil {
-		return err
+		return 0, fmt.Errorf("read tape width: %w, set -t to skip reading it", err)
+	}
+	if len(st.Errors()) > 0 {
+		return 0, &ptouchgo.StatusError{Status: st}
+	}
+	if err := ser.UseStatus(st); err != nil {
+		return 0, withExit(exitTape, err)
 	}
+	// features of older firmware are checked while printing
+	if ser.Model.FirmwareGated() {
+		if _, err := ser.ReadFirmware(); err != nil {
+			log.Printf("read firmware: %v, printing without checking it\n", err)
+		}
+	}
+	return st.TapeWidth, nil
+}
+
+// traceConn is a connection logging into the trace file closed with it
+type traceConn struct {
+	*conn.Tap
+	f *os.File
+}
 
-	err = ser.SetCompressionModeEnabled(true)
+// openTrace creates the trace file at path and returns c logging into it
+func openTrace(path, address string, c io.ReadWriteCloser) (io.ReadWriteCloser, error) {
+	f, err := os.Create(path)
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("trace: %w", err)
 	}
+	fmt.Fprintf(f, "# ptouchgo %s, %s\n", address, time.Now().Format(time.RFC3339))
+	return traceConn{Tap: conn.NewTap(c, f, conn.TapTimedHexDump), f: f}, nil
+}
 
-	if !*dryRunMode {
-		err = ser.SendImage(packedData)
-		if err != nil {
-			return err
-		}
+func (c traceConn) Close() error {
+	err := c.Tap.Close()
+	if terr := c.Tap.Err(); terr != nil && err == nil {
+		err = fmt.Errorf("trace: %w", terr)
+	}
+	if ferr := c.f.Close(); ferr != nil && err == nil {
+		err = fmt.Errorf("trace: %w", ferr)
 	}
+	return err
+}
 
-	if !*dryRunMode {
-		err = ser.PrintAndEject()
-		if err != nil {
-			return err
-		}
+// readStatus requests the status of the printer
+func readStatus(ser ptouchgo.Serial) (*ptouchgo.Status, error) {
+	if !ser.Capabilities.StatusReadback {
+		return nil, fmt.Errorf("the connection can not read the printer status")
 	}
+	if err := ser.Reset(); err != nil {
+		return nil, withExit(exitTransfer, err)
+	}
+	if err := ser.RequestStatus(); err != nil {
+		return nil, withExit(exitTransfer, err)
+	}
+	st, err := ser.ReadStatus()
+	return st, withExit(exitTransfer, err)
+}
+
+// jsonOutput is set by -json, results are printed as JSON instead of text
+var jsonOutput bool
 
-	ser.Reset()
-	return nil
+// writeJSON prints v as indented JSON to stdout
+func writeJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
 }
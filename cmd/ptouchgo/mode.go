@@ -0,0 +1,183 @@
+package main
+
+import (
+	"flag"
+	"log"
+
+	"github.com/ka2n/ptouchgo"
+)
+
+// defaultMargin is the feed margin around the labels, the 10 dots of ptouchgo.DefaultPrintOptions
+const defaultMargin = 1.4 // mm
+
+// printMode is how labels are cut and printed, see defaultPrintMode
+type printMode struct {
+	noCut    bool // no cut at all, the last label stays in the printer until the next job or "ptouchgo cut"
+	cutAtEnd bool // cut after the last label only
+	halfCut  bool
+	mirror   bool
+	asIs     bool // keep mirror and cut on fabric tape
+	hires    bool
+	quality  bool
+	length   float64 // mm every label is padded to, 0 keeps the length of the label
+	margin   float64 // mm fed before and after the labels
+	convert  ptouchgo.ConvertOptions
+}
+
+// defaultPrintMode cuts after every label with the default feed margin
+func defaultPrintMode() printMode {
+	return printMode{margin: defaultMargin}
+}
+
+// modeFlags are the flags setting printMode
+type modeFlags struct {
+	cut      *bool
+	noCut    *bool
+	cutAtEnd *bool
+	halfCut  *bool
+	mirror   *bool
+	asIs     *bool
+	hires    *bool
+	quality  *bool
+	length   *float64
+	margin   *float64
+	convert  convertFlags
+	fs       *flag.FlagSet
+}
+
+func addModeFlags(fs *flag.FlagSet) modeFlags {
+	return modeFlags{
+		cut:      fs.Bool("cut", true, "Cut after every label, see -cut-every"),
+		noCut:    fs.Bool("no-cut", cfg.NoCut, `Do not cut, the last label stays in the printer until the next job or "ptouchgo cut"`),
+		cutAtEnd: fs.Bool("cut-at-end", cfg.CutAtEnd, "Cut after the last label only"),
+		halfCut:  fs.Bool("half-cut", cfg.HalfCut, "Cut through the tape but not the backing paper, on printers with a half cutter like PT-P750W"),
+		mirror:   fs.Bool("mirror", false, "Print mirrored, for transparent tapes, fabric tape read from the printer is always printed mirrored"),
+		asIs:     fs.Bool("fabric-as-is", false, "Keep -mirror and cutting on fabric tape, which is printed mirrored without cutting for ironing on"),
+		hires:    fs.Bool("hires", cfg.HiRes, "Print at 180x360dpi, 360x720dpi on the PT-P900 series, labels come out half as long"),
+		quality:  fs.Bool("quality", false, "Prefer the print quality over the speed, on the PT-P900 series"),
+		length:   fs.Float64("length-mm", 0, "Pad every label to this length in mm with blank tape around it, 0 keeps the length of the label"),
+		margin:   fs.Float64("margin-mm", defaultMargin, "Length of blank tape fed before and after the labels in mm"),
+		convert:  addConvertFlags(fs),
+		fs:       fs,
+	}
+}
+
+// given reports whether the flag name was set on the command line rather than by its default from the config
+func (f modeFlags) given(name string) bool {
+	found := false
+	f.fs.Visit(func(fl *flag.Flag) {
+		found = found || fl.Name == name
+	})
+	return found
+}
+
+func (f modeFlags) mode() (printMode, error) {
+	m := printMode{
+		noCut:    !*f.cut || *f.noCut,
+		cutAtEnd: *f.cutAtEnd,
+		halfCut:  *f.halfCut,
+		mirror:   *f.mirror,
+		asIs:     *f.asIs,
+		hires:    *f.hires,
+		quality:  *f.quality,
+		length:   *f.length,
+		margin:   *f.margin,
+	}
+	if m.length < 0 || m.margin < 0 {
+		return m, usageError("length-mm and margin-mm must not be negative")
+	}
+	var err error
+	if m.convert, err = f.convert.options(); err != nil {
+		return m, err
+	}
+	// cutting given on the command line overrides the one of the config
+	cutGiven := f.given("cut") || f.given("no-cut")
+	endGiven := f.given("cut-at-end") || f.given("half-cut")
+	switch {
+	case f.given("cut") && !f.given("no-cut"):
+		m.noCut = !*f.cut
+	case !cutGiven && endGiven:
+		m.noCut = false
+	}
+	if cutGiven && !endGiven && m.noCut {
+		m.cutAtEnd, m.halfCut = false, false
+	}
+	if m.noCut && (m.cutAtEnd || m.halfCut) {
+		return m, usageError("no-cut cannot be combined with cut-at-end or half-cut")
+	}
+	return m, nil
+}
+
+// convertFlags are the flags setting how images are turned into raster data
+type convertFlags struct {
+	rotate    *string
+	dither    *string
+	threshold *float64
+}
+
+func addConvertFlags(fs *flag.FlagSet) convertFlags {
+	return convertFlags{
+		rotate:    fs.String("rotate", ptouchgo.RotateAuto.String(), "Turn images clockwise by 0, 90, 180 or 270 degrees so their height spans the tape, auto prints images as high as the tape as they are and turns others counterclockwise"),
+		dither:    fs.String("dither", defaultDither(), "Print gray with none for text and logos, floyd for photos, bayer for an even pattern or halftone for growing dots"),
+		threshold: fs.Float64("threshold", defaultThreshold(), "Lightness from 0 to 1 up to which pixels are printed, higher prints darker"),
+	}
+}
+
+func defaultDither() string {
+	if cfg.Dither != "" {
+		return cfg.Dither
+	}
+	return ptouchgo.DitherNone.String()
+}
+
+func defaultThreshold() float64 {
+	if cfg.Threshold != 0 {
+		return cfg.Threshold
+	}
+	return 0.5
+}
+
+func (f convertFlags) options() (ptouchgo.ConvertOptions, error) {
+	var opts ptouchgo.ConvertOptions
+	var err error
+	if opts.Rotate, err = ptouchgo.ParseRotation(*f.rotate); err != nil {
+		return opts, withExit(exitUsage, err)
+	}
+	if opts.Dither, err = ptouchgo.ParseDither(*f.dither); err != nil {
+		return opts, withExit(exitUsage, err)
+	}
+	if *f.threshold <= 0 || *f.threshold > 1 {
+		return opts, usageError("threshold must be above 0 and at most 1")
+	}
+	opts.Threshold = *f.threshold
+	return opts, nil
+}
+
+// apply sets the options of the mode for a job of labels printed by ser
+func (m printMode) apply(opts *ptouchgo.PrintOptions, ser ptouchgo.Serial, labels int) error {
+	opts.Mirror = m.mirror
+	opts.FabricAsIs = m.asIs
+	if ser.Fabric && !m.asIs {
+		log.Println("fabric tape is printed mirrored without cutting, set -fabric-as-is to keep the settings")
+	}
+	opts.HighDPI = m.hires
+	opts.Quality = m.quality
+	opts.HalfCut = m.halfCut
+	opts.ConvertOptions = m.convert
+	if ser.Media.DieCut() && m.length != 0 {
+		return usageError("length-mm can not be set for %s", ser.Media)
+	}
+	opts.Length = ser.LengthDots(*opts, m.length)
+	opts.FeedAmount = ser.LengthDots(*opts, m.margin)
+	switch {
+	case m.noCut:
+		opts.AutoCut = false
+		opts.ChainPrint = true
+	case m.cutAtEnd:
+		if labels > ptouchgo.MaxCutEvery {
+			return usageError("cut-at-end supports jobs of up to %d labels, use -cut-every", ptouchgo.MaxCutEvery)
+		}
+		opts.CutEvery = labels
+	}
+	return nil
+}
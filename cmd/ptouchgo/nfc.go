@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/ka2n/ptouchgo/conn/nfc"
+)
+
+// nfcJSON is the output of "ptouchgo nfc -json"
+type nfcJSON struct {
+	Name             string `json:"name,omitempty"`
+	SSID             string `json:"ssid,omitempty"`
+	Passphrase       string `json:"passphrase,omitempty"`
+	BluetoothAddress string `json:"bluetooth_address,omitempty"`
+	Address          string `json:"address"` // for -d
+}
+
+// nfcCLI runs "ptouchgo nfc", reading the Wi-Fi and Bluetooth parameters from the NFC tag
+// of a printer like the PT-P750W touched to a USB reader, "-d nfc:" connects with them
+func nfcCLI(args []string) error {
+	fs := newFlagSet("nfc", "")
+	file := fs.String("file", "", "Read a dump of the tag memory or of its NDEF message instead of a USB reader")
+	fs.Parse(args)
+
+	p, err := nfc.ReadParams(*file)
+	if err != nil {
+		return withExit(exitDevice, err)
+	}
+	out := nfcJSON{Name: p.Name, SSID: p.SSID, Passphrase: p.Passphrase, BluetoothAddress: p.BluetoothAddress, Address: p.Address()}
+	if jsonOutput {
+		return writeJSON(out)
+	}
+
+	if out.Name != "" {
+		fmt.Printf("Name:       %s\n", out.Name)
+	}
+	if out.SSID != "" {
+		fmt.Printf("SSID:       %s\n", out.SSID)
+		fmt.Printf("Passphrase: %s\n", out.Passphrase)
+	}
+	if out.BluetoothAddress != "" {
+		fmt.Printf("Bluetooth:  %s\n", out.BluetoothAddress)
+	}
+	fmt.Printf("Address:    %s\n", out.Address)
+	if out.BluetoothAddress == "" {
+		fmt.Printf("Join the network %s to print to it\n", out.SSID)
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/ka2n/ptouchgo/conn/bluetooth"
+)
+
+// pairCLI runs "ptouchgo pair [address]", the printer is scanned for when no address is given
+func pairCLI(args []string) error {
+	fs := newFlagSet("pair", " [AA:BB:CC:DD:EE:FF]")
+	timeout := fs.Duration("scan", 10*time.Second, "Time to scan for printers")
+	pin := fs.String("pin", bluetooth.DefaultPIN, "PIN code for legacy pairing")
+	bind := fs.Int("bind", -1, "Bind /dev/rfcommN to the printer, needs root")
+	channel := fs.Int("channel", 1, "RFCOMM channel for -bind")
+	fs.Parse(args)
+
+	address := fs.Arg(0)
+	if address == "" {
+		if !jsonOutput {
+			fmt.Fprintf(os.Stderr, "Scanning for %s...\n", *timeout)
+		}
+		devices, err := bluetooth.Scan(*timeout)
+		if err != nil {
+			return err
+		}
+		switch len(devices) {
+		case 0:
+			return fmt.Errorf("no printer found, turn on Bluetooth of the printer and try again")
+		case 1:
+			address = devices[0].Address
+		default:
+			var found []string
+			for _, d := range devices {
+				found = append(found, fmt.Sprintf("  %s %s", d.Address, d.Name))
+			}
+			return fmt.Errorf("several printers found, pass the address of one:\n%s", strings.Join(found, "\n"))
+		}
+	}
+
+	if err := bluetooth.Pair(address, *pin, *timeout); err != nil {
+		return err
+	}
+
+	dev := "bt:" + address
+	if *bind >= 0 {
+		var err error
+		dev, err = bluetooth.BindRFCOMM(*bind, address, *channel)
+		if err != nil {
+			return err
+		}
+	}
+	if jsonOutput {
+		return writeJSON(struct {
+			Address string `json:"address"`
+			Device  string `json:"device"` // for -d
+		}{address, dev})
+	}
+	fmt.Printf("Paired %s\n", address)
+	fmt.Printf("Print with -d %s\n", dev)
+	return nil
+}
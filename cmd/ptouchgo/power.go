@@ -0,0 +1,102 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"time"
+
+	"github.com/ka2n/ptouchgo"
+)
+
+// powerJSON is the output of "ptouchgo power -json"
+type powerJSON struct {
+	Model               string `json:"model,omitempty"`
+	BatteryLevel        *int   `json:"battery_level,omitempty"` // percent, without a battery on the AC adapter
+	AutoPowerOffAC      string `json:"auto_power_off_ac"`       // "0s" never turns off
+	AutoPowerOffBattery string `json:"auto_power_off_battery"`
+}
+
+// powerCLI runs "ptouchgo power", showing the battery level and auto power-off times of a PT-P710BT and setting them
+func powerCLI(args []string) error {
+	fs := newFlagSet("power", "")
+	device := addDeviceFlags(fs)
+	autoOff := fs.Duration("auto-off", 0, fmt.Sprintf("Turn off after this idle time on the AC adapter, a multiple of %s up to %s, 0 keeps it on", ptouchgo.AutoPowerOffStep, ptouchgo.MaxAutoPowerOff))
+	autoOffBattery := fs.Duration("auto-off-battery", 0, "Turn off after this idle time on battery, like -auto-off")
+	fs.Parse(args)
+
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	for _, d := range []time.Duration{*autoOff, *autoOffBattery} {
+		if d < 0 || d > ptouchgo.MaxAutoPowerOff || d%ptouchgo.AutoPowerOffStep != 0 {
+			return usageError("auto power-off must be a multiple of %s up to %s", ptouchgo.AutoPowerOffStep, ptouchgo.MaxAutoPowerOff)
+		}
+	}
+
+	ser, err := device.open()
+	if err != nil {
+		return err
+	}
+	defer ser.Close()
+	if !ser.Capabilities.StatusReadback {
+		return withExit(exitDevice, fmt.Errorf("the connection can not read the power settings"))
+	}
+	if ser.Model == 0 {
+		st, err := readStatus(ser)
+		if err != nil {
+			return err
+		}
+		ser.Model = st.Model
+	}
+	if m := ser.Model; m.Supported() && !m.PowerSettings() {
+		return fmt.Errorf("%s has no power settings, only the PT-P710BT has", m)
+	}
+
+	if set["auto-off"] {
+		if err := ser.SetAutoPowerOff(false, *autoOff); err != nil {
+			return withExit(exitTransfer, err)
+		}
+	}
+	if set["auto-off-battery"] {
+		if err := ser.SetAutoPowerOff(true, *autoOffBattery); err != nil {
+			return withExit(exitTransfer, err)
+		}
+	}
+	settings, err := ser.ReadPowerSettings()
+	if err != nil {
+		return withExit(exitTransfer, err)
+	}
+	out := powerJSON{
+		Model:               ser.Model.String(),
+		AutoPowerOffAC:      settings.AutoPowerOffAC.String(),
+		AutoPowerOffBattery: settings.AutoPowerOffBattery.String(),
+	}
+	level, err := ser.BatteryLevel()
+	switch {
+	case err == nil:
+		out.BatteryLevel = &level
+	case !errors.Is(err, ptouchgo.ErrNoBattery):
+		return withExit(exitTransfer, err)
+	}
+	if jsonOutput {
+		return writeJSON(out)
+	}
+
+	battery := "none, on the AC adapter"
+	if out.BatteryLevel != nil {
+		battery = fmt.Sprintf("%d%%", *out.BatteryLevel)
+	}
+	fmt.Printf("Model:                  %s\n", out.Model)
+	fmt.Printf("Battery level:          %s\n", battery)
+	fmt.Printf("Auto power-off AC:      %s\n", powerOffTime(settings.AutoPowerOffAC))
+	fmt.Printf("Auto power-off battery: %s\n", powerOffTime(settings.AutoPowerOffBattery))
+	return nil
+}
+
+// powerOffTime describes an auto power-off time, zero keeps the printer on
+func powerOffTime(d time.Duration) string {
+	if d == 0 {
+		return "never"
+	}
+	return d.String()
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/png"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/ka2n/ptouchgo"
+)
+
+// previewCLI runs "ptouchgo preview", writing the raster of an image as PNG without printing
+func previewCLI(args []string) error {
+	fs := newFlagSet("preview", "")
+	imagePath := fs.String("i", "", `Image path, "-" reads stdin`)
+	outPath := fs.String("o", "preview.png", `Output PNG path, "-" writes to stdout`)
+	tapeWidth := addTapeFlag(fs, tapeUsage+" given by -d")
+	devicePath := fs.String("d", "", "Read the tape width and colors from the printer at this address, "+devicePathUsage)
+	convert := addConvertFlags(fs)
+	fs.Parse(args)
+
+	if *imagePath == "" {
+		return usageError("image file path required")
+	}
+	conv, err := convert.options()
+	if err != nil {
+		return err
+	}
+	tw := ptouchgo.TapeWidth(fallbackTape)
+	if !strings.EqualFold(*tapeWidth, tapeAuto) {
+		if tw, err = parseTapeWidth(*tapeWidth); err != nil {
+			return err
+		}
+	}
+
+	var st *ptouchgo.Status
+	if *devicePath != "" {
+		ser, err := ptouchgo.Open(*devicePath, uint(tw), false)
+		if err != nil {
+			return fmt.Errorf("%s, %w", *devicePath, err)
+		}
+		st, err = readStatus(ser)
+		ser.Close()
+		if err != nil {
+			return err
+		}
+		if st.TapeWidth.Valid() {
+			tw = st.TapeWidth
+		} else {
+			log.Printf("printer reports %s, using %s\n", st.TapeWidth, tw)
+		}
+	}
+
+	data, bytesWidth, err := loadImage(*imagePath, tw, conv)
+	if err != nil {
+		return err
+	}
+	var img image.Image = ptouchgo.RasterImage(data, bytesWidth)
+	if st != nil {
+		img = ptouchgo.PreviewImage(data, bytesWidth, tw, st.TapeColor, st.FontColor)
+	}
+
+	if *outPath == "-" {
+		return png.Encode(os.Stdout, img)
+	}
+	if err := writePNG(*outPath, img); err != nil {
+		return err
+	}
+
+	if jsonOutput {
+		size := img.Bounds().Size()
+		return writeJSON(struct {
+			Output      string  `json:"output"`
+			Width       int     `json:"width"`
+			Height      int     `json:"height"`
+			TapeWidthMM int     `json:"tape_width_mm"`
+			LengthMM    float64 `json:"length_mm"`
+		}{*outPath, size.X, size.Y, int(tw), ptouchgo.DotsToMM(size.X)})
+	}
+	return nil
+}
+
+// writePNG writes img into a PNG file at path
+func writePNG(path string, img image.Image) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return fmt.Errorf("write preview: %w", err)
+	}
+	return f.Close()
+}
@@ -0,0 +1,339 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/ka2n/ptouchgo"
+	_ "golang.org/x/image/bmp"
+	_ "golang.org/x/image/tiff"
+	_ "golang.org/x/image/webp"
+)
+
+// printCLI runs "ptouchgo print [image...]", the flags are the ones of ptouchgo before commands existed.
+// Several images are printed as one job with a cut between them, a label given by -text, -qr or -barcode comes last.
+func printCLI(args []string) error {
+	fs := newFlagSet("print", " [image...]")
+	imagePath := fs.String("i", "", `Image path, may be a glob pattern like "labels/*.png", "-" reads stdin`)
+	device := addDeviceFlags(fs)
+	labelOpts := addLabelFlags(fs)
+	var dry dryRun
+	fs.Var(&dry, "dry", `not printing, -dry=job.prn writes the bytes which would be sent into job.prn instead`)
+	modes := addModeFlags(fs)
+	copies := fs.Int("copies", 1, "Number of copies, several images are printed in order for each copy")
+	cutEvery := fs.Int("cut-every", defaultCutEvery(), "Cut after every N labels, a value of at least the number of labels cuts after the last one only")
+	fs.Parse(args)
+
+	patterns := fs.Args()
+	if *imagePath != "" {
+		patterns = append([]string{*imagePath}, patterns...)
+	}
+	if (len(patterns) == 0 && !labelOpts.spec().requested()) || *device.devicePath == "" {
+		return usageError("image file path or label and device path required")
+	}
+	paths, err := expandPaths(patterns)
+	if err != nil {
+		return err
+	}
+	stdin := 0
+	for _, path := range paths {
+		if path == "-" {
+			stdin++
+		}
+	}
+	if stdin > 1 {
+		return usageError("stdin can be read only once")
+	}
+
+	if *copies < 1 {
+		return usageError("copies must be at least 1")
+	}
+	if *cutEvery < 1 {
+		return usageError("cut-every must be at least 1")
+	}
+	mode, err := modes.mode()
+	if err != nil {
+		return err
+	}
+
+	tw, auto, err := device.tape()
+	if err != nil {
+		return err
+	}
+
+	// images are decoded before connecting
+	imgs := make([]image.Image, len(paths))
+	for i, path := range paths {
+		imgs[i], err = decodeImage(path)
+		if err != nil {
+			return err
+		}
+	}
+
+	debug := *device.debugMode
+	if debug {
+		log.Printf("%d images loaded\n", len(imgs))
+	}
+
+	// Open printer, or the spool file of a dry run
+	var ser ptouchgo.Serial
+	if dry.spool != "" {
+		ser, err = device.openAt("file:" + dry.spool)
+	} else {
+		ser, err = device.open()
+	}
+	if err != nil {
+		return err
+	}
+	defer ser.Close()
+	if auto {
+		if tw, err = detectTape(&ser); err != nil {
+			return err
+		}
+	}
+
+	j := job{images: imgs, names: paths, label: labelOpts.spec(), copies: *copies, cutEvery: *cutEvery, mode: mode, progress: showProgress()}
+	res, err := j.print(ser, tw, dry.enabled && dry.spool == "")
+	if err != nil {
+		return err
+	}
+
+	if jsonOutput {
+		res.Device = *device.devicePath
+		res.DryRun = dry.enabled
+		res.Spool = dry.spool
+		return writeJSON(res)
+	}
+	return nil
+}
+
+// job is a print job of decoded images followed by an optional label
+type job struct {
+	images   []image.Image
+	names    []string // of images, for errors
+	label    labelSpec
+	copies   int
+	cutEvery int
+	mode     printMode
+	progress bool // draw the transfer and wait for printing, see showProgress
+}
+
+// checkMedia fails for labels on media, which are laid out for PT tape only
+func (j job) checkMedia(media ptouchgo.QLMedia) error {
+	if media.Dots != 0 && j.label.requested() {
+		return usageError("text labels are laid out for PT tape, print an image on %s", media)
+	}
+	return nil
+}
+
+// print sends the job to ser for tw, reset only resets the printer instead of printing
+func (j job) print(ser ptouchgo.Serial, tw ptouchgo.TapeWidth, reset bool) (jobResult, error) {
+	imgs, names := j.images, j.names
+	if err := j.checkMedia(ser.Media); err != nil {
+		return jobResult{}, err
+	}
+	img, err := j.label.render(tw)
+	if err != nil {
+		return jobResult{}, withExit(exitConvert, err)
+	}
+	if img != nil {
+		// labels are laid out for the head of the PT-P700 series
+		img = ser.Head().Fit(img)
+		imgs = append(imgs[:len(imgs):len(imgs)], img)
+		names = append(names[:len(names):len(names)], "label")
+	}
+	if len(imgs) == 0 {
+		return jobResult{}, usageError("nothing to print")
+	}
+
+	opts := ptouchgo.DefaultPrintOptions()
+	opts.Copies = j.copies
+	opts.CutEvery = j.cutEvery
+	// cutting after more labels than printed cuts after the last one, the printer accepts at most 99
+	labels := len(imgs) * j.copies
+	if opts.CutEvery > labels {
+		opts.CutEvery = labels
+	}
+	if err := j.mode.apply(&opts, ser, labels); err != nil {
+		return jobResult{}, err
+	}
+
+	rasterLines := 0
+	for i, img := range imgs {
+		lines, err := convertedLines(ser, img, tw, opts.ConvertOptions)
+		if err != nil {
+			return jobResult{}, withExit(exitConvert, fmt.Errorf("%s: convert image: %w", names[i], err))
+		}
+		if ser.Media.DieCut() {
+			length := ser.Media.LengthDots
+			if opts.HighDPI {
+				length *= 2
+			}
+			if lines > length {
+				return jobResult{}, withExit(exitConvert, fmt.Errorf("%s is %.1fmm long, longer than the %s", names[i], float64(ser.Media.LengthMM)*float64(lines)/float64(length), ser.Media))
+			}
+			lines = length
+		}
+		if opts.Length > 0 {
+			if lines > opts.Length {
+				return jobResult{}, withExit(exitConvert, fmt.Errorf("%s is %.1fmm long, longer than -length-mm %g", names[i], j.mode.length*float64(lines)/float64(opts.Length), j.mode.length))
+			}
+			lines = opts.Length
+		}
+		rasterLines += lines
+	}
+	if reset {
+		err = ser.Reset()
+	} else {
+		if j.progress {
+			bar := newProgressBar()
+			opts.Progress = bar.update
+			opts.WaitPrinted = ser.Capabilities.StatusReadback
+			defer bar.finish()
+		}
+		err = ser.PrintImages(imgs, opts)
+	}
+	if err != nil {
+		return jobResult{}, withExit(exitTransfer, err)
+	}
+
+	return jobResult{
+		TapeWidthMM: int(tw),
+		Pages:       len(imgs),
+		Copies:      j.copies,
+		Labels:      len(imgs) * j.copies,
+		RasterLines: rasterLines * j.copies,
+		LengthMM:    lengthMM(ser, rasterLines*j.copies),
+		Cut:         opts.AutoCut,
+	}, nil
+}
+
+// jobResult describes a job for -json
+type jobResult struct {
+	Device      string  `json:"device"`
+	TapeWidthMM int     `json:"tape_width_mm"`
+	Pages       int     `json:"pages"`
+	Copies      int     `json:"copies"`
+	Labels      int     `json:"labels"`
+	RasterLines int     `json:"raster_lines"`
+	LengthMM    float64 `json:"length_mm"` // length of the raster data, without the feed margins
+	Cut         bool    `json:"cut"`
+	DryRun      bool    `json:"dry_run,omitempty"`
+	Spool       string  `json:"spool,omitempty"` // file written by -dry=path
+}
+
+// dryRun is the value of -dry, a boolean flag which optionally names a spool file like -dry=job.prn
+type dryRun struct {
+	enabled bool
+	spool   string
+}
+
+func (d *dryRun) String() string {
+	if d == nil || d.spool == "" {
+		return ""
+	}
+	return d.spool
+}
+
+func (d *dryRun) Set(s string) error {
+	if b, err := strconv.ParseBool(s); err == nil {
+		d.enabled, d.spool = b, ""
+		return nil
+	}
+	d.enabled, d.spool = true, s
+	return nil
+}
+
+func (d *dryRun) IsBoolFlag() bool {
+	return true
+}
+
+// expandPaths expands the glob patterns in patterns, other paths are kept as they are
+func expandPaths(patterns []string) ([]string, error) {
+	var paths []string
+	for _, pattern := range patterns {
+		if !strings.ContainsAny(pattern, "*?[") {
+			paths = append(paths, pattern)
+			continue
+		}
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", pattern, err)
+		}
+		if len(matches) == 0 {
+			return nil, withExit(exitConvert, fmt.Errorf("%s: no such file", pattern))
+		}
+		paths = append(paths, matches...)
+	}
+	return paths, nil
+}
+
+// decodeImage decodes the image at path, "-" reads stdin. PNG, JPEG, GIF, BMP, TIFF and WebP are supported.
+func decodeImage(path string) (image.Image, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, withExit(exitConvert, err)
+		}
+		defer f.Close()
+		r = f
+	}
+
+	img, _, err := image.Decode(bufio.NewReader(r))
+	if err != nil {
+		if path == "-" {
+			path = "stdin"
+		}
+		return nil, withExit(exitConvert, fmt.Errorf("%s: load image: %w", path, err))
+	}
+	return img, nil
+}
+
+// convertedLines converts img as ser prints it and returns its raster lines
+func convertedLines(ser ptouchgo.Serial, img image.Image, tw ptouchgo.TapeWidth, opts ptouchgo.ConvertOptions) (int, error) {
+	if ser.Media.Dots != 0 {
+		black, _, err := ptouchgo.ConvertQLImage(img, ser.Media, opts)
+		return len(black) / (ser.Head().Pins / 8), err
+	}
+	data, bytesWidth, err := ser.Head().ConvertImage(img, tw, opts)
+	if err != nil {
+		return 0, err
+	}
+	return len(data) / bytesWidth, nil
+}
+
+// lengthMM converts raster lines printed by ser into mm
+func lengthMM(ser ptouchgo.Serial, lines int) float64 {
+	return float64(lines) * 25.4 / float64(ser.DPI())
+}
+
+// loadImage loads the image at path as raster data for tw
+func loadImage(path string, tw ptouchgo.TapeWidth, opts ptouchgo.ConvertOptions) ([]byte, int, error) {
+	img, err := decodeImage(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	data, bytesWidth, err := ptouchgo.ConvertImage(img, tw, opts)
+	if err != nil {
+		return nil, 0, withExit(exitConvert, fmt.Errorf("%s: convert image: %w", path, err))
+	}
+	return data, bytesWidth, nil
+}
+
+func defaultCutEvery() int {
+	if cfg.CutEvery != 0 {
+		return cfg.CutEvery
+	}
+	return 1
+}
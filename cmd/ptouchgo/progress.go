@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ka2n/ptouchgo"
+	"golang.org/x/term"
+)
+
+// progressWidth is the number of characters of the bar
+const progressWidth = 30
+
+// progressBar draws the transfer of a job on stderr and a spinner while the printer prints
+type progressBar struct {
+	start time.Time
+
+	mu   sync.Mutex
+	stop chan struct{}
+	done chan struct{}
+}
+
+// showProgress reports whether progress is drawn, stderr has to be a terminal
+func showProgress() bool {
+	return !jsonOutput && term.IsTerminal(int(os.Stderr.Fd()))
+}
+
+func newProgressBar() *progressBar {
+	return &progressBar{start: time.Now()}
+}
+
+// update is a PrintOptions.Progress
+func (b *progressBar) update(p ptouchgo.Progress) {
+	if p.Waiting {
+		b.spin()
+		return
+	}
+	if p.Total == 0 {
+		return
+	}
+	filled := progressWidth * p.Sent / p.Total
+	line := fmt.Sprintf("[%s%s] %3d%% %s/%s", strings.Repeat("=", filled), strings.Repeat(" ", progressWidth-filled),
+		100*p.Sent/p.Total, formatBytes(p.Sent), formatBytes(p.Total))
+	if elapsed := time.Since(b.start); p.Sent > 0 && p.Sent < p.Total && elapsed > time.Second {
+		eta := time.Duration(float64(elapsed) * float64(p.Total-p.Sent) / float64(p.Sent))
+		line += fmt.Sprintf(" ETA %s", eta.Round(time.Second))
+	}
+	fmt.Fprintf(os.Stderr, "\r%-72s", line)
+}
+
+// spin shows a spinner until finish
+func (b *progressBar) spin() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.stop != nil {
+		return
+	}
+	b.stop, b.done = make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(b.done)
+		t := time.NewTicker(100 * time.Millisecond)
+		defer t.Stop()
+		for i := 0; ; i++ {
+			fmt.Fprintf(os.Stderr, "\r%-72s", fmt.Sprintf("%c printing", `|/-\`[i%4]))
+			select {
+			case <-b.stop:
+				return
+			case <-t.C:
+			}
+		}
+	}()
+}
+
+// finish stops the spinner and clears the line
+func (b *progressBar) finish() {
+	b.mu.Lock()
+	if b.stop != nil {
+		close(b.stop)
+		<-b.done
+		b.stop = nil
+	}
+	b.mu.Unlock()
+	fmt.Fprintf(os.Stderr, "\r%-72s\r", "")
+}
+
+func formatBytes(n int) string {
+	if n < 1024 {
+		return fmt.Sprintf("%dB", n)
+	}
+	return fmt.Sprintf("%.1fKB", float64(n)/1024)
+}
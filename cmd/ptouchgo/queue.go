@@ -0,0 +1,166 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ka2n/ptouchgo/server"
+	"github.com/ka2n/ptouchgo/server/store"
+)
+
+// queueCLI runs "ptouchgo queue", listing the jobs of a running "ptouchgo serve" or of its -queue file,
+// or what the clients printed with -usage
+func queueCLI(args []string) error {
+	fs := newFlagSet("queue", "")
+	serverURL := fs.String("server", cfg.Server, `URL of "ptouchgo serve" like "http://localhost:8080"`)
+	apiKey := fs.String("api-key", cfg.APIKey, "API key of the server")
+	queue := fs.String("queue", "", "Read the -queue file of a server which is not running instead of asking the server")
+	printer := fs.String("printer", "", "List the jobs of the printer only")
+	state := fs.String("state", "", "List the jobs of the state only, like queued, printing, waiting, completed or failed")
+	usage := fs.Bool("usage", false, "List the jobs and tape printed by each client instead")
+	fs.Parse(args)
+	address := *serverURL
+	if address == "" {
+		address = "http://localhost:8080"
+	}
+	if *usage {
+		return listUsage(address, *apiKey, *queue)
+	}
+
+	var jobs []server.Job
+	var err error
+	if *queue != "" {
+		jobs, err = storedJobs(*queue, *printer, server.JobState(*state))
+	} else {
+		jobs, err = serverJobs(address, *apiKey, *printer, *state)
+	}
+	if err != nil {
+		return err
+	}
+	if jsonOutput {
+		return writeJSON(jobs)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tPRINTER\tSTATE\tCLIENT\tCREATED\tERROR")
+	for _, j := range jobs {
+		client := j.Client
+		if client == "" {
+			client = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", j.ID, j.Printer, j.State, client, j.Created.Local().Format(time.Stamp), j.Error)
+	}
+	return w.Flush()
+}
+
+// listUsage lists the usage of GET /usage of a server or of a queue file
+func listUsage(address, apiKey, queue string) error {
+	var list []server.Usage
+	if queue != "" {
+		if _, err := os.Stat(queue); err != nil {
+			return err
+		}
+		st, err := store.Open(queue)
+		if err != nil {
+			return err
+		}
+		defer st.Close()
+		if list, err = st.Usages(); err != nil {
+			return err
+		}
+	} else if err := getJSON(address, apiKey, "/usage", &list); err != nil {
+		return err
+	}
+	if jsonOutput {
+		return writeJSON(list)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "CLIENT\tJOBS\tFAILED\tLABELS\tTAPE\tTODAY")
+	for _, u := range list {
+		name := u.Client
+		if name == "" {
+			name = "-"
+		}
+		today := 0.0
+		if u.Day == time.Now().Format("2006-01-02") {
+			today = u.DayMM
+		}
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%.0fmm\t%.0fmm\n", name, u.Jobs, u.Failed, u.Labels, u.LengthMM, today)
+	}
+	return w.Flush()
+}
+
+// serverJobs returns the jobs of GET /jobs of a server
+func serverJobs(address, apiKey, printer, state string) ([]server.Job, error) {
+	q := url.Values{}
+	if printer != "" {
+		q.Set("printer", printer)
+	}
+	if state != "" {
+		q.Set("state", state)
+	}
+	var jobs []server.Job
+	if err := getJSON(address, apiKey, "/jobs?"+q.Encode(), &jobs); err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+// getJSON decodes the response of GET path of a server into v
+func getJSON(address, apiKey, path string, v interface{}) error {
+	req, err := http.NewRequest(http.MethodGet, strings.TrimSuffix(address, "/")+path, nil)
+	if err != nil {
+		return err
+	}
+	if apiKey != "" {
+		req.Header.Set("X-API-Key", apiKey)
+	}
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		var e struct {
+			Error string `json:"error"`
+		}
+		if json.NewDecoder(resp.Body).Decode(&e) == nil && e.Error != "" {
+			return fmt.Errorf("%s: %s", address, e.Error)
+		}
+		return fmt.Errorf("%s: %s", address, resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("%s: %w", address, err)
+	}
+	return nil
+}
+
+// storedJobs returns the jobs of a queue file
+func storedJobs(path, printer string, state server.JobState) ([]server.Job, error) {
+	if _, err := os.Stat(path); err != nil {
+		// Open would create it
+		return nil, err
+	}
+	st, err := store.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer st.Close()
+	stored, err := st.Jobs()
+	if err != nil {
+		return nil, err
+	}
+	jobs := []server.Job{}
+	for _, sj := range stored {
+		if (printer == "" || sj.Printer == printer) && (state == "" || sj.State == state) {
+			jobs = append(jobs, sj.Job)
+		}
+	}
+	return jobs, nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ka2n/ptouchgo"
+)
+
+// statusJSON is the output of "ptouchgo status -json"
+type statusJSON struct {
+	Model        string   `json:"model"`
+	ModelCode    int      `json:"model_code"`
+	Firmware     string   `json:"firmware,omitempty"` // when read on the connection
+	TapeWidthMM  int      `json:"tape_width_mm"`
+	TapeWidth    string   `json:"tape_width"`
+	TapeLength   int      `json:"tape_length_mm"`
+	MediaType    string   `json:"media_type"`
+	TapeColor    string   `json:"tape_color"`
+	TextColor    string   `json:"text_color"`
+	Battery      string   `json:"battery"`
+	Errors       []string `json:"errors"`
+	Error1       int      `json:"error1"`
+	Error2       int      `json:"error2"`
+	StatusType   string   `json:"status_type"`
+	PhaseType    string   `json:"phase_type"`
+	Phase        string   `json:"phase"`
+	Notification string   `json:"notification"`
+	Mode         int      `json:"mode"`
+}
+
+func newStatusJSON(st *ptouchgo.Status) statusJSON {
+	errs := st.Errors()
+	if errs == nil {
+		errs = []string{}
+	}
+	tape := st.TapeWidth.String()
+	if st.Model.QL() {
+		// QL media is not a tape width, name the roll
+		tape = fmt.Sprintf("%dmm", st.TapeWidth)
+		if media, err := st.Model.QLMediaFor(int(st.TapeWidth), st.TapeLength); err == nil {
+			tape = media.String()
+		}
+	}
+	return statusJSON{
+		Model:        st.Model.String(),
+		ModelCode:    int(st.Model),
+		Firmware:     st.Firmware,
+		TapeWidthMM:  int(st.TapeWidth),
+		TapeWidth:    tape,
+		TapeLength:   st.TapeLength,
+		MediaType:    st.MediaType.String(),
+		TapeColor:    st.TapeColor.String(),
+		TextColor:    st.FontColor.String(),
+		Battery:      st.Battery.String(),
+		Errors:       errs,
+		Error1:       int(st.Error1),
+		Error2:       int(st.Error2),
+		StatusType:   st.StatusType.String(),
+		PhaseType:    st.PhaseType.String(),
+		Phase:        st.Phase.String(),
+		Notification: st.Notification.String(),
+		Mode:         st.Mode,
+	}
+}
+
+// statusCLI runs "ptouchgo status"
+func statusCLI(args []string) error {
+	fs := newFlagSet("status", "")
+	device := addDeviceFlags(fs)
+	fs.Parse(args)
+
+	ser, err := device.open()
+	if err != nil {
+		return err
+	}
+	defer ser.Close()
+
+	st, err := readStatus(ser)
+	if err != nil {
+		return err
+	}
+	out := newStatusJSON(st)
+	if jsonOutput {
+		return writeJSON(out)
+	}
+
+	errs := "None"
+	if len(out.Errors) > 0 {
+		errs = strings.Join(out.Errors, ", ")
+	}
+	fmt.Printf("Model:        %s\n", out.Model)
+	fmt.Printf("Tape width:   %s\n", out.TapeWidth)
+	if out.TapeLength > 0 {
+		fmt.Printf("Tape length:  %dmm\n", out.TapeLength)
+	}
+	fmt.Printf("Media type:   %s\n", out.MediaType)
+	fmt.Printf("Tape color:   %s\n", out.TapeColor)
+	fmt.Printf("Text color:   %s\n", out.TextColor)
+	fmt.Printf("Battery:      %s\n", out.Battery)
+	fmt.Printf("Errors:       %s (0x%02x 0x%02x)\n", errs, out.Error1, out.Error2)
+	fmt.Printf("Status type:  %s\n", out.StatusType)
+	fmt.Printf("Phase:        %s %s\n", out.PhaseType, out.Phase)
+	fmt.Printf("Notification: %s\n", out.Notification)
+	return nil
+}
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"strings"
+
+	"github.com/ka2n/ptouchgo"
+	"github.com/ka2n/ptouchgo/label"
+)
+
+// testPatterns are the patterns of testpage in the order they are printed
+var testPatterns = []struct {
+	name, usage string
+	draw        func(tw ptouchgo.TapeWidth, rulerMM float64) (image.Image, error)
+}{
+	{"rule", "solid bar over the printable width, white streaks are failing head dots", drawRule},
+	{"edges", "lines on the first, middle and last printable dot, missing lines show a pin offset", drawEdges},
+	{"checker", "checkerboard of 2 dot squares, blurred squares show the head or tape alignment", drawChecker},
+	{"ramp", "gray ramp from black to white printed with -dither and -threshold", drawRamp},
+	{"ruler", "mm scale of -ruler-mm, compare with a ruler to check the feed accuracy", drawRuler},
+}
+
+// testPatternGap is the blank space between patterns
+const testPatternGap = 2 // mm
+
+// testpageCLI runs "ptouchgo testpage", printing test patterns for the loaded tape on one label
+func testpageCLI(args []string) error {
+	fs := newFlagSet("testpage", "")
+	device := addDeviceFlags(fs)
+	modes := addModeFlags(fs)
+	names := make([]string, len(testPatterns))
+	for i, p := range testPatterns {
+		names[i] = p.name
+	}
+	patterns := fs.String("pattern", strings.Join(names, ","), "Comma separated patterns to print: "+strings.Join(names, ", "))
+	rulerMM := fs.Float64("ruler-mm", 50, "Length of the ruler pattern in mm")
+	fs.Parse(args)
+
+	mode, err := modes.mode()
+	if err != nil {
+		return err
+	}
+	if *rulerMM < 10 {
+		return usageError("ruler-mm must be at least 10")
+	}
+	var selected []int
+	for _, name := range strings.Split(*patterns, ",") {
+		found := -1
+		for i, p := range testPatterns {
+			if p.name == strings.TrimSpace(name) {
+				found = i
+			}
+		}
+		if found < 0 {
+			return usageError("unknown pattern %q, use %s", name, strings.Join(names, ", "))
+		}
+		selected = append(selected, found)
+	}
+
+	ser, tw, err := device.connect()
+	if err != nil {
+		return err
+	}
+	defer ser.Close()
+
+	var parts []image.Image
+	for _, i := range selected {
+		img, err := testPatterns[i].draw(tw, *rulerMM)
+		if err != nil {
+			return withExit(exitConvert, fmt.Errorf("%s pattern: %w", testPatterns[i].name, err))
+		}
+		parts = append(parts, img)
+	}
+	j := job{images: []image.Image{ser.Head().Fit(joinPatterns(parts))}, names: []string{"testpage"}, copies: 1, cutEvery: 1, mode: mode, progress: showProgress()}
+	res, err := j.print(ser, tw, false)
+	if err != nil {
+		return err
+	}
+	if jsonOutput {
+		res.Device = *device.devicePath
+		return writeJSON(res)
+	}
+	return nil
+}
+
+// joinPatterns places the patterns after each other with testPatternGap between them
+func joinPatterns(parts []image.Image) image.Image {
+	gap := ptouchgo.MMToDots(testPatternGap)
+	length := gap * (len(parts) - 1)
+	for _, p := range parts {
+		length += p.Bounds().Dx()
+	}
+	canvas := newPattern(length)
+	x := 0
+	for _, p := range parts {
+		r := image.Rect(x, 0, x+p.Bounds().Dx(), ptouchgo.HeadPins)
+		draw.Draw(canvas, r, p, p.Bounds().Min, draw.Src)
+		x = r.Max.X + gap
+	}
+	return canvas
+}
+
+// newPattern returns a white image of length dots for the whole print head
+func newPattern(length int) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, length, ptouchgo.HeadPins))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+	return img
+}
+
+// printable returns the rows of the print head printing on tw between x0 and x1
+func printable(tw ptouchgo.TapeWidth, x0, x1 int) image.Rectangle {
+	return image.Rect(x0, tw.Margin(), x1, tw.Margin()+tw.PrintableDots())
+}
+
+func drawRule(tw ptouchgo.TapeWidth, _ float64) (image.Image, error) {
+	length := ptouchgo.MMToDots(5)
+	img := newPattern(length)
+	draw.Draw(img, printable(tw, 0, length), image.Black, image.Point{}, draw.Src)
+	return img, nil
+}
+
+func drawEdges(tw ptouchgo.TapeWidth, _ float64) (image.Image, error) {
+	length := ptouchgo.MMToDots(15)
+	img := newPattern(length)
+	area := printable(tw, 0, length)
+	for _, y := range []int{area.Min.Y, (area.Min.Y + area.Max.Y) / 2, area.Max.Y - 1} {
+		draw.Draw(img, image.Rect(0, y, length, y+1), image.Black, image.Point{}, draw.Src)
+	}
+	// a tick every mm on the first and last dot
+	for mm := 0; mm <= 15; mm++ {
+		x := ptouchgo.MMToDots(float64(mm))
+		if x >= length {
+			x = length - 1
+		}
+		draw.Draw(img, image.Rect(x, area.Min.Y, x+1, area.Min.Y+3), image.Black, image.Point{}, draw.Src)
+		draw.Draw(img, image.Rect(x, area.Max.Y-3, x+1, area.Max.Y), image.Black, image.Point{}, draw.Src)
+	}
+	return img, nil
+}
+
+func drawChecker(tw ptouchgo.TapeWidth, _ float64) (image.Image, error) {
+	const square = 2 // dots
+	length := ptouchgo.MMToDots(10)
+	img := newPattern(length)
+	area := printable(tw, 0, length)
+	for y := area.Min.Y; y < area.Max.Y; y++ {
+		for x := 0; x < length; x++ {
+			if (x/square+(y-area.Min.Y)/square)%2 == 0 {
+				img.SetGray(x, y, color.Gray{})
+			}
+		}
+	}
+	return img, nil
+}
+
+func drawRamp(tw ptouchgo.TapeWidth, _ float64) (image.Image, error) {
+	length := ptouchgo.MMToDots(30)
+	img := newPattern(length)
+	area := printable(tw, 0, length)
+	for x := 0; x < length; x++ {
+		gray := color.Gray{Y: uint8(x * 255 / (length - 1))}
+		draw.Draw(img, image.Rect(x, area.Min.Y, x+1, area.Max.Y), image.NewUniform(gray), image.Point{}, draw.Src)
+	}
+	return img, nil
+}
+
+func drawRuler(tw ptouchgo.TapeWidth, rulerMM float64) (image.Image, error) {
+	return label.Render(label.Ruler(int(tw), rulerMM, label.UnitMM), nil)
+}
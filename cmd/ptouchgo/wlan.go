@@ -0,0 +1,64 @@
+package main
+
+import "fmt"
+
+// wlanJSON is the output of "ptouchgo wlan -json"
+type wlanJSON struct {
+	Model   string `json:"model,omitempty"`
+	Mode    string `json:"mode"`
+	IP      string `json:"ip,omitempty"`      // in the infrastructure network
+	Address string `json:"address,omitempty"` // for -d, empty with the wireless LAN off
+}
+
+// wlanCLI runs "ptouchgo wlan", showing the wireless LAN settings of a PT-P750W connected over USB
+// and the address to print to it over the network
+func wlanCLI(args []string) error {
+	fs := newFlagSet("wlan", "")
+	device := addDeviceFlags(fs)
+	fs.Parse(args)
+
+	ser, err := device.open()
+	if err != nil {
+		return err
+	}
+	defer ser.Close()
+	if !ser.Capabilities.StatusReadback {
+		return withExit(exitDevice, fmt.Errorf("the connection can not read the network settings"))
+	}
+	if ser.Model == 0 {
+		st, err := readStatus(ser)
+		if err != nil {
+			return err
+		}
+		ser.Model = st.Model
+	}
+	if m := ser.Model; m.Supported() && !m.WLAN() {
+		return fmt.Errorf("%s has no wireless LAN", m)
+	}
+
+	settings, err := ser.ReadNetworkSettings()
+	if err != nil {
+		return withExit(exitTransfer, err)
+	}
+	out := wlanJSON{
+		Model:   ser.Model.String(),
+		Mode:    settings.Mode.String(),
+		Address: settings.Address(),
+	}
+	if settings.IP != nil {
+		out.IP = settings.IP.String()
+	}
+	if jsonOutput {
+		return writeJSON(out)
+	}
+
+	fmt.Printf("Model:   %s\n", out.Model)
+	fmt.Printf("Mode:    %s\n", out.Mode)
+	if out.IP != "" {
+		fmt.Printf("IP:      %s\n", out.IP)
+	}
+	if out.Address != "" {
+		fmt.Printf("Address: %s\n", out.Address)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+// Package ble is a Bluetooth Low Energy connection backend for printers which
+// expose the raster protocol through GATT characteristics, like P-touch Cube models.
+//
+// The printer is written through a write characteristic and answers through
+// a notify characteristic, without explicit UUIDs the first vendor specific service
+// having both is used.
+package ble
+
+import (
+	"io"
+	"time"
+
+	"github.com/ka2n/ptouchgo/conn"
+)
+
+// defaultChunkSize is the payload of a write with the minimum ATT MTU of 23 bytes
+const defaultChunkSize = 20
+
+// Driver connects to BLE printers, address is the MAC address of the printer
+type Driver struct {
+	Service string // service UUID, empty selects the first vendor specific service
+	Write   string // write characteristic UUID, empty selects a writable one
+	Notify  string // notify characteristic UUID, empty selects a notifying one
+
+	ScanTimeout time.Duration // time to wait for the printer to be discovered and connected
+}
+
+// DefaultDriver is registered as "ble"
+var DefaultDriver = Driver{
+	ScanTimeout: 10 * time.Second,
+}
+
+func init() {
+	conn.Register("ble", DefaultDriver)
+}
+
+// Open connects to the printer with MAC address like "AA:BB:CC:DD:EE:FF"
+func (d Driver) Open(address string) (io.ReadWriteCloser, error) {
+	return d.open(address, conn.DefaultTimeouts)
+}
+
+// OpenTimeout is Open with reads waiting for notifications and writes waiting for BlueZ up to timeouts
+func (d Driver) OpenTimeout(address string, timeouts conn.Timeouts) (io.ReadWriteCloser, error) {
+	return d.open(address, timeouts)
+}
+
+// Capabilities of BLE, a write fits into one ATT write with the minimum MTU
+func (Driver) Capabilities() conn.Capabilities {
+	return conn.Capabilities{MaxWriteChunk: defaultChunkSize, StatusReadback: true}
+}
+
+// List returns printers known to the Bluetooth stack, discovery is not started
+func (d Driver) List() ([]conn.Device, error) {
+	return d.list()
+}
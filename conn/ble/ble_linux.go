@@ -0,0 +1,394 @@
+package ble
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/ka2n/ptouchgo/conn"
+)
+
+// BlueZ D-Bus API
+const (
+	bluezService       = "org.bluez"
+	adapterIface       = "org.bluez.Adapter1"
+	deviceIface        = "org.bluez.Device1"
+	serviceIface       = "org.bluez.GattService1"
+	charIface          = "org.bluez.GattCharacteristic1"
+	propertiesIface    = "org.freedesktop.DBus.Properties"
+	objectManagerIface = "org.freedesktop.DBus.ObjectManager"
+)
+
+const pollInterval = 500 * time.Millisecond
+
+type managedObjects map[dbus.ObjectPath]map[string]map[string]dbus.Variant
+
+type characteristic struct {
+	path  dbus.ObjectPath
+	flags []string
+	mtu   int
+}
+
+type bleConn struct {
+	bus    *dbus.Conn
+	device dbus.BusObject
+	write  dbus.BusObject
+	notify dbus.BusObject
+
+	chunkSize       int
+	withoutResponse bool
+	timeouts        conn.Timeouts
+
+	signals chan *dbus.Signal
+	data    chan []byte
+	pending []byte
+
+	readm     sync.Mutex
+	writem    sync.Mutex
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+func (d Driver) open(address string, timeouts conn.Timeouts) (io.ReadWriteCloser, error) {
+	hw, err := net.ParseMAC(address)
+	if err != nil || len(hw) != 6 {
+		return nil, fmt.Errorf("ble: invalid bluetooth address %q", address)
+	}
+	bus, err := dbus.ConnectSystemBus()
+	if err != nil {
+		return nil, fmt.Errorf("ble: connect system bus: %w", err)
+	}
+
+	c := &bleConn{
+		bus:      bus,
+		timeouts: timeouts,
+		data:     make(chan []byte, 64),
+		closed:   make(chan struct{}),
+	}
+	if err := c.connect(d, strings.ToUpper(hw.String())); err != nil {
+		c.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *bleConn) connect(d Driver, address string) error {
+	path, err := findDevice(c.bus, address, d.ScanTimeout)
+	if err != nil {
+		return err
+	}
+	c.device = c.bus.Object(bluezService, path)
+	if err := c.device.Call(deviceIface+".Connect", 0).Err; err != nil {
+		return fmt.Errorf("ble: connect %s: %w", address, err)
+	}
+
+	objs, err := waitServices(c.bus, c.device, d.ScanTimeout)
+	if err != nil {
+		return err
+	}
+	write, notify, err := d.characteristics(objs, path)
+	if err != nil {
+		return err
+	}
+	c.write = c.bus.Object(bluezService, write.path)
+	c.chunkSize = write.mtu
+	c.withoutResponse = hasFlag(write.flags, "write-without-response")
+
+	c.notify = c.bus.Object(bluezService, notify.path)
+	if err := c.bus.AddMatchSignal(
+		dbus.WithMatchObjectPath(notify.path),
+		dbus.WithMatchInterface(propertiesIface),
+		dbus.WithMatchMember("PropertiesChanged"),
+	); err != nil {
+		return fmt.Errorf("ble: watch notifications: %w", err)
+	}
+	c.signals = make(chan *dbus.Signal, 64)
+	c.bus.Signal(c.signals)
+	go c.receive()
+
+	if err := c.notify.Call(charIface+".StartNotify", 0).Err; err != nil {
+		return fmt.Errorf("ble: start notify: %w", err)
+	}
+	return nil
+}
+
+func getManagedObjects(bus *dbus.Conn) (managedObjects, error) {
+	var objs managedObjects
+	err := bus.Object(bluezService, "/").Call(objectManagerIface+".GetManagedObjects", 0).Store(&objs)
+	if err != nil {
+		return nil, fmt.Errorf("ble: list bluez objects: %w", err)
+	}
+	return objs, nil
+}
+
+func (d Driver) list() ([]conn.Device, error) {
+	bus, err := dbus.ConnectSystemBus()
+	if err != nil {
+		return nil, fmt.Errorf("ble: connect system bus: %w", err)
+	}
+	defer bus.Close()
+	objs, err := getManagedObjects(bus)
+	if err != nil {
+		return nil, err
+	}
+
+	var devices []conn.Device
+	for _, ifaces := range objs {
+		dev, ok := ifaces[deviceIface]
+		if !ok {
+			continue
+		}
+		name, _ := dev["Name"].Value().(string)
+		address, _ := dev["Address"].Value().(string)
+		model := conn.ModelHint(name)
+		if model == "" || address == "" {
+			continue
+		}
+		devices = append(devices, conn.Device{Driver: "ble", Address: address, Model: model, Name: name})
+	}
+	sort.Slice(devices, func(i, j int) bool { return devices[i].Address < devices[j].Address })
+	return devices, nil
+}
+
+// findDevice returns the BlueZ object of address, discovery is started when it is not known yet
+func findDevice(bus *dbus.Conn, address string, timeout time.Duration) (dbus.ObjectPath, error) {
+	deadline := time.Now().Add(timeout)
+	var adapter dbus.BusObject
+	defer func() {
+		if adapter != nil {
+			adapter.Call(adapterIface+".StopDiscovery", 0)
+		}
+	}()
+
+	for {
+		objs, err := getManagedObjects(bus)
+		if err != nil {
+			return "", err
+		}
+		var adapterPath dbus.ObjectPath
+		for path, ifaces := range objs {
+			if dev, ok := ifaces[deviceIface]; ok {
+				if a, _ := dev["Address"].Value().(string); strings.EqualFold(a, address) {
+					return path, nil
+				}
+			}
+			if _, ok := ifaces[adapterIface]; ok && adapterPath == "" {
+				adapterPath = path
+			}
+		}
+		if adapterPath == "" {
+			return "", fmt.Errorf("ble: no bluetooth adapter found")
+		}
+		if time.Now().After(deadline) {
+			return "", fmt.Errorf("ble: device %s not found", address)
+		}
+		if adapter == nil {
+			adapter = bus.Object(bluezService, adapterPath)
+			if err := adapter.Call(adapterIface+".StartDiscovery", 0).Err; err != nil {
+				adapter = nil
+				return "", fmt.Errorf("ble: start discovery: %w", err)
+			}
+		}
+		time.Sleep(pollInterval)
+	}
+}
+
+// waitServices waits until BlueZ resolved the GATT services of the connected device
+func waitServices(bus *dbus.Conn, device dbus.BusObject, timeout time.Duration) (managedObjects, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		v, err := device.GetProperty(deviceIface + ".ServicesResolved")
+		if err != nil {
+			return nil, fmt.Errorf("ble: %w", err)
+		}
+		if resolved, _ := v.Value().(bool); resolved {
+			return getManagedObjects(bus)
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("ble: timeout resolving services")
+		}
+		time.Sleep(pollInterval)
+	}
+}
+
+// characteristics selects the write and notify characteristics of device
+func (d Driver) characteristics(objs managedObjects, device dbus.ObjectPath) (write, notify *characteristic, err error) {
+	paths := make([]string, 0, len(objs))
+	for path := range objs {
+		if strings.HasPrefix(string(path), string(device)+"/") {
+			paths = append(paths, string(path))
+		}
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		props, ok := objs[dbus.ObjectPath(path)][charIface]
+		if !ok {
+			continue
+		}
+		service, _ := props["Service"].Value().(dbus.ObjectPath)
+		serviceUUID, _ := objs[service][serviceIface]["UUID"].Value().(string)
+		if d.Service != "" && !strings.EqualFold(serviceUUID, d.Service) {
+			continue
+		}
+		if d.Service == "" && isStandardService(serviceUUID) {
+			continue
+		}
+
+		uuid, _ := props["UUID"].Value().(string)
+		flags, _ := props["Flags"].Value().([]string)
+		ch := &characteristic{path: dbus.ObjectPath(path), flags: flags, mtu: defaultChunkSize}
+		if mtu, ok := props["MTU"].Value().(uint16); ok && mtu > 3 {
+			ch.mtu = int(mtu) - 3
+		}
+
+		if write == nil && matchCharacteristic(d.Write, uuid, flags, "write-without-response", "write") {
+			write = ch
+		}
+		if notify == nil && matchCharacteristic(d.Notify, uuid, flags, "notify", "indicate") {
+			notify = ch
+		}
+	}
+	if write == nil || notify == nil {
+		return nil, nil, fmt.Errorf("ble: device has no write and notify characteristics")
+	}
+	return write, notify, nil
+}
+
+func matchCharacteristic(want, uuid string, flags []string, anyFlag ...string) bool {
+	if want != "" {
+		return strings.EqualFold(want, uuid)
+	}
+	for _, f := range anyFlag {
+		if hasFlag(flags, f) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasFlag(flags []string, flag string) bool {
+	for _, f := range flags {
+		if f == flag {
+			return true
+		}
+	}
+	return false
+}
+
+// isStandardService reports services assigned by the Bluetooth SIG like Generic Access or Battery
+func isStandardService(uuid string) bool {
+	u := strings.ToLower(uuid)
+	return strings.HasPrefix(u, "000018") && strings.HasSuffix(u, "-0000-1000-8000-00805f9b34fb")
+}
+
+func (c *bleConn) receive() {
+	for sig := range c.signals {
+		if sig.Path != c.notify.Path() || len(sig.Body) < 2 {
+			continue
+		}
+		if iface, _ := sig.Body[0].(string); iface != charIface {
+			continue
+		}
+		changed, _ := sig.Body[1].(map[string]dbus.Variant)
+		b, ok := changed["Value"].Value().([]byte)
+		if !ok {
+			continue
+		}
+		select {
+		case c.data <- b:
+		case <-c.closed:
+			return
+		}
+	}
+}
+
+func (c *bleConn) Read(b []byte) (int, error) {
+	c.readm.Lock()
+	defer c.readm.Unlock()
+
+	if len(c.pending) == 0 {
+		var timeout <-chan time.Time
+		if c.timeouts.Read > 0 {
+			t := time.NewTimer(c.timeouts.Read)
+			defer t.Stop()
+			timeout = t.C
+		}
+		select {
+		case c.pending = <-c.data:
+		case <-c.closed:
+			return 0, io.EOF
+		case <-timeout:
+			return 0, fmt.Errorf("ble: read: %w", conn.ErrTimeout)
+		}
+	}
+	n := copy(b, c.pending)
+	c.pending = c.pending[n:]
+	return n, nil
+}
+
+// Write splits b into writes fitting the ATT MTU
+func (c *bleConn) Write(b []byte) (int, error) {
+	c.writem.Lock()
+	defer c.writem.Unlock()
+
+	opts := map[string]dbus.Variant{}
+	if c.withoutResponse {
+		opts["type"] = dbus.MakeVariant("command")
+	}
+	var n int
+	for len(b) > 0 {
+		chunk := b
+		if len(chunk) > c.chunkSize {
+			chunk = chunk[:c.chunkSize]
+		}
+		if err := c.writeChunk(chunk, opts); err != nil {
+			return n, err
+		}
+		n += len(chunk)
+		b = b[len(chunk):]
+	}
+	return n, nil
+}
+
+func (c *bleConn) writeChunk(chunk []byte, opts map[string]dbus.Variant) error {
+	ctx := context.Background()
+	if c.timeouts.Write > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeouts.Write)
+		defer cancel()
+	}
+	if err := c.write.CallWithContext(ctx, charIface+".WriteValue", 0, chunk, opts).Err; err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return fmt.Errorf("ble: write: %w", conn.ErrTimeout)
+		}
+		var derr dbus.Error
+		if errors.As(err, &derr) && derr.Name == "org.bluez.Error.NotConnected" {
+			return fmt.Errorf("ble: write: %v: %w", err, conn.ErrLinkLost)
+		}
+		return fmt.Errorf("ble: write: %w", err)
+	}
+	return nil
+}
+
+func (c *bleConn) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.closed)
+		if c.notify != nil {
+			c.notify.Call(charIface+".StopNotify", 0)
+		}
+		if c.device != nil {
+			c.device.Call(deviceIface+".Disconnect", 0)
+		}
+		// closing the bus also closes the signal channel and ends receive
+		c.bus.Close()
+	})
+	return nil
+}
@@ -0,0 +1,19 @@
+//go:build !linux
+// +build !linux
+
+package ble
+
+import (
+	"errors"
+	"io"
+
+	"github.com/ka2n/ptouchgo/conn"
+)
+
+func (d Driver) open(address string, timeouts conn.Timeouts) (io.ReadWriteCloser, error) {
+	return nil, errors.New("ble: not supported on this platform")
+}
+
+func (d Driver) list() ([]conn.Device, error) {
+	return nil, nil
+}
@@ -0,0 +1,41 @@
+// Package bluetooth sets up Bluetooth Classic printers on Linux through BlueZ:
+// it discovers nearby P-touch printers, pairs them and binds RFCOMM devices.
+//
+// A paired printer can be opened with the native RFCOMM driver as "bt:AA:BB:CC:DD:EE:FF",
+// binding a device like /dev/rfcomm0 is only needed by tools which expect a serial port.
+package bluetooth
+
+import (
+	"time"
+
+	"github.com/ka2n/ptouchgo/conn"
+)
+
+// DefaultPIN is the PIN code of Brother printers using legacy pairing
+const DefaultPIN = "0000"
+
+// Scan discovers printers for timeout and returns the ones whose name is a known model,
+// printers already known to BlueZ are included
+func Scan(timeout time.Duration) ([]conn.Device, error) {
+	return scan(timeout)
+}
+
+// Pair pairs and trusts the printer at address like "AA:BB:CC:DD:EE:FF",
+// pin answers legacy PIN requests and defaults to DefaultPIN. Paired printers are left as they are.
+func Pair(address, pin string, timeout time.Duration) error {
+	if pin == "" {
+		pin = DefaultPIN
+	}
+	return pair(address, pin, timeout)
+}
+
+// BindRFCOMM binds /dev/rfcomm<id> to channel of the printer at address like "rfcomm bind",
+// which needs the CAP_NET_ADMIN capability. The connection is made when the device is opened.
+func BindRFCOMM(id int, address string, channel int) (string, error) {
+	return bindRFCOMM(id, address, channel)
+}
+
+// ReleaseRFCOMM releases /dev/rfcomm<id> like "rfcomm release"
+func ReleaseRFCOMM(id int) error {
+	return releaseRFCOMM(id)
+}
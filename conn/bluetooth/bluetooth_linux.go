@@ -0,0 +1,292 @@
+package bluetooth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unsafe"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/ka2n/ptouchgo/conn"
+	"golang.org/x/sys/unix"
+)
+
+// BlueZ D-Bus API
+const (
+	bluezService       = "org.bluez"
+	adapterIface       = "org.bluez.Adapter1"
+	deviceIface        = "org.bluez.Device1"
+	agentIface         = "org.bluez.Agent1"
+	agentManagerIface  = "org.bluez.AgentManager1"
+	objectManagerIface = "org.freedesktop.DBus.ObjectManager"
+
+	agentPath = dbus.ObjectPath("/com/github/ka2n/ptouchgo/agent")
+)
+
+// rfcomm ioctls of the kernel's net/bluetooth/rfcomm.h
+const (
+	rfcommCreateDev  = 0x400452c8 // _IOW('R', 200, int)
+	rfcommReleaseDev = 0x400452c9 // _IOW('R', 201, int)
+)
+
+const pollInterval = 500 * time.Millisecond
+
+type managedObjects map[dbus.ObjectPath]map[string]map[string]dbus.Variant
+
+func getManagedObjects(bus *dbus.Conn) (managedObjects, error) {
+	var objs managedObjects
+	err := bus.Object(bluezService, "/").Call(objectManagerIface+".GetManagedObjects", 0).Store(&objs)
+	if err != nil {
+		return nil, fmt.Errorf("bluetooth: list bluez objects: %w", err)
+	}
+	return objs, nil
+}
+
+// adapter returns the first Bluetooth adapter
+func adapter(objs managedObjects) (dbus.ObjectPath, error) {
+	var paths []string
+	for path, ifaces := range objs {
+		if _, ok := ifaces[adapterIface]; ok {
+			paths = append(paths, string(path))
+		}
+	}
+	if len(paths) == 0 {
+		return "", errors.New("bluetooth: no bluetooth adapter found")
+	}
+	sort.Strings(paths)
+	return dbus.ObjectPath(paths[0]), nil
+}
+
+func scan(timeout time.Duration) ([]conn.Device, error) {
+	bus, err := dbus.ConnectSystemBus()
+	if err != nil {
+		return nil, fmt.Errorf("bluetooth: connect system bus: %w", err)
+	}
+	defer bus.Close()
+
+	objs, err := getManagedObjects(bus)
+	if err != nil {
+		return nil, err
+	}
+	path, err := adapter(objs)
+	if err != nil {
+		return nil, err
+	}
+	a := bus.Object(bluezService, path)
+	if err := a.Call(adapterIface+".StartDiscovery", 0).Err; err != nil {
+		return nil, fmt.Errorf("bluetooth: start discovery: %w", err)
+	}
+	time.Sleep(timeout)
+	a.Call(adapterIface+".StopDiscovery", 0)
+
+	if objs, err = getManagedObjects(bus); err != nil {
+		return nil, err
+	}
+	var devices []conn.Device
+	for _, ifaces := range objs {
+		dev, ok := ifaces[deviceIface]
+		if !ok {
+			continue
+		}
+		name, _ := dev["Name"].Value().(string)
+		address, _ := dev["Address"].Value().(string)
+		model := conn.ModelHint(name)
+		if model == "" || address == "" {
+			continue
+		}
+		devices = append(devices, conn.Device{Driver: "bt", Address: address, Model: model, Name: name})
+	}
+	sort.Slice(devices, func(i, j int) bool { return devices[i].Address < devices[j].Address })
+	return devices, nil
+}
+
+// findDevice returns the BlueZ object of address, discovery is started when it is not known yet
+func findDevice(bus *dbus.Conn, address string, timeout time.Duration) (dbus.ObjectPath, error) {
+	deadline := time.Now().Add(timeout)
+	var discovering dbus.BusObject
+	defer func() {
+		if discovering != nil {
+			discovering.Call(adapterIface+".StopDiscovery", 0)
+		}
+	}()
+
+	for {
+		objs, err := getManagedObjects(bus)
+		if err != nil {
+			return "", err
+		}
+		for path, ifaces := range objs {
+			if dev, ok := ifaces[deviceIface]; ok {
+				if a, _ := dev["Address"].Value().(string); strings.EqualFold(a, address) {
+					return path, nil
+				}
+			}
+		}
+		if time.Now().After(deadline) {
+			return "", fmt.Errorf("bluetooth: device %s not found", address)
+		}
+		if discovering == nil {
+			path, err := adapter(objs)
+			if err != nil {
+				return "", err
+			}
+			discovering = bus.Object(bluezService, path)
+			if err := discovering.Call(adapterIface+".StartDiscovery", 0).Err; err != nil {
+				discovering = nil
+				return "", fmt.Errorf("bluetooth: start discovery: %w", err)
+			}
+		}
+		time.Sleep(pollInterval)
+	}
+}
+
+func pair(address, pin string, timeout time.Duration) error {
+	hw, err := net.ParseMAC(address)
+	if err != nil || len(hw) != 6 {
+		return fmt.Errorf("bluetooth: invalid bluetooth address %q", address)
+	}
+	address = strings.ToUpper(hw.String())
+
+	bus, err := dbus.ConnectSystemBus()
+	if err != nil {
+		return fmt.Errorf("bluetooth: connect system bus: %w", err)
+	}
+	defer bus.Close()
+
+	path, err := findDevice(bus, address, timeout)
+	if err != nil {
+		return err
+	}
+	device := bus.Object(bluezService, path)
+
+	v, err := device.GetProperty(deviceIface + ".Paired")
+	if err != nil {
+		return fmt.Errorf("bluetooth: %w", err)
+	}
+	if paired, _ := v.Value().(bool); !paired {
+		// BlueZ asks the agent of the caller of Pair for the PIN
+		if err := bus.Export(agent{pin: pin}, agentPath, agentIface); err != nil {
+			return fmt.Errorf("bluetooth: export agent: %w", err)
+		}
+		manager := bus.Object(bluezService, "/org/bluez")
+		if err := manager.Call(agentManagerIface+".RegisterAgent", 0, agentPath, "KeyboardDisplay").Err; err != nil {
+			return fmt.Errorf("bluetooth: register agent: %w", err)
+		}
+		defer manager.Call(agentManagerIface+".UnregisterAgent", 0, agentPath)
+
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		err := device.CallWithContext(ctx, deviceIface+".Pair", 0).Err
+		var derr dbus.Error
+		if err != nil && !(errors.As(err, &derr) && derr.Name == "org.bluez.Error.AlreadyExists") {
+			return fmt.Errorf("bluetooth: pair %s: %w", address, err)
+		}
+	}
+
+	// trusted devices may reconnect without asking
+	if err := device.SetProperty(deviceIface+".Trusted", dbus.MakeVariant(true)); err != nil {
+		return fmt.Errorf("bluetooth: trust %s: %w", address, err)
+	}
+	return nil
+}
+
+// agent answers the pairing requests of BlueZ, passkeys are confirmed without asking
+type agent struct {
+	pin string
+}
+
+func (a agent) Release() *dbus.Error {
+	return nil
+}
+
+func (a agent) RequestPinCode(device dbus.ObjectPath) (string, *dbus.Error) {
+	return a.pin, nil
+}
+
+func (a agent) DisplayPinCode(device dbus.ObjectPath, pincode string) *dbus.Error {
+	return nil
+}
+
+func (a agent) RequestPasskey(device dbus.ObjectPath) (uint32, *dbus.Error) {
+	passkey, err := strconv.ParseUint(a.pin, 10, 32)
+	if err != nil {
+		return 0, dbus.NewError("org.bluez.Error.Rejected", nil)
+	}
+	return uint32(passkey), nil
+}
+
+func (a agent) DisplayPasskey(device dbus.ObjectPath, passkey uint32, entered uint16) *dbus.Error {
+	return nil
+}
+
+func (a agent) RequestConfirmation(device dbus.ObjectPath, passkey uint32) *dbus.Error {
+	return nil
+}
+
+func (a agent) RequestAuthorization(device dbus.ObjectPath) *dbus.Error {
+	return nil
+}
+
+func (a agent) AuthorizeService(device dbus.ObjectPath, uuid string) *dbus.Error {
+	return nil
+}
+
+func (a agent) Cancel() *dbus.Error {
+	return nil
+}
+
+// rfcommDevReq is struct rfcomm_dev_req
+type rfcommDevReq struct {
+	devID   int16
+	_       [2]byte
+	flags   uint32
+	src     [6]byte
+	dst     [6]byte
+	channel uint8
+	_       [3]byte
+}
+
+func rfcommIoctl(req uintptr, arg *rfcommDevReq) error {
+	fd, err := unix.Socket(unix.AF_BLUETOOTH, unix.SOCK_RAW|unix.SOCK_CLOEXEC, unix.BTPROTO_RFCOMM)
+	if err != nil {
+		return fmt.Errorf("rfcomm socket: %w", err)
+	}
+	defer unix.Close(fd)
+	_, _, errno := unix.Syscall(unix.SYS_IOCTL, uintptr(fd), req, uintptr(unsafe.Pointer(arg)))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+func bindRFCOMM(id int, address string, channel int) (string, error) {
+	hw, err := net.ParseMAC(address)
+	if err != nil || len(hw) != 6 {
+		return "", fmt.Errorf("bluetooth: invalid bluetooth address %q", address)
+	}
+	if channel < 1 || channel > 30 {
+		return "", fmt.Errorf("bluetooth: invalid rfcomm channel %d", channel)
+	}
+	req := rfcommDevReq{devID: int16(id), channel: uint8(channel)}
+	// bdaddr_t is little-endian, the source stays BDADDR_ANY
+	for i := range hw {
+		req.dst[i] = hw[len(hw)-1-i]
+	}
+	if err := rfcommIoctl(rfcommCreateDev, &req); err != nil {
+		return "", fmt.Errorf("bluetooth: bind /dev/rfcomm%d: %w", id, err)
+	}
+	return fmt.Sprintf("/dev/rfcomm%d", id), nil
+}
+
+func releaseRFCOMM(id int) error {
+	req := rfcommDevReq{devID: int16(id)}
+	if err := rfcommIoctl(rfcommReleaseDev, &req); err != nil {
+		return fmt.Errorf("bluetooth: release /dev/rfcomm%d: %w", id, err)
+	}
+	return nil
+}
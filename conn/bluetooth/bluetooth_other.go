@@ -0,0 +1,29 @@
+//go:build !linux
+// +build !linux
+
+package bluetooth
+
+import (
+	"errors"
+	"time"
+
+	"github.com/ka2n/ptouchgo/conn"
+)
+
+var errUnsupported = errors.New("bluetooth: pairing is only supported with BlueZ on Linux")
+
+func scan(timeout time.Duration) ([]conn.Device, error) {
+	return nil, errUnsupported
+}
+
+func pair(address, pin string, timeout time.Duration) error {
+	return errUnsupported
+}
+
+func bindRFCOMM(id int, address string, channel int) (string, error) {
+	return "", errUnsupported
+}
+
+func releaseRFCOMM(id int) error {
+	return errUnsupported
+}
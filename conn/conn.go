@@ -3,17 +3,27 @@ package conn
 import (
 	"fmt"
 	"io"
+	"reflect"
+	"sort"
 	"sync"
+	"time"
 )
 
 var (
-	driversMu sync.RWMutex
-	drivers   = make(map[string]Driver)
+	driversMu    sync.RWMutex
+	drivers      = make(map[string]Driver)
+	capabilities = make(map[string]Capabilities)
 )
 
 func init() {
-	Register("serial", DriverFunc(openSerial))
-	Register("tcp", DriverFunc(openTCP))
+	Register("serial", serialDriver{})
+	Register("net", DefaultNetDriver)
+	Register("tcp", DefaultNetDriver)
+	Register("wlan", DefaultWLANDriver)
+	Register("unix", DefaultUnixDriver)
+	Register("file", DefaultFileDriver)
+	Register("replay", ReplayDriver{})
+	Register("mock", mockDriver{})
 }
 
 // Driver is interface for connection backend
@@ -34,15 +44,32 @@ func Register(name string, driver Driver) {
 	drivers[name] = driver
 }
 
-// Open connection with specific driver backend and address
+// Open connection with specific driver backend and address using DefaultTimeouts,
+// an empty name selects the driver by the scheme of address like OpenAddress
 func Open(name, address string) (io.ReadWriteCloser, error) {
+	return OpenTimeout(name, address, DefaultTimeouts)
+}
+
+// OpenTimeout opens a connection whose reads and writes give up after timeouts,
+// connections of drivers without TimeoutDriver support are wrapped with WithTimeouts
+func OpenTimeout(name, address string, timeouts Timeouts) (io.ReadWriteCloser, error) {
+	if name == "" {
+		return OpenAddressTimeout(address, timeouts)
+	}
 	driversMu.RLock()
 	driver, ok := drivers[name]
 	driversMu.RUnlock()
 	if !ok {
 		return nil, fmt.Errorf("serial: unknown driver %q", name)
 	}
-	return driver.Open(address)
+	if d, ok := driver.(TimeoutDriver); ok {
+		return d.OpenTimeout(address, timeouts)
+	}
+	c, err := driver.Open(address)
+	if err != nil {
+		return nil, err
+	}
+	return WithTimeouts(c, timeouts), nil
 }
 
 // DriverFunc convert function into Driver like http.HandlerFunc
@@ -52,3 +79,104 @@ type DriverFunc func(address string) (io.ReadWriteCloser, error)
 func (f DriverFunc) Open(address string) (io.ReadWriteCloser, error) {
 	return f(address)
 }
+
+// Capabilities describe what a transport supports
+type Capabilities struct {
+	MaxWriteChunk  int           // largest write the transport takes at once, zero is unlimited
+	WriteInterval  time.Duration // pause between chunks of a large write
+	StatusReadback bool          // reads return status replies of the printer
+	FullDuplex     bool          // reads and writes may run at the same time
+}
+
+// DefaultCapabilities are assumed for drivers without CapabilitiesDriver support
+var DefaultCapabilities = Capabilities{StatusReadback: true}
+
+// CapabilitiesDriver is implemented by drivers which describe their transport
+type CapabilitiesDriver interface {
+	Driver
+	Capabilities() Capabilities
+}
+
+// SetCapabilities overrides the capabilities of the named driver, like a smaller
+// chunk size for a Bluetooth stack which drops large writes
+func SetCapabilities(name string, caps Capabilities) {
+	driversMu.Lock()
+	defer driversMu.Unlock()
+	capabilities[name] = caps
+}
+
+// DriverCapabilities returns the capabilities of the named driver
+func DriverCapabilities(name string) (Capabilities, error) {
+	driversMu.RLock()
+	driver, ok := drivers[name]
+	caps, overridden := capabilities[name]
+	driversMu.RUnlock()
+	if !ok {
+		return Capabilities{}, fmt.Errorf("serial: unknown driver %q", name)
+	}
+	if overridden {
+		return caps, nil
+	}
+	if d, ok := driver.(CapabilitiesDriver); ok {
+		return d.Capabilities(), nil
+	}
+	return DefaultCapabilities, nil
+}
+
+// Device is a printer candidate found by a driver
+type Device struct {
+	Driver  string // driver name for Open
+	Address string // address for Open
+	Model   string // model hint like "PT-P710BT", empty when unknown
+	Name    string // human readable description
+}
+
+// Lister is implemented by drivers which can discover devices
+type Lister interface {
+	List() ([]Device, error)
+}
+
+// List returns the devices discovered by every registered driver implementing Lister.
+// Drivers failing to list are skipped, the first error is returned together with
+// the devices found by the other drivers.
+func List() ([]Device, error) {
+	driversMu.RLock()
+	names := make([]string, 0, len(drivers))
+	for name := range drivers {
+		names = append(names, name)
+	}
+	listers := make(map[string]Lister)
+	for _, name := range names {
+		if l, ok := drivers[name].(Lister); ok {
+			listers[name] = l
+		}
+	}
+	driversMu.RUnlock()
+	sort.Strings(names)
+
+	var devices []Device
+	var firstErr error
+	listed := make(map[Lister]bool)
+	for _, name := range names {
+		l, ok := listers[name]
+		if !ok {
+			continue
+		}
+		// drivers registered with several names are listed once
+		if reflect.TypeOf(l).Comparable() {
+			if listed[l] {
+				continue
+			}
+			listed[l] = true
+		}
+		found, err := l.List()
+		if err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("list %s devices: %w", name, err)
+			}
+			continue
+		}
+		devices = append(devices, found...)
+	}
+	return devices, firstErr
+}
@@ -0,0 +1,75 @@
+package conn
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// AddressParser is implemented by drivers which check or rewrite the addresses of their scheme,
+// like a driver registered as "mqtt" turning "mqtt://broker/printers/p710" into its own address
+type AddressParser interface {
+	Driver
+	// ParseAddress receives the address after "scheme:" or "scheme://"
+	ParseAddress(address string) (string, error)
+}
+
+// ParseAddress splits an address into a driver name and a driver address.
+// The scheme is the name a driver is registered with like "usb:", "usb://0x7c35", "serial:/dev/rfcomm0",
+// "net:192.168.100.1", "wlan:192.168.118.1", "tcp://192.168.100.1:9100", "unix:/run/ptouchgo.sock" or "ble://AA:BB:CC:DD:EE:FF",
+// the rest after "scheme:" or "scheme://" is the driver address. Drivers implementing AddressParser
+// parse it themselves. Addresses without a scheme like "/dev/rfcomm0", "COM3" or `C:\job.prn`
+// are serial ports and "usb" alone is the first USB printer.
+func ParseAddress(address string) (driver, addr string, err error) {
+	if address == "usb" {
+		return "usb", "", nil
+	}
+	i := strings.Index(address, ":")
+	// a single letter is a Windows drive
+	if i < 2 || !isScheme(address[:i]) {
+		return "serial", address, nil
+	}
+	driver = strings.ToLower(address[:i])
+	addr = strings.TrimPrefix(address[i+1:], "//")
+
+	driversMu.RLock()
+	d, ok := drivers[driver]
+	driversMu.RUnlock()
+	if !ok {
+		return "", "", fmt.Errorf("serial: unknown driver %q", driver)
+	}
+	if p, ok := d.(AddressParser); ok {
+		addr, err = p.ParseAddress(addr)
+		if err != nil {
+			return "", "", fmt.Errorf("%s: %w", driver, err)
+		}
+	}
+	return driver, addr, nil
+}
+
+// isScheme reports whether s is a URI scheme as in RFC 3986
+func isScheme(s string) bool {
+	for i, c := range s {
+		switch {
+		case 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z':
+		case i > 0 && ('0' <= c && c <= '9' || c == '+' || c == '-' || c == '.'):
+		default:
+			return false
+		}
+	}
+	return s != ""
+}
+
+// OpenAddress opens an address in the format of ParseAddress with the driver claiming its scheme
+func OpenAddress(address string) (io.ReadWriteCloser, error) {
+	return OpenAddressTimeout(address, DefaultTimeouts)
+}
+
+// OpenAddressTimeout is OpenAddress with timeouts like OpenTimeout
+func OpenAddressTimeout(address string, timeouts Timeouts) (io.ReadWriteCloser, error) {
+	driver, addr, err := ParseAddress(address)
+	if err != nil {
+		return nil, err
+	}
+	return OpenTimeout(driver, addr, timeouts)
+}
@@ -0,0 +1,67 @@
+package conn
+
+import (
+	"io"
+	"os"
+	"sync"
+)
+
+// FileDriver writes everything sent to the printer into a spool file,
+// the file can be sent to a printer later like "cat job.prn > /dev/usb/lp0"
+type FileDriver struct {
+	TapeWidth int  // tape width in mm reported by status replies
+	Append    bool // append to an existing file instead of truncating it
+}
+
+// DefaultFileDriver is registered as "file"
+var DefaultFileDriver = FileDriver{TapeWidth: 24}
+
+// Open creates the spool file at address
+func (d FileDriver) Open(address string) (io.ReadWriteCloser, error) {
+	flag := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if d.Append {
+		flag = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	}
+	f, err := os.OpenFile(address, flag, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &fileConn{f: f, tapeWidth: d.TapeWidth}, nil
+}
+
+// OpenTimeout is Open, writing files has no need for timeouts
+func (d FileDriver) OpenTimeout(address string, timeouts Timeouts) (io.ReadWriteCloser, error) {
+	return d.Open(address)
+}
+
+// Capabilities of spool files, reads return canned statuses instead of the printer's
+func (FileDriver) Capabilities() Capabilities {
+	return Capabilities{}
+}
+
+type fileConn struct {
+	mu        sync.Mutex
+	f         *os.File
+	tapeWidth int
+	status    []byte
+}
+
+func (c *fileConn) Write(b []byte) (int, error) {
+	return c.f.Write(b)
+}
+
+// Read answers every read with a ready status
+func (c *fileConn) Read(b []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.status) == 0 {
+		c.status = statusFrame(c.tapeWidth, statusTypeReply)
+	}
+	n := copy(b, c.status)
+	c.status = c.status[n:]
+	return n, nil
+}
+
+func (c *fileConn) Close() error {
+	return c.f.Close()
+}
@@ -1,22 +1,57 @@
+//go:build !windows
+// +build !windows
+
 package conn
 
 import (
 	"io"
-	"net"
+	"path/filepath"
+	"runtime"
+	"strings"
 
 	"github.com/goburrow/serial"
 )
 
-// openSerial for generic serial connection
-func openSerial(address string) (io.ReadWriteCloser, error) {
-	return serial.Open(&serial.Config{
+// openSerial for generic serial connection, the port times out reads itself
+func openSerial(address string, timeouts Timeouts) (io.ReadWriteCloser, error) {
+	port, err := serial.Open(&serial.Config{
 		Address:  address,
 		BaudRate: 115200,
 		StopBits: 1,
 		Parity:   "N",
+		Timeout:  timeouts.Read,
 	})
+	if err != nil {
+		return nil, err
+	}
+	return WithTimeouts(port, Timeouts{Write: timeouts.Write}), nil
 }
 
-func openTCP(address string) (io.ReadWriteCloser, error) {
-	return net.Dial("tcp", address)
+// serialPorts lists RFCOMM devices bound with "rfcomm bind" on Linux
+// and the Bluetooth serial ports macOS creates for paired printers
+func serialPorts() ([]Device, error) {
+	pattern := "/dev/rfcomm*"
+	if runtime.GOOS == "darwin" {
+		pattern = "/dev/cu.*"
+	}
+	paths, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	var devices []Device
+	for _, path := range paths {
+		name := filepath.Base(path)
+		model := ModelHint(name)
+		if runtime.GOOS == "darwin" && model == "" {
+			continue
+		}
+		devices = append(devices, Device{
+			Driver:  "serial",
+			Address: path,
+			Model:   model,
+			Name:    strings.TrimPrefix(name, "cu."),
+		})
+	}
+	return devices, nil
 }
@@ -0,0 +1,123 @@
+package conn
+
+import (
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// mdnsService is advertised by Brother printers accepting raw jobs on port 9100
+const mdnsService = "_pdl-datastream._tcp.local."
+
+var mdnsGroup = &net.UDPAddr{IP: net.IPv4(224, 0, 0, 251), Port: 5353}
+
+type mdnsInstance struct {
+	host  string
+	port  uint16
+	model string
+}
+
+// browseMDNS queries service and collects answers until timeout.
+// The query is sent from an ephemeral port, so responders answer with unicast
+// and no multicast group membership is needed.
+func browseMDNS(service string, timeout time.Duration) ([]Device, error) {
+	name, err := dnsmessage.NewName(service)
+	if err != nil {
+		return nil, err
+	}
+	msg := dnsmessage.Message{
+		Questions: []dnsmessage.Question{{Name: name, Type: dnsmessage.TypePTR, Class: dnsmessage.ClassINET}},
+	}
+	query, err := msg.Pack()
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := net.ListenUDP("udp4", &net.UDPAddr{})
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+	if _, err := c.WriteToUDP(query, mdnsGroup); err != nil {
+		return nil, err
+	}
+	c.SetReadDeadline(time.Now().Add(timeout))
+
+	instances := make(map[string]*mdnsInstance)
+	addrs := make(map[string]net.IP)
+	var order []string
+	instance := func(name string) *mdnsInstance {
+		in, ok := instances[name]
+		if !ok {
+			in = &mdnsInstance{}
+			instances[name] = in
+			order = append(order, name)
+		}
+		return in
+	}
+
+	buf := make([]byte, 9000)
+	for {
+		n, from, err := c.ReadFromUDP(buf)
+		if err != nil {
+			// the deadline ends browsing
+			break
+		}
+		var resp dnsmessage.Message
+		if err := resp.Unpack(buf[:n]); err != nil {
+			continue
+		}
+		records := append(resp.Answers, resp.Additionals...)
+		for _, r := range records {
+			switch body := r.Body.(type) {
+			case *dnsmessage.PTRResource:
+				if strings.EqualFold(r.Header.Name.String(), service) {
+					instance(body.PTR.String())
+				}
+			case *dnsmessage.SRVResource:
+				in := instance(r.Header.Name.String())
+				in.host, in.port = body.Target.String(), body.Port
+			case *dnsmessage.TXTResource:
+				in := instance(r.Header.Name.String())
+				for _, txt := range body.TXT {
+					if strings.HasPrefix(txt, "ty=") || strings.HasPrefix(txt, "product=") {
+						if m := ModelHint(txt); m != "" {
+							in.model = m
+						}
+					}
+				}
+			case *dnsmessage.AResource:
+				addrs[r.Header.Name.String()] = net.IP(body.A[:])
+			}
+		}
+		// answers may come without address records, the sender is the printer
+		for _, in := range instances {
+			if in.host != "" && addrs[in.host] == nil {
+				addrs[in.host] = from.IP
+			}
+		}
+	}
+
+	var devices []Device
+	for _, name := range order {
+		in := instances[name]
+		ip := addrs[in.host]
+		if ip == nil {
+			continue
+		}
+		address := ip.String()
+		if in.port != 0 && strconv.Itoa(int(in.port)) != DefaultNetPort {
+			address = net.JoinHostPort(address, strconv.Itoa(int(in.port)))
+		}
+		devices = append(devices, Device{
+			Driver:  "net",
+			Address: address,
+			Model:   in.model,
+			Name:    strings.TrimSuffix(name, "."+service),
+		})
+	}
+	return devices, nil
+}
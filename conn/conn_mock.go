@@ -0,0 +1,592 @@
+package conn
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ka2n/ptouchgo/models"
+)
+
+// Errors reported by a Mock through InjectError, the low byte is the first and
+// the high byte the second error information byte of status replies
+const (
+	MockErrorNoMedia      = 0x0001
+	MockErrorCutterJam    = 0x0004
+	MockErrorWeakBattery  = 0x0008
+	MockErrorInvalidMedia = 0x0100
+	MockErrorCoverOpen    = 0x1000
+	MockErrorOverheat     = 0x2000
+)
+
+// Media types of NewModelMock, the media type byte of the status
+const (
+	MockMediaLaminated  = mediaTypeLaminated
+	MockMediaFabric     = mediaTypeFabric
+	MockMediaContinuous = mediaTypeContinuous // QL media and RD rolls
+	MockMediaDieCut     = mediaTypeDieCut
+	MockMediaHeatShrink = mediaTypeHeatShrink
+)
+
+// ErrMockNoStatus is returned by Mock reads when the emulated printer has nothing to send
+var ErrMockNoStatus = errors.New("mock: no status to read")
+
+const (
+	mockLineBytes    = 16 // 128 head pins of NewMock
+	mockModeAutoCut  = 0x40
+	mockModeMirror   = 0x80
+	mockExtHalfCut   = 0x04
+	mockExtHighDPI   = 0x40
+	mockExtTwoColor  = 0x01 // QL printers
+	mockCompressTIFF = 0x02
+
+	// printer settings of the PT-P710BT
+	mockSettingAutoPowerOffAC      = 0x41
+	mockSettingAutoPowerOffBattery = 0x42
+	mockSettingBatteryLevel        = 0x43
+
+	// wireless LAN settings of models with WLAN
+	mockSettingWLANMode = 0x60
+	mockSettingWLANIP   = 0x61
+)
+
+type mockState int
+
+const (
+	mockIdle mockState = iota
+	mockInitialized
+	mockRaster
+)
+
+// MockPage is a page printed by a Mock
+type MockPage struct {
+	Lines      [][]byte // raster lines of the bytes of the head of the model, the first bit is the first head pin
+	Red        [][]byte // red raster lines of two-color QL printing
+	AutoCut    bool
+	Mirror     bool
+	HalfCut    bool
+	HighDPI    bool
+	TwoColor   bool
+	FeedAmount int
+	Last       bool // printed with print and feed, ending the job
+}
+
+// Image returns the page as seen on the tape, lines go from left to right
+func (p MockPage) Image() *image.Gray {
+	pins := mockLineBytes * 8
+	if len(p.Lines) > 0 {
+		pins = len(p.Lines[0]) * 8
+	}
+	img := image.NewGray(image.Rect(0, 0, len(p.Lines), pins))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+	for x, line := range p.Lines {
+		for y := 0; y < pins; y++ {
+			if line[y/8]&(0x80>>uint(y%8)) != 0 {
+				img.SetGray(x, y, color.Gray{})
+			}
+		}
+	}
+	return img
+}
+
+// Mock emulates a raster printer in memory so printing code can be tested without a device.
+// It follows the command sequence, answers status requests and keeps printed pages.
+type Mock struct {
+	mu        sync.Mutex
+	spec      models.Spec
+	mediaType byte
+	tapeWidth int
+	length    int // mm of the die-cut labels of QL printers, zero for continuous length tape
+	state     mockState
+	in        []byte
+	out       []byte
+	errors    uint16
+	writeErr  error
+	protoErrs []error
+
+	settings map[byte]byte // PT-P710BT printer settings by setting id
+	version  string        // firmware version, mockFirmware when empty
+
+	compress bool
+	page     MockPage
+	lines    int // raster lines announced by print information
+	pages    []MockPage
+}
+
+// NewMock returns an emulated PT-P710BT loaded with laminated tape of tapeWidth mm
+func NewMock(tapeWidth int) *Mock {
+	m, _ := NewModelMock(statusModelPTP710BT, MockMediaLaminated, tapeWidth, 0)
+	return m
+}
+
+// NewModelMock returns an emulated printer of the model of code, see models.Lookup, loaded with media of
+// mediaType and width mm. length is the length of die-cut labels in mm, zero for continuous length tape.
+// Models printing on a wireless LAN are connected in infrastructure mode as 192.168.1.20.
+func NewModelMock(code, mediaType byte, width, length int) (*Mock, error) {
+	spec, ok := models.Lookup(code)
+	if !ok {
+		return nil, fmt.Errorf("mock: unknown model code %#02x", code)
+	}
+	m := &Mock{spec: spec, mediaType: mediaType, tapeWidth: width, length: length, settings: map[byte]byte{}}
+	if spec.PowerSettings {
+		m.settings[mockSettingAutoPowerOffAC] = 0
+		m.settings[mockSettingAutoPowerOffBattery] = 3
+		m.settings[mockSettingBatteryLevel] = 80
+	}
+	if spec.WLAN {
+		m.settings[mockSettingWLANMode] = 1
+		for i, b := range []byte{192, 168, 1, 20} {
+			m.settings[mockSettingWLANIP+byte(i)] = b
+		}
+	}
+	return m, nil
+}
+
+// mockFirmware is the firmware version of a Mock, new enough for every feature
+const mockFirmware = "1.10"
+
+// SetFirmware sets the firmware version the printer reports
+func (m *Mock) SetFirmware(version string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.version = version
+}
+
+func (m *Mock) firmware() string {
+	if m.version == "" {
+		return mockFirmware
+	}
+	return m.version
+}
+
+// mockDriver is registered as "mock", address is the tape width in mm of NewMock and defaults to 24,
+// or MODEL:MEDIA emulates the model named MODEL like "QL-820NWB:62" loaded with MEDIA of parseMockMedia
+type mockDriver struct{}
+
+// OpenTimeout is Open, a Mock never blocks
+func (d mockDriver) OpenTimeout(address string, timeouts Timeouts) (io.ReadWriteCloser, error) {
+	return d.Open(address)
+}
+
+func (mockDriver) Capabilities() Capabilities {
+	return Capabilities{StatusReadback: true}
+}
+
+func (mockDriver) Open(address string) (io.ReadWriteCloser, error) {
+	if i := strings.IndexByte(address, ':'); i >= 0 {
+		spec, ok := models.ByName(address[:i])
+		if !ok {
+			return nil, fmt.Errorf("mock: unknown model %q", address[:i])
+		}
+		mediaType, width, length, err := parseMockMedia(spec, address[i+1:])
+		if err != nil {
+			return nil, err
+		}
+		return NewModelMock(spec.Code, mediaType, width, length)
+	}
+	tapeWidth := 24
+	if address != "" {
+		w, err := strconv.Atoi(address)
+		if err != nil {
+			return nil, fmt.Errorf("mock: invalid tape width %q", address)
+		}
+		tapeWidth = w
+	}
+	return NewMock(tapeWidth), nil
+}
+
+// mockMediaTypes are the media types of mock addresses by name
+var mockMediaTypes = map[string]byte{
+	"laminated":  MockMediaLaminated,
+	"fabric":     MockMediaFabric,
+	"heatshrink": MockMediaHeatShrink,
+}
+
+// parseMockMedia parses media of spec like "24", "24-fabric", "12-heatshrink" or "62x29" of die-cut labels,
+// PT models are loaded with laminated tape and the others with continuous length tape without a type
+func parseMockMedia(spec models.Spec, media string) (mediaType byte, width, length int, err error) {
+	size := media
+	mediaType = MockMediaLaminated
+	if spec.Family != models.PT {
+		mediaType = MockMediaContinuous
+	}
+	if i := strings.IndexByte(media, '-'); i >= 0 {
+		size = media[:i]
+		t, ok := mockMediaTypes[media[i+1:]]
+		if !ok {
+			return 0, 0, 0, fmt.Errorf("mock: unknown media type %q", media[i+1:])
+		}
+		mediaType = t
+	}
+	widthStr, lengthStr := size, "0"
+	if i := strings.IndexByte(size, 'x'); i >= 0 {
+		widthStr, lengthStr = size[:i], size[i+1:]
+		mediaType = MockMediaDieCut
+	}
+	width, err = strconv.Atoi(widthStr)
+	if err == nil {
+		length, err = strconv.Atoi(lengthStr)
+	}
+	if err != nil {
+		return 0, 0, 0, fmt.Errorf("mock: invalid media %q", media)
+	}
+	return mediaType, width, length, nil
+}
+
+// InjectError makes the printer report errors, a combination of MockError values.
+// While errors are set print commands fail with an error status, zero clears them.
+func (m *Mock) InjectError(bits uint16) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.errors = bits
+	if bits != 0 {
+		m.reply(statusTypeErrorOccured, phaseTypeReceiving)
+	}
+}
+
+// InjectWriteError makes writes fail with err like a broken connection, nil restores them
+func (m *Mock) InjectWriteError(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.writeErr = err
+}
+
+// Pages returns the pages printed so far
+func (m *Mock) Pages() []MockPage {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]MockPage(nil), m.pages...)
+}
+
+// ProtocolErrors returns the violations of the command sequence seen so far
+func (m *Mock) ProtocolErrors() []error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]error(nil), m.protoErrs...)
+}
+
+func (m *Mock) Write(b []byte) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.writeErr != nil {
+		return 0, m.writeErr
+	}
+	m.in = append(m.in, b...)
+	for len(m.in) > 0 {
+		n := m.command(m.in)
+		if n == 0 {
+			break // incomplete command
+		}
+		m.in = m.in[n:]
+	}
+	return len(b), nil
+}
+
+func (m *Mock) Read(b []byte) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.out) == 0 {
+		return 0, ErrMockNoStatus
+	}
+	n := copy(b, m.out)
+	m.out = m.out[n:]
+	return n, nil
+}
+
+func (m *Mock) Close() error {
+	return nil
+}
+
+func (m *Mock) protocolError(format string, args ...interface{}) {
+	m.protoErrs = append(m.protoErrs, fmt.Errorf("mock: "+format, args...))
+}
+
+func (m *Mock) reply(statusType, phaseType byte) {
+	b := statusFrame(m.tapeWidth, statusType)
+	b[statusOffsetModel] = m.spec.Code
+	b[statusOffsetMediaType] = m.mediaType
+	if m.ql() {
+		b[statusOffsetSeries] = statusSeriesQL
+		b[statusOffsetMediaLength] = byte(m.length)
+	}
+	b[statusOffsetError1] = byte(m.errors)
+	b[statusOffsetError2] = byte(m.errors >> 8)
+	b[statusOffsetPhaseType] = phaseType
+	m.out = append(m.out, b...)
+}
+
+// command handles the command at the start of b and returns its length, 0 when b is incomplete
+func (m *Mock) command(b []byte) int {
+	// need reports whether b holds n bytes
+	need := func(n int) bool { return len(b) >= n }
+
+	switch b[0] {
+	case 0x00: // invalidate
+		if m.state == mockRaster && len(m.page.Lines) > 0 {
+			m.protocolError("invalidate while receiving raster data")
+		}
+		m.state = mockIdle
+		return 1
+	case 0x4d: // compression mode
+		if !need(2) {
+			return 0
+		}
+		m.compress = b[1] == mockCompressTIFF
+		return 2
+	case 0x47: // raster line
+		if !need(3) {
+			return 0
+		}
+		n := int(b[1]) | int(b[2])<<8
+		if !need(3 + n) {
+			return 0
+		}
+		m.rasterLine(b[3 : 3+n])
+		return 3 + n
+	case 0x5a: // zero raster line
+		m.rasterLine(nil)
+		return 1
+	case 0x67, 0x77: // uncompressed raster line of QL printers, black or red of two colors
+		if !need(3) {
+			return 0
+		}
+		n := int(b[2])
+		if !need(3 + n) {
+			return 0
+		}
+		if !m.ql() {
+			m.protocolError("QL raster command %#02x", b[0])
+		}
+		if b[0] == 0x77 && b[1] == 0x02 {
+			m.redLine(b[3 : 3+n])
+		} else {
+			m.rasterLine(b[3 : 3+n])
+		}
+		return 3 + n
+	case 0x0c, 0x1a: // print, print with feeding
+		m.print(b[0] == 0x1a)
+		return 1
+	case 0x1b:
+	default:
+		m.protocolError("unknown command %#02x", b[0])
+		return len(b)
+	}
+
+	if !need(2) {
+		return 0
+	}
+	if b[1] == 0x40 { // initialize
+		m.state = mockInitialized
+		m.compress = false
+		m.page = MockPage{}
+		m.lines = 0
+		return 2
+	}
+	if b[1] != 0x69 {
+		m.protocolError("unknown command %#02x %#02x", b[0], b[1])
+		return len(b)
+	}
+	if !need(3) {
+		return 0
+	}
+
+	switch b[2] {
+	case 0x53: // status request
+		m.reply(statusTypeReply, phaseTypeReceiving)
+		return 3
+	case 0x56: // firmware version
+		m.out = append(append(m.out, byte(len(m.firmware()))), m.firmware()...)
+		return 3
+	case 0x61: // switch mode
+		if !need(4) {
+			return 0
+		}
+		if m.state == mockIdle {
+			m.protocolError("mode switched before initialize")
+		}
+		if b[3] != 0x01 {
+			m.protocolError("unsupported command mode %d", b[3])
+		}
+		m.state = mockRaster
+		return 4
+	case 0x21: // notification mode
+		if !need(4) {
+			return 0
+		}
+		return 4
+	case 0x7a: // print information
+		if !need(13) {
+			return 0
+		}
+		if width := int(b[5]); width != 0 && width != m.tapeWidth {
+			m.protocolError("print information for %dmm tape, %dmm loaded", width, m.tapeWidth)
+			m.errors |= MockErrorInvalidMedia
+			m.reply(statusTypeErrorOccured, phaseTypeReceiving)
+		}
+		if m.ql() && int(b[6]) != m.length {
+			m.protocolError("print information for %dmm labels, %dmm loaded", b[6], m.length)
+		}
+		m.lines = int(b[7]) | int(b[8])<<8 | int(b[9])<<16 | int(b[10])<<24
+		return 13
+	case 0x4d: // various mode
+		if !need(4) {
+			return 0
+		}
+		m.page.AutoCut = b[3]&mockModeAutoCut != 0
+		m.page.Mirror = b[3]&mockModeMirror != 0
+		return 4
+	case 0x41: // cut each pages
+		if !need(4) {
+			return 0
+		}
+		return 4
+	case 0x4b: // advanced mode
+		if !need(4) {
+			return 0
+		}
+		if m.ql() {
+			m.page.TwoColor = b[3]&mockExtTwoColor != 0
+		} else {
+			m.page.HalfCut = b[3]&mockExtHalfCut != 0
+		}
+		m.page.HighDPI = b[3]&mockExtHighDPI != 0
+		return 4
+	case 0x55: // printer settings
+		if !need(6) {
+			return 0
+		}
+		if len(m.settings) == 0 || b[4] != 0x01 {
+			m.protocolError("unknown command 1b 69 55 %02x %02x", b[3], b[4])
+			return len(b)
+		}
+		switch b[3] {
+		case 0x72: // read
+			if v, ok := m.settings[b[5]]; ok {
+				m.out = append(m.out, b[5], v)
+			} else {
+				m.protocolError("unknown setting %#02x", b[5])
+			}
+			return 6
+		case 0x77: // write
+			if !need(7) {
+				return 0
+			}
+			if _, ok := m.settings[b[5]]; !ok || b[5] == mockSettingBatteryLevel {
+				m.protocolError("setting %#02x can not be written", b[5])
+			} else {
+				m.settings[b[5]] = b[6]
+			}
+			return 7
+		}
+		m.protocolError("unknown command 1b 69 55 %02x", b[3])
+		return len(b)
+	case 0x64: // margin amount
+		if !need(5) {
+			return 0
+		}
+		m.page.FeedAmount = int(b[3]) | int(b[4])<<8
+		return 5
+	}
+	m.protocolError("unknown command 1b 69 %02x", b[2])
+	return len(b)
+}
+
+func (m *Mock) rasterLine(data []byte) {
+	if m.state != mockRaster {
+		m.protocolError("raster data before switching to raster mode")
+	}
+	line := make([]byte, m.lineBytes())
+	if m.compress {
+		unpacked, err := unpackBits(data)
+		if err != nil {
+			m.protocolError("raster line %d: %v", len(m.page.Lines), err)
+		}
+		data = unpacked
+	}
+	if len(data) > len(line) {
+		m.protocolError("raster line %d has %d bytes", len(m.page.Lines), len(data))
+	}
+	copy(line, data)
+	m.page.Lines = append(m.page.Lines, line)
+}
+
+// redLine keeps a red raster line of two-color printing
+func (m *Mock) redLine(data []byte) {
+	if !m.page.TwoColor {
+		m.protocolError("red raster line without two-color printing")
+	}
+	line := make([]byte, m.lineBytes())
+	copy(line, data)
+	m.page.Red = append(m.page.Red, line)
+}
+
+func (m *Mock) lineBytes() int {
+	return m.spec.LineBytes()
+}
+
+// ql reports whether the model speaks the protocol of QL printers
+func (m *Mock) ql() bool {
+	return m.spec.Family != models.PT
+}
+
+func (m *Mock) print(last bool) {
+	if m.state != mockRaster {
+		m.protocolError("print before switching to raster mode")
+	}
+	if m.lines != 0 && m.lines != len(m.page.Lines) {
+		m.protocolError("print information announced %d raster lines, got %d", m.lines, len(m.page.Lines))
+	}
+	if m.errors != 0 {
+		m.reply(statusTypeErrorOccured, phaseTypeReceiving)
+	} else {
+		m.reply(statusTypePhaseChange, phaseTypePrinting)
+		m.page.Last = last
+		m.pages = append(m.pages, m.page)
+		m.reply(statusTypePrintingCompleted, phaseTypePrinting)
+		m.reply(statusTypePhaseChange, phaseTypeReceiving)
+	}
+
+	// settings stay for the following pages of the job
+	m.page = MockPage{
+		AutoCut:    m.page.AutoCut,
+		Mirror:     m.page.Mirror,
+		HalfCut:    m.page.HalfCut,
+		HighDPI:    m.page.HighDPI,
+		TwoColor:   m.page.TwoColor,
+		FeedAmount: m.page.FeedAmount,
+	}
+	m.lines = 0
+}
+
+// unpackBits decodes TIFF PackBits
+func unpackBits(b []byte) ([]byte, error) {
+	var out []byte
+	for i := 0; i < len(b); {
+		n := int(int8(b[i]))
+		i++
+		switch {
+		case n >= 0:
+			if i+n+1 > len(b) {
+				return out, fmt.Errorf("packbits literal exceeds data")
+			}
+			out = append(out, b[i:i+n+1]...)
+			i += n + 1
+		case n != -128:
+			if i >= len(b) {
+				return out, fmt.Errorf("packbits run exceeds data")
+			}
+			for j := 0; j < 1-n; j++ {
+				out = append(out, b[i])
+			}
+			i++
+		}
+	}
+	return out, nil
+}
@@ -0,0 +1,268 @@
+package conn
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// job returns the commands of a job printing lines as its only page, lines are packed with TIFF PackBits
+func job(tapeWidth int, lines ...[]byte) []byte {
+	var b bytes.Buffer
+	b.Write(make([]byte, 100))                   // invalidate
+	b.Write([]byte{0x1b, 0x40})                  // initialize
+	b.Write([]byte{0x1b, 0x69, 0x61, 0x01})      // raster mode
+	b.Write([]byte{0x1b, 0x69, 0x7a, 0x84, 0x00, // print information of lines on tapeWidth mm
+		byte(tapeWidth), 0x00, byte(len(lines)), 0x00, 0x00, 0x00, 0x00, 0x00})
+	b.Write([]byte{0x1b, 0x69, 0x4d, mockModeAutoCut})
+	b.Write([]byte{0x1b, 0x69, 0x64, 0x0e, 0x00}) // 14 lines of margin
+	b.Write([]byte{0x4d, mockCompressTIFF})
+	for _, line := range lines {
+		if bytes.Equal(line, make([]byte, len(line))) {
+			b.WriteByte(0x5a)
+			continue
+		}
+		packed := append([]byte{byte(len(line) - 1)}, line...)
+		b.Write([]byte{0x47, byte(len(packed)), 0x00})
+		b.Write(packed)
+	}
+	b.WriteByte(0x1a) // print with feeding
+	return b.Bytes()
+}
+
+// line returns a raster line of 16 bytes starting with b
+func line(b ...byte) []byte {
+	l := make([]byte, mockLineBytes)
+	copy(l, b)
+	return l
+}
+
+func write(t *testing.T, m *Mock, b []byte) {
+	t.Helper()
+	if _, err := m.Write(b); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+}
+
+// statuses reads the status replies sent so far
+func statuses(t *testing.T, m *Mock) [][]byte {
+	t.Helper()
+	var out [][]byte
+	for {
+		b := make([]byte, statusFrameSize)
+		_, err := io.ReadFull(m, b)
+		if errors.Is(err, ErrMockNoStatus) {
+			return out
+		}
+		if err != nil {
+			t.Fatalf("Read: %v", err)
+		}
+		out = append(out, b)
+	}
+}
+
+func TestMockPrintsJob(t *testing.T) {
+	m := NewMock(24)
+	write(t, m, job(24, line(0x80), line(), line(0xff, 0xff)))
+
+	if errs := m.ProtocolErrors(); len(errs) != 0 {
+		t.Fatalf("ProtocolErrors = %v", errs)
+	}
+	pages := m.Pages()
+	if len(pages) != 1 {
+		t.Fatalf("printed %d pages, want 1", len(pages))
+	}
+	p := pages[0]
+	if !p.AutoCut || !p.Last || p.Mirror || p.FeedAmount != 14 {
+		t.Errorf("page settings = %+v", p)
+	}
+	want := [][]byte{line(0x80), line(), line(0xff, 0xff)}
+	if len(p.Lines) != len(want) {
+		t.Fatalf("page has %d lines, want %d", len(p.Lines), len(want))
+	}
+	for i := range want {
+		if !bytes.Equal(p.Lines[i], want[i]) {
+			t.Errorf("line %d = %x, want %x", i, p.Lines[i], want[i])
+		}
+	}
+	if img := p.Image(); img.Bounds().Dx() != 3 || img.Bounds().Dy() != 128 || img.GrayAt(0, 0).Y != 0 || img.GrayAt(0, 1).Y != 0xff {
+		t.Errorf("Image is %v with dots %d and %d", img.Bounds(), img.GrayAt(0, 0).Y, img.GrayAt(0, 1).Y)
+	}
+
+	// printing, printing completed and receiving again
+	var got []byte
+	for _, st := range statuses(t, m) {
+		got = append(got, st[statusOffsetStatusType])
+	}
+	if want := []byte{statusTypePhaseChange, statusTypePrintingCompleted, statusTypePhaseChange}; !bytes.Equal(got, want) {
+		t.Errorf("status types = %x, want %x", got, want)
+	}
+}
+
+func TestMockCommandsSplitAcrossWrites(t *testing.T) {
+	m := NewMock(12)
+	for _, b := range job(12, line(0x01), line(0x02)) {
+		write(t, m, []byte{b})
+	}
+	if errs := m.ProtocolErrors(); len(errs) != 0 {
+		t.Fatalf("ProtocolErrors = %v", errs)
+	}
+	if pages := m.Pages(); len(pages) != 1 || len(pages[0].Lines) != 2 {
+		t.Fatalf("Pages = %+v", pages)
+	}
+}
+
+func TestMockStatusRequest(t *testing.T) {
+	m := NewMock(18)
+	write(t, m, []byte{0x1b, 0x69, 0x53})
+	sts := statuses(t, m)
+	if len(sts) != 1 {
+		t.Fatalf("%d replies, want 1", len(sts))
+	}
+	st := sts[0]
+	if st[statusOffsetModel] != statusModelPTP710BT || st[statusOffsetMediaWidth] != 18 ||
+		st[statusOffsetMediaType] != MockMediaLaminated || st[statusOffsetStatusType] != statusTypeReply {
+		t.Errorf("status = %x", st)
+	}
+}
+
+func TestMockProtocolErrors(t *testing.T) {
+	// announces one line and sends two
+	extraLine := job(24, line(0x01))
+	extraLine = append(extraLine[:len(extraLine)-1], 0x5a, 0x1a)
+
+	tests := []struct {
+		name     string
+		commands []byte
+		want     string
+	}{
+		{"raster before raster mode", []byte{0x1b, 0x40, 0x47, 0x01, 0x00, 0x00}, "raster data before switching to raster mode"},
+		{"mode before initialize", []byte{0x1b, 0x69, 0x61, 0x01}, "mode switched before initialize"},
+		{"print before raster mode", []byte{0x1b, 0x40, 0x0c}, "print before switching to raster mode"},
+		{"unsupported mode", []byte{0x1b, 0x40, 0x1b, 0x69, 0x61, 0x03}, "unsupported command mode 3"},
+		{"unknown command", []byte{0x99}, "unknown command 0x99"},
+		{"QL raster on PT", []byte{0x1b, 0x40, 0x1b, 0x69, 0x61, 0x01, 0x67, 0x00, 0x01, 0xff}, "QL raster command 0x67"},
+		{"tape mismatch", []byte{0x1b, 0x69, 0x7a, 0x84, 0x00, 12, 0, 0, 0, 0, 0, 0, 0}, "print information for 12mm tape, 24mm loaded"},
+		{"line count mismatch", extraLine, "print information announced 1 raster lines, got 2"},
+	}
+	for _, tt := range tests {
+		m := NewMock(24)
+		write(t, m, tt.commands)
+		errs := m.ProtocolErrors()
+		found := false
+		for _, err := range errs {
+			found = found || strings.Contains(err.Error(), tt.want)
+		}
+		if !found {
+			t.Errorf("%s: ProtocolErrors = %v, want %q", tt.name, errs, tt.want)
+		}
+	}
+}
+
+func TestMockInjectError(t *testing.T) {
+	m := NewMock(24)
+	m.InjectError(MockErrorCoverOpen | MockErrorNoMedia)
+	sts := statuses(t, m)
+	if len(sts) != 1 || sts[0][statusOffsetStatusType] != statusTypeErrorOccured {
+		t.Fatalf("replies after InjectError = %x", sts)
+	}
+	if e1, e2 := sts[0][statusOffsetError1], sts[0][statusOffsetError2]; e1 != byte(MockErrorNoMedia) || e2 != byte(MockErrorCoverOpen>>8) {
+		t.Errorf("error information = %#02x %#02x", e1, e2)
+	}
+
+	// jobs fail while the error is set
+	write(t, m, job(24, line(0x01)))
+	if pages := m.Pages(); len(pages) != 0 {
+		t.Errorf("printed %d pages with the cover open", len(pages))
+	}
+	if sts := statuses(t, m); len(sts) != 1 || sts[0][statusOffsetStatusType] != statusTypeErrorOccured {
+		t.Errorf("replies to the print = %x", sts)
+	}
+
+	m.InjectError(0)
+	write(t, m, job(24, line(0x01)))
+	if pages := m.Pages(); len(pages) != 1 {
+		t.Errorf("printed %d pages after clearing the error, want 1", len(pages))
+	}
+	if errs := m.ProtocolErrors(); len(errs) != 0 {
+		t.Errorf("ProtocolErrors = %v", errs)
+	}
+}
+
+func TestMockInvalidMedia(t *testing.T) {
+	m := NewMock(24)
+	write(t, m, job(12, line(0x01)))
+	if errs := m.ProtocolErrors(); len(errs) != 1 || !strings.Contains(errs[0].Error(), "12mm tape, 24mm loaded") {
+		t.Errorf("ProtocolErrors = %v", errs)
+	}
+	if pages := m.Pages(); len(pages) != 0 {
+		t.Errorf("printed %d pages on the wrong tape", len(pages))
+	}
+	sts := statuses(t, m)
+	if len(sts) == 0 || sts[0][statusOffsetError2] != byte(MockErrorInvalidMedia>>8) {
+		t.Errorf("replies = %x, want an invalid media error", sts)
+	}
+}
+
+func TestMockInjectWriteError(t *testing.T) {
+	m := NewMock(24)
+	broken := errors.New("broken pipe")
+	m.InjectWriteError(broken)
+	if _, err := m.Write([]byte{0x1b, 0x40}); err != broken {
+		t.Errorf("Write = %v, want %v", err, broken)
+	}
+	m.InjectWriteError(nil)
+	write(t, m, job(24, line(0x01)))
+	if len(m.Pages()) != 1 {
+		t.Errorf("the job after restoring writes did not print")
+	}
+}
+
+func TestMockDriverAddresses(t *testing.T) {
+	tests := []struct {
+		address   string
+		model     byte
+		mediaType byte
+		width     byte
+		length    byte
+		lineBytes int
+	}{
+		{"", statusModelPTP710BT, MockMediaLaminated, 24, 0, 16},
+		{"12", statusModelPTP710BT, MockMediaLaminated, 12, 0, 16},
+		{"QL-820NWB:62", 0x41, MockMediaContinuous, 62, 0, 90},
+		{"ql-820nwb:62x29", 0x41, MockMediaDieCut, 62, 29, 90},
+		{"QL-1100:102", 0x43, MockMediaContinuous, 102, 0, 162},
+		{"TD-2130N:58", 0x3c, MockMediaContinuous, 58, 0, 56},
+		{"PT-P950NW:36", 0x70, MockMediaLaminated, 36, 0, 70},
+		{"PT-P750W:12-heatshrink", 0x68, MockMediaHeatShrink, 12, 0, 16},
+		{"PT-P710BT:12-fabric", statusModelPTP710BT, MockMediaFabric, 12, 0, 16},
+	}
+	for _, tt := range tests {
+		rwc, err := mockDriver{}.Open(tt.address)
+		if err != nil {
+			t.Errorf("Open(%q): %v", tt.address, err)
+			continue
+		}
+		m := rwc.(*Mock)
+		write(t, m, []byte{0x1b, 0x69, 0x53})
+		st := statuses(t, m)[0]
+		if st[statusOffsetModel] != tt.model || st[statusOffsetMediaType] != tt.mediaType ||
+			st[statusOffsetMediaWidth] != tt.width || st[statusOffsetMediaLength] != tt.length {
+			t.Errorf("Open(%q) status = %x", tt.address, st)
+		}
+		if m.lineBytes() != tt.lineBytes {
+			t.Errorf("Open(%q) takes lines of %d bytes, want %d", tt.address, m.lineBytes(), tt.lineBytes)
+		}
+	}
+
+	for _, address := range []string{"x", "ql:62", "PT-P750W:12-glitter", "QL-820NWB:62xx", "QL-820NWB:"} {
+		if _, err := (mockDriver{}).Open(address); err == nil {
+			t.Errorf("Open(%q) succeeded", address)
+		}
+	}
+	if _, err := NewModelMock(0xfe, MockMediaLaminated, 24, 0); err == nil {
+		t.Errorf("NewModelMock of an unknown model succeeded")
+	}
+}
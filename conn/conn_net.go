@@ -0,0 +1,92 @@
+package conn
+
+import (
+	"io"
+	"io/ioutil"
+	"net"
+	"strings"
+	"time"
+)
+
+// DefaultNetPort is the raw printing port of Brother network printers
+const DefaultNetPort = "9100"
+
+// NetDriver connects to network printers over TCP.
+// Zero timeouts disable the corresponding deadline.
+type NetDriver struct {
+	DialTimeout time.Duration
+	KeepAlive   time.Duration
+
+	BrowseTimeout time.Duration // time List waits for mDNS answers
+}
+
+// DefaultNetDriver is registered as "net" and "tcp"
+var DefaultNetDriver = NetDriver{
+	DialTimeout: 10 * time.Second,
+	KeepAlive:   30 * time.Second,
+
+	BrowseTimeout: time.Second,
+}
+
+// Open connects to address with DefaultTimeouts
+func (d NetDriver) Open(address string) (io.ReadWriteCloser, error) {
+	return d.OpenTimeout(address, DefaultTimeouts)
+}
+
+// OpenTimeout connects to address, port 9100 is used when address has no port
+func (d NetDriver) OpenTimeout(address string, timeouts Timeouts) (io.ReadWriteCloser, error) {
+	dialer := net.Dialer{
+		Timeout:   d.DialTimeout,
+		KeepAlive: d.KeepAlive,
+	}
+	c, err := dialer.Dial("tcp", netAddress(address))
+	if err != nil {
+		return nil, err
+	}
+	tcp := c.(*net.TCPConn)
+	return &netConn{
+		deadlineConn: deadlineConn{deadliner: tcp, timeouts: timeouts},
+		conn:         tcp,
+	}, nil
+}
+
+// Capabilities of raw TCP printing, status replies come back on the same connection
+func (NetDriver) Capabilities() Capabilities {
+	return Capabilities{StatusReadback: true, FullDuplex: true}
+}
+
+// List browses for printers announcing raw printing with mDNS
+func (d NetDriver) List() ([]Device, error) {
+	return browseMDNS(mdnsService, d.BrowseTimeout)
+}
+
+func netAddress(address string) string {
+	if _, _, err := net.SplitHostPort(address); err == nil {
+		return address
+	}
+	host := strings.TrimSuffix(strings.TrimPrefix(address, "["), "]")
+	return net.JoinHostPort(host, DefaultNetPort)
+}
+
+// halfCloser is a *net.TCPConn or *net.UnixConn
+type halfCloser interface {
+	net.Conn
+	CloseWrite() error
+}
+
+type netConn struct {
+	deadlineConn
+	conn halfCloser
+}
+
+// Close half-closes the connection so the printer sees the end of the job,
+// then waits shortly for the printer to close its side
+func (c *netConn) Close() error {
+	if err := c.conn.CloseWrite(); err != nil {
+		c.conn.Close()
+		return err
+	}
+	c.conn.SetReadDeadline(time.Now().Add(time.Second))
+	io.Copy(ioutil.Discard, c.conn)
+	return c.conn.Close()
+}
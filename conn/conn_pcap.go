@@ -0,0 +1,217 @@
+package conn
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// pcap link types of supported captures
+const (
+	linkTypeEthernet       = 1
+	linkTypeRaw            = 101
+	linkTypeLinuxSLL       = 113
+	linkTypeUSBLinux       = 189
+	linkTypeUSBLinuxMmaped = 220
+	linkTypeUSBPcap        = 249
+	linkTypeLinuxSLL2      = 276
+)
+
+// USB endpoints of the printer's bulk interface
+const (
+	usbEndpointOut = 0x02
+	usbEndpointIn  = 0x81
+	usbBulk        = 3
+)
+
+// rawPrintPort is DefaultNetPort
+const rawPrintPort = 9100
+
+var errTruncated = errors.New("truncated packet")
+
+// ReadPcap extracts a session from a pcap capture, either TCP traffic to port 9100
+// or USB bulk transfers captured with usbmon on Linux or USBPcap on Windows.
+func ReadPcap(r io.Reader) ([]Transfer, error) {
+	var hdr [24]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return nil, fmt.Errorf("pcap header: %w", err)
+	}
+	var order binary.ByteOrder
+	switch binary.LittleEndian.Uint32(hdr[:4]) {
+	case 0xa1b2c3d4, 0xa1b23c4d:
+		order = binary.LittleEndian
+	case 0xd4c3b2a1, 0x4d3cb2a1:
+		order = binary.BigEndian
+	default:
+		return nil, fmt.Errorf("not a pcap file, pcapng captures have to be converted with editcap -F pcap")
+	}
+	linkType := order.Uint32(hdr[20:24])
+
+	var session []Transfer
+	tcp := make(map[byte]uint32) // end of the highest sequence seen per direction
+	var rec [16]byte
+	for {
+		if _, err := io.ReadFull(r, rec[:]); err != nil {
+			if err == io.EOF {
+				return session, nil
+			}
+			return nil, fmt.Errorf("pcap record: %w", err)
+		}
+		packet := make([]byte, order.Uint32(rec[8:12]))
+		if _, err := io.ReadFull(r, packet); err != nil {
+			return nil, fmt.Errorf("pcap record: %w", err)
+		}
+
+		var t Transfer
+		var err error
+		switch linkType {
+		case linkTypeUSBLinux, linkTypeUSBLinuxMmaped:
+			t, err = usbmonTransfer(packet, linkType)
+		case linkTypeUSBPcap:
+			t, err = usbpcapTransfer(packet)
+		case linkTypeEthernet, linkTypeRaw, linkTypeLinuxSLL, linkTypeLinuxSLL2:
+			t, err = tcpTransfer(packet, linkType, tcp)
+		default:
+			return nil, fmt.Errorf("unsupported pcap link type %d", linkType)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(t.Data) == 0 {
+			continue
+		}
+		// consecutive packets in one direction are one transfer
+		if n := len(session); n > 0 && session[n-1].Dir == t.Dir {
+			session[n-1].Data = append(session[n-1].Data, t.Data...)
+			continue
+		}
+		session = append(session, t)
+	}
+}
+
+// usbmonTransfer decodes Linux usbmon packets, data of OUT transfers is captured
+// on submission and data of IN transfers on completion
+func usbmonTransfer(p []byte, linkType uint32) (Transfer, error) {
+	size := 48
+	if linkType == linkTypeUSBLinuxMmaped {
+		size = 64
+	}
+	if len(p) < size {
+		return Transfer{}, errTruncated
+	}
+	event, xfer, ep := p[8], p[9], p[10]
+	data := p[size:]
+	switch {
+	case xfer != usbBulk:
+	case event == 'S' && ep == usbEndpointOut:
+		return Transfer{Dir: DirWrite, Data: data}, nil
+	case event == 'C' && ep == usbEndpointIn:
+		return Transfer{Dir: DirRead, Data: data}, nil
+	}
+	return Transfer{}, nil
+}
+
+// usbpcapTransfer decodes USBPcap packets
+func usbpcapTransfer(p []byte) (Transfer, error) {
+	if len(p) < 27 {
+		return Transfer{}, errTruncated
+	}
+	headerLen := int(binary.LittleEndian.Uint16(p[0:2]))
+	if len(p) < headerLen {
+		return Transfer{}, errTruncated
+	}
+	completion := p[16]&1 == 1
+	ep, xfer := p[21], p[22]
+	data := p[headerLen:]
+	switch {
+	case xfer != usbBulk:
+	case !completion && ep == usbEndpointOut:
+		return Transfer{Dir: DirWrite, Data: data}, nil
+	case completion && ep == usbEndpointIn:
+		return Transfer{Dir: DirRead, Data: data}, nil
+	}
+	return Transfer{}, nil
+}
+
+// tcpTransfer decodes TCP segments from or to port 9100, retransmissions are skipped
+func tcpTransfer(p []byte, linkType uint32, seen map[byte]uint32) (Transfer, error) {
+	var etherType uint16
+	switch linkType {
+	case linkTypeEthernet:
+		if len(p) < 14 {
+			return Transfer{}, errTruncated
+		}
+		etherType, p = binary.BigEndian.Uint16(p[12:14]), p[14:]
+		if etherType == 0x8100 && len(p) >= 4 {
+			etherType, p = binary.BigEndian.Uint16(p[2:4]), p[4:]
+		}
+	case linkTypeLinuxSLL:
+		if len(p) < 16 {
+			return Transfer{}, errTruncated
+		}
+		etherType, p = binary.BigEndian.Uint16(p[14:16]), p[16:]
+	case linkTypeLinuxSLL2:
+		if len(p) < 20 {
+			return Transfer{}, errTruncated
+		}
+		etherType, p = binary.BigEndian.Uint16(p[0:2]), p[20:]
+	case linkTypeRaw:
+		if len(p) > 0 && p[0]>>4 == 6 {
+			etherType = 0x86dd
+		} else {
+			etherType = 0x0800
+		}
+	}
+
+	switch etherType {
+	case 0x0800:
+		if len(p) < 20 || p[9] != 6 {
+			return Transfer{}, nil
+		}
+		total := int(binary.BigEndian.Uint16(p[2:4]))
+		if total >= 20 && total < len(p) {
+			p = p[:total] // drop ethernet padding
+		}
+		p = p[int(p[0]&0x0f)*4:]
+	case 0x86dd:
+		if len(p) < 40 || p[6] != 6 {
+			return Transfer{}, nil
+		}
+		p = p[40:]
+	default:
+		return Transfer{}, nil
+	}
+
+	if len(p) < 20 {
+		return Transfer{}, errTruncated
+	}
+	src, dst := binary.BigEndian.Uint16(p[0:2]), binary.BigEndian.Uint16(p[2:4])
+	seq := binary.BigEndian.Uint32(p[4:8])
+	offset := int(p[12]>>4) * 4
+	if len(p) < offset {
+		return Transfer{}, errTruncated
+	}
+	data := p[offset:]
+
+	var dir byte
+	switch {
+	case dst == rawPrintPort:
+		dir = DirWrite
+	case src == rawPrintPort:
+		dir = DirRead
+	default:
+		return Transfer{}, nil
+	}
+	end := seq + uint32(len(data))
+	if last, ok := seen[dir]; ok {
+		if int32(end-last) <= 0 {
+			return Transfer{}, nil
+		}
+		if int32(last-seq) > 0 {
+			data = data[last-seq:]
+		}
+	}
+	seen[dir] = end
+	return Transfer{Dir: dir, Data: data}, nil
+}
@@ -0,0 +1,195 @@
+package conn
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"syscall"
+	"time"
+)
+
+var (
+	// ErrLinkLost marks driver errors caused by a lost link like a disconnected device
+	ErrLinkLost = errors.New("conn: link lost")
+
+	// ErrReconnected is returned by a Reconnecting connection for the operation which lost the link
+	// after it was reopened. Data sent before is gone with the printer's buffer, so an interrupted
+	// job has to be sent again from the start.
+	ErrReconnected = errors.New("conn: link lost and reopened")
+)
+
+// IsLinkLost reports whether err means the connection is gone and has to be reopened,
+// timeouts are not link losses since the operation may still complete
+func IsLinkLost(err error) bool {
+	if err == nil || IsTimeout(err) {
+		return false
+	}
+	for _, target := range []error{
+		ErrLinkLost,
+		io.EOF,
+		io.ErrUnexpectedEOF,
+		io.ErrClosedPipe,
+		syscall.EPIPE,
+		syscall.ECONNRESET,
+		syscall.ECONNABORTED,
+		syscall.ENOTCONN,
+		syscall.EHOSTDOWN,
+		syscall.EHOSTUNREACH,
+		syscall.ENODEV,
+		syscall.ENXIO,
+		syscall.EIO,
+	} {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// ReconnectOptions configure a Reconnecting connection
+type ReconnectOptions struct {
+	Timeouts Timeouts
+	Attempts int           // reopen attempts after a lost link
+	Backoff  time.Duration // wait before the first attempt, doubled after each failed attempt
+
+	// Restore is called with the reopened connection to bring the printer back into a known state
+	Restore func(rw io.ReadWriter) error
+}
+
+// DefaultReconnectOptions give a Bluetooth printer a few seconds to come back
+var DefaultReconnectOptions = ReconnectOptions{
+	Timeouts: DefaultTimeouts,
+	Attempts: 5,
+	Backoff:  500 * time.Millisecond,
+}
+
+// Reconnecting reopens the connection of a driver with the same address when the link is lost.
+// The failed read or write returns an error wrapping ErrReconnected once the link is back,
+// or the last open error when all attempts failed, the next operation tries again.
+type Reconnecting struct {
+	name, address string
+	opts          ReconnectOptions
+
+	mu         sync.Mutex
+	conn       io.ReadWriteCloser
+	reconnects int
+	closed     bool
+}
+
+// OpenReconnecting opens a connection like OpenTimeout which is reopened when the link is lost
+func OpenReconnecting(name, address string, opts ReconnectOptions) (*Reconnecting, error) {
+	c, err := OpenTimeout(name, address, opts.Timeouts)
+	if err != nil {
+		return nil, err
+	}
+	return &Reconnecting{name: name, address: address, opts: opts, conn: c}, nil
+}
+
+// Reconnects returns the number of times the link was reopened
+func (r *Reconnecting) Reconnects() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.reconnects
+}
+
+func (r *Reconnecting) current() (io.ReadWriteCloser, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return nil, io.ErrClosedPipe
+	}
+	if r.conn == nil {
+		if err := r.reopen(); err != nil {
+			return nil, err
+		}
+	}
+	return r.conn, nil
+}
+
+// recover reopens the link after err happened on c
+func (r *Reconnecting) recover(c io.ReadWriteCloser, err error) error {
+	if !IsLinkLost(err) {
+		return err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return err
+	}
+	if r.conn != c {
+		// another operation already reopened the link
+		return fmt.Errorf("%v: %w", err, ErrReconnected)
+	}
+	r.conn.Close()
+	r.conn = nil
+	if rerr := r.reopen(); rerr != nil {
+		return rerr
+	}
+	return fmt.Errorf("%v: %w", err, ErrReconnected)
+}
+
+// reopen must be called with mu held
+func (r *Reconnecting) reopen() error {
+	attempts := r.opts.Attempts
+	if attempts < 1 {
+		attempts = 1
+	}
+	wait := r.opts.Backoff
+	var err error
+	for i := 0; i < attempts; i++ {
+		time.Sleep(wait)
+		wait *= 2
+		var c io.ReadWriteCloser
+		c, err = OpenTimeout(r.name, r.address, r.opts.Timeouts)
+		if err != nil {
+			continue
+		}
+		if r.opts.Restore != nil {
+			if err = r.opts.Restore(c); err != nil {
+				c.Close()
+				continue
+			}
+		}
+		r.conn = c
+		r.reconnects++
+		return nil
+	}
+	return fmt.Errorf("reconnect %s %s after %d attempts: %w", r.name, r.address, attempts, err)
+}
+
+func (r *Reconnecting) Read(b []byte) (int, error) {
+	c, err := r.current()
+	if err != nil {
+		return 0, err
+	}
+	n, err := c.Read(b)
+	if err != nil {
+		err = r.recover(c, err)
+	}
+	return n, err
+}
+
+func (r *Reconnecting) Write(b []byte) (int, error) {
+	c, err := r.current()
+	if err != nil {
+		return 0, err
+	}
+	n, err := c.Write(b)
+	if err != nil {
+		err = r.recover(c, err)
+	}
+	return n, err
+}
+
+func (r *Reconnecting) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.closed = true
+	if r.conn == nil {
+		return nil
+	}
+	err := r.conn.Close()
+	r.conn = nil
+	return err
+}
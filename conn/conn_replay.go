@@ -0,0 +1,172 @@
+package conn
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// ErrNoResponse is returned by replay reads when the recording has no response at this point
+var ErrNoResponse = errors.New("replay: no recorded response")
+
+// Direction of a recorded transfer
+const (
+	DirWrite = '>' // host to printer
+	DirRead  = '<' // printer to host
+)
+
+// Transfer is a chunk of data recorded in one direction
+type Transfer struct {
+	Dir  byte
+	Data []byte
+}
+
+// ReplayDriver answers writes with the responses of a recorded session.
+// Address is a session log or a pcap capture of a network or USB session, see ReadSession.
+type ReplayDriver struct {
+	// Strict fails writes which differ from the recorded ones
+	Strict bool
+}
+
+// Open loads the recording at address
+func (d ReplayDriver) Open(address string) (io.ReadWriteCloser, error) {
+	f, err := os.Open(address)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var session []Transfer
+	switch strings.ToLower(filepath.Ext(address)) {
+	case ".pcap", ".cap":
+		session, err = ReadPcap(f)
+	default:
+		session, err = ReadSession(f)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("replay: %s: %w", address, err)
+	}
+	return &replayConn{session: session, strict: d.Strict}, nil
+}
+
+// OpenTimeout is Open, replayed responses are available immediately
+func (d ReplayDriver) OpenTimeout(address string, timeouts Timeouts) (io.ReadWriteCloser, error) {
+	return d.Open(address)
+}
+
+// Capabilities of a replay, responses are available once the preceding writes were replayed
+func (ReplayDriver) Capabilities() Capabilities {
+	return Capabilities{StatusReadback: true}
+}
+
+// ReadSession parses a session log, each line is the direction followed by hex data
+// like "> 1b4069" or "< 80204230...", empty lines and lines starting with "#" are ignored
+func ReadSession(r io.Reader) ([]Transfer, error) {
+	var session []Transfer
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, 1024*1024)
+	for line := 1; sc.Scan(); line++ {
+		s := strings.TrimSpace(sc.Text())
+		if s == "" || s[0] == '#' {
+			continue
+		}
+		dir := s[0]
+		if dir != DirWrite && dir != DirRead {
+			return nil, fmt.Errorf("line %d: unknown direction %q", line, dir)
+		}
+		data, err := hex.DecodeString(strings.Join(strings.Fields(s[1:]), ""))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		session = append(session, Transfer{Dir: dir, Data: data})
+	}
+	return session, sc.Err()
+}
+
+// WriteSession writes transfers in the session log format read by ReadSession
+func WriteSession(w io.Writer, session []Transfer) error {
+	for _, t := range session {
+		if _, err := fmt.Fprintf(w, "%c %s\n", t.Dir, hex.EncodeToString(t.Data)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// replayConn walks through the session, recorded writes are matched by byte count
+// since the library may chunk writes differently than the recording
+type replayConn struct {
+	mu      sync.Mutex
+	session []Transfer
+	strict  bool
+	pos     int    // next transfer
+	written int    // bytes of session[pos] already written
+	pending []byte // response data ready to read
+}
+
+func (c *replayConn) Write(b []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	rest := b
+	for len(rest) > 0 {
+		c.queueResponses()
+		if c.pos >= len(c.session) {
+			if c.strict {
+				return len(b) - len(rest), fmt.Errorf("replay: write beyond the end of the recording")
+			}
+			break
+		}
+		want := c.session[c.pos].Data[c.written:]
+		n := len(want)
+		if len(rest) < n {
+			n = len(rest)
+		}
+		if c.strict && !bytes.Equal(rest[:n], want[:n]) {
+			return len(b) - len(rest), fmt.Errorf("replay: transfer %d differs from recording: got %x, want %x", c.pos, rest[:n], want[:n])
+		}
+		rest = rest[n:]
+		c.written += n
+		if c.written == len(c.session[c.pos].Data) {
+			c.pos++
+			c.written = 0
+		}
+	}
+	c.queueResponses()
+	return len(b), nil
+}
+
+// queueResponses moves recorded responses following the completed writes into pending
+func (c *replayConn) queueResponses() {
+	if c.written > 0 {
+		return
+	}
+	for c.pos < len(c.session) && c.session[c.pos].Dir == DirRead {
+		c.pending = append(c.pending, c.session[c.pos].Data...)
+		c.pos++
+	}
+}
+
+func (c *replayConn) Read(b []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.queueResponses()
+	if len(c.pending) == 0 {
+		return 0, ErrNoResponse
+	}
+	n := copy(b, c.pending)
+	c.pending = c.pending[n:]
+	return n, nil
+}
+
+func (c *replayConn) Close() error {
+	return nil
+}
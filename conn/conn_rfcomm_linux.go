@@ -0,0 +1,83 @@
+package conn
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// DefaultRFCOMMChannel is the serial port profile channel of Brother Bluetooth printers
+const DefaultRFCOMMChannel = 1
+
+func init() {
+	Register("bluetooth", rfcommDriver{})
+	Register("bt", rfcommDriver{})
+	Register("rfcomm", rfcommDriver{})
+}
+
+// rfcommDriver connects to the RFCOMM channel of a paired printer with BlueZ sockets,
+// address is a MAC address optionally followed by a channel like "AA:BB:CC:DD:EE:FF/1"
+type rfcommDriver struct{}
+
+func (d rfcommDriver) Open(address string) (io.ReadWriteCloser, error) {
+	return d.OpenTimeout(address, DefaultTimeouts)
+}
+
+func (rfcommDriver) OpenTimeout(address string, timeouts Timeouts) (io.ReadWriteCloser, error) {
+	sa, err := rfcommAddress(address)
+	if err != nil {
+		return nil, err
+	}
+
+	fd, err := unix.Socket(unix.AF_BLUETOOTH, unix.SOCK_STREAM|unix.SOCK_CLOEXEC, unix.BTPROTO_RFCOMM)
+	if err != nil {
+		return nil, fmt.Errorf("rfcomm: socket: %w", err)
+	}
+	if err := unix.Connect(fd, sa); err != nil {
+		unix.Close(fd)
+		return nil, fmt.Errorf("rfcomm: connect %s: %w", address, err)
+	}
+	// non-blocking lets the runtime poller handle the socket, which enables deadlines
+	if err := unix.SetNonblock(fd, true); err != nil {
+		unix.Close(fd)
+		return nil, fmt.Errorf("rfcomm: %w", err)
+	}
+	return WithTimeouts(os.NewFile(uintptr(fd), "rfcomm:"+address), timeouts), nil
+}
+
+func (rfcommDriver) Capabilities() Capabilities {
+	return Capabilities{
+		MaxWriteChunk:  sppWriteChunk,
+		WriteInterval:  sppWriteInterval,
+		StatusReadback: true,
+		FullDuplex:     true,
+	}
+}
+
+func rfcommAddress(address string) (*unix.SockaddrRFCOMM, error) {
+	mac, channel := address, DefaultRFCOMMChannel
+	if i := strings.LastIndex(address, "/"); i >= 0 {
+		mac = address[:i]
+		ch, err := strconv.Atoi(address[i+1:])
+		if err != nil || ch < 1 || ch > 30 {
+			return nil, fmt.Errorf("rfcomm: invalid channel in %q", address)
+		}
+		channel = ch
+	}
+
+	hw, err := net.ParseMAC(mac)
+	if err != nil || len(hw) != 6 {
+		return nil, fmt.Errorf("rfcomm: invalid bluetooth address %q", mac)
+	}
+	sa := &unix.SockaddrRFCOMM{Channel: uint8(channel)}
+	// bdaddr_t is little-endian
+	for i := range hw {
+		sa.Addr[i] = hw[len(hw)-1-i]
+	}
+	return sa, nil
+}
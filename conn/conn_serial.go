@@ -0,0 +1,47 @@
+package conn
+
+import (
+	"io"
+	"regexp"
+	"time"
+)
+
+// Bluetooth SPP writes are split into chunks with a pause, some stacks abort the job
+// when a large write overflows their buffer
+const (
+	sppWriteChunk    = 512
+	sppWriteInterval = 10 * time.Millisecond
+)
+
+// serialDriver opens serial ports and lists those which look like Bluetooth printers
+type serialDriver struct{}
+
+func (serialDriver) Open(address string) (io.ReadWriteCloser, error) {
+	return openSerial(address, DefaultTimeouts)
+}
+
+func (serialDriver) OpenTimeout(address string, timeouts Timeouts) (io.ReadWriteCloser, error) {
+	return openSerial(address, timeouts)
+}
+
+// Capabilities of serial ports, which are mostly Bluetooth SPP ports
+// whose stacks may drop large writes
+func (serialDriver) Capabilities() Capabilities {
+	return Capabilities{
+		MaxWriteChunk:  sppWriteChunk,
+		WriteInterval:  sppWriteInterval,
+		StatusReadback: true,
+		FullDuplex:     true,
+	}
+}
+
+func (serialDriver) List() ([]Device, error) {
+	return serialPorts()
+}
+
+var modelPattern = regexp.MustCompile(`(PT|QL|TD)-[A-Z]*[0-9]+[A-Z]*`)
+
+// ModelHint extracts a model name like "PT-P710BT" from device names
+func ModelHint(name string) string {
+	return modelPattern.FindString(name)
+}
@@ -0,0 +1,88 @@
+package conn
+
+import "strings"
+
+// Settings of the COM ports of Windows, kept apart from the Win32 calls so they build on every system
+
+const (
+	dcbBinary     = 0x0001
+	dcbDTREnable  = 0x0010
+	dcbRTSEnable  = 0x1000
+	maxDWORD      = 0xFFFFFFFF
+	oneStopBit    = 0
+	noParity      = 0
+	serialBitRate = 115200
+)
+
+// dcb is the Win32 DCB structure
+type dcb struct {
+	DCBlength  uint32
+	BaudRate   uint32
+	Flags      uint32
+	wReserved  uint16
+	XonLim     uint16
+	XoffLim    uint16
+	ByteSize   byte
+	Parity     byte
+	StopBits   byte
+	XonChar    byte
+	XoffChar   byte
+	ErrorChar  byte
+	EofChar    byte
+	EvtChar    byte
+	wReserved1 uint16
+}
+
+// setLine sets 115200 baud 8N1 binary transfers with DTR and RTS on, the other fields stay as read
+func (d *dcb) setLine() {
+	d.BaudRate = serialBitRate
+	d.ByteSize = 8
+	d.Parity = noParity
+	d.StopBits = oneStopBit
+	d.Flags = dcbBinary | dcbDTREnable | dcbRTSEnable
+}
+
+// commTimeouts is the Win32 COMMTIMEOUTS structure
+type commTimeouts struct {
+	ReadIntervalTimeout         uint32
+	ReadTotalTimeoutMultiplier  uint32
+	ReadTotalTimeoutConstant    uint32
+	WriteTotalTimeoutMultiplier uint32
+	WriteTotalTimeoutConstant   uint32
+}
+
+// commTimeoutsOf returns the COMMTIMEOUTS of t, reads return as soon as any byte arrived or empty after
+// the timeout, which is at least 1ms as MAXDWORD intervals without one do not wait. Without a read timeout
+// reads wait until the buffer is filled
+func commTimeoutsOf(t Timeouts) commTimeouts {
+	timeouts := commTimeouts{
+		WriteTotalTimeoutConstant: uint32(t.Write.Milliseconds()),
+	}
+	if t.Read > 0 {
+		timeouts.ReadIntervalTimeout = maxDWORD
+		timeouts.ReadTotalTimeoutMultiplier = maxDWORD
+		timeouts.ReadTotalTimeoutConstant = uint32(t.Read.Milliseconds())
+		if timeouts.ReadTotalTimeoutConstant == 0 {
+			timeouts.ReadTotalTimeoutConstant = 1
+		}
+	}
+	return timeouts
+}
+
+// comPortPath returns the path of a COM port like "COM3", names above COM9 need the device namespace
+// prefix which is added when missing
+func comPortPath(address string) string {
+	if strings.HasPrefix(strings.ToUpper(address), "COM") && !strings.Contains(address, `\`) {
+		return `\\.\` + address
+	}
+	return address
+}
+
+// bluetoothPort returns the device of a value of the SERIALCOMM registry key like `\Device\BthModem0` = "COM5",
+// ports which are not of Bluetooth pairings are skipped
+func bluetoothPort(name, port string) (Device, bool) {
+	if !strings.Contains(name, "BthModem") || port == "" {
+		return Device{}, false
+	}
+	return Device{Driver: "serial", Address: port, Name: "Bluetooth " + port}, true
+}
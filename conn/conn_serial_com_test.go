@@ -0,0 +1,99 @@
+package conn
+
+import (
+	"testing"
+	"time"
+	"unsafe"
+)
+
+func TestComPortPath(t *testing.T) {
+	tests := []struct {
+		address string
+		want    string
+	}{
+		{"COM3", `\\.\COM3`},
+		{"COM12", `\\.\COM12`},
+		{"com5", `\\.\com5`},
+		{`\\.\COM12`, `\\.\COM12`},
+		{`\\?\usb#vid_04f9`, `\\?\usb#vid_04f9`},
+		{"/dev/rfcomm0", "/dev/rfcomm0"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := comPortPath(tt.address); got != tt.want {
+			t.Errorf("comPortPath(%q) = %q, want %q", tt.address, got, tt.want)
+		}
+	}
+}
+
+func TestBluetoothPort(t *testing.T) {
+	tests := []struct {
+		name, port string
+		want       Device
+		ok         bool
+	}{
+		{`\Device\BthModem0`, "COM5", Device{Driver: "serial", Address: "COM5", Name: "Bluetooth COM5"}, true},
+		{`\Device\BthModem12`, "COM14", Device{Driver: "serial", Address: "COM14", Name: "Bluetooth COM14"}, true},
+		{`\Device\Serial0`, "COM1", Device{}, false},
+		{`\Device\VCP0`, "COM7", Device{}, false},
+		{`\Device\BthModem1`, "", Device{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := bluetoothPort(tt.name, tt.port)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("bluetoothPort(%q, %q) = %+v, %v, want %+v, %v", tt.name, tt.port, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestDCBSetLine(t *testing.T) {
+	// the Win32 DCB is 28 bytes, GetCommState fails on another DCBlength
+	if size := unsafe.Sizeof(dcb{}); size != 28 {
+		t.Fatalf("dcb is %d bytes, want 28", size)
+	}
+	d := dcb{BaudRate: 9600, ByteSize: 7, Parity: 2, StopBits: 2, Flags: 0x4000, XonLim: 2048, XonChar: 0x11}
+	d.setLine()
+	want := dcb{
+		BaudRate: 115200,
+		ByteSize: 8,
+		Parity:   noParity,
+		StopBits: oneStopBit,
+		Flags:    dcbBinary | dcbDTREnable | dcbRTSEnable,
+		XonLim:   2048,
+		XonChar:  0x11,
+	}
+	if d != want {
+		t.Errorf("setLine = %+v, want %+v", d, want)
+	}
+}
+
+func TestCommTimeoutsOf(t *testing.T) {
+	// COMMTIMEOUTS is five DWORDs
+	if size := unsafe.Sizeof(commTimeouts{}); size != 20 {
+		t.Fatalf("commTimeouts is %d bytes, want 20", size)
+	}
+	tests := []struct {
+		name     string
+		timeouts Timeouts
+		want     commTimeouts
+	}{
+		{"none", Timeouts{}, commTimeouts{}},
+		{"write only", Timeouts{Write: 5 * time.Second}, commTimeouts{WriteTotalTimeoutConstant: 5000}},
+		{"read and write", Timeouts{Read: 2 * time.Second, Write: 10 * time.Second}, commTimeouts{
+			ReadIntervalTimeout:        maxDWORD,
+			ReadTotalTimeoutMultiplier: maxDWORD,
+			ReadTotalTimeoutConstant:   2000,
+			WriteTotalTimeoutConstant:  10000,
+		}},
+		{"sub millisecond read", Timeouts{Read: 500 * time.Microsecond}, commTimeouts{
+			ReadIntervalTimeout:        maxDWORD,
+			ReadTotalTimeoutMultiplier: maxDWORD,
+			ReadTotalTimeoutConstant:   1,
+		}},
+	}
+	for _, tt := range tests {
+		if got := commTimeoutsOf(tt.timeouts); got != tt.want {
+			t.Errorf("%s: commTimeoutsOf(%+v) = %+v, want %+v", tt.name, tt.timeouts, got, tt.want)
+		}
+	}
+}
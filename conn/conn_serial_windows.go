@@ -0,0 +1,136 @@
+package conn
+
+import (
+	"fmt"
+	"io"
+	"unsafe"
+
+	"github.com/goburrow/serial"
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/registry"
+)
+
+// Bluetooth SPP pairings show up as virtual COM ports which ignore the line settings,
+// buffers and timeouts still matter since a raster job is written in one go
+const (
+	serialInputBuffer  = 4096
+	serialOutputBuffer = 64 * 1024
+)
+
+var (
+	kernel32         = windows.NewLazySystemDLL("kernel32.dll")
+	procSetupComm    = kernel32.NewProc("SetupComm")
+	procGetCommState = kernel32.NewProc("GetCommState")
+	procSetCommState = kernel32.NewProc("SetCommState")
+	procPurgeComm    = kernel32.NewProc("PurgeComm")
+)
+
+const (
+	purgeTXClear = 0x0004
+	purgeRXClear = 0x0008
+)
+
+type comPort struct {
+	handle windows.Handle
+}
+
+// openSerial opens a COM port like "COM3", see comPortPath
+func openSerial(address string, timeouts Timeouts) (io.ReadWriteCloser, error) {
+	name, err := windows.UTF16PtrFromString(comPortPath(address))
+	if err != nil {
+		return nil, err
+	}
+	h, err := windows.CreateFile(name, windows.GENERIC_READ|windows.GENERIC_WRITE, 0, nil, windows.OPEN_EXISTING, 0, 0)
+	if err != nil {
+		return nil, fmt.Errorf("serial: open %s: %w", address, err)
+	}
+	p := &comPort{handle: h}
+	if err := p.configure(timeouts); err != nil {
+		windows.CloseHandle(h)
+		return nil, fmt.Errorf("serial: configure %s: %w", address, err)
+	}
+	return p, nil
+}
+
+func (p *comPort) configure(t Timeouts) error {
+	if err := commCall(procSetupComm, uintptr(p.handle), serialInputBuffer, serialOutputBuffer); err != nil {
+		return fmt.Errorf("SetupComm: %w", err)
+	}
+
+	var state dcb
+	state.DCBlength = uint32(unsafe.Sizeof(state))
+	if err := commCall(procGetCommState, uintptr(p.handle), uintptr(unsafe.Pointer(&state))); err != nil {
+		return fmt.Errorf("GetCommState: %w", err)
+	}
+	state.setLine()
+	if err := commCall(procSetCommState, uintptr(p.handle), uintptr(unsafe.Pointer(&state))); err != nil {
+		return fmt.Errorf("SetCommState: %w", err)
+	}
+
+	timeouts := windows.CommTimeouts(commTimeoutsOf(t))
+	if err := windows.SetCommTimeouts(p.handle, &timeouts); err != nil {
+		return fmt.Errorf("SetCommTimeouts: %w", err)
+	}
+
+	if err := commCall(procPurgeComm, uintptr(p.handle), purgeTXClear|purgeRXClear); err != nil {
+		return fmt.Errorf("PurgeComm: %w", err)
+	}
+	return nil
+}
+
+func commCall(proc *windows.LazyProc, args ...uintptr) error {
+	r, _, err := proc.Call(args...)
+	if r == 0 {
+		return err
+	}
+	return nil
+}
+
+func (p *comPort) Read(b []byte) (int, error) {
+	var n uint32
+	if err := windows.ReadFile(p.handle, b, &n, nil); err != nil {
+		return 0, err
+	}
+	if n == 0 && len(b) > 0 {
+		return 0, serial.ErrTimeout
+	}
+	return int(n), nil
+}
+
+func (p *comPort) Write(b []byte) (int, error) {
+	var n uint32
+	err := windows.WriteFile(p.handle, b, &n, nil)
+	if err == nil && int(n) < len(b) {
+		err = serial.ErrTimeout
+	}
+	return int(n), err
+}
+
+func (p *comPort) Close() error {
+	return windows.CloseHandle(p.handle)
+}
+
+// serialPorts lists the COM ports of Bluetooth serial port pairings
+func serialPorts() ([]Device, error) {
+	k, err := registry.OpenKey(registry.LOCAL_MACHINE, `HARDWARE\DEVICEMAP\SERIALCOMM`, registry.QUERY_VALUE)
+	if err != nil {
+		return nil, err
+	}
+	defer k.Close()
+
+	names, err := k.ReadValueNames(-1)
+	if err != nil {
+		return nil, err
+	}
+	var devices []Device
+	for _, name := range names {
+		port, _, err := k.GetStringValue(name)
+		if err != nil {
+			continue
+		}
+		if d, ok := bluetoothPort(name, port); ok {
+			devices = append(devices, d)
+		}
+	}
+	return devices, nil
+}
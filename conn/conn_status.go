@@ -0,0 +1,50 @@
+package conn
+
+// Status frames sent by printers, drivers without a real printer answer with these
+const (
+	statusFrameSize = 32
+
+	statusOffsetSeries      = 3
+	statusOffsetModel       = 4
+	statusOffsetError1      = 8
+	statusOffsetError2      = 9
+	statusOffsetMediaWidth  = 10
+	statusOffsetMediaType   = 11
+	statusOffsetMediaLength = 17
+	statusOffsetStatusType  = 18
+	statusOffsetPhaseType   = 19
+	statusOffsetTapeColor   = 24
+	statusOffsetFontColor   = 25
+
+	statusModelPTP710BT = 0x76
+	statusSeriesQL      = 0x34
+	mediaTypeLaminated  = 0x01
+	mediaTypeFabric     = 0x04
+	mediaTypeContinuous = 0x0a
+	mediaTypeDieCut     = 0x0b
+	mediaTypeHeatShrink = 0x11
+	tapeColorWhite      = 0x01
+	fontColorBlack      = 0x08
+
+	statusTypeReply             = 0x00
+	statusTypePrintingCompleted = 0x01
+	statusTypeErrorOccured      = 0x02
+	statusTypePhaseChange       = 0x06
+
+	phaseTypeReceiving = 0x00
+	phaseTypePrinting  = 0x01
+)
+
+// statusFrame returns a status reply of a ready printer with tape of tapeWidth mm
+func statusFrame(tapeWidth int, statusType byte) []byte {
+	b := make([]byte, statusFrameSize)
+	copy(b, []byte{0x80, 0x20, 0x42, 0x30})
+	b[statusOffsetModel] = statusModelPTP710BT
+	b[5] = 0x30
+	b[statusOffsetMediaWidth] = byte(tapeWidth)
+	b[statusOffsetMediaType] = mediaTypeLaminated
+	b[statusOffsetStatusType] = statusType
+	b[statusOffsetTapeColor] = tapeColorWhite
+	b[statusOffsetFontColor] = fontColorBlack
+	return b
+}
@@ -0,0 +1,99 @@
+package conn
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// TapFormat is the log format of a Tap
+type TapFormat int
+
+const (
+	// TapSession logs transfers in the session log format, the log can be replayed with the replay driver
+	TapSession TapFormat = iota
+	// TapHexDump logs transfers as hex dumps like hexdump -C, headed by the direction and length
+	TapHexDump
+	// TapBinary logs each transfer as the direction byte, the length as 4 bytes little endian and the data
+	TapBinary
+	// TapTimedHexDump is TapHexDump with the local time of the transfer in the header
+	TapTimedHexDump
+)
+
+// tapTimeFormat is the time in the headers of TapTimedHexDump
+const tapTimeFormat = "15:04:05.000000"
+
+// Tap copies the data written to and read from a connection into a log,
+// the log can be switched at runtime
+type Tap struct {
+	conn io.ReadWriteCloser
+
+	mu     sync.Mutex
+	log    io.Writer
+	format TapFormat
+	err    error
+}
+
+// NewTap wraps c logging its traffic to w in format, nil w starts with logging disabled
+func NewTap(c io.ReadWriteCloser, w io.Writer, format TapFormat) *Tap {
+	return &Tap{conn: c, log: w, format: format}
+}
+
+// SetLog switches the log, nil disables logging
+func (t *Tap) SetLog(w io.Writer, format TapFormat) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.log, t.format, t.err = w, format, nil
+}
+
+// Err returns the first error writing the current log, logging stops after an error
+func (t *Tap) Err() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.err
+}
+
+func (t *Tap) record(dir byte, data []byte) {
+	if len(data) == 0 {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.log == nil || t.err != nil {
+		return
+	}
+	switch t.format {
+	case TapHexDump:
+		_, t.err = fmt.Fprintf(t.log, "%c %d bytes\n%s", dir, len(data), hex.Dump(data))
+	case TapTimedHexDump:
+		_, t.err = fmt.Fprintf(t.log, "%s %c %d bytes\n%s", time.Now().Format(tapTimeFormat), dir, len(data), hex.Dump(data))
+	case TapBinary:
+		var hdr [5]byte
+		hdr[0] = dir
+		binary.LittleEndian.PutUint32(hdr[1:], uint32(len(data)))
+		if _, t.err = t.log.Write(hdr[:]); t.err == nil {
+			_, t.err = t.log.Write(data)
+		}
+	default:
+		t.err = WriteSession(t.log, []Transfer{{Dir: dir, Data: data}})
+	}
+}
+
+func (t *Tap) Read(b []byte) (int, error) {
+	n, err := t.conn.Read(b)
+	t.record(DirRead, b[:n])
+	return n, err
+}
+
+func (t *Tap) Write(b []byte) (int, error) {
+	n, err := t.conn.Write(b)
+	t.record(DirWrite, b[:n])
+	return n, err
+}
+
+func (t *Tap) Close() error {
+	return t.conn.Close()
+}
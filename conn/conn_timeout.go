@@ -0,0 +1,195 @@
+package conn
+
+import (
+	"errors"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/goburrow/serial"
+)
+
+// ErrTimeout is returned when a read or write did not complete in time
+var ErrTimeout = errors.New("conn: timeout")
+
+// Timeouts limit the duration of each read and write, zero disables a limit
+type Timeouts struct {
+	Read  time.Duration
+	Write time.Duration
+}
+
+// DefaultTimeouts are used by Open, a printer answers a status request within a second
+// and a write of a raster job can wait for the printer to catch up
+var DefaultTimeouts = Timeouts{
+	Read:  10 * time.Second,
+	Write: 30 * time.Second,
+}
+
+// TimeoutDriver is implemented by drivers which apply timeouts themselves
+type TimeoutDriver interface {
+	Driver
+	OpenTimeout(address string, timeouts Timeouts) (io.ReadWriteCloser, error)
+}
+
+// IsTimeout reports whether err is a timeout reported by any driver
+func IsTimeout(err error) bool {
+	if errors.Is(err, ErrTimeout) || errors.Is(err, serial.ErrTimeout) || errors.Is(err, os.ErrDeadlineExceeded) {
+		return true
+	}
+	var ne net.Error
+	return errors.As(err, &ne) && ne.Timeout()
+}
+
+// deadliner is implemented by connections with native deadlines like net.Conn and pollable *os.File
+type deadliner interface {
+	io.ReadWriteCloser
+	SetReadDeadline(t time.Time) error
+	SetWriteDeadline(t time.Time) error
+}
+
+// deadlineConn sets a deadline before each read and write
+type deadlineConn struct {
+	deadliner
+	timeouts Timeouts
+}
+
+func (c *deadlineConn) Read(b []byte) (int, error) {
+	if c.timeouts.Read > 0 {
+		c.SetReadDeadline(time.Now().Add(c.timeouts.Read))
+	}
+	return c.deadliner.Read(b)
+}
+
+func (c *deadlineConn) Write(b []byte) (int, error) {
+	if c.timeouts.Write > 0 {
+		c.SetWriteDeadline(time.Now().Add(c.timeouts.Write))
+	}
+	return c.deadliner.Write(b)
+}
+
+// WithTimeouts wraps a connection without deadline support so reads and writes
+// return ErrTimeout after timeouts. A timed out operation keeps running in the
+// background, its data is returned by the next read or the next write waits for it.
+func WithTimeouts(c io.ReadWriteCloser, timeouts Timeouts) io.ReadWriteCloser {
+	if timeouts.Read <= 0 && timeouts.Write <= 0 {
+		return c
+	}
+	if d, ok := c.(deadliner); ok {
+		return &deadlineConn{deadliner: d, timeouts: timeouts}
+	}
+	return &timeoutConn{conn: c, timeouts: timeouts}
+}
+
+type readResult struct {
+	data []byte
+	err  error
+}
+
+type writeResult struct {
+	n   int
+	err error
+}
+
+type timeoutConn struct {
+	conn     io.ReadWriteCloser
+	timeouts Timeouts
+
+	readm   sync.Mutex
+	reading chan readResult // pending background read
+	unread  []byte
+
+	writem  sync.Mutex
+	writing chan writeResult // pending background write
+}
+
+func (c *timeoutConn) Read(b []byte) (int, error) {
+	c.readm.Lock()
+	defer c.readm.Unlock()
+
+	if len(c.unread) > 0 {
+		n := copy(b, c.unread)
+		c.unread = c.unread[n:]
+		return n, nil
+	}
+	if c.timeouts.Read <= 0 && c.reading == nil {
+		return c.conn.Read(b)
+	}
+	if c.reading == nil {
+		c.reading = make(chan readResult, 1)
+		buf := make([]byte, len(b))
+		go func(ch chan readResult) {
+			n, err := c.conn.Read(buf)
+			ch <- readResult{data: buf[:n], err: err}
+		}(c.reading)
+	}
+
+	r, ok := c.wait(c.reading, c.timeouts.Read)
+	if !ok {
+		return 0, ErrTimeout
+	}
+	c.reading = nil
+	n := copy(b, r.data)
+	c.unread = r.data[n:]
+	return n, r.err
+}
+
+func (c *timeoutConn) wait(ch chan readResult, timeout time.Duration) (readResult, bool) {
+	if timeout <= 0 {
+		return <-ch, true
+	}
+	t := time.NewTimer(timeout)
+	defer t.Stop()
+	select {
+	case r := <-ch:
+		return r, true
+	case <-t.C:
+		return readResult{}, false
+	}
+}
+
+func (c *timeoutConn) Write(b []byte) (int, error) {
+	c.writem.Lock()
+	defer c.writem.Unlock()
+
+	var timeout <-chan time.Time
+	if c.timeouts.Write > 0 {
+		t := time.NewTimer(c.timeouts.Write)
+		defer t.Stop()
+		timeout = t.C
+	}
+	if c.writing != nil {
+		select {
+		case r := <-c.writing:
+			c.writing = nil
+			if r.err != nil {
+				return 0, r.err
+			}
+		case <-timeout:
+			return 0, ErrTimeout
+		}
+	}
+	if timeout == nil {
+		return c.conn.Write(b)
+	}
+
+	// b may be reused by the caller once Write returned
+	data := append([]byte(nil), b...)
+	done := make(chan writeResult, 1)
+	go func() {
+		n, err := c.conn.Write(data)
+		done <- writeResult{n: n, err: err}
+	}()
+	select {
+	case r := <-done:
+		return r.n, r.err
+	case <-timeout:
+		c.writing = done
+		return 0, ErrTimeout
+	}
+}
+
+func (c *timeoutConn) Close() error {
+	return c.conn.Close()
+}
@@ -0,0 +1,41 @@
+package conn
+
+import (
+	"io"
+	"net"
+	"time"
+)
+
+// UnixDriver connects to a local print daemon over a unix domain socket, the daemon
+// relays the raster protocol to a printer it has access to. Address is the socket path.
+type UnixDriver struct {
+	DialTimeout time.Duration
+}
+
+// DefaultUnixDriver is registered as "unix"
+var DefaultUnixDriver = UnixDriver{
+	DialTimeout: 10 * time.Second,
+}
+
+// Open connects to the socket at address with DefaultTimeouts
+func (d UnixDriver) Open(address string) (io.ReadWriteCloser, error) {
+	return d.OpenTimeout(address, DefaultTimeouts)
+}
+
+// OpenTimeout connects to the socket at address
+func (d UnixDriver) OpenTimeout(address string, timeouts Timeouts) (io.ReadWriteCloser, error) {
+	c, err := net.DialTimeout("unix", address, d.DialTimeout)
+	if err != nil {
+		return nil, err
+	}
+	unix := c.(*net.UnixConn)
+	return &netConn{
+		deadlineConn: deadlineConn{deadliner: unix, timeouts: timeouts},
+		conn:         unix,
+	}, nil
+}
+
+// Capabilities of a daemon connection, the daemon forwards status replies of its printer
+func (UnixDriver) Capabilities() Capabilities {
+	return Capabilities{StatusReadback: true, FullDuplex: true}
+}
@@ -0,0 +1,157 @@
+package conn
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+func init() {
+	Register("usbfd", usbfdDriver{})
+}
+
+// usbdevfs ioctls of linux/usbdevice_fs.h
+var (
+	usbdevfsBulk             = ioctlReadWrite('U', 2, unsafe.Sizeof(usbdevfsBulkTransfer{}))
+	usbdevfsClaimInterface   = ioctlRead('U', 15, 4)
+	usbdevfsReleaseInterface = ioctlRead('U', 16, 4)
+)
+
+const (
+	usbfsMaxTransfer = 16384 // MAX_USBFS_BUFFER_SIZE of older kernels
+	usbfsReadSize    = 512   // multiple of the packet size, so short status packets never overflow
+)
+
+// usbdevfsBulkTransfer is struct usbdevfs_bulktransfer
+type usbdevfsBulkTransfer struct {
+	ep      uint32
+	len     uint32
+	timeout uint32 // milliseconds, zero waits forever
+	data    *byte
+}
+
+func ioctlRead(typ, nr byte, size uintptr) uintptr {
+	return 2<<30 | size<<16 | uintptr(typ)<<8 | uintptr(nr)
+}
+
+func ioctlReadWrite(typ, nr byte, size uintptr) uintptr {
+	return 3<<30 | size<<16 | uintptr(typ)<<8 | uintptr(nr)
+}
+
+// usbfdDriver uses a file descriptor of an opened usbfs device, address is the descriptor number.
+// It is meant for Android where apps get the descriptor from UsbDeviceConnection.getFileDescriptor.
+type usbfdDriver struct{}
+
+func (d usbfdDriver) Open(address string) (io.ReadWriteCloser, error) {
+	return d.OpenTimeout(address, DefaultTimeouts)
+}
+
+func (usbfdDriver) OpenTimeout(address string, timeouts Timeouts) (io.ReadWriteCloser, error) {
+	fd, err := strconv.Atoi(address)
+	if err != nil {
+		return nil, fmt.Errorf("usbfd: invalid file descriptor %q", address)
+	}
+	return OpenUSBFD(fd, timeouts)
+}
+
+func (usbfdDriver) Capabilities() Capabilities {
+	return Capabilities{StatusReadback: true, FullDuplex: true}
+}
+
+// OpenUSBFD claims the printer interface of the usbfs device opened as fd.
+// The descriptor stays owned by the caller, Close releases the interface but does not close fd.
+func OpenUSBFD(fd int, timeouts Timeouts) (io.ReadWriteCloser, error) {
+	iface := uint32(0)
+	if err := usbfsIoctl(fd, usbdevfsClaimInterface, unsafe.Pointer(&iface)); err != nil {
+		return nil, fmt.Errorf("usbfd: claim interface: %w", err)
+	}
+	return &usbfdConn{fd: fd, timeouts: timeouts}, nil
+}
+
+func usbfsIoctl(fd int, req uintptr, arg unsafe.Pointer) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), req, uintptr(arg))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+type usbfdConn struct {
+	fd       int
+	timeouts Timeouts
+
+	readm  sync.Mutex
+	unread []byte
+	writem sync.Mutex
+}
+
+// bulk transfers b on ep and returns the transferred length
+func (c *usbfdConn) bulk(ep byte, b []byte, timeout time.Duration) (int, error) {
+	if len(b) == 0 {
+		return 0, nil
+	}
+	t := usbdevfsBulkTransfer{
+		ep:   uint32(ep),
+		len:  uint32(len(b)),
+		data: &b[0],
+	}
+	if timeout > 0 {
+		t.timeout = uint32((timeout + time.Millisecond - 1) / time.Millisecond)
+	}
+	r, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(c.fd), usbdevfsBulk, uintptr(unsafe.Pointer(&t)))
+	switch errno {
+	case 0:
+		return int(r), nil
+	case syscall.ETIMEDOUT:
+		return 0, fmt.Errorf("usbfd: %v: %w", errno, ErrTimeout)
+	}
+	return 0, fmt.Errorf("usbfd: %w", errno)
+}
+
+func (c *usbfdConn) Read(b []byte) (int, error) {
+	c.readm.Lock()
+	defer c.readm.Unlock()
+
+	if len(c.unread) == 0 {
+		buf := make([]byte, usbfsReadSize)
+		n, err := c.bulk(usbEndpointIn, buf, c.timeouts.Read)
+		if err != nil {
+			return 0, err
+		}
+		c.unread = buf[:n]
+	}
+	n := copy(b, c.unread)
+	c.unread = c.unread[n:]
+	return n, nil
+}
+
+func (c *usbfdConn) Write(b []byte) (int, error) {
+	c.writem.Lock()
+	defer c.writem.Unlock()
+
+	written := 0
+	for written < len(b) {
+		chunk := b[written:]
+		if len(chunk) > usbfsMaxTransfer {
+			chunk = chunk[:usbfsMaxTransfer]
+		}
+		n, err := c.bulk(usbEndpointOut, chunk, c.timeouts.Write)
+		written += n
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+func (c *usbfdConn) Close() error {
+	iface := uint32(0)
+	if err := usbfsIoctl(c.fd, usbdevfsReleaseInterface, unsafe.Pointer(&iface)); err != nil {
+		return fmt.Errorf("usbfd: release interface: %w", err)
+	}
+	return nil
+}
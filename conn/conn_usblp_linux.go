@@ -0,0 +1,140 @@
+package conn
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+func init() {
+	Register("usblp", usblpDriver{})
+}
+
+// usblpReadInterval is how often reads poll when the printer returns empty transfers
+const usblpReadInterval = 50 * time.Millisecond
+
+// usblpDriver talks to USB printers through the kernel usblp driver without libusb,
+// address is a device like "/dev/usb/lp0", empty selects the first Brother printer
+type usblpDriver struct{}
+
+func (d usblpDriver) Open(address string) (io.ReadWriteCloser, error) {
+	return d.OpenTimeout(address, DefaultTimeouts)
+}
+
+func (usblpDriver) OpenTimeout(address string, timeouts Timeouts) (io.ReadWriteCloser, error) {
+	if address == "" {
+		devices, err := usblpDevices()
+		if err != nil {
+			return nil, fmt.Errorf("usblp: %w", err)
+		}
+		if len(devices) == 0 {
+			return nil, fmt.Errorf("usblp: no Brother printer found in /dev/usb")
+		}
+		address = devices[0].Address
+	}
+	// non-blocking lets the runtime poller handle the device, which enables deadlines
+	f, err := os.OpenFile(address, os.O_RDWR|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		return nil, fmt.Errorf("usblp: %w", err)
+	}
+	return &usblpConn{f: f, timeouts: timeouts}, nil
+}
+
+func (usblpDriver) Capabilities() Capabilities {
+	return Capabilities{StatusReadback: true, FullDuplex: true}
+}
+
+// List returns the usblp devices whose IEEE 1284 device ID names Brother
+func (usblpDriver) List() ([]Device, error) {
+	return usblpDevices()
+}
+
+func usblpDevices() ([]Device, error) {
+	paths, err := filepath.Glob("/dev/usb/lp*")
+	if err != nil {
+		return nil, err
+	}
+	var devices []Device
+	for _, path := range paths {
+		id, err := ioutil.ReadFile(filepath.Join("/sys/class/usbmisc", filepath.Base(path), "device/ieee1284_id"))
+		if err != nil {
+			continue
+		}
+		fields := deviceIDFields(string(id))
+		if !strings.EqualFold(fields["MFG"], "Brother") {
+			continue
+		}
+		devices = append(devices, Device{
+			Driver:  "usblp",
+			Address: path,
+			Model:   ModelHint(fields["MDL"]),
+			Name:    strings.TrimSpace("Brother " + fields["MDL"]),
+		})
+	}
+	return devices, nil
+}
+
+// deviceIDFields parses an IEEE 1284 device ID like "MFG:Brother;CMD:PT-CBP;MDL:PT-P710BT;CLS:PRINTER;"
+func deviceIDFields(id string) map[string]string {
+	fields := make(map[string]string)
+	for _, field := range strings.Split(id, ";") {
+		if i := strings.Index(field, ":"); i > 0 {
+			key := strings.ToUpper(strings.TrimSpace(field[:i]))
+			switch key {
+			case "MANUFACTURER":
+				key = "MFG"
+			case "MODEL":
+				key = "MDL"
+			}
+			fields[key] = strings.TrimSpace(field[i+1:])
+		}
+	}
+	return fields
+}
+
+type usblpConn struct {
+	f        *os.File
+	timeouts Timeouts
+}
+
+// Read waits for data, usblp completes a read with nothing when the printer has nothing to send
+func (c *usblpConn) Read(b []byte) (int, error) {
+	deadline := time.Now().Add(c.timeouts.Read)
+	if c.timeouts.Read > 0 {
+		c.f.SetReadDeadline(deadline)
+	}
+	for {
+		n, err := c.f.Read(b)
+		switch {
+		case err == io.EOF && len(b) > 0:
+			if c.timeouts.Read > 0 && time.Now().After(deadline) {
+				return 0, fmt.Errorf("usblp: read: %w", ErrTimeout)
+			}
+			time.Sleep(usblpReadInterval)
+			continue
+		case IsTimeout(err):
+			return n, fmt.Errorf("usblp: read: %w", ErrTimeout)
+		}
+		return n, err
+	}
+}
+
+func (c *usblpConn) Write(b []byte) (int, error) {
+	if c.timeouts.Write > 0 {
+		c.f.SetWriteDeadline(time.Now().Add(c.timeouts.Write))
+	}
+	n, err := c.f.Write(b)
+	if IsTimeout(err) {
+		return n, fmt.Errorf("usblp: write: %w", ErrTimeout)
+	}
+	return n, err
+}
+
+func (c *usblpConn) Close() error {
+	return c.f.Close()
+}
@@ -0,0 +1,175 @@
+package conn
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ka2n/ptouchgo/models"
+)
+
+// WirelessDirectAddress is the address of a printer on its own Wireless Direct network
+const WirelessDirectAddress = "192.168.118.1"
+
+// objects of the Host Resources and Printer MIBs answering status requests
+const (
+	oidDeviceDescr   = "1.3.6.1.2.1.25.3.2.1.3.1"    // hrDeviceDescr, like "Brother PT-P750W"
+	oidPrinterErrors = "1.3.6.1.2.1.25.3.5.1.2.1"    // hrPrinterDetectedErrorState
+	oidMediaName     = "1.3.6.1.2.1.43.8.2.1.12.1.1" // prtInputMediaName, like "24mm"
+)
+
+// bits of the first byte of hrPrinterDetectedErrorState
+const (
+	printerErrorNoPaper  = 0x40
+	printerErrorDoorOpen = 0x08
+	printerErrorJammed   = 0x04
+)
+
+// bits of the error information of a status frame
+const (
+	statusError1NoMedia   = 0x01
+	statusError1CutterJam = 0x04
+	statusError2CoverOpen = 0x10
+)
+
+// cmdStatusRequest is the status information request answered from SNMP
+var cmdStatusRequest = []byte{0x1b, 0x69, 0x53}
+
+// WLANDriver connects to printers like the PT-P750W on their wireless LAN in infrastructure
+// mode or Wireless Direct. Jobs go to the raw printing port like NetDriver, status requests
+// are answered from the printer MIB read with SNMP. Statuses sent by the printer during
+// a job still arrive on the raw printing port.
+type WLANDriver struct {
+	Net         NetDriver
+	Community   string // SNMP community
+	SNMPTimeout time.Duration
+}
+
+// DefaultWLANDriver is registered as "wlan", an empty address is WirelessDirectAddress
+var DefaultWLANDriver = WLANDriver{
+	Net:         DefaultNetDriver,
+	Community:   "public",
+	SNMPTimeout: 2 * time.Second,
+}
+
+// Open connects to address with DefaultTimeouts
+func (d WLANDriver) Open(address string) (io.ReadWriteCloser, error) {
+	return d.OpenTimeout(address, DefaultTimeouts)
+}
+
+// OpenTimeout connects to the raw printing port of address, port 9100 is used when address has no port
+func (d WLANDriver) OpenTimeout(address string, timeouts Timeouts) (io.ReadWriteCloser, error) {
+	if address == "" {
+		address = WirelessDirectAddress
+	}
+	data, err := d.Net.OpenTimeout(address, timeouts)
+	if err != nil {
+		return nil, err
+	}
+	host := address
+	if h, _, err := net.SplitHostPort(address); err == nil {
+		host = h
+	}
+	host = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]")
+	return &wlanConn{
+		ReadWriteCloser: data,
+		snmp:            net.JoinHostPort(host, DefaultSNMPPort),
+		driver:          d,
+	}, nil
+}
+
+// Capabilities of wireless printing, status requests are answered by SNMP
+func (WLANDriver) Capabilities() Capabilities {
+	return Capabilities{StatusReadback: true}
+}
+
+type wlanConn struct {
+	io.ReadWriteCloser
+	snmp   string
+	driver WLANDriver
+
+	mu     sync.Mutex
+	status []byte // status frames answering requests, read before the raw printing port
+}
+
+// Write sends b to the printer, a status request is answered from SNMP instead
+func (c *wlanConn) Write(b []byte) (int, error) {
+	if !bytes.Equal(b, cmdStatusRequest) {
+		return c.ReadWriteCloser.Write(b)
+	}
+	frame, err := c.readStatus()
+	if err != nil {
+		return 0, err
+	}
+	c.mu.Lock()
+	c.status = append(c.status, frame...)
+	c.mu.Unlock()
+	return len(b), nil
+}
+
+func (c *wlanConn) Read(b []byte) (int, error) {
+	c.mu.Lock()
+	if len(c.status) > 0 {
+		n := copy(b, c.status)
+		c.status = c.status[n:]
+		c.mu.Unlock()
+		return n, nil
+	}
+	c.mu.Unlock()
+	return c.ReadWriteCloser.Read(b)
+}
+
+// readStatus reads the model, errors and tape of the printer with SNMP and returns them as a status frame
+func (c *wlanConn) readStatus() ([]byte, error) {
+	values, err := snmpGet(c.snmp, c.driver.Community, []string{oidDeviceDescr, oidPrinterErrors}, c.driver.SNMPTimeout)
+	if err != nil {
+		return nil, err
+	}
+	tapeWidth := 0
+	// the tape is left unknown by agents without the Printer MIB media table
+	if media, err := snmpGet(c.snmp, c.driver.Community, []string{oidMediaName}, c.driver.SNMPTimeout); err == nil {
+		if name, ok := media[0].([]byte); ok {
+			tapeWidth = mediaWidth(string(name))
+		}
+	}
+	b := statusFrame(tapeWidth, statusTypeReply)
+	if descr, ok := values[0].([]byte); ok {
+		for _, word := range strings.Fields(string(descr)) {
+			if spec, ok := models.ByName(word); ok {
+				b[statusOffsetModel] = spec.Code
+			}
+		}
+	}
+	if state, ok := values[1].([]byte); ok && len(state) > 0 {
+		if state[0]&printerErrorNoPaper != 0 {
+			b[statusOffsetError1] |= statusError1NoMedia
+			b[statusOffsetMediaWidth] = 0
+		}
+		if state[0]&printerErrorJammed != 0 {
+			b[statusOffsetError1] |= statusError1CutterJam
+		}
+		if state[0]&printerErrorDoorOpen != 0 {
+			b[statusOffsetError2] |= statusError2CoverOpen
+		}
+	}
+	return b, nil
+}
+
+// mediaWidth returns the width in mm at the start of a media name like "24mm" or "3.5mm TZe",
+// the 3.5mm tape is reported as 4 like in status frames, zero for other names
+func mediaWidth(name string) int {
+	name = strings.TrimSpace(name)
+	i := strings.IndexFunc(name, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+	if i < 0 {
+		i = len(name)
+	}
+	mm, err := strconv.ParseFloat(name[:i], 64)
+	if err != nil || !strings.HasPrefix(strings.TrimSpace(name[i:]), "mm") {
+		return 0
+	}
+	return int(mm + 0.5)
+}
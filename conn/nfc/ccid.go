@@ -0,0 +1,171 @@
+//go:build !windows && cgo
+// +build !windows,cgo
+
+package nfc
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/google/gousb"
+)
+
+// CCID messages of USB smart card readers, PC/SC readers like the ACR122U
+// read contactless tags through the pseudo APDUs of PC/SC part 3
+const (
+	ccidClass = gousb.Class(0x0b)
+
+	ccidPowerOn   = 0x62
+	ccidXfrBlock  = 0x6f
+	ccidDataBlock = 0x80
+
+	ccidHeaderSize  = 10
+	ccidTimeExtend  = 0x80 // bmCommandStatus of a reader asking for more time
+	ccidStatusError = 0x40
+)
+
+// directionIn is the direction of IN endpoints
+const directionIn = gousb.EndpointDirection(true)
+
+// ccidReader is a USB smart card reader
+type ccidReader struct {
+	ctx  *gousb.Context
+	dev  *gousb.Device
+	done func()
+	in   *gousb.InEndpoint
+	out  *gousb.OutEndpoint
+
+	mu  sync.Mutex
+	seq byte
+}
+
+// OpenReader opens the first USB smart card reader and powers up the tag touching it
+func OpenReader() (Reader, error) {
+	ctx := gousb.NewContext()
+	var setting gousb.InterfaceSetting
+	devs, err := ctx.OpenDevices(func(desc *gousb.DeviceDesc) bool {
+		for _, cfg := range desc.Configs {
+			for _, intf := range cfg.Interfaces {
+				for _, alt := range intf.AltSettings {
+					if alt.Class == ccidClass && setting.Endpoints == nil {
+						setting = alt
+						return true
+					}
+				}
+			}
+		}
+		return false
+	})
+	if len(devs) == 0 {
+		ctx.Close()
+		if err != nil {
+			return nil, fmt.Errorf("nfc: %w", err)
+		}
+		return nil, errors.New("nfc: no USB smart card reader found")
+	}
+	r := &ccidReader{ctx: ctx, dev: devs[0]}
+	for _, dev := range devs[1:] {
+		dev.Close()
+	}
+	if err := r.open(setting); err != nil {
+		r.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *ccidReader) open(setting gousb.InterfaceSetting) error {
+	r.dev.SetAutoDetach(true)
+	cfg, err := r.dev.Config(1)
+	if err != nil {
+		return fmt.Errorf("nfc: %w", err)
+	}
+	intf, err := cfg.Interface(setting.Number, setting.Alternate)
+	if err != nil {
+		cfg.Close()
+		return fmt.Errorf("nfc: claim the reader: %w", err)
+	}
+	r.done = func() {
+		intf.Close()
+		cfg.Close()
+	}
+	for _, ep := range setting.Endpoints {
+		if ep.TransferType != gousb.TransferTypeBulk {
+			continue
+		}
+		if ep.Direction == directionIn {
+			r.in, err = intf.InEndpoint(ep.Number)
+		} else {
+			r.out, err = intf.OutEndpoint(ep.Number)
+		}
+		if err != nil {
+			return fmt.Errorf("nfc: %w", err)
+		}
+	}
+	if r.in == nil || r.out == nil {
+		return errors.New("nfc: the reader has no bulk endpoints")
+	}
+	if _, err := r.transfer(ccidPowerOn, nil); err != nil {
+		return fmt.Errorf("nfc: no tag touching the reader: %w", err)
+	}
+	return nil
+}
+
+// ReadPages reads with the PC/SC read binary APDU
+func (r *ccidReader) ReadPages(page int) ([]byte, error) {
+	resp, err := r.transfer(ccidXfrBlock, []byte{0xff, 0xb0, 0x00, byte(page), 16})
+	if err != nil {
+		return nil, err
+	}
+	if len(resp) != 18 || resp[16] != 0x90 || resp[17] != 0x00 {
+		return nil, fmt.Errorf("nfc: read page %d: status % x", page, resp[len(resp)-2:])
+	}
+	return resp[:16], nil
+}
+
+// transfer sends a message to the reader and returns the data of its answer
+func (r *ccidReader) transfer(msgType byte, data []byte) ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.seq++
+	msg := make([]byte, ccidHeaderSize, ccidHeaderSize+len(data))
+	msg[0] = msgType
+	binary.LittleEndian.PutUint32(msg[1:5], uint32(len(data)))
+	msg[6] = r.seq
+	msg = append(msg, data...)
+	if _, err := r.out.Write(msg); err != nil {
+		return nil, fmt.Errorf("nfc: %w", err)
+	}
+	buf := make([]byte, 512)
+	for {
+		n, err := r.in.Read(buf)
+		if err != nil {
+			return nil, fmt.Errorf("nfc: %w", err)
+		}
+		if n < ccidHeaderSize || buf[0] != ccidDataBlock || buf[6] != r.seq {
+			return nil, errors.New("nfc: invalid answer of the reader")
+		}
+		status := buf[7]
+		if status&0xc0 == ccidTimeExtend {
+			continue
+		}
+		if status&0xc0 == ccidStatusError {
+			return nil, fmt.Errorf("nfc: reader error %#02x", buf[8])
+		}
+		size := int(binary.LittleEndian.Uint32(buf[1:5]))
+		if n < ccidHeaderSize+size {
+			return nil, errors.New("nfc: short answer of the reader")
+		}
+		return append([]byte(nil), buf[ccidHeaderSize:ccidHeaderSize+size]...), nil
+	}
+}
+
+func (r *ccidReader) Close() error {
+	if r.done != nil {
+		r.done()
+	}
+	r.dev.Close()
+	return r.ctx.Close()
+}
@@ -0,0 +1,11 @@
+//go:build windows || !cgo
+// +build windows !cgo
+
+package nfc
+
+import "errors"
+
+// OpenReader needs libusb, dumps of tags can be read with ReadFile
+func OpenReader() (Reader, error) {
+	return nil, errors.New("nfc: built without USB reader support, read a dump of the tag")
+}
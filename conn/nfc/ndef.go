@@ -0,0 +1,197 @@
+package nfc
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ka2n/ptouchgo/conn"
+)
+
+// Record is a record of an NDEF message
+type Record struct {
+	TNF     byte // type name format, tnfMedia for MIME types
+	Type    string
+	ID      []byte
+	Payload []byte
+}
+
+const (
+	tnfMedia = 0x02
+
+	ndefMB = 0x80 // message begin
+	ndefME = 0x40 // message end
+	ndefCF = 0x20 // chunked, not used by tags
+	ndefSR = 0x10 // short record, one byte payload length
+	ndefIL = 0x08 // ID length present
+
+	// MIME types of the connection handover carriers
+	mimeWSC         = "application/vnd.wfa.wsc"
+	mimeBluetoothEP = "application/vnd.bluetooth.ep.oob"
+)
+
+// ParseMessage splits an NDEF message into its records
+func ParseMessage(b []byte) ([]Record, error) {
+	var records []Record
+	for len(b) > 0 {
+		flags := b[0]
+		if flags&ndefCF != 0 {
+			return nil, errors.New("nfc: chunked NDEF records are not supported")
+		}
+		head := 3
+		if flags&ndefSR == 0 {
+			head = 6
+		}
+		if flags&ndefIL != 0 {
+			head++
+		}
+		if len(b) < head {
+			return nil, errors.New("nfc: short NDEF record")
+		}
+		typeLen := int(b[1])
+		var payloadLen int
+		if flags&ndefSR != 0 {
+			payloadLen = int(b[2])
+		} else {
+			payloadLen = int(binary.BigEndian.Uint32(b[2:6]))
+		}
+		idLen := 0
+		if flags&ndefIL != 0 {
+			idLen = int(b[head-1])
+		}
+		if payloadLen < 0 || len(b) < head+typeLen+idLen+payloadLen {
+			return nil, errors.New("nfc: short NDEF record")
+		}
+		b = b[head:]
+		r := Record{TNF: flags & 0x07, Type: string(b[:typeLen])}
+		b = b[typeLen:]
+		r.ID, b = b[:idLen], b[idLen:]
+		r.Payload, b = b[:payloadLen], b[payloadLen:]
+		records = append(records, r)
+		if flags&ndefME != 0 {
+			break
+		}
+	}
+	if len(records) == 0 {
+		return nil, errors.New("nfc: empty NDEF message")
+	}
+	return records, nil
+}
+
+// Params are the connection parameters a printer announces on its tag
+type Params struct {
+	Name string // Bluetooth device name like "PT-P750W1234", empty when the tag has none
+
+	// Wireless Direct network of the printer
+	SSID       string
+	Passphrase string
+
+	BluetoothAddress string // like "AA:BB:CC:DD:EE:FF"
+}
+
+// Address returns the address to open the printer with, Bluetooth when the tag has its address
+// and the Wireless Direct address of the printer when it has a network, the SSID has to be joined
+func (p Params) Address() string {
+	switch {
+	case p.BluetoothAddress != "":
+		return "bt:" + p.BluetoothAddress
+	case p.SSID != "":
+		return "wlan:" + conn.WirelessDirectAddress
+	}
+	return ""
+}
+
+// ParseParams reads the Wi-Fi credential and Bluetooth carrier records of a handover message
+func ParseParams(records []Record) (Params, error) {
+	var p Params
+	for _, r := range records {
+		if r.TNF != tnfMedia {
+			continue
+		}
+		var err error
+		switch strings.ToLower(r.Type) {
+		case mimeWSC:
+			err = p.parseWSC(r.Payload)
+		case mimeBluetoothEP:
+			err = p.parseBluetooth(r.Payload)
+		}
+		if err != nil {
+			return p, err
+		}
+	}
+	if p.Address() == "" {
+		return p, errors.New("nfc: the tag has no Wi-Fi or Bluetooth parameters")
+	}
+	return p, nil
+}
+
+// attributes of Wi-Fi Simple Configuration
+const (
+	wscCredential = 0x100e
+	wscSSID       = 0x1045
+	wscNetworkKey = 0x1027
+)
+
+// parseWSC reads the SSID and passphrase of the credential of a Wi-Fi Simple Configuration payload
+func (p *Params) parseWSC(b []byte) error {
+	cred, ok, err := wscAttribute(b, wscCredential)
+	if err != nil || !ok {
+		return err
+	}
+	ssid, _, err := wscAttribute(cred, wscSSID)
+	if err != nil {
+		return err
+	}
+	key, _, err := wscAttribute(cred, wscNetworkKey)
+	if err != nil {
+		return err
+	}
+	p.SSID, p.Passphrase = string(ssid), string(key)
+	return nil
+}
+
+// wscAttribute returns the value of the attribute id in the attributes of b
+func wscAttribute(b []byte, id uint16) ([]byte, bool, error) {
+	for len(b) > 0 {
+		if len(b) < 4 {
+			return nil, false, errors.New("nfc: short Wi-Fi attribute")
+		}
+		n := int(binary.BigEndian.Uint16(b[2:4]))
+		if len(b) < 4+n {
+			return nil, false, errors.New("nfc: short Wi-Fi attribute")
+		}
+		if binary.BigEndian.Uint16(b[:2]) == id {
+			return b[4 : 4+n], true, nil
+		}
+		b = b[4+n:]
+	}
+	return nil, false, nil
+}
+
+// EIR data types of the Bluetooth out-of-band data
+const (
+	eirShortName    = 0x08
+	eirCompleteName = 0x09
+)
+
+// parseBluetooth reads the device address and name of Bluetooth out-of-band data,
+// the length and the address are little endian
+func (p *Params) parseBluetooth(b []byte) error {
+	if len(b) < 8 {
+		return errors.New("nfc: short Bluetooth data")
+	}
+	addr := b[2:8]
+	p.BluetoothAddress = fmt.Sprintf("%02X:%02X:%02X:%02X:%02X:%02X", addr[5], addr[4], addr[3], addr[2], addr[1], addr[0])
+	for eir := b[8:]; len(eir) > 1; {
+		n := int(eir[0])
+		if n == 0 || len(eir) < 1+n {
+			break
+		}
+		if t := eir[1]; t == eirCompleteName || t == eirShortName && p.Name == "" {
+			p.Name = string(eir[2 : 1+n])
+		}
+		eir = eir[1+n:]
+	}
+	return nil
+}
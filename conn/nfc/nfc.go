@@ -0,0 +1,164 @@
+// Package nfc reads the NFC tag of printers like the PT-P750W, touched to a USB reader,
+// and connects to the printer over the Wi-Fi network or Bluetooth announced on it.
+// Importing it registers the "nfc" driver: "nfc:" reads the tag touching the first reader
+// and "nfc:tag.bin" a dump of the tag memory or of the NDEF message.
+package nfc
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/ka2n/ptouchgo/conn"
+)
+
+func init() {
+	conn.Register("nfc", Driver{})
+}
+
+// Reader reads the memory of an NFC Forum Type 2 tag
+type Reader interface {
+	// ReadPages returns the 16 bytes of the 4 byte pages from page
+	ReadPages(page int) ([]byte, error)
+	Close() error
+}
+
+// TLV blocks of the data area of a Type 2 tag
+const (
+	tlvNull       = 0x00
+	tlvNDEF       = 0x03
+	tlvTerminator = 0xfe
+
+	type2DataPage  = 4    // first page of the data area, after the capability container on page 3
+	type2CCMagic   = 0xe1 // first byte of the capability container of NDEF tags
+	type2PageBytes = 4
+)
+
+// ReadTag reads the NDEF message of the Type 2 tag touching r
+func ReadTag(r Reader) ([]byte, error) {
+	head, err := r.ReadPages(0)
+	if err != nil {
+		return nil, err
+	}
+	cc := head[3*type2PageBytes:]
+	if cc[0] != type2CCMagic {
+		return nil, errors.New("nfc: the tag holds no NDEF message")
+	}
+	size := int(cc[2]) * 8 // bytes of the data area
+	var mem []byte
+	for page := type2DataPage; len(mem) < size; page += 4 {
+		b, err := r.ReadPages(page)
+		if err != nil {
+			return nil, err
+		}
+		mem = append(mem, b...)
+		if msg, ok, err := type2Message(mem); ok || err != nil {
+			return msg, err
+		}
+	}
+	return nil, errors.New("nfc: no NDEF message in the data area of the tag")
+}
+
+// type2Message returns the NDEF message of the TLV blocks of mem, ok is false while mem ends before it
+func type2Message(mem []byte) (msg []byte, ok bool, err error) {
+	for i := 0; i < len(mem); {
+		t := mem[i]
+		switch t {
+		case tlvNull:
+			i++
+			continue
+		case tlvTerminator:
+			return nil, false, errors.New("nfc: no NDEF message in the data area of the tag")
+		}
+		if i+1 >= len(mem) {
+			return nil, false, nil
+		}
+		n, head := int(mem[i+1]), 2
+		if n == 0xff {
+			if i+3 >= len(mem) {
+				return nil, false, nil
+			}
+			n, head = int(mem[i+2])<<8|int(mem[i+3]), 4
+		}
+		if i+head+n > len(mem) {
+			return nil, false, nil
+		}
+		if t == tlvNDEF {
+			return mem[i+head : i+head+n], true, nil
+		}
+		i += head + n
+	}
+	return nil, false, nil
+}
+
+// Read reads the connection parameters from the tag touching r
+func Read(r Reader) (Params, error) {
+	msg, err := ReadTag(r)
+	if err != nil {
+		return Params{}, err
+	}
+	records, err := ParseMessage(msg)
+	if err != nil {
+		return Params{}, err
+	}
+	return ParseParams(records)
+}
+
+// ReadFile reads the connection parameters from a dump of the memory of a Type 2 tag,
+// starting at page 0, or from a file of the NDEF message alone
+func ReadFile(path string) (Params, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return Params{}, err
+	}
+	msg := b
+	if len(b) > type2DataPage*type2PageBytes && b[3*type2PageBytes] == type2CCMagic {
+		var ok bool
+		if msg, ok, err = type2Message(b[type2DataPage*type2PageBytes:]); err != nil {
+			return Params{}, err
+		} else if !ok {
+			return Params{}, errors.New("nfc: the dump ends before the NDEF message")
+		}
+	}
+	records, err := ParseMessage(msg)
+	if err != nil {
+		return Params{}, err
+	}
+	return ParseParams(records)
+}
+
+// ReadParams reads the connection parameters from the tag touching the first USB reader,
+// or from the dump at path when it is not empty
+func ReadParams(path string) (Params, error) {
+	if path != "" {
+		return ReadFile(path)
+	}
+	r, err := OpenReader()
+	if err != nil {
+		return Params{}, err
+	}
+	defer r.Close()
+	return Read(r)
+}
+
+// Driver reads the tag and opens the printer at the address on it, see Params.Address
+type Driver struct{}
+
+// Open reads the tag like ReadParams and opens the printer with DefaultTimeouts
+func (d Driver) Open(address string) (io.ReadWriteCloser, error) {
+	return d.OpenTimeout(address, conn.DefaultTimeouts)
+}
+
+// OpenTimeout reads the tag like ReadParams and opens the printer with timeouts
+func (Driver) OpenTimeout(address string, timeouts conn.Timeouts) (io.ReadWriteCloser, error) {
+	p, err := ReadParams(address)
+	if err != nil {
+		return nil, err
+	}
+	c, err := conn.OpenAddressTimeout(p.Address(), timeouts)
+	if err != nil {
+		return nil, fmt.Errorf("nfc: %s: %w", p.Address(), err)
+	}
+	return c, nil
+}
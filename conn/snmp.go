@@ -0,0 +1,225 @@
+package conn
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultSNMPPort is the SNMP agent port of network printers
+const DefaultSNMPPort = "161"
+
+// BER tags of the SNMPv1 messages
+const (
+	berInteger     = 0x02
+	berOctetString = 0x04
+	berNull        = 0x05
+	berOID         = 0x06
+	berSequence    = 0x30
+	snmpGetRequest = 0xa0
+	snmpGetReply   = 0xa2
+)
+
+// snmpGet reads the values of oids from the SNMPv1 agent at address, values are
+// []byte for strings and int for integers, nil for objects the agent does not have
+func snmpGet(address, community string, oids []string, timeout time.Duration) ([]interface{}, error) {
+	id := rand.Int31()
+	var binds []byte
+	for _, oid := range oids {
+		enc, err := berEncodeOID(oid)
+		if err != nil {
+			return nil, err
+		}
+		binds = append(binds, berTLV(berSequence, append(enc, berNull, 0))...)
+	}
+	pdu := berInt(int(id))
+	pdu = append(pdu, berInt(0)...) // error status
+	pdu = append(pdu, berInt(0)...) // error index
+	pdu = append(pdu, berTLV(berSequence, binds)...)
+	msg := berInt(0) // version 1
+	msg = append(msg, berTLV(berOctetString, []byte(community))...)
+	msg = append(msg, berTLV(snmpGetRequest, pdu)...)
+
+	c, err := net.Dial("udp", address)
+	if err != nil {
+		return nil, fmt.Errorf("snmp: %w", err)
+	}
+	defer c.Close()
+	c.SetDeadline(time.Now().Add(timeout))
+	if _, err := c.Write(berTLV(berSequence, msg)); err != nil {
+		return nil, fmt.Errorf("snmp: %w", err)
+	}
+	buf := make([]byte, 1500)
+	for {
+		n, err := c.Read(buf)
+		if err != nil {
+			return nil, fmt.Errorf("snmp: %w", err)
+		}
+		values, replyID, err := parseSNMPReply(buf[:n], len(oids))
+		if err != nil {
+			return nil, fmt.Errorf("snmp: %w", err)
+		}
+		// replies of an earlier request are skipped
+		if replyID == int(id) {
+			return values, nil
+		}
+	}
+}
+
+// parseSNMPReply returns the values and the request ID of a GetResponse
+func parseSNMPReply(b []byte, count int) ([]interface{}, int, error) {
+	msg, _, err := berRead(b, berSequence)
+	if err != nil {
+		return nil, 0, err
+	}
+	if _, msg, err = berRead(msg, berInteger); err != nil {
+		return nil, 0, err
+	}
+	if _, msg, err = berRead(msg, berOctetString); err != nil {
+		return nil, 0, err
+	}
+	pdu, _, err := berRead(msg, snmpGetReply)
+	if err != nil {
+		return nil, 0, err
+	}
+	var fields [3]int // request ID, error status, error index
+	for i := range fields {
+		var v []byte
+		if v, pdu, err = berRead(pdu, berInteger); err != nil {
+			return nil, 0, err
+		}
+		fields[i] = berDecodeInt(v)
+	}
+	// like noSuchName for an object the agent does not have
+	if fields[1] != 0 {
+		return nil, fields[0], fmt.Errorf("agent error %d at object %d", fields[1], fields[2])
+	}
+	binds, _, err := berRead(pdu, berSequence)
+	if err != nil {
+		return nil, 0, err
+	}
+	values := make([]interface{}, 0, count)
+	for len(binds) > 0 {
+		var bind []byte
+		if bind, binds, err = berRead(binds, berSequence); err != nil {
+			return nil, 0, err
+		}
+		if _, bind, err = berRead(bind, berOID); err != nil {
+			return nil, 0, err
+		}
+		if len(bind) < 2 {
+			return nil, 0, errors.New("short variable binding")
+		}
+		tag := bind[0]
+		v, _, err := berRead(bind, tag)
+		if err != nil {
+			return nil, 0, err
+		}
+		switch tag {
+		case berInteger:
+			values = append(values, berDecodeInt(v))
+		case berOctetString:
+			values = append(values, v)
+		default:
+			values = append(values, nil)
+		}
+	}
+	if len(values) != count {
+		return nil, 0, fmt.Errorf("%d values for %d objects", len(values), count)
+	}
+	return values, fields[0], nil
+}
+
+// berTLV encodes a value of tag
+func berTLV(tag byte, v []byte) []byte {
+	b := []byte{tag}
+	switch n := len(v); {
+	case n < 0x80:
+		b = append(b, byte(n))
+	case n < 0x100:
+		b = append(b, 0x81, byte(n))
+	default:
+		b = append(b, 0x82, byte(n>>8), byte(n))
+	}
+	return append(b, v...)
+}
+
+// berInt encodes an integer in the fewest bytes of two's complement
+func berInt(n int) []byte {
+	var v []byte
+	for {
+		v = append([]byte{byte(n)}, v...)
+		if -0x80 <= n && n < 0x80 {
+			break
+		}
+		n >>= 8
+	}
+	return berTLV(berInteger, v)
+}
+
+// berDecodeInt decodes the value of an integer
+func berDecodeInt(v []byte) int {
+	n := 0
+	if len(v) > 0 && v[0]&0x80 != 0 {
+		n = -1
+	}
+	for _, b := range v {
+		n = n<<8 | int(b)
+	}
+	return n
+}
+
+// berEncodeOID encodes a dotted object identifier like "1.3.6.1.2.1.1.1.0"
+func berEncodeOID(oid string) ([]byte, error) {
+	parts := strings.Split(oid, ".")
+	if len(parts) < 2 {
+		return nil, fmt.Errorf("snmp: invalid OID %q", oid)
+	}
+	arcs := make([]int, len(parts))
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("snmp: invalid OID %q", oid)
+		}
+		arcs[i] = n
+	}
+	v := []byte{byte(arcs[0]*40 + arcs[1])}
+	for _, arc := range arcs[2:] {
+		enc := []byte{byte(arc & 0x7f)}
+		for arc >>= 7; arc > 0; arc >>= 7 {
+			enc = append([]byte{byte(arc&0x7f | 0x80)}, enc...)
+		}
+		v = append(v, enc...)
+	}
+	return berTLV(berOID, v), nil
+}
+
+// berRead returns the value of the tag at the start of b and the bytes after it
+func berRead(b []byte, tag byte) ([]byte, []byte, error) {
+	if len(b) < 2 {
+		return nil, nil, errors.New("short message")
+	}
+	if b[0] != tag {
+		return nil, nil, fmt.Errorf("tag %#02x, want %#02x", b[0], tag)
+	}
+	n, head := int(b[1]), 2
+	if n&0x80 != 0 {
+		size := n & 0x7f
+		if size == 0 || size > 2 || len(b) < 2+size {
+			return nil, nil, errors.New("invalid length")
+		}
+		n = 0
+		for _, c := range b[2 : 2+size] {
+			n = n<<8 | int(c)
+		}
+		head += size
+	}
+	if len(b) < head+n {
+		return nil, nil, errors.New("short message")
+	}
+	return b[head : head+n], b[head+n:], nil
+}
@@ -0,0 +1,255 @@
+// Package ssh is a connection backend for printers attached to a remote host,
+// the job is piped through an SSH session so no service has to run on the host.
+//
+// Address is "user@host[:port]/device" like "pi@raspberrypi/dev/usb/lp0", the device
+// is opened on the host for reading and writing so status replies come back.
+// Driver.Command runs a custom command instead, like "lp -d PT-P710BT -o raw".
+package ssh
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ka2n/ptouchgo/conn"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// Driver opens SSH sessions, keys are taken from ssh-agent and ~/.ssh
+type Driver struct {
+	// Command runs instead of relaying the device of the address, "%s" is replaced by the device
+	Command string
+
+	KeyFiles       []string // private keys, empty uses id_ed25519, id_ecdsa and id_rsa in ~/.ssh
+	KnownHostsFile string   // empty uses ~/.ssh/known_hosts
+	DialTimeout    time.Duration
+}
+
+// DefaultDriver is registered as "ssh"
+var DefaultDriver = Driver{
+	DialTimeout: 10 * time.Second,
+}
+
+func init() {
+	conn.Register("ssh", DefaultDriver)
+}
+
+// relayCommand copies the device to stdout and stdin to the device until stdin is closed
+const relayCommand = `exec 3<>%s || exit 1; cat <&3 & pid=$!; cat >&3; st=$?; kill $pid 2>/dev/null; exit $st`
+
+// Open starts a session piping the job to the device or command on the host
+func (d Driver) Open(address string) (io.ReadWriteCloser, error) {
+	userName, host, device, err := parseAddress(address)
+	if err != nil {
+		return nil, err
+	}
+
+	command := d.Command
+	switch {
+	case command == "" && device == "":
+		return nil, fmt.Errorf("ssh: no device in %q and no command configured", address)
+	case command == "":
+		command = fmt.Sprintf(relayCommand, shellQuote(device))
+	case strings.Contains(command, "%s"):
+		command = fmt.Sprintf(command, shellQuote(device))
+	}
+
+	config, closeAgent, err := d.clientConfig(userName)
+	if err != nil {
+		return nil, err
+	}
+	// the agent signs during the handshake only
+	client, err := ssh.Dial("tcp", host, config)
+	closeAgent()
+	if err != nil {
+		return nil, fmt.Errorf("ssh: %w", err)
+	}
+	session, err := client.NewSession()
+	if err != nil {
+		client.Close()
+		return nil, fmt.Errorf("ssh: %w", err)
+	}
+	stdin, err := session.StdinPipe()
+	if err != nil {
+		client.Close()
+		return nil, fmt.Errorf("ssh: %w", err)
+	}
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		client.Close()
+		return nil, fmt.Errorf("ssh: %w", err)
+	}
+	stderr := &tailBuffer{}
+	session.Stderr = stderr
+	if err := session.Start(command); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("ssh: %w", err)
+	}
+	return &sshConn{client: client, session: session, stdin: stdin, stdout: stdout, stderr: stderr}, nil
+}
+
+// Capabilities of a session, only the device relay returns status replies
+func (d Driver) Capabilities() conn.Capabilities {
+	return conn.Capabilities{StatusReadback: d.Command == "", FullDuplex: true}
+}
+
+// parseAddress splits "user@host:port/device", the user defaults to the local user and the port to 22
+func parseAddress(address string) (userName, host, device string, err error) {
+	rest := address
+	if i := strings.LastIndex(rest, "@"); i >= 0 {
+		userName, rest = rest[:i], rest[i+1:]
+	}
+	host = rest
+	if i := strings.Index(rest, "/"); i >= 0 {
+		host, device = rest[:i], rest[i:]
+	}
+	if host == "" {
+		return "", "", "", fmt.Errorf("ssh: no host in %q", address)
+	}
+	if _, _, err := net.SplitHostPort(host); err != nil {
+		host = net.JoinHostPort(strings.Trim(host, "[]"), "22")
+	}
+	if userName == "" {
+		u, err := user.Current()
+		if err != nil {
+			return "", "", "", fmt.Errorf("ssh: no user in %q: %w", address, err)
+		}
+		userName = u.Username
+	}
+	return userName, host, device, nil
+}
+
+// clientConfig returns the config for userName and a function closing the agent connection
+func (d Driver) clientConfig(userName string) (*ssh.ClientConfig, func(), error) {
+	home, _ := os.UserHomeDir()
+
+	knownHostsFile := d.KnownHostsFile
+	if knownHostsFile == "" {
+		knownHostsFile = filepath.Join(home, ".ssh", "known_hosts")
+	}
+	hostKeyCallback, err := knownhosts.New(knownHostsFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("ssh: known hosts: %w", err)
+	}
+
+	closeAgent := func() {}
+	var signers []ssh.Signer
+	if sock := os.Getenv("SSH_AUTH_SOCK"); sock != "" {
+		if c, err := net.Dial("unix", sock); err == nil {
+			closeAgent = func() { c.Close() }
+			if agentSigners, err := agent.NewClient(c).Signers(); err == nil {
+				signers = append(signers, agentSigners...)
+			}
+		}
+	}
+	keyFiles := d.KeyFiles
+	if len(keyFiles) == 0 {
+		for _, name := range []string{"id_ed25519", "id_ecdsa", "id_rsa"} {
+			keyFiles = append(keyFiles, filepath.Join(home, ".ssh", name))
+		}
+	}
+	for _, path := range keyFiles {
+		key, err := ioutil.ReadFile(path)
+		if err != nil {
+			if len(d.KeyFiles) > 0 {
+				closeAgent()
+				return nil, nil, fmt.Errorf("ssh: %w", err)
+			}
+			continue
+		}
+		signer, err := ssh.ParsePrivateKey(key)
+		if err != nil {
+			// keys with a passphrase are expected to be in the agent
+			continue
+		}
+		signers = append(signers, signer)
+	}
+	if len(signers) == 0 {
+		closeAgent()
+		return nil, nil, errors.New("ssh: no keys found in ssh-agent or ~/.ssh")
+	}
+
+	return &ssh.ClientConfig{
+		User:            userName,
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(signers...)},
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         d.DialTimeout,
+	}, closeAgent, nil
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+type sshConn struct {
+	client  *ssh.Client
+	session *ssh.Session
+	stdin   io.WriteCloser
+	stdout  io.Reader
+	stderr  *tailBuffer
+}
+
+func (c *sshConn) Read(b []byte) (int, error) {
+	return c.stdout.Read(b)
+}
+
+func (c *sshConn) Write(b []byte) (int, error) {
+	n, err := c.stdin.Write(b)
+	if err != nil {
+		return n, fmt.Errorf("ssh: %v: %w", err, conn.ErrLinkLost)
+	}
+	return n, nil
+}
+
+// Close ends the input of the remote command and reports its failure
+func (c *sshConn) Close() error {
+	defer c.client.Close()
+	c.stdin.Close()
+
+	done := make(chan error, 1)
+	go func() { done <- c.session.Wait() }()
+	select {
+	case err := <-done:
+		if err != nil {
+			if msg := c.stderr.String(); msg != "" {
+				return fmt.Errorf("ssh: %v: %s", err, msg)
+			}
+			return fmt.Errorf("ssh: %w", err)
+		}
+		return nil
+	case <-time.After(10 * time.Second):
+		return errors.New("ssh: remote command did not exit")
+	}
+}
+
+// tailBuffer keeps the end of the remote command's error output
+type tailBuffer struct {
+	mu  sync.Mutex
+	buf []byte
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.buf = append(b.buf, p...)
+	if len(b.buf) > 1024 {
+		b.buf = b.buf[len(b.buf)-1024:]
+	}
+	return len(p), nil
+}
+
+func (b *tailBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return strings.TrimSpace(string(b.buf))
+}
@@ -1,26 +1,50 @@
+//go:build !windows && cgo
+// +build !windows,cgo
+
 package usb
 
 import (
-	"encoding/binary"
-	"encoding/hex"
+	"context"
+	"errors"
 	"fmt"
 	"io"
-	"strings"
 	"sync"
+	"time"
 
 	"github.com/google/gousb"
 	"github.com/ka2n/ptouchgo/conn"
 )
 
-const (
-	brotherVendorID   = 0x04f9
-	productIDPTP700   = 0x2061
-	productIDPTP750W  = 0x2062
-	productIDPTP710BT = 0x20af
+var (
+	contextMu  sync.Mutex
+	sharedCtx  *gousb.Context
+	debugLevel int
 )
 
+// usbContext returns the libusb context shared by every connection,
+// it is created on first use and kept so opening a device again is cheap
+func usbContext() *gousb.Context {
+	contextMu.Lock()
+	defer contextMu.Unlock()
+	if sharedCtx == nil {
+		sharedCtx = gousb.NewContext()
+		if debugLevel > 0 {
+			sharedCtx.Debug(debugLevel)
+		}
+	}
+	return sharedCtx
+}
+
+func setDebug(level int) {
+	contextMu.Lock()
+	defer contextMu.Unlock()
+	debugLevel = level
+	if sharedCtx != nil {
+		sharedCtx.Debug(level)
+	}
+}
+
 type USBSerial struct {
-	ctx    *gousb.Context
 	dev    *gousb.Device
 	mu     sync.Mutex
 	readm  sync.Mutex
@@ -28,66 +52,43 @@ type USBSerial struct {
 	input  *gousb.InEndpoint
 	output *gousb.OutEndpoint
 	done   func()
-}
 
-func init() {
-	conn.Register("usb", conn.DriverFunc(OpenUSB))
+	timeouts conn.Timeouts
 }
 
-// OpenUSB open usb connection to device. if address is empty string, it will find pre defined device id.
-// address should formatted like "20af" or empty string.
-func OpenUSB(address string) (io.ReadWriteCloser, error) {
+// OpenUSBTimeout is OpenUSB with reads and writes giving up after timeouts
+func OpenUSBTimeout(address string, timeouts conn.Timeouts) (io.ReadWriteCloser, error) {
 	var err error
-	var ctx *gousb.Context
 	var done func()
 	var dev *gousb.Device
 	var usbif *gousb.Interface
 	var input *gousb.InEndpoint
 	var output *gousb.OutEndpoint
+	var sel selector
 
-	ctx = gousb.NewContext()
-	ctx.Debug(10)
-
-	if address != "" {
-		if !strings.HasPrefix(address, "0x") {
-			err = fmt.Errorf("invalid device address. address should \"0x0000\" form")
-			goto handleError
-		}
-
-		var productID []byte
-		productID, err = hex.DecodeString(address[2:])
-		if err != nil {
-			goto handleError
-		}
-		dev, err = ctx.OpenDeviceWithVIDPID(brotherVendorID, gousb.ID(binary.BigEndian.Uint16(productID)))
-		if err != nil {
-			goto handleError
-		}
-	} else {
-		dev, _ = ctx.OpenDeviceWithVIDPID(brotherVendorID, productIDPTP750W)
-		if dev == nil {
-			dev, _ = ctx.OpenDeviceWithVIDPID(brotherVendorID, productIDPTP700)
-		}
-
-		if dev == nil {
-			dev, _ = ctx.OpenDeviceWithVIDPID(brotherVendorID, productIDPTP710BT)
-		}
+	sel, err = parseSelector(address)
+	if err != nil {
+		goto handleError
 	}
-
-	if dev == nil {
-		err = fmt.Errorf("USB device not found")
+	dev, err = openDevice(usbContext(), sel)
+	if err != nil {
 		goto handleError
 	}
 
 	err = dev.SetAutoDetach(true)
-	if err != nil {
+	// libusb can not detach kernel drivers on macOS, claiming may still succeed
+	if err != nil && !errors.Is(err, gousb.ErrorNotSupported) {
 		err = fmt.Errorf("set auto detach kernel driver: %w", err)
 		goto handleError
 	}
 
 	usbif, done, err = dev.DefaultInterface()
 	if err != nil {
-		err = fmt.Errorf("get default interface: %w", err)
+		if ser, ferr := openFallback(uint16(dev.Desc.Product), err); ferr == nil {
+			dev.Close()
+			return ser, nil
+		}
+		err = fmt.Errorf("get default interface: %w", claimError(err))
 		goto handleError
 	}
 
@@ -104,13 +105,13 @@ func OpenUSB(address string) (io.ReadWriteCloser, error) {
 	}
 
 	return &USBSerial{
-		dev:    dev,
-		input:  input,
-		output: output,
+		dev:      dev,
+		input:    input,
+		output:   output,
+		timeouts: timeouts,
 		done: func() {
 			done()
 			dev.Close()
-			ctx.Close()
 		},
 	}, nil
 
@@ -121,17 +122,17 @@ handleError:
 	if dev != nil {
 		dev.Close()
 	}
-	if ctx != nil {
-		ctx.Close()
-	}
 	return nil, err
 }
 
-func (s USBSerial) Close() error {
+func (s *USBSerial) Close() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	done := s.done
+	if done == nil {
+		return nil
+	}
 	s.done = nil
 	s.input = nil
 	s.output = nil
@@ -139,14 +140,101 @@ func (s USBSerial) Close() error {
 	return nil
 }
 
-func (s USBSerial) Write(b []byte) (int, error) {
+func (s *USBSerial) Write(b []byte) (int, error) {
 	s.writem.Lock()
 	defer s.writem.Unlock()
-	return s.output.Write(b)
+	ctx, cancel := timeoutContext(s.timeouts.Write)
+	defer cancel()
+	n, err := s.output.WriteContext(ctx, b)
+	return n, transferError(ctx, err)
 }
 
-func (s USBSerial) Read(b []byte) (int, error) {
+func (s *USBSerial) Read(b []byte) (int, error) {
 	s.readm.Lock()
 	defer s.readm.Unlock()
-	return s.input.Read(b)
+	ctx, cancel := timeoutContext(s.timeouts.Read)
+	defer cancel()
+	n, err := s.input.ReadContext(ctx, b)
+	return n, transferError(ctx, err)
+}
+
+func timeoutContext(timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithTimeout(context.Background(), timeout)
+}
+
+// transferError reports transfers cancelled by their deadline as conn.ErrTimeout
+// and transfers to an unplugged device as conn.ErrLinkLost
+func transferError(ctx context.Context, err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case ctx.Err() == context.DeadlineExceeded:
+		return fmt.Errorf("usb: %v: %w", err, conn.ErrTimeout)
+	case errors.Is(err, gousb.TransferNoDevice), errors.Is(err, gousb.ErrorNoDevice), errors.Is(err, gousb.ErrorIO):
+		return fmt.Errorf("usb: %v: %w", err, conn.ErrLinkLost)
+	}
+	return err
+}
+
+// openDevice opens the preferred device matching sel, serial numbers are only read when selecting by one
+func openDevice(ctx *gousb.Context, sel selector) (*gousb.Device, error) {
+	devs, err := ctx.OpenDevices(func(desc *gousb.DeviceDesc) bool {
+		return desc.Vendor == brotherVendorID && sel.matches(usbDevice{
+			productID: uint16(desc.Product),
+			serial:    sel.serial, // checked once opened
+			bus:       desc.Bus,
+			port:      desc.Port,
+		})
+	})
+	candidates := make([]usbDevice, len(devs))
+	for i, dev := range devs {
+		candidates[i] = usbDevice{productID: uint16(dev.Desc.Product), bus: dev.Desc.Bus, port: dev.Desc.Port}
+		if sel.serial != "" {
+			candidates[i].serial, _ = dev.SerialNumber()
+		}
+	}
+	best := sel.find(candidates)
+	for i, dev := range devs {
+		if i != best {
+			dev.Close()
+		}
+	}
+	if best < 0 {
+		if err != nil {
+			// a matching device could not be opened
+			return nil, fmt.Errorf("open USB device: %w", err)
+		}
+		return nil, ErrNotFound
+	}
+	return devs[best], nil
+}
+
+// listDevices returns the connected Brother devices, serial numbers are
+// empty for devices which can not be opened
+func listDevices() ([]usbDevice, error) {
+	ctx := usbContext()
+
+	var devices []usbDevice
+	index := make(map[[2]int]int)
+	devs, err := ctx.OpenDevices(func(desc *gousb.DeviceDesc) bool {
+		if desc.Vendor != brotherVendorID {
+			return false
+		}
+		index[[2]int{desc.Bus, desc.Address}] = len(devices)
+		devices = append(devices, usbDevice{productID: uint16(desc.Product), bus: desc.Bus, port: desc.Port})
+		return true
+	})
+	for _, dev := range devs {
+		if i, ok := index[[2]int{dev.Desc.Bus, dev.Desc.Address}]; ok {
+			devices[i].serial, _ = dev.SerialNumber()
+		}
+		dev.Close()
+	}
+	if len(devices) == 0 && err != nil {
+		return nil, err
+	}
+	return devices, nil
 }
@@ -0,0 +1,25 @@
+//go:build !windows && !cgo
+// +build !windows,!cgo
+
+package usb
+
+import (
+	"errors"
+	"io"
+
+	"github.com/ka2n/ptouchgo/conn"
+)
+
+// errNoCgo is returned by builds without cgo, which can not use libusb
+var errNoCgo = errors.New("usb: built without cgo, use the usblp driver on Linux")
+
+// OpenUSBTimeout is OpenUSB with reads and writes giving up after timeouts
+func OpenUSBTimeout(address string, timeouts conn.Timeouts) (io.ReadWriteCloser, error) {
+	return nil, errNoCgo
+}
+
+func listDevices() ([]usbDevice, error) {
+	return nil, errNoCgo
+}
+
+func setDebug(level int) {}
@@ -0,0 +1,235 @@
+package usb
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unsafe"
+
+	"github.com/ka2n/ptouchgo/conn"
+	"golang.org/x/sys/windows"
+)
+
+// guidDevInterfaceUSBPrint is GUID_DEVINTERFACE_USBPRINT
+var guidDevInterfaceUSBPrint = windows.GUID{
+	Data1: 0x28d78fad,
+	Data2: 0x5a12,
+	Data3: 0x11d1,
+	Data4: [8]byte{0xae, 0x5b, 0x00, 0x00, 0xf8, 0x03, 0xa8, 0xc2},
+}
+
+var (
+	setupapi                             = windows.NewLazySystemDLL("setupapi.dll")
+	procSetupDiGetClassDevsW             = setupapi.NewProc("SetupDiGetClassDevsW")
+	procSetupDiEnumDeviceInterfaces      = setupapi.NewProc("SetupDiEnumDeviceInterfaces")
+	procSetupDiGetDeviceInterfaceDetailW = setupapi.NewProc("SetupDiGetDeviceInterfaceDetailW")
+	procSetupDiDestroyDeviceInfoList     = setupapi.NewProc("SetupDiDestroyDeviceInfoList")
+)
+
+const (
+	digcfPresent         = 0x02
+	digcfDeviceInterface = 0x10
+)
+
+const readInterval = 50 * time.Millisecond
+
+// spDeviceInterfaceData is SP_DEVICE_INTERFACE_DATA
+type spDeviceInterfaceData struct {
+	cbSize             uint32
+	InterfaceClassGuid windows.GUID
+	Flags              uint32
+	Reserved           uintptr
+}
+
+// USBSerial talks to the printer through the usbprint interface of the installed
+// Brother driver, so neither libusb nor a replaced driver is needed on Windows
+type USBSerial struct {
+	handle      windows.Handle
+	readm       sync.Mutex
+	writem      sync.Mutex
+	readTimeout time.Duration
+}
+
+// OpenUSBTimeout is OpenUSB with reads and writes giving up after timeouts,
+// writes to usbprint can not be cancelled and are left running in the background
+func OpenUSBTimeout(address string, timeouts conn.Timeouts) (io.ReadWriteCloser, error) {
+	sel, err := parseSelector(address)
+	if err != nil {
+		return nil, err
+	}
+	if sel.bus != 0 || sel.port != 0 {
+		return nil, fmt.Errorf("usb: selecting by bus and port is not supported on Windows, use serial")
+	}
+
+	paths, err := usbPrintPaths()
+	if err != nil {
+		return nil, fmt.Errorf("enumerate usb printers: %w", err)
+	}
+	var devices []usbDevice
+	var devicePaths []string
+	for _, path := range paths {
+		if dev, ok := parseDevicePath(path); ok {
+			devices = append(devices, dev)
+			devicePaths = append(devicePaths, path)
+		}
+	}
+	best := sel.find(devices)
+	if best < 0 {
+		return nil, fmt.Errorf("%w, is the Brother printer driver installed?", ErrNotFound)
+	}
+	path := devicePaths[best]
+
+	name, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return nil, err
+	}
+	h, err := windows.CreateFile(name,
+		windows.GENERIC_READ|windows.GENERIC_WRITE,
+		windows.FILE_SHARE_READ|windows.FILE_SHARE_WRITE,
+		nil, windows.OPEN_EXISTING, 0, 0)
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", path, err)
+	}
+	s := &USBSerial{handle: h, readTimeout: timeouts.Read}
+	return conn.WithTimeouts(s, conn.Timeouts{Write: timeouts.Write}), nil
+}
+
+// setDebug does nothing, usbprint.sys does not log
+func setDebug(level int) {}
+
+// parseDevicePath parses a usbprint path like "\\?\usb#vid_04f9&pid_20af#000F1Z123456#{28d78fad-...}",
+// the third part is the serial number or an instance ID containing "&" for devices without one
+func parseDevicePath(path string) (usbDevice, bool) {
+	parts := strings.Split(path, "#")
+	if len(parts) < 3 {
+		return usbDevice{}, false
+	}
+	ids := strings.ToLower(parts[1])
+	vid := fmt.Sprintf("vid_%04x", brotherVendorID)
+	i := strings.Index(ids, "&pid_")
+	if !strings.HasPrefix(ids, vid) || i < 0 || len(ids) < i+9 {
+		return usbDevice{}, false
+	}
+	productID, err := strconv.ParseUint(ids[i+5:i+9], 16, 16)
+	if err != nil {
+		return usbDevice{}, false
+	}
+	dev := usbDevice{productID: uint16(productID)}
+	if !strings.Contains(parts[2], "&") {
+		dev.serial = parts[2]
+	}
+	return dev, true
+}
+
+// listDevices returns the connected Brother usbprint devices
+func listDevices() ([]usbDevice, error) {
+	paths, err := usbPrintPaths()
+	if err != nil {
+		return nil, err
+	}
+	var devices []usbDevice
+	for _, path := range paths {
+		if dev, ok := parseDevicePath(path); ok {
+			devices = append(devices, dev)
+		}
+	}
+	return devices, nil
+}
+
+// usbPrintPaths lists the device paths of connected usbprint devices
+func usbPrintPaths() ([]string, error) {
+	guid := uintptr(unsafe.Pointer(&guidDevInterfaceUSBPrint))
+	devs, _, err := procSetupDiGetClassDevsW.Call(guid, 0, 0, digcfPresent|digcfDeviceInterface)
+	if windows.Handle(devs) == windows.InvalidHandle {
+		return nil, err
+	}
+	defer procSetupDiDestroyDeviceInfoList.Call(devs)
+
+	var paths []string
+	for i := 0; ; i++ {
+		var data spDeviceInterfaceData
+		data.cbSize = uint32(unsafe.Sizeof(data))
+		r, _, err := procSetupDiEnumDeviceInterfaces.Call(devs, 0, guid, uintptr(i), uintptr(unsafe.Pointer(&data)))
+		if r == 0 {
+			if err == windows.ERROR_NO_MORE_ITEMS {
+				return paths, nil
+			}
+			return nil, err
+		}
+
+		var size uint32
+		procSetupDiGetDeviceInterfaceDetailW.Call(devs, uintptr(unsafe.Pointer(&data)), 0, 0, uintptr(unsafe.Pointer(&size)), 0)
+		if size <= 4 {
+			continue
+		}
+		// SP_DEVICE_INTERFACE_DETAIL_DATA_W is a DWORD size followed by the path
+		buf := make([]uint16, (size+1)/2)
+		cbSize := uint32(8)
+		if unsafe.Sizeof(uintptr(0)) == 4 {
+			cbSize = 6
+		}
+		*(*uint32)(unsafe.Pointer(&buf[0])) = cbSize
+		r, _, err = procSetupDiGetDeviceInterfaceDetailW.Call(devs, uintptr(unsafe.Pointer(&data)),
+			uintptr(unsafe.Pointer(&buf[0])), uintptr(size), 0, 0)
+		if r == 0 {
+			return nil, err
+		}
+		paths = append(paths, windows.UTF16ToString(buf[2:]))
+	}
+}
+
+func (s *USBSerial) Close() error {
+	s.readm.Lock()
+	s.writem.Lock()
+	defer s.readm.Unlock()
+	defer s.writem.Unlock()
+
+	if s.handle == windows.InvalidHandle {
+		return nil
+	}
+	err := windows.CloseHandle(s.handle)
+	s.handle = windows.InvalidHandle
+	return err
+}
+
+func (s *USBSerial) Write(b []byte) (int, error) {
+	s.writem.Lock()
+	defer s.writem.Unlock()
+
+	var n uint32
+	err := windows.WriteFile(s.handle, b, &n, nil)
+	return int(n), usbprintError(err)
+}
+
+// usbprintError reports failures of an unplugged printer as conn.ErrLinkLost
+func usbprintError(err error) error {
+	switch err {
+	case windows.ERROR_DEVICE_NOT_CONNECTED, windows.ERROR_GEN_FAILURE, windows.ERROR_BAD_COMMAND:
+		return fmt.Errorf("usb: %v: %w", err, conn.ErrLinkLost)
+	}
+	return err
+}
+
+// Read waits for data since usbprint returns immediately when the printer has nothing to send
+func (s *USBSerial) Read(b []byte) (int, error) {
+	s.readm.Lock()
+	defer s.readm.Unlock()
+
+	deadline := time.Now().Add(s.readTimeout)
+	for {
+		var n uint32
+		if err := windows.ReadFile(s.handle, b, &n, nil); err != nil {
+			return 0, usbprintError(err)
+		}
+		if n > 0 || len(b) == 0 {
+			return int(n), nil
+		}
+		if s.readTimeout > 0 && time.Now().After(deadline) {
+			return 0, fmt.Errorf("usb: read: %w", conn.ErrTimeout)
+		}
+		time.Sleep(readInterval)
+	}
+}
@@ -0,0 +1,93 @@
+//go:build cgo
+// +build cgo
+
+package usb
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"github.com/google/gousb"
+)
+
+// errNoStatus is returned by reads from a CUPS queue which can only send data to the printer
+var errNoStatus = errors.New("usb: printer status is not available through a CUPS queue")
+
+// openFallback sends jobs through a CUPS queue of the printer when the macOS printer
+// driver holds the USB interface, cause is returned when there is no such queue
+func openFallback(productID uint16, cause error) (io.ReadWriteCloser, error) {
+	queue, err := cupsQueue(productNames[productID])
+	if err != nil || queue == "" {
+		return nil, cause
+	}
+	return &cupsConn{queue: queue}, nil
+}
+
+// claimError explains how to resolve interfaces held by the system printer driver
+func claimError(err error) error {
+	if errors.Is(err, gousb.ErrorAccess) || errors.Is(err, gousb.ErrorBusy) {
+		return fmt.Errorf("%w: the printer is in use by the macOS printer driver, "+
+			"quit apps using it or add the printer in System Preferences > Printers & Scanners to print through CUPS", err)
+	}
+	return err
+}
+
+// cupsQueue finds the queue of a Brother USB printer whose model contains model,
+// any Brother USB queue matches when model is empty
+func cupsQueue(model string) (string, error) {
+	out, err := exec.Command("lpstat", "-v").Output()
+	if err != nil {
+		return "", err
+	}
+	// "device for PT-P710BT: usb://Brother/PT-P710BT?serial=000000000000"
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	for sc.Scan() {
+		line := strings.TrimPrefix(sc.Text(), "device for ")
+		i := strings.Index(line, ": ")
+		if i < 0 {
+			continue
+		}
+		queue, uri := line[:i], strings.ToLower(line[i+2:])
+		if strings.HasPrefix(uri, "usb://brother/") && strings.Contains(uri, strings.ToLower(model)) {
+			return queue, nil
+		}
+	}
+	return "", sc.Err()
+}
+
+// cupsConn buffers the job and submits it as a raw job on Close
+type cupsConn struct {
+	mu    sync.Mutex
+	queue string
+	buf   bytes.Buffer
+}
+
+func (c *cupsConn) Write(b []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.buf.Write(b)
+}
+
+func (c *cupsConn) Read(b []byte) (int, error) {
+	return 0, errNoStatus
+}
+
+func (c *cupsConn) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.buf.Len() == 0 {
+		return nil
+	}
+	cmd := exec.Command("lp", "-d", c.queue, "-o", "raw")
+	cmd.Stdin = &c.buf
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("lp: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
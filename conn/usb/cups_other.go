@@ -0,0 +1,14 @@
+//go:build !darwin && !windows && cgo
+// +build !darwin,!windows,cgo
+
+package usb
+
+import "io"
+
+func openFallback(productID uint16, cause error) (io.ReadWriteCloser, error) {
+	return nil, cause
+}
+
+func claimError(err error) error {
+	return err
+}
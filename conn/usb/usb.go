@@ -0,0 +1,229 @@
+package usb
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ka2n/ptouchgo/conn"
+	"github.com/ka2n/ptouchgo/models"
+)
+
+const brotherVendorID = 0x04f9
+
+// productNames are the model names of the product IDs of the model registry
+var productNames = func() map[uint16]string {
+	names := map[uint16]string{}
+	for _, spec := range models.All() {
+		if spec.ProductID != 0 {
+			names[spec.ProductID] = spec.Name
+		}
+	}
+	return names
+}()
+
+// ErrNotFound is returned when no matching printer is connected
+var ErrNotFound = errors.New("USB device not found")
+
+// hotplugInterval is how often WaitUSB looks for the printer, gousb does not expose libusb hotplug events
+const hotplugInterval = 500 * time.Millisecond
+
+// defaultProductIDs are tried in order when no address is given, in the order of the model registry
+var defaultProductIDs = func() []uint16 {
+	var ids []uint16
+	for _, spec := range models.All() {
+		if spec.ProductID != 0 {
+			ids = append(ids, spec.ProductID)
+		}
+	}
+	return ids
+}()
+
+func init() {
+	conn.Register("usb", driver{})
+}
+
+type driver struct{}
+
+func (driver) Open(address string) (io.ReadWriteCloser, error) {
+	return OpenUSB(address)
+}
+
+func (driver) OpenTimeout(address string, timeouts conn.Timeouts) (io.ReadWriteCloser, error) {
+	return OpenUSBTimeout(address, timeouts)
+}
+
+// Capabilities of USB, bulk in and out endpoints are independent
+func (driver) Capabilities() conn.Capabilities {
+	return conn.Capabilities{StatusReadback: true, FullDuplex: true}
+}
+
+// OpenUSB open usb connection to device with conn.DefaultTimeouts. if address is empty string, it will find pre defined device id.
+// address should formatted like "0x20af" or empty string, optionally followed by comma separated
+// selectors to pick one of several identical printers like "0x20af,serial=K1Z123456",
+// "serial=K1Z123456" or "bus=1,port=4".
+func OpenUSB(address string) (io.ReadWriteCloser, error) {
+	return OpenUSBTimeout(address, conn.DefaultTimeouts)
+}
+
+// WaitUSB is OpenUSBTimeout which waits until a matching printer is plugged in or ctx is done
+func WaitUSB(ctx context.Context, address string, timeouts conn.Timeouts) (io.ReadWriteCloser, error) {
+	sel, err := parseSelector(address)
+	if err != nil {
+		return nil, err
+	}
+
+	t := time.NewTicker(hotplugInterval)
+	defer t.Stop()
+	for {
+		if devices, err := listDevices(); err == nil && sel.find(devices) >= 0 {
+			c, err := OpenUSBTimeout(address, timeouts)
+			// the printer may be gone again or still be initializing
+			if !errors.Is(err, ErrNotFound) {
+				return c, err
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("wait for USB printer: %w", ctx.Err())
+		case <-t.C:
+		}
+	}
+}
+
+// SetDebug sets the libusb log level, written to stderr, from 0 which disables logging to 4 for debug messages.
+// Logging is disabled by default, the level has no effect on Windows.
+func SetDebug(level int) {
+	setDebug(level)
+}
+
+// List returns connected Brother USB devices, identical printers are told apart by serial number or port
+func (driver) List() ([]conn.Device, error) {
+	found, err := listDevices()
+	if err != nil {
+		return nil, err
+	}
+	count := make(map[uint16]int)
+	for _, dev := range found {
+		count[dev.productID]++
+	}
+
+	var devices []conn.Device
+	for _, dev := range found {
+		d := conn.Device{
+			Driver:  "usb",
+			Address: fmt.Sprintf("0x%04x", dev.productID),
+			Model:   productNames[dev.productID],
+		}
+		if count[dev.productID] > 1 {
+			switch {
+			case dev.serial != "":
+				d.Address += ",serial=" + dev.serial
+			case dev.bus != 0:
+				d.Address += fmt.Sprintf(",bus=%d,port=%d", dev.bus, dev.port)
+			}
+		}
+		d.Name = "Brother " + d.Model
+		if d.Model == "" {
+			d.Name = "Brother USB device " + d.Address
+		}
+		devices = append(devices, d)
+	}
+	return devices, nil
+}
+
+// usbDevice is a connected Brother device, fields a platform can not tell are zero
+type usbDevice struct {
+	productID uint16
+	serial    string
+	bus, port int
+}
+
+// selector picks a device by the address given to OpenUSB
+type selector struct {
+	productIDs []uint16 // in order of preference
+	serial     string   // empty matches any
+	bus, port  int      // zero matches any
+}
+
+func parseSelector(address string) (selector, error) {
+	sel := selector{productIDs: defaultProductIDs}
+	if address == "" {
+		return sel, nil
+	}
+	for _, field := range strings.Split(address, ",") {
+		key, value := "", field
+		if i := strings.Index(field, "="); i >= 0 {
+			key, value = field[:i], field[i+1:]
+		}
+		var err error
+		switch key {
+		case "":
+			var productID uint16
+			productID, err = parseProductID(value)
+			sel.productIDs = []uint16{productID}
+		case "serial":
+			sel.serial = value
+		case "bus":
+			sel.bus, err = strconv.Atoi(value)
+		case "port":
+			sel.port, err = strconv.Atoi(value)
+		default:
+			err = fmt.Errorf("unknown selector %q", key)
+		}
+		if err != nil {
+			return selector{}, fmt.Errorf("invalid usb address %q: %w", address, err)
+		}
+	}
+	return sel, nil
+}
+
+// matches reports whether dev is selected, serial numbers are compared ignoring case
+// since Windows device paths may change it
+func (sel selector) matches(dev usbDevice) bool {
+	return sel.rank(dev.productID) >= 0 &&
+		(sel.serial == "" || strings.EqualFold(sel.serial, dev.serial)) &&
+		(sel.bus == 0 || sel.bus == dev.bus) &&
+		(sel.port == 0 || sel.port == dev.port)
+}
+
+// rank returns the preference of productID, -1 if it is not selected
+func (sel selector) rank(productID uint16) int {
+	for i, id := range sel.productIDs {
+		if id == productID {
+			return i
+		}
+	}
+	return -1
+}
+
+// find returns the index of the preferred device in devices, -1 if none matches
+func (sel selector) find(devices []usbDevice) int {
+	best := -1
+	for i, dev := range devices {
+		if sel.matches(dev) && (best < 0 || sel.rank(dev.productID) < sel.rank(devices[best].productID)) {
+			best = i
+		}
+	}
+	return best
+}
+
+func parseProductID(address string) (uint16, error) {
+	if !strings.HasPrefix(address, "0x") {
+		return 0, fmt.Errorf("invalid device address. address should \"0x0000\" form")
+	}
+	productID, err := hex.DecodeString(address[2:])
+	if err != nil {
+		return 0, err
+	}
+	if len(productID) != 2 {
+		return 0, fmt.Errorf("invalid device address. address should \"0x0000\" form")
+	}
+	return binary.BigEndian.Uint16(productID), nil
+}
@@ -0,0 +1,150 @@
+// Package cups prints through the CUPS print system: it reads the CUPS and PWG raster of jobs,
+// writes the PPDs of the printers and maps their status to CUPS printer states for the
+// backend and driver of cmd/ptouchgo-cups.
+package cups
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"strings"
+
+	"github.com/disintegration/imaging"
+	"github.com/ka2n/ptouchgo"
+	"github.com/ka2n/ptouchgo/conn"
+)
+
+// exit codes of a CUPS backend
+const (
+	BackendOK           = 0 // the job is printed
+	BackendFailed       = 1 // the job failed, the error policy of the queue applies
+	BackendHold         = 3 // the job is held
+	BackendStop         = 4 // the queue is stopped
+	BackendCancel       = 5 // the job is canceled
+	BackendRetry        = 6 // the job is retried later
+	BackendRetryCurrent = 7 // the job is retried right away
+)
+
+// StateReasons returns the printer-state-reasons of the errors of st like "media-empty-error",
+// errors without a reason of their own are "other-error"
+func StateReasons(st *ptouchgo.Status) []string {
+	var reasons []string
+	add := func(reason string) {
+		for _, r := range reasons {
+			if r == reason {
+				return
+			}
+		}
+		reasons = append(reasons, reason)
+	}
+	known := 0
+	for _, e := range []struct {
+		set    bool
+		reason string
+	}{
+		{st.NoMedia(), "media-empty-error"},
+		{st.EndOfMedia(), "media-empty-error"},
+		{st.CutterJam(), "media-jam-error"},
+		{st.WeakBattery(), "other-warning"},
+		{st.InvalidMedia(), "media-needed-error"},
+		{st.CoverOpen(), "cover-open-error"},
+	} {
+		if e.set {
+			add(e.reason)
+			known++
+		}
+	}
+	if len(st.Errors()) > known {
+		add("other-error")
+	}
+	return reasons
+}
+
+// Blocking reports whether reasons keep the printer from printing, warnings do not
+func Blocking(reasons []string) bool {
+	for _, r := range reasons {
+		if strings.HasSuffix(r, "-error") {
+			return true
+		}
+	}
+	return false
+}
+
+// DeviceLine returns the line of d listed by the backend of scheme without arguments,
+// network printers are of the network class
+func DeviceLine(scheme string, d conn.Device) string {
+	class := "direct"
+	switch d.Driver {
+	case "net", "wlan", "tcp", "mdns", "ssh":
+		class = "network"
+	}
+	makeModel, info, id := "Unknown", d.Name, ""
+	if m, err := ptouchgo.ParseModel(d.Model); err == nil {
+		makeModel, id = MakeAndModel(m), DeviceID(m)
+		info = "Brother " + m.String() + " (" + d.Driver + ")"
+	}
+	return fmt.Sprintf("%s %s:%s:%s %q %q %q %q", class, scheme, d.Driver, d.Address, makeModel, info, id, "")
+}
+
+// PageTape returns the tape of the page size of h for connections which can not read the printer status,
+// 24mm when the page is not as wide as a tape
+func PageTape(h *PageHeader) ptouchgo.TapeWidth {
+	tw := ptouchgo.TapeWidth(math.Round(float64(h.PageSize[0]) * 25.4 / 72))
+	switch {
+	case tw == 3:
+		return 4 // 3.5mm tape
+	case !tw.Valid():
+		return 24
+	}
+	return tw
+}
+
+// FitPage lays a page out across the print head of ser: it is scaled from the resolution of h to the
+// one of the head and centered on the printable dots, the QL media or the pins of the head, cutting
+// off what is beyond them and beyond the length of die-cut labels. Lines stay raster lines,
+// the top of the page is printed first.
+func FitPage(ser ptouchgo.Serial, h *PageHeader, page image.Image) image.Image {
+	head := ser.Head()
+	dots := head.Pins
+	if ser.Media.Dots != 0 {
+		dots = ser.Media.Dots
+	}
+	size := page.Bounds().Size()
+	if res := h.HWResolution[0]; res > 0 && res != head.DPI {
+		width := int(math.Round(float64(size.X) * float64(head.DPI) / float64(res)))
+		page = imaging.Resize(page, width, size.Y, imaging.Linear)
+		size = page.Bounds().Size()
+	}
+	lines := size.Y
+	if ser.Media.DieCut() {
+		// the page of a label is longer than its printable lines
+		length := ser.Media.LengthDots
+		if h.HWResolution[1] >= 2*h.HWResolution[0] {
+			length *= 2
+		}
+		if lines > length {
+			lines = length
+		}
+	}
+	canvas := imaging.New(dots, lines, image.White)
+	return imaging.Paste(canvas, page, image.Pt((dots-size.X)/2, 0))
+}
+
+// Options returns the print options of the pages of a job, the cut and mirror settings and the
+// resolution of the first page apply to all of them
+func Options(ser ptouchgo.Serial, h *PageHeader, pages int) ptouchgo.PrintOptions {
+	opts := ptouchgo.DefaultPrintOptions()
+	opts.Mirror = h.MirrorPrint
+	opts.HighDPI = h.HWResolution[1] > 0 && h.HWResolution[1] >= 2*h.HWResolution[0] && ser.Model.HighDPI()
+	switch h.CutMedia {
+	case CutNever:
+		opts.AutoCut = false
+		opts.ChainPrint = true
+	case CutAfterPage:
+	default:
+		if pages <= ptouchgo.MaxCutEvery && ser.Model.CutEvery() {
+			opts.CutEvery = pages
+		}
+	}
+	return opts
+}
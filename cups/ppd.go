@@ -0,0 +1,215 @@
+package cups
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ka2n/ptouchgo"
+)
+
+// labelLengths are the label lengths in mm of the page sizes of continuous length tape,
+// custom page sizes give other lengths
+var labelLengths = []int{25, 50, 100, 200}
+
+// maxLabelLength is the longest custom page size in mm
+const maxLabelLength = 1000
+
+// PageSize is a page size of a tape or media and a label length
+type PageSize struct {
+	Name      string // like "24mm50"
+	Text      string // like "24mm x 50mm"
+	WidthMM   float64
+	LengthMM  float64
+	PrintedMM float64 // printed across the media, centered
+	DieCut    bool
+}
+
+// PPDName returns the file name of the PPD of m like "PT-P710BT.ppd"
+func PPDName(m ptouchgo.Model) string {
+	return m.String() + ".ppd"
+}
+
+// MakeAndModel is the make and model of the PPD and the discovered devices of m
+func MakeAndModel(m ptouchgo.Model) string {
+	return "Brother " + m.String() + " ptouchgo"
+}
+
+// DeviceID is the IEEE 1284 device ID matching the PPD of m
+func DeviceID(m ptouchgo.Model) string {
+	return "MFG:Brother;MDL:" + m.String() + ";"
+}
+
+// WritePPD writes the PPD of m. Jobs are converted into CUPS raster of 8 bit gray at the resolution
+// of the head by the filters of CUPS and sent to the ptouchgo backend as they are, each page is a label.
+func WritePPD(w io.Writer, m ptouchgo.Model) error {
+	if !m.Supported() {
+		return fmt.Errorf("cups: %s is not supported", m)
+	}
+	sizes := PageSizes(m)
+	head := m.Head()
+	bw := bufio.NewWriter(w)
+	p := func(format string, args ...interface{}) {
+		fmt.Fprintf(bw, format+"\n", args...)
+	}
+
+	p(`*PPD-Adobe: "4.3"`)
+	p(`*FormatVersion: "4.3"`)
+	p(`*FileVersion: "1.0"`)
+	p(`*LanguageVersion: English`)
+	p(`*LanguageEncoding: ISOLatin1`)
+	p(`*PCFileName: "%s"`, strings.ToUpper(strings.Replace(m.String(), "-", "", -1))+".PPD")
+	p(`*Manufacturer: "Brother"`)
+	p(`*Product: "(%s)"`, m)
+	p(`*ModelName: "%s"`, MakeAndModel(m))
+	p(`*ShortNickName: "%s"`, MakeAndModel(m))
+	p(`*NickName: "%s"`, MakeAndModel(m))
+	p(`*1284DeviceID: "%s"`, DeviceID(m))
+	p(`*PSVersion: "(3010.000) 0"`)
+	p(`*LanguageLevel: "3"`)
+	p(`*ColorDevice: False`)
+	p(`*DefaultColorSpace: Gray`)
+	p(`*FileSystem: False`)
+	p(`*Throughput: "1"`)
+	p(`*LandscapeOrientation: Plus90`)
+	p(`*TTRasterizer: Type42`)
+	p(`*cupsVersion: 1.4`)
+	p(`*cupsModelNumber: %d`, int(m))
+	p(`*cupsManualCopies: False`)
+	p(`*cupsFilter: "application/vnd.cups-raster 0 -"`)
+	p(``)
+
+	// the widest tape or media of 50mm labels
+	def := sizes[0].Name
+	for _, s := range sizes {
+		if s.LengthMM == 50 {
+			def = s.Name
+		}
+	}
+	for _, kw := range []string{"PageSize", "PageRegion"} {
+		p(`*OpenUI *%s/Media Size: PickOne`, kw)
+		p(`*OrderDependency: 10 AnySetup *%s`, kw)
+		p(`*Default%s: %s`, kw, def)
+		for _, s := range sizes {
+			p(`*%s %s/%s: "<</PageSize[%.2f %.2f]/ImagingBBox null>>setpagedevice"`, kw, s.Name, s.Text, points(s.WidthMM), points(s.LengthMM))
+		}
+		p(`*CloseUI: *%s`, kw)
+	}
+	p(`*DefaultImageableArea: %s`, def)
+	for _, s := range sizes {
+		margin := points(s.WidthMM-s.PrintedMM) / 2
+		p(`*ImageableArea %s/%s: "%.2f 0 %.2f %.2f"`, s.Name, s.Text, margin, points(s.WidthMM)-margin, points(s.LengthMM))
+	}
+	p(`*DefaultPaperDimension: %s`, def)
+	for _, s := range sizes {
+		p(`*PaperDimension %s/%s: "%.2f %.2f"`, s.Name, s.Text, points(s.WidthMM), points(s.LengthMM))
+	}
+	maxWidth := 0.0
+	for _, s := range sizes {
+		if w := points(s.WidthMM); w > maxWidth {
+			maxWidth = w
+		}
+	}
+	p(`*VariablePaperSize: True`)
+	p(`*MaxMediaWidth: "%.2f"`, maxWidth)
+	p(`*MaxMediaHeight: "%.2f"`, points(maxLabelLength))
+	p(`*HWMargins: 0 0 0 0`)
+	p(`*CustomPageSize True: "pop pop pop <</PageSize[5 -2 roll]/ImagingBBox null>>setpagedevice"`)
+	p(`*ParamCustomPageSize Width: 1 points %.2f %.2f`, points(3), maxWidth)
+	p(`*ParamCustomPageSize Height: 2 points %.2f %.2f`, points(5), points(maxLabelLength))
+	p(`*ParamCustomPageSize WidthOffset: 3 points 0 0`)
+	p(`*ParamCustomPageSize HeightOffset: 4 points 0 0`)
+	p(`*ParamCustomPageSize Orientation: 5 int 0 0`)
+	p(``)
+
+	p(`*OpenUI *Resolution/Resolution: PickOne`)
+	p(`*OrderDependency: 20 AnySetup *Resolution`)
+	p(`*DefaultResolution: %ddpi`, head.DPI)
+	res := func(name, text string, along int) {
+		p(`*Resolution %s/%s: "<</HWResolution[%d %d]/cupsBitsPerColor 8/cupsColorOrder 0/cupsColorSpace %d>>setpagedevice"`,
+			name, text, head.DPI, along, ColorSpaceW)
+	}
+	res(fmt.Sprintf("%ddpi", head.DPI), fmt.Sprintf("%d dpi", head.DPI), head.DPI)
+	if m.HighDPI() {
+		res(fmt.Sprintf("%dx%ddpi", head.DPI, 2*head.DPI), fmt.Sprintf("%dx%d dpi, labels come out half as long", head.DPI, 2*head.DPI), 2*head.DPI)
+	}
+	p(`*CloseUI: *Resolution`)
+	p(``)
+
+	p(`*OpenUI *CutMedia/Cut: PickOne`)
+	p(`*OrderDependency: 30 AnySetup *CutMedia`)
+	p(`*DefaultCutMedia: Label`)
+	p(`*CutMedia Label/After every label: "<</CutMedia %d>>setpagedevice"`, CutAfterPage)
+	if m.CutEvery() {
+		p(`*CutMedia Job/After the last label: "<</CutMedia %d>>setpagedevice"`, CutAfterJob)
+	}
+	p(`*CutMedia Never/Do not cut: "<</CutMedia %d>>setpagedevice"`, CutNever)
+	p(`*CloseUI: *CutMedia`)
+	p(``)
+
+	p(`*OpenUI *MirrorPrint/Mirror: Boolean`)
+	p(`*OrderDependency: 40 AnySetup *MirrorPrint`)
+	p(`*DefaultMirrorPrint: False`)
+	p(`*MirrorPrint True/Mirrored: "<</MirrorPrint true>>setpagedevice"`)
+	p(`*MirrorPrint False/Not mirrored: "<</MirrorPrint false>>setpagedevice"`)
+	p(`*CloseUI: *MirrorPrint`)
+	p(``)
+
+	p(`*DefaultFont: Courier`)
+	p(`*Font Courier: Standard "(002.004S)" Standard ROM`)
+	p(`*% End of %s`, PPDName(m))
+	return bw.Flush()
+}
+
+// PageSizes returns the page sizes of the tapes or media of m, narrowest first. Continuous length tapes
+// and media have labelLengths, die-cut QL labels their length.
+func PageSizes(m ptouchgo.Model) []PageSize {
+	head := m.Head()
+	var sizes []PageSize
+	continuous := func(prefix string, widthMM float64, dots int) {
+		for _, l := range labelLengths {
+			sizes = append(sizes, PageSize{
+				Name:      fmt.Sprintf("%s%d", prefix, l),
+				Text:      fmt.Sprintf("%s x %dmm", prefix, l),
+				WidthMM:   widthMM,
+				LengthMM:  float64(l),
+				PrintedMM: float64(dots) * 25.4 / float64(head.DPI),
+			})
+		}
+	}
+	if !m.QL() {
+		for _, tw := range m.TapeWidths() {
+			mm := float64(tw)
+			prefix := fmt.Sprintf("%dmm", tw)
+			if tw == 4 {
+				mm, prefix = 3.5, "3.5mm"
+			}
+			continuous(prefix, mm, head.PrintableDots(tw))
+		}
+		return sizes
+	}
+	for _, media := range ptouchgo.QLMediaList() {
+		if media.TwoColor || media.TD != m.TD() || media.Offset+media.Dots > head.Pins {
+			continue
+		}
+		if !media.DieCut() {
+			continuous(fmt.Sprintf("%dmm", media.WidthMM), float64(media.WidthMM), media.Dots)
+			continue
+		}
+		sizes = append(sizes, PageSize{
+			Name:      media.Name,
+			Text:      fmt.Sprintf("%dmm x %dmm die-cut labels", media.WidthMM, media.LengthMM),
+			WidthMM:   float64(media.WidthMM),
+			LengthMM:  float64(media.LengthMM),
+			PrintedMM: float64(media.Dots) * 25.4 / float64(head.DPI),
+			DieCut:    true,
+		})
+	}
+	return sizes
+}
+
+// points converts mm into PostScript points
+func points(mm float64) float64 {
+	return mm * 72 / 25.4
+}
@@ -0,0 +1,300 @@
+package cups
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/ka2n/ptouchgo"
+	"github.com/ka2n/ptouchgo/models"
+)
+
+// sync words of raster streams, the byte order of the headers follows the one of the word
+const (
+	syncV1 = "RaSt" // uncompressed
+	syncV2 = "RaS2" // compressed, the form of PWG raster
+	syncV3 = "RaS3" // uncompressed
+)
+
+// headerSize is the size of the page header of cups_page_header2_t and of PWG raster
+const headerSize = 1796
+
+// maxResolution is the highest dpi of pages, the high resolution of the PT-P900 series is 720dpi along the tape
+const maxResolution = 720
+
+// widestPage is the widest page of the models in mm, it limits the pages of a RasterReader without a Model
+var widestPage = func() float64 {
+	widest := 0.0
+	for _, spec := range models.All() {
+		widest = math.Max(widest, pageWidth(spec))
+	}
+	return widest
+}()
+
+// pageWidth returns the widest page printed by the model of spec in mm, the one of its widest media or of its head
+func pageWidth(spec models.Spec) float64 {
+	return math.Max(float64(spec.MaxTapeWidth()), float64(spec.HeadPins)/float64(spec.DPI)*25.4)
+}
+
+// color spaces of cupsColorSpace
+const (
+	ColorSpaceW    = 0  // luminance
+	ColorSpaceRGB  = 1  // red, green, blue
+	ColorSpaceK    = 3  // black
+	ColorSpaceSW   = 18 // sGray luminance, the gray of PWG raster
+	ColorSpaceSRGB = 19 // sRGB
+)
+
+// PageHeader is the page header of a raster page, the fields ptouchgo reads
+type PageHeader struct {
+	MediaType    string
+	CutMedia     int    // 0 never, 1 after the document, 2 after the job, 3 after the set, 4 after every page
+	HWResolution [2]int // dpi across and along the media
+	MirrorPrint  bool
+	NumCopies    int
+	PageSize     [2]int // points
+
+	Width        int // pixels of a line
+	Height       int // lines
+	BitsPerColor int
+	BitsPerPixel int
+	BytesPerLine int
+	ColorOrder   int // 0 chunky
+	ColorSpace   int
+
+	PageSizeName string // like "24mm50", empty when the writer does not set it
+}
+
+// cut media values of PageHeader
+const (
+	CutNever     = 0
+	CutAfterJob  = 2
+	CutAfterPage = 4
+)
+
+// RasterReader reads the pages of a CUPS raster stream of application/vnd.cups-raster or image/pwg-raster
+type RasterReader struct {
+	// Model is the printer of the pages, pages wider than its widest media or print head at their resolution
+	// or longer than the longest label are rejected before they are read. Zero allows the widest of the models
+	Model ptouchgo.Model
+
+	r          *bufio.Reader
+	order      binary.ByteOrder
+	compressed bool
+}
+
+// NewRasterReader reads the sync word of the stream of r
+func NewRasterReader(r io.Reader) (*RasterReader, error) {
+	br := bufio.NewReader(r)
+	var sync [4]byte
+	if _, err := io.ReadFull(br, sync[:]); err != nil {
+		return nil, fmt.Errorf("raster: %w", err)
+	}
+	rr := &RasterReader{r: br, order: binary.BigEndian}
+	word := string(sync[:])
+	if reversed := string([]byte{sync[3], sync[2], sync[1], sync[0]}); reversed == syncV1 || reversed == syncV2 || reversed == syncV3 {
+		word, rr.order = reversed, binary.LittleEndian
+	}
+	switch word {
+	case syncV1, syncV3:
+	case syncV2:
+		rr.compressed = true
+	default:
+		return nil, fmt.Errorf("raster: not a CUPS or PWG raster stream, starts with %q", sync[:])
+	}
+	return rr, nil
+}
+
+// NextPage reads the next page as a gray image, io.EOF is returned after the last page
+func (rr *RasterReader) NextPage() (*PageHeader, *image.Gray, error) {
+	b := make([]byte, headerSize)
+	if _, err := io.ReadFull(rr.r, b); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return nil, nil, errors.New("raster: short page header")
+		}
+		return nil, nil, err
+	}
+	h := rr.header(b)
+	if err := h.check(); err != nil {
+		return nil, nil, err
+	}
+	widthMM := widestPage
+	if spec, ok := models.Lookup(byte(rr.Model)); ok {
+		widthMM = pageWidth(spec)
+	}
+	if err := h.checkSize(widthMM); err != nil {
+		return nil, nil, err
+	}
+	img := image.NewGray(image.Rect(0, 0, h.Width, h.Height))
+	line := make([]byte, h.BytesPerLine)
+	for y := 0; y < h.Height; {
+		repeat := 1
+		var err error
+		if rr.compressed {
+			repeat, err = rr.readCompressedLine(h, line)
+		} else {
+			_, err = io.ReadFull(rr.r, line)
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("raster: line %d: %w", y, err)
+		}
+		for ; repeat > 0 && y < h.Height; repeat-- {
+			h.grayLine(line, img.Pix[y*img.Stride:y*img.Stride+h.Width])
+			y++
+		}
+	}
+	return h, img, nil
+}
+
+// header decodes the fields of a page header
+func (rr *RasterReader) header(b []byte) *PageHeader {
+	u := func(off int) int { return int(rr.order.Uint32(b[off : off+4])) }
+	str := func(off int) string {
+		s := b[off : off+64]
+		if i := strings.IndexByte(string(s), 0); i >= 0 {
+			s = s[:i]
+		}
+		return string(s)
+	}
+	return &PageHeader{
+		MediaType:    str(128),
+		CutMedia:     u(268),
+		HWResolution: [2]int{u(276), u(280)},
+		MirrorPrint:  u(332) != 0,
+		NumCopies:    u(340),
+		PageSize:     [2]int{u(352), u(356)},
+		Width:        u(372),
+		Height:       u(376),
+		BitsPerColor: u(384),
+		BitsPerPixel: u(388),
+		BytesPerLine: u(392),
+		ColorOrder:   u(396),
+		ColorSpace:   u(400),
+		PageSizeName: str(1732),
+	}
+}
+
+// check reports headers of pages which can not be read
+func (h *PageHeader) check() error {
+	switch {
+	case h.Width <= 0 || h.Height <= 0 || h.Width > 1<<15 || h.Height > 1<<20:
+		return fmt.Errorf("raster: invalid page size %dx%d", h.Width, h.Height)
+	case h.ColorOrder != 0:
+		return fmt.Errorf("raster: color order %d is not supported, use chunky pixels", h.ColorOrder)
+	case h.BytesPerLine != (h.Width*h.BitsPerPixel+7)/8:
+		return fmt.Errorf("raster: %d bytes per line do not match %d pixels of %d bits", h.BytesPerLine, h.Width, h.BitsPerPixel)
+	}
+	switch h.ColorSpace {
+	case ColorSpaceW, ColorSpaceSW, ColorSpaceK:
+		if h.BitsPerPixel == 1 || h.BitsPerPixel == 8 {
+			return nil
+		}
+	case ColorSpaceRGB, ColorSpaceSRGB:
+		if h.BitsPerPixel == 24 {
+			return nil
+		}
+	}
+	return fmt.Errorf("raster: color space %d of %d bits per pixel is not supported, use gray, black or RGB", h.ColorSpace, h.BitsPerPixel)
+}
+
+// checkSize reports pages which are wider than widthMM or longer than the longest label at their resolution,
+// their image is not allocated
+func (h *PageHeader) checkSize(widthMM float64) error {
+	across, along := h.HWResolution[0], h.HWResolution[1]
+	if across <= 0 {
+		across = maxResolution
+	}
+	if along <= 0 {
+		along = across
+	}
+	if across > maxResolution || along > maxResolution {
+		return fmt.Errorf("raster: resolution %dx%ddpi is not supported, the highest is %ddpi", across, along, maxResolution)
+	}
+	if width := int(math.Ceil(widthMM / 25.4 * float64(across))); h.Width > width {
+		return fmt.Errorf("raster: page width %dpx at %ddpi is wider than %.0fmm the printer prints", h.Width, across, widthMM)
+	}
+	if length := int(math.Ceil(maxLabelLength / 25.4 * float64(along))); h.Height > length {
+		return fmt.Errorf("raster: page length %d lines at %ddpi is longer than %dmm", h.Height, along, maxLabelLength)
+	}
+	return nil
+}
+
+// white returns the byte of white pixels
+func (h *PageHeader) white() byte {
+	if h.ColorSpace == ColorSpaceK {
+		return 0x00
+	}
+	return 0xff
+}
+
+// readCompressedLine reads a line of a compressed stream into line and returns how many times it repeats.
+// A line starts with its repeat count minus one, then each run starts with a byte n: 0-127 repeats the next
+// pixel n+1 times, 129-255 copies 257-n pixels and 128 fills the rest of the line with white.
+func (rr *RasterReader) readCompressedLine(h *PageHeader, line []byte) (int, error) {
+	repeat, err := rr.r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	bpp := (h.BitsPerPixel + 7) / 8
+	for i := 0; i < len(line); {
+		n, err := rr.r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		switch {
+		case n == 128:
+			for ; i < len(line); i++ {
+				line[i] = h.white()
+			}
+		case n > 128:
+			count := (257 - int(n)) * bpp
+			if i+count > len(line) {
+				return 0, errors.New("run beyond the line")
+			}
+			if _, err := io.ReadFull(rr.r, line[i:i+count]); err != nil {
+				return 0, err
+			}
+			i += count
+		default:
+			count := (int(n) + 1) * bpp
+			if i+count > len(line) {
+				return 0, errors.New("run beyond the line")
+			}
+			if _, err := io.ReadFull(rr.r, line[i:i+bpp]); err != nil {
+				return 0, err
+			}
+			for j := i + bpp; j < i+count; j++ {
+				line[j] = line[j-bpp]
+			}
+			i += count
+		}
+	}
+	return int(repeat) + 1, nil
+}
+
+// grayLine converts a line of pixels into the gray values of out
+func (h *PageHeader) grayLine(line, out []byte) {
+	for x := range out {
+		var v byte
+		switch h.BitsPerPixel {
+		case 1:
+			bit := line[x/8] >> (7 - uint(x%8)) & 1
+			v = 0xff * bit
+		case 8:
+			v = line[x]
+		case 24:
+			p := line[x*3 : x*3+3]
+			v = color.GrayModel.Convert(color.RGBA{p[0], p[1], p[2], 0xff}).(color.Gray).Y
+		}
+		if h.ColorSpace == ColorSpaceK {
+			v = ^v // amount of black
+		}
+		out[x] = v
+	}
+}
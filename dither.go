@@ -0,0 +1,132 @@
+package ptouchgo
+
+import (
+	"fmt"
+	"image"
+)
+
+// Dither selects how gray pixels are printed with the black and white dots of the print head
+type Dither int
+
+const (
+	// DitherNone prints pixels up to the threshold lightness, best for text and logos
+	DitherNone Dither = iota
+	// DitherFloyd diffuses the error of each dot onto its neighbours, Floyd-Steinberg, best for photos
+	DitherFloyd
+	// DitherBayer prints gray as an even pattern of dots
+	DitherBayer
+	// DitherHalftone prints gray as dots growing with darkness, like printed newspapers
+	DitherHalftone
+)
+
+func (d Dither) String() string {
+	switch d {
+	case DitherNone:
+		return "none"
+	case DitherFloyd:
+		return "floyd"
+	case DitherBayer:
+		return "bayer"
+	case DitherHalftone:
+		return "halftone"
+	}
+	return fmt.Sprintf("Dither(%d)", int(d))
+}
+
+// ParseDither parses "none", "floyd", "bayer" or "halftone"
+func ParseDither(s string) (Dither, error) {
+	for d := DitherNone; d <= DitherHalftone; d++ {
+		if d.String() == s {
+			return d, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown dither %q, use none, floyd, bayer or halftone", s)
+}
+
+// bayerMatrix is the 8x8 ordered dither threshold map
+var bayerMatrix = [8][8]int{
+	{0, 32, 8, 40, 2, 34, 10, 42},
+	{48, 16, 56, 24, 50, 18, 58, 26},
+	{12, 44, 4, 36, 14, 46, 6, 38},
+	{60, 28, 52, 20, 62, 30, 54, 22},
+	{3, 35, 11, 43, 1, 33, 9, 41},
+	{51, 19, 59, 27, 49, 17, 57, 25},
+	{15, 47, 7, 39, 13, 45, 5, 37},
+	{63, 31, 55, 23, 61, 29, 53, 21},
+}
+
+// halftoneMatrix is a clustered dot threshold map, dots grow from the center of each cell
+var halftoneMatrix = [4][4]int{
+	{12, 5, 6, 13},
+	{4, 0, 1, 7},
+	{11, 3, 2, 8},
+	{15, 10, 9, 14},
+}
+
+// rasterize packs the pixels of canvas printed by dither into raster lines, one for each row
+func rasterize(canvas image.Image, dither Dither, threshold float64) ([]byte, int, error) {
+	size := canvas.Bounds().Size()
+	min := canvas.Bounds().Min
+	bytesWidth := size.X / 8
+	if size.X%8 != 0 {
+		bytesWidth++
+	}
+
+	// dithers print the lightness shifted by the threshold as gray
+	shift := 0.0
+	if dither != DitherNone {
+		shift = 0.5 - threshold
+	}
+	light := make([]float64, size.X*size.Y)
+	for y := 0; y < size.Y; y++ {
+		for x := 0; x < size.X; x++ {
+			r, g, b, _ := canvas.At(min.X+x, min.Y+y).RGBA()
+			light[y*size.X+x] = float64(55*r+182*g+18*b)/float64(0xffff*(55+182+18)) + shift
+		}
+	}
+
+	var dot func(x, y int) bool
+	switch dither {
+	case DitherNone:
+		dot = func(x, y int) bool { return light[y*size.X+x] <= threshold }
+	case DitherFloyd:
+		dot = func(x, y int) bool {
+			v := light[y*size.X+x]
+			printed := v <= 0.5
+			diffuse := v
+			if !printed {
+				diffuse = v - 1
+			}
+			spread := func(dx, dy int, weight float64) {
+				if x+dx >= 0 && x+dx < size.X && y+dy < size.Y {
+					light[(y+dy)*size.X+x+dx] += diffuse * weight / 16
+				}
+			}
+			spread(1, 0, 7)
+			spread(-1, 1, 3)
+			spread(0, 1, 5)
+			spread(1, 1, 1)
+			return printed
+		}
+	case DitherBayer:
+		dot = func(x, y int) bool {
+			return light[y*size.X+x] < (float64(bayerMatrix[y%8][x%8])+0.5)/64
+		}
+	case DitherHalftone:
+		dot = func(x, y int) bool {
+			return 1-light[y*size.X+x] > (float64(halftoneMatrix[y%4][x%4])+0.5)/16
+		}
+	default:
+		return nil, 0, fmt.Errorf("unknown dither %d", int(dither))
+	}
+
+	data := make([]byte, bytesWidth*size.Y)
+	for y := 0; y < size.Y; y++ {
+		for x := 0; x < size.X; x++ {
+			if dot(x, y) {
+				data[y*bytesWidth+x/8] |= 0x80 >> uint(x%8)
+			}
+		}
+	}
+	return data, bytesWidth, nil
+}
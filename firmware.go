@@ -0,0 +1,117 @@
+package ptouchgo
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+)
+
+// cmdReadFirmware requests the firmware version, answered by its length and the version in ASCII like "1.04"
+var cmdReadFirmware = []byte{0x1b, 0x69, 0x56}
+
+// maxFirmwareSize is the longest version read, longer answers are not a version
+const maxFirmwareSize = 16
+
+// ReadFirmware reads the firmware version of the printer, printing on the connection
+// checks the features of the model needing newer firmware against it afterwards
+func (s Serial) ReadFirmware() (string, error) {
+	if s.Debug {
+		log.Println("ReadFirmware", hex.EncodeToString(cmdReadFirmware))
+	}
+	if _, err := s.Conn.Write(cmdReadFirmware); err != nil {
+		return "", err
+	}
+	size := make([]byte, 1)
+	if _, err := io.ReadFull(s.Conn, size); err != nil {
+		return "", fmt.Errorf("read firmware: %w", err)
+	}
+	if size[0] == 0 || size[0] > maxFirmwareSize {
+		return "", fmt.Errorf("read firmware: invalid version length %d", size[0])
+	}
+	version := make([]byte, size[0])
+	if _, err := io.ReadFull(s.Conn, version); err != nil {
+		return "", fmt.Errorf("read firmware: %w", err)
+	}
+	if _, ok := parseVersion(string(version)); !ok {
+		return "", fmt.Errorf("read firmware: invalid version %q", version)
+	}
+	if d := s.detected; d != nil {
+		d.mu.Lock()
+		d.firmware = string(version)
+		d.mu.Unlock()
+	}
+	return string(version), nil
+}
+
+// Firmware returns the version read with ReadFirmware on the connection, empty before
+func (s Serial) Firmware() string {
+	d := s.detected
+	if d == nil {
+		return ""
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.firmware
+}
+
+// MinFirmware returns the oldest firmware of m with feature, empty when every version has it
+func (m Model) MinFirmware(feature string) string {
+	spec, _ := m.spec()
+	for _, f := range spec.MinFirmware {
+		if f.Feature == feature {
+			return f.Version
+		}
+	}
+	return ""
+}
+
+// FirmwareGated reports whether m has features needing newer firmware, see ReadFirmware
+func (m Model) FirmwareGated() bool {
+	spec, _ := m.spec()
+	return len(spec.MinFirmware) > 0
+}
+
+// checkFirmware returns an UnsupportedError if the firmware read from m is older than feature needs
+func (s Serial) checkFirmware(m Model, feature string) error {
+	min := m.MinFirmware(feature)
+	fw := s.Firmware()
+	if min == "" || fw == "" || !versionBefore(fw, min) {
+		return nil
+	}
+	return &UnsupportedError{Model: m, Feature: feature, Firmware: fw, MinFirmware: min}
+}
+
+// versionBefore reports whether the dotted version a is older than b
+func versionBefore(a, b string) bool {
+	va, _ := parseVersion(a)
+	vb, _ := parseVersion(b)
+	for i := 0; i < len(va) || i < len(vb); i++ {
+		var x, y int
+		if i < len(va) {
+			x = va[i]
+		}
+		if i < len(vb) {
+			y = vb[i]
+		}
+		if x != y {
+			return x < y
+		}
+	}
+	return false
+}
+
+// parseVersion splits a version like "1.04" into its numbers
+func parseVersion(v string) ([]int, bool) {
+	var nums []int
+	for _, part := range strings.Split(v, ".") {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return nil, false
+		}
+		nums = append(nums, n)
+	}
+	return nums, true
+}
@@ -0,0 +1,100 @@
+package ptouchgo
+
+import (
+	"fmt"
+	"image"
+
+	"github.com/disintegration/imaging"
+	"github.com/ka2n/ptouchgo/models"
+)
+
+// Head is the print head of a model
+type Head struct {
+	Pins int // dots of a raster line
+	DPI  int // across the tape, HighDPI doubles the raster lines along it
+
+	tapes []models.Tape
+}
+
+// ptDefault is the model of unknown PT printers
+var ptDefault, _ = models.Lookup(byte(modelPTP700))
+
+// head180 is the head of the PT-P700 series, HeadPins at DPI
+var head180 = headOf(ptDefault)
+
+// headOf returns the head of spec
+func headOf(spec models.Spec) Head {
+	return Head{Pins: spec.HeadPins, DPI: spec.DPI, tapes: spec.Tapes}
+}
+
+// Head returns the print head of m, unknown models have the head of the PT-P700 series
+func (m Model) Head() Head {
+	if spec, ok := m.spec(); ok {
+		return headOf(spec)
+	}
+	return head180
+}
+
+// spec returns the capabilities of the printer of s, QL printers of unknown models
+// take every setting and unknown PT models are a PT-P700
+func (s Serial) spec() models.Spec {
+	if s.ql() {
+		return s.qlSpec()
+	}
+	if spec, ok := s.model().spec(); ok && spec.Family == models.PT {
+		return spec
+	}
+	return ptDefault
+}
+
+// Head returns the print head of the printer of s, the QL head for QL media,
+// with the printable dots of heat shrink tubes on HeatShrink
+func (s Serial) Head() Head {
+	spec := s.spec()
+	h := headOf(spec)
+	if s.HeatShrink && !s.ql() {
+		h.tapes = spec.Tubes
+	}
+	return h
+}
+
+// PrintableDots returns the number of printable dots across tw,
+// tapes the model does not list are scaled from the PT-P700 series head
+func (h Head) PrintableDots(tw TapeWidth) int {
+	for _, t := range h.tapes {
+		if t.WidthMM == int(tw) {
+			return t.Dots
+		}
+	}
+	return tw.PrintableDots() * h.DPI / DPI
+}
+
+// Margin returns the number of unused pins on each side of the printable area of tw
+func (h Head) Margin(tw TapeWidth) int {
+	return (h.Pins - h.PrintableDots(tw)) / 2
+}
+
+// ConvertImage is ConvertImage for the head, images span all of its pins
+func (h Head) ConvertImage(p image.Image, tapeWidth TapeWidth, opts ConvertOptions) ([]byte, int, error) {
+	canvas, err := orient(p, h.Pins, fmt.Sprintf("%d tape", tapeWidth), opts.Rotate)
+	if err != nil {
+		return nil, 0, err
+	}
+	threshold, err := opts.threshold()
+	if err != nil {
+		return nil, 0, err
+	}
+	return rasterize(canvas, opts.Dither, threshold)
+}
+
+// Fit scales an image laid out for the PT-P700 series head, HeadPins high and at DPI,
+// to the head keeping the tape centered. Images for that head are returned as they are.
+func (h Head) Fit(p image.Image) image.Image {
+	if h.Pins == HeadPins && h.DPI == DPI {
+		return p
+	}
+	size := p.Bounds().Size()
+	scaled := imaging.Resize(p, size.X*h.DPI/DPI, size.Y*h.DPI/DPI, imaging.NearestNeighbor)
+	canvas := imaging.New(scaled.Bounds().Dx(), h.Pins, image.White)
+	return imaging.Paste(canvas, scaled, image.Pt(0, (h.Pins-scaled.Bounds().Dy())/2))
+}
@@ -0,0 +1,270 @@
+package mqtt
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// pipe returns a client and the broker end of a connection, the broker reads and writes packets like the client
+func pipe(t *testing.T) (*Client, *Client) {
+	t.Helper()
+	c, b := net.Pipe()
+	t.Cleanup(func() {
+		c.Close()
+		b.Close()
+	})
+	c.SetDeadline(time.Now().Add(5 * time.Second))
+	b.SetDeadline(time.Now().Add(5 * time.Second))
+	return &Client{conn: c, r: bufio.NewReader(c), keepAlive: time.Minute},
+		&Client{conn: b, r: bufio.NewReader(b)}
+}
+
+// serveBroker runs broker in the background, its error is returned by the function returned
+func serveBroker(broker *Client, f func(broker *Client) error) func() error {
+	errc := make(chan error, 1)
+	go func() { errc <- f(broker) }()
+	return func() error { return <-errc }
+}
+
+func TestClientConnect(t *testing.T) {
+	tests := []struct {
+		name   string
+		header byte
+		reply  []byte
+		want   string
+	}{
+		{"accepted", packetConnack << 4, []byte{0, 0}, ""},
+		{"bad password", packetConnack << 4, []byte{0, 4}, "refused the user name or password (4)"},
+		{"not authorized", packetConnack << 4, []byte{0, 5}, "refused the user name or password (5)"},
+		{"server unavailable", packetConnack << 4, []byte{0, 3}, "refused the connection (3)"},
+		{"other packet", packetSuback << 4, []byte{0, 0}, "expected CONNACK, got packet type 9"},
+		{"long CONNACK", packetConnack << 4, []byte{0, 0, 0}, "expected CONNACK"},
+	}
+	for _, tt := range tests {
+		c, broker := pipe(t)
+		var connect []byte
+		var header byte
+		done := serveBroker(broker, func(broker *Client) error {
+			var err error
+			if header, connect, err = broker.read(); err != nil {
+				return err
+			}
+			return broker.write(tt.header, tt.reply)
+		})
+		opts := ClientOptions{ClientID: "label", KeepAlive: 30 * time.Second, Will: &Will{Topic: "t/state", Payload: []byte("offline"), Retain: true}}
+		err := c.connect(opts, "user", "secret", true)
+		if berr := done(); berr != nil {
+			t.Fatalf("%s: broker: %v", tt.name, berr)
+		}
+		if tt.want == "" && err != nil || tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)) {
+			t.Errorf("%s: connect = %v, want %q", tt.name, err, tt.want)
+		}
+
+		want := appendString(nil, "MQTT")
+		want = append(want, 4, 0x02|0x04|0x20|0x80|0x40, 0, 30)
+		for _, s := range []string{"label", "t/state", "offline", "user", "secret"} {
+			want = appendString(want, s)
+		}
+		if header != packetConnect<<4 || !bytes.Equal(connect, want) {
+			t.Errorf("%s: CONNECT = %#x %q, want %q", tt.name, header, connect, want)
+		}
+	}
+}
+
+func TestClientConnectWithoutPassword(t *testing.T) {
+	c, broker := pipe(t)
+	var connect []byte
+	done := serveBroker(broker, func(broker *Client) error {
+		var err error
+		if _, connect, err = broker.read(); err != nil {
+			return err
+		}
+		return broker.write(packetConnack<<4, []byte{0, 0})
+	})
+	if err := c.connect(ClientOptions{ClientID: "x", KeepAlive: time.Minute}, "", "", false); err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	if err := done(); err != nil {
+		t.Fatalf("broker: %v", err)
+	}
+	// clean session only, a keep alive of 60s
+	if len(connect) < 10 || connect[7] != 0x02 || binary.BigEndian.Uint16(connect[8:]) != 60 {
+		t.Errorf("CONNECT = %x", connect)
+	}
+}
+
+func TestClientSubscribe(t *testing.T) {
+	tests := []struct {
+		name  string
+		id    uint16 // of the SUBACK, zero answers with the packet id of SUBSCRIBE
+		codes []byte
+		want  string
+	}{
+		{"granted", 0, []byte{1, 0}, ""},
+		{"refused", 0, []byte{1, 0x80}, "refused the subscription to labels/b"},
+		{"wrong packet id", 99, []byte{1, 1}, "unexpected SUBACK"},
+		{"missing return code", 0, []byte{1}, "unexpected SUBACK"},
+	}
+	for _, tt := range tests {
+		c, broker := pipe(t)
+		var header byte
+		var subscribe []byte
+		done := serveBroker(broker, func(broker *Client) error {
+			var err error
+			if header, subscribe, err = broker.read(); err != nil {
+				return err
+			}
+			// packets before SUBACK are skipped
+			if err := broker.write(packetPingresp<<4, nil); err != nil {
+				return err
+			}
+			id := tt.id
+			if id == 0 {
+				id = binary.BigEndian.Uint16(subscribe)
+			}
+			return broker.write(packetSuback<<4, append(appendUint16(nil, id), tt.codes...))
+		})
+		err := c.Subscribe("labels/a", "labels/b")
+		if berr := done(); berr != nil {
+			t.Fatalf("%s: broker: %v", tt.name, berr)
+		}
+		if tt.want == "" && err != nil || tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)) {
+			t.Errorf("%s: Subscribe = %v, want %q", tt.name, err, tt.want)
+		}
+
+		want := append(appendString(appendUint16(nil, 1), "labels/a"), 1)
+		want = append(appendString(want, "labels/b"), 1)
+		if header != packetSubscribe<<4|0x02 || !bytes.Equal(subscribe, want) {
+			t.Errorf("%s: SUBSCRIBE = %#x %x, want %x", tt.name, header, subscribe, want)
+		}
+	}
+}
+
+func TestClientServeAcknowledgesQoS1(t *testing.T) {
+	c, broker := pipe(t)
+	var pubackHeader byte
+	var puback []byte
+	done := serveBroker(broker, func(broker *Client) error {
+		// QoS 1 and retained, then QoS 0
+		body := append(appendUint16(appendString(nil, "labels/print"), 7), `{"text":"a"}`...)
+		if err := broker.write(packetPublish<<4|0x02|0x01, body); err != nil {
+			return err
+		}
+		var err error
+		if pubackHeader, puback, err = broker.read(); err != nil {
+			return err
+		}
+		if err := broker.write(packetPublish<<4, append(appendString(nil, "labels/print"), `{"text":"b"}`...)); err != nil {
+			return err
+		}
+		return broker.conn.Close()
+	})
+
+	var got []Message
+	err := c.Serve(func(m Message) { got = append(got, m) })
+	if berr := done(); berr != nil {
+		t.Fatalf("broker: %v", berr)
+	}
+	if err == nil {
+		t.Errorf("Serve returned without an error after the broker closed")
+	}
+	if pubackHeader != packetPuback<<4 || !bytes.Equal(puback, []byte{0, 7}) {
+		t.Errorf("PUBACK = %#x %x, want one acknowledging packet 7", pubackHeader, puback)
+	}
+	if len(got) != 2 {
+		t.Fatalf("handled %d messages, want 2", len(got))
+	}
+	if got[0].Topic != "labels/print" || string(got[0].Payload) != `{"text":"a"}` || !got[0].Retain {
+		t.Errorf("QoS 1 message = %+v", got[0])
+	}
+	if string(got[1].Payload) != `{"text":"b"}` || got[1].Retain {
+		t.Errorf("QoS 0 message = %+v", got[1])
+	}
+}
+
+func TestClientServeRejectsUnexpectedPackets(t *testing.T) {
+	c, broker := pipe(t)
+	done := serveBroker(broker, func(broker *Client) error {
+		return broker.write(packetConnack<<4, []byte{0, 0})
+	})
+	if err := c.Serve(func(Message) {}); err == nil || !strings.Contains(err.Error(), "unexpected packet type 2") {
+		t.Errorf("Serve = %v, want an unexpected packet", err)
+	}
+	if err := done(); err != nil {
+		t.Fatalf("broker: %v", err)
+	}
+}
+
+func TestRemainingLength(t *testing.T) {
+	// the boundaries of one to four bytes of remaining length
+	for _, n := range []int{0, 127, 128, 16383, 16384, 2097152} {
+		c, broker := pipe(t)
+		body := bytes.Repeat([]byte{'x'}, n)
+		done := serveBroker(broker, func(broker *Client) error {
+			return broker.write(packetPublish<<4, body)
+		})
+		header, b, err := c.read()
+		if werr := done(); werr != nil {
+			t.Fatalf("write of %d bytes: %v", n, werr)
+		}
+		if err != nil || header != packetPublish<<4 || len(b) != n {
+			t.Errorf("read of %d bytes = %#x, %d bytes, %v", n, header, len(b), err)
+		}
+	}
+}
+
+func TestReadMalformed(t *testing.T) {
+	tests := []struct {
+		name string
+		in   []byte
+		want string
+	}{
+		{"five bytes of length", []byte{0x30, 0xff, 0xff, 0xff, 0xff, 0x01}, "malformed remaining length"},
+		{"no header", nil, "EOF"},
+		{"no length", []byte{0x30}, "EOF"},
+		{"length ends early", []byte{0x30, 0x80}, "EOF"},
+		{"body ends early", []byte{0x30, 0x05, 0, 1, 'a'}, "unexpected EOF"},
+	}
+	for _, tt := range tests {
+		c := &Client{r: bufio.NewReader(bytes.NewReader(tt.in))}
+		if _, _, err := c.read(); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: read = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestParsePublish(t *testing.T) {
+	tests := []struct {
+		name    string
+		flags   byte
+		in      []byte
+		topic   string
+		id      uint16
+		payload string
+		err     bool
+	}{
+		{"QoS 0", 0, append(appendString(nil, "a/b"), "hi"...), "a/b", 0, "hi", false},
+		{"QoS 1", 0x02, append(appendUint16(appendString(nil, "a"), 300), "hi"...), "a", 300, "hi", false},
+		{"empty payload", 0x02, appendUint16(appendString(nil, "a"), 1), "a", 1, "", false},
+		{"no topic length", 0, []byte{0}, "", 0, "", true},
+		{"topic beyond the packet", 0, []byte{0, 5, 'a'}, "", 0, "", true},
+		{"QoS 1 without packet id", 0x02, append(appendString(nil, "a"), 1), "", 0, "", true},
+	}
+	for _, tt := range tests {
+		m, id, err := parsePublish(tt.flags, tt.in)
+		if tt.err {
+			if err == nil || !strings.Contains(err.Error(), "short PUBLISH") {
+				t.Errorf("%s: parsePublish = %v, want a short PUBLISH", tt.name, err)
+			}
+			continue
+		}
+		if err != nil || m.Topic != tt.topic || id != tt.id || string(m.Payload) != tt.payload {
+			t.Errorf("%s: parsePublish = %+v %d %v, want %s %d %q", tt.name, m, id, err, tt.topic, tt.id, tt.payload)
+		}
+	}
+}
@@ -0,0 +1,355 @@
+// Package slack prints labels from the slash commands of Slack, and of chats with Slack compatible slash commands
+// like Mattermost, so a team can share a printer from its chat. A command like
+//
+//	/label SERVER-42
+//	/label qr https://example.com/wiki Wiki
+//	/label barcode ean:4901234567894 Stock
+//
+// prints a text label, or a QR code or barcode of the first word with the rest as its caption. The command is
+// answered at once to the user and the result of the job is posted to the channel, with the preview of the label
+// when the Handler has a PublicURL: chats load the images of messages from a URL.
+//
+// The slash command of the chat is configured with the URL the handler is served at. Slack requests are
+// verified with the signing secret of the app, the requests of other chats with their verification token.
+package slack
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"io/ioutil"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ka2n/ptouchgo/server"
+)
+
+const (
+	// DefaultResultTimeout is the time a job may take to finish before its state is posted
+	DefaultResultTimeout = 25 * time.Minute // chats accept responses for 30 minutes
+	// PreviewTTL is how long the previews are served
+	PreviewTTL = 24 * time.Hour
+	// maxPreviews is the number of previews served, the oldest are dropped
+	maxPreviews = 256
+	// maxSkew is the age of the timestamp of Slack requests which are accepted
+	maxSkew = 5 * time.Minute
+	maxBody = 64 << 10
+)
+
+// Handler is an http.Handler of slash commands printing on the printers of a spooler
+type Handler struct {
+	// Token verifies the requests of chats sending a verification token in the token field, like Mattermost
+	Token string
+	// Printer prints the labels, empty uses the default printer
+	Printer string
+	// Defaults are the settings of jobs
+	Defaults server.PrintRequest
+	// Preview renders the label of a print request on printer, see the Preview of server/http.
+	// Nil does not post previews
+	Preview func(printer string, tape int, req *server.PrintRequest) (image.Image, error)
+	// PublicURL is the URL the handler is served at for the chat, like "https://labels.example.com/slack",
+	// the previews are served under it. Empty does not post previews
+	PublicURL string
+	// ResultTimeout is the time a job may take before its state is posted, DefaultResultTimeout when zero
+	ResultTimeout time.Duration
+	// Client posts the responses, nil uses a client with a timeout of 10s
+	Client *http.Client
+
+	signingSecret string
+	spooler       *server.Spooler
+
+	mu       sync.Mutex
+	previews map[string]preview
+	order    []string // ids of previews, oldest first
+}
+
+// preview is a PNG served for a message
+type preview struct {
+	png     []byte
+	expires time.Time
+}
+
+// New returns a handler of slash commands verified with the signing secret of a Slack app, which may be empty
+// with a Token. A handler with neither accepts every request.
+func New(spooler *server.Spooler, signingSecret string) *Handler {
+	return &Handler{
+		Defaults:      server.PrintRequest{Copies: 1, CutEvery: 1},
+		signingSecret: signingSecret,
+		spooler:       spooler,
+		previews:      map[string]preview{},
+	}
+}
+
+// Message is a response to a slash command
+type Message struct {
+	ResponseType    string       `json:"response_type"` // "ephemeral" for the user or "in_channel"
+	ReplaceOriginal bool         `json:"replace_original"`
+	Text            string       `json:"text"`
+	Attachments     []Attachment `json:"attachments,omitempty"`
+}
+
+// Attachment is an attachment of a Message, the previews are attachments as Slack and Mattermost show them alike
+type Attachment struct {
+	Fallback string `json:"fallback"`
+	Color    string `json:"color,omitempty"`
+	ImageURL string `json:"image_url,omitempty"`
+}
+
+// ServeHTTP answers POST slash commands and serves the previews of GET previews/ID.png under the URL of the handler
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if i := strings.LastIndex(r.URL.Path, "/previews/"); i >= 0 && r.Method == http.MethodGet {
+		h.servePreview(w, strings.TrimSuffix(r.URL.Path[i+len("/previews/"):], ".png"))
+		return
+	}
+	if r.Method != http.MethodPost {
+		http.Error(w, "use POST", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxBody))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := h.verify(r.Header, body, time.Now()); err != nil {
+		log.Printf("slack: %s: %v\n", r.RemoteAddr, err)
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if h.Token != "" && !hmac.Equal([]byte(form.Get("token")), []byte(h.Token)) {
+		http.Error(w, "invalid token", http.StatusUnauthorized)
+		return
+	}
+	// user_name names the user in the messages, user_id is sent by every chat
+	user := form.Get("user_name")
+	if user == "" {
+		user = form.Get("user_id")
+	}
+	text := strings.TrimSpace(form.Get("text"))
+	if text == "" || text == "help" {
+		writeMessage(w, Message{ResponseType: "ephemeral", Text: usage(form.Get("command"))})
+		return
+	}
+	req, err := h.request(text)
+	if err != nil {
+		writeMessage(w, Message{ResponseType: "ephemeral", Text: fmt.Sprintf("%v\n%s", err, usage(form.Get("command")))})
+		return
+	}
+	req.Client = "slack:" + user
+	responseURL := form.Get("response_url")
+	if u, err := url.Parse(responseURL); err != nil || (u.Scheme != "https" && u.Scheme != "http") {
+		http.Error(w, "response_url required", http.StatusBadRequest)
+		return
+	}
+	// commands are answered within 3 seconds, the job is posted when it finishes
+	go h.print(user, req, responseURL)
+	writeMessage(w, Message{ResponseType: "ephemeral", Text: "Printing " + describe(req) + "…"})
+}
+
+// verify checks the X-Slack-Signature of a request, see https://api.slack.com/authentication/verifying-requests-from-slack
+func (h *Handler) verify(header http.Header, body []byte, now time.Time) error {
+	if h.signingSecret == "" {
+		return nil
+	}
+	ts := header.Get("X-Slack-Request-Timestamp")
+	sec, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return errors.New("X-Slack-Request-Timestamp required")
+	}
+	if math.Abs(now.Sub(time.Unix(sec, 0)).Seconds()) > maxSkew.Seconds() {
+		return errors.New("request too old")
+	}
+	if !hmac.Equal([]byte(header.Get("X-Slack-Signature")), []byte(Sign(h.signingSecret, ts, body))) {
+		return errors.New("invalid signature")
+	}
+	return nil
+}
+
+// Sign returns the X-Slack-Signature of a request with its X-Slack-Request-Timestamp
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("v0:" + timestamp + ":"))
+	mac.Write(body)
+	return "v0=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// request returns the print request of the text of a command
+func (h *Handler) request(text string) (*server.PrintRequest, error) {
+	req := h.Defaults
+	req.Images, req.Names = nil, nil
+	fields := strings.Fields(text)
+	switch strings.ToLower(fields[0]) {
+	case "qr", "barcode":
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("%s needs its data", fields[0])
+		}
+		if strings.EqualFold(fields[0], "qr") {
+			req.QR = fields[1]
+		} else {
+			req.Barcode = fields[1]
+		}
+		req.Text = strings.Join(fields[2:], " ")
+	default:
+		req.Text = text
+	}
+	return &req, nil
+}
+
+func usage(command string) string {
+	if command == "" {
+		command = "/label"
+	}
+	return fmt.Sprintf("Usage: %[1]s TEXT, %[1]s qr DATA [CAPTION] or %[1]s barcode [SYMBOLOGY:]DATA [CAPTION]", command)
+}
+
+// describe returns what req prints for messages
+func describe(req *server.PrintRequest) string {
+	switch {
+	case req.QR != "":
+		return "a QR code of " + quote(req.QR)
+	case req.Barcode != "":
+		return "a barcode of " + quote(req.Barcode)
+	default:
+		return quote(req.Text)
+	}
+}
+
+func quote(s string) string {
+	return "“" + s + "”"
+}
+
+// print prints req for user and posts the result to responseURL
+func (h *Handler) print(user string, req *server.PrintRequest, responseURL string) {
+	msg := Message{ResponseType: "in_channel"}
+	if previewURL, err := h.previewURL(req); err != nil {
+		// the job tells why the label can not be printed
+		log.Printf("slack: preview: %v\n", err)
+	} else if previewURL != "" {
+		msg.Attachments = []Attachment{{Fallback: describe(req), ImageURL: previewURL}}
+	}
+	j, err := h.spooler.Submit(h.Printer, req)
+	if err != nil {
+		h.respond(responseURL, Message{ResponseType: "ephemeral", Text: fmt.Sprintf("Not printed: %v", err)})
+		return
+	}
+	log.Printf("slack: %s: job %s on %s\n", user, j.ID, j.Printer)
+	timeout := h.ResultTimeout
+	if timeout == 0 {
+		timeout = DefaultResultTimeout
+	}
+	select {
+	case <-j.Done():
+	case <-time.After(timeout):
+	}
+	j, _ = h.spooler.Job(j.ID)
+	switch j.State {
+	case server.JobCompleted:
+		msg.Text = fmt.Sprintf("%s printed %s on %s (job %s)", user, describe(req), j.Printer, j.ID)
+		if r := j.Result; r != nil {
+			msg.Text += fmt.Sprintf(", %d label(s) of %.0fmm on %dmm tape", r.Labels, r.LengthMM, r.TapeWidthMM)
+		}
+		for i := range msg.Attachments {
+			msg.Attachments[i].Color = "good"
+		}
+	case server.JobFailed:
+		msg.Text = fmt.Sprintf("%s could not print %s on %s (job %s): %s", user, describe(req), j.Printer, j.ID, j.Error)
+		for i := range msg.Attachments {
+			msg.Attachments[i].Color = "danger"
+		}
+	default:
+		msg.Text = fmt.Sprintf("%s's %s is %s on %s (job %s)", user, describe(req), j.State, j.Printer, j.ID)
+	}
+	h.respond(responseURL, msg)
+}
+
+// previewURL renders the preview of req and returns its URL, empty without Preview or PublicURL
+func (h *Handler) previewURL(req *server.PrintRequest) (string, error) {
+	if h.Preview == nil || h.PublicURL == "" {
+		return "", nil
+	}
+	img, err := h.Preview(h.Printer, 0, req)
+	if err != nil {
+		return "", err
+	}
+	var b bytes.Buffer
+	if err := png.Encode(&b, img); err != nil {
+		return "", err
+	}
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	name := hex.EncodeToString(id)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	now := time.Now()
+	for len(h.order) > 0 && (len(h.order) >= maxPreviews || h.previews[h.order[0]].expires.Before(now)) {
+		delete(h.previews, h.order[0])
+		h.order = h.order[1:]
+	}
+	h.previews[name] = preview{png: b.Bytes(), expires: now.Add(PreviewTTL)}
+	h.order = append(h.order, name)
+	return strings.TrimSuffix(h.PublicURL, "/") + "/previews/" + name + ".png", nil
+}
+
+func (h *Handler) servePreview(w http.ResponseWriter, id string) {
+	h.mu.Lock()
+	p, ok := h.previews[id]
+	h.mu.Unlock()
+	if !ok || p.expires.Before(time.Now()) {
+		http.NotFound(w, nil)
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", "public, max-age=86400")
+	w.Write(p.png)
+}
+
+// respond posts msg to the response_url of a command
+func (h *Handler) respond(responseURL string, msg Message) {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		log.Printf("slack: %v\n", err)
+		return
+	}
+	client := h.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	resp, err := client.Post(responseURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		if uerr, ok := err.(*url.Error); ok {
+			// the URL of the response is a credential of the command
+			err = uerr.Err
+		}
+		log.Printf("slack: respond: %v\n", err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		log.Printf("slack: respond: %s\n", resp.Status)
+	}
+}
+
+func writeMessage(w http.ResponseWriter, msg Message) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(msg)
+}
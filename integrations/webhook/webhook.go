@@ -0,0 +1,260 @@
+// Package webhook posts the jobs of a server.Spooler to webhook URLs as JSON when they are accepted, started,
+// completed or failed, so the systems submitting labels know whether they printed. Jobs waiting for their
+// printer, see server.Spooler.Retry, are posted as well.
+//
+// Each callback is a POST of an Event with the headers
+//
+//	Content-Type            application/json
+//	X-Ptouchgo-Event        the event like "job.completed"
+//	X-Ptouchgo-Delivery     an id of the callback, the same for its retries
+//	X-Ptouchgo-Signature    "sha256=" and the hex HMAC-SHA256 of the body with the secret, when one is set
+//
+// Callbacks failing with a network error, 429 or a 5xx status are retried, the callbacks of a URL are posted in order.
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ka2n/ptouchgo"
+	"github.com/ka2n/ptouchgo/server"
+)
+
+// events of the states of jobs
+const (
+	EventAccepted  = "job.accepted"
+	EventStarted   = "job.started"
+	EventCompleted = "job.completed"
+	EventFailed    = "job.failed"
+	EventWaiting   = "job.waiting"
+)
+
+const (
+	// DefaultTimeout is the timeout of a callback
+	DefaultTimeout = 10 * time.Second
+	// DefaultRetries is the number of times a failed callback is posted again
+	DefaultRetries = 3
+	// queueSize is the number of callbacks waiting for a URL, more are dropped
+	queueSize = 256
+)
+
+// Event is the body of a callback
+type Event struct {
+	Event string     `json:"event"`
+	Time  time.Time  `json:"time"`
+	Job   server.Job `json:"job"`
+	// Status is the status of the printer after completed, failed and waiting jobs, telling why a job failed
+	// like a cover which is open or the end of the tape
+	Status      interface{} `json:"status,omitempty"`
+	StatusError string      `json:"status_error,omitempty"` // when the status can not be read
+}
+
+// Notifier posts the events of the jobs of a spooler to URLs
+type Notifier struct {
+	// Secret signs the callbacks in X-Ptouchgo-Signature, empty does not sign them
+	Secret string
+	// StatusJSON returns the JSON of a status, the status itself when nil
+	StatusJSON func(*ptouchgo.Status) interface{}
+	// Client posts the callbacks, nil uses a client of DefaultTimeout
+	Client *http.Client
+	// Retries is the number of retries of a failed callback, DefaultRetries when zero and none when negative
+	Retries int
+
+	urls    []string
+	spooler *server.Spooler
+
+	mu     sync.Mutex
+	lastID int
+}
+
+// New returns a notifier of the jobs of spooler to urls
+func New(spooler *server.Spooler, urls []string) *Notifier {
+	return &Notifier{urls: urls, spooler: spooler}
+}
+
+// delivery is a callback to post
+type delivery struct {
+	id    string
+	event string
+	body  []byte
+}
+
+// Run posts the events until stop is closed
+func (n *Notifier) Run(stop <-chan struct{}) {
+	updates, stopWatch := n.spooler.Watch()
+	defer stopWatch()
+
+	queues := make([]chan delivery, len(n.urls))
+	var wg sync.WaitGroup
+	for i, u := range n.urls {
+		queues[i] = make(chan delivery, queueSize)
+		wg.Add(1)
+		go func(u string, q <-chan delivery) {
+			defer wg.Done()
+			for d := range q {
+				n.deliver(u, d)
+			}
+		}(u, queues[i])
+	}
+	defer func() {
+		for _, q := range queues {
+			close(q)
+		}
+		wg.Wait()
+	}()
+
+	// the status is read apart from watching, the updates of the spooler are not kept waiting for the printer
+	events := make(chan server.Job, queueSize)
+	go func() {
+		defer close(events)
+		for {
+			select {
+			case <-stop:
+				return
+			case j := <-updates:
+				select {
+				case events <- j:
+				default:
+					log.Printf("webhook: job %s: too many events waiting, dropped\n", j.ID)
+				}
+			}
+		}
+	}()
+	for j := range events {
+		d, err := n.delivery(j)
+		if err != nil {
+			log.Printf("webhook: job %s: %v\n", j.ID, err)
+			continue
+		}
+		for i, q := range queues {
+			select {
+			case q <- d:
+			default:
+				log.Printf("webhook: %s: too many callbacks waiting, %s of job %s dropped\n", redacted(n.urls[i]), d.event, j.ID)
+			}
+		}
+	}
+}
+
+// delivery returns the callback of an update of j
+func (n *Notifier) delivery(j server.Job) (delivery, error) {
+	e := Event{Event: eventOf(j.State), Time: time.Now(), Job: j}
+	if j.State.Finished() || j.State == server.JobWaiting {
+		st, err := n.spooler.Status(j.Printer)
+		switch {
+		case err != nil:
+			e.StatusError = err.Error()
+		case n.StatusJSON != nil:
+			e.Status = n.StatusJSON(st)
+		default:
+			e.Status = st
+		}
+	}
+	body, err := json.Marshal(e)
+	if err != nil {
+		return delivery{}, err
+	}
+	n.mu.Lock()
+	n.lastID++
+	id := strconv.FormatInt(time.Now().Unix(), 10) + "-" + strconv.Itoa(n.lastID)
+	n.mu.Unlock()
+	return delivery{id: id, event: e.Event, body: body}, nil
+}
+
+func eventOf(s server.JobState) string {
+	switch s {
+	case server.JobQueued:
+		return EventAccepted
+	case server.JobPrinting:
+		return EventStarted
+	case server.JobCompleted:
+		return EventCompleted
+	case server.JobWaiting:
+		return EventWaiting
+	default:
+		return EventFailed
+	}
+}
+
+// deliver posts d to u, retrying with a growing delay
+func (n *Notifier) deliver(u string, d delivery) {
+	retries := n.Retries
+	if retries == 0 {
+		retries = DefaultRetries
+	}
+	wait := time.Second
+	for attempt := 0; ; attempt++ {
+		retry, err := n.post(u, d)
+		if err == nil {
+			return
+		}
+		if !retry || attempt >= retries {
+			log.Printf("webhook: %s: %s: %v\n", redacted(u), d.event, err)
+			return
+		}
+		time.Sleep(wait)
+		wait *= 2
+	}
+}
+
+// post posts d to u once, retry reports whether a failure may succeed later
+func (n *Notifier) post(u string, d delivery) (retry bool, err error) {
+	req, err := http.NewRequest(http.MethodPost, u, bytes.NewReader(d.body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "ptouchgo")
+	req.Header.Set("X-Ptouchgo-Event", d.event)
+	req.Header.Set("X-Ptouchgo-Delivery", d.id)
+	if n.Secret != "" {
+		req.Header.Set("X-Ptouchgo-Signature", Sign(n.Secret, d.body))
+	}
+	client := n.Client
+	if client == nil {
+		client = &http.Client{Timeout: DefaultTimeout}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		// the error of Do has the URL, which may have a password
+		if uerr, ok := err.(*url.Error); ok {
+			err = uerr.Err
+		}
+		return true, err
+	}
+	resp.Body.Close()
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return true, fmt.Errorf("%s", resp.Status)
+	default:
+		return false, fmt.Errorf("%s", resp.Status)
+	}
+}
+
+// Sign returns the X-Ptouchgo-Signature of body, receivers compare it to the header with hmac.Equal
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// redacted returns u without its password
+func redacted(u string) string {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return u
+	}
+	return parsed.Redacted()
+}
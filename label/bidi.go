@@ -0,0 +1,243 @@
+package label
+
+import (
+	"golang.org/x/text/unicode/bidi"
+)
+
+// visualLine shapes Arabic letters and reorders a line from logical into visual order
+// following the Unicode bidirectional algorithm without explicit embeddings,
+// rtl reports whether the paragraph direction is right to left
+func visualLine(s string) (string, bool) {
+	runes := []rune(shapeArabic(s))
+	if len(runes) == 0 {
+		return s, false
+	}
+
+	classes := make([]bidi.Class, len(runes))
+	var hasRTL bool
+	for i, r := range runes {
+		p, _ := bidi.LookupRune(r)
+		classes[i] = p.Class()
+		if classes[i] == bidi.R || classes[i] == bidi.AL || classes[i] == bidi.AN {
+			hasRTL = true
+		}
+	}
+	if !hasRTL {
+		return s, false
+	}
+
+	base := paragraphLevel(classes)
+	resolveWeak(classes, base)
+	resolveNeutral(classes, base)
+	levels := resolveLevels(classes, base)
+
+	for i := len(levels) - 1; i >= 0 && isWhitespace(runes[i]); i-- {
+		levels[i] = base
+	}
+	for i := range runes {
+		if levels[i]%2 == 1 {
+			if m, ok := mirrored[runes[i]]; ok {
+				runes[i] = m
+			}
+		}
+	}
+	reorder(runes, levels)
+	return string(runes), base == 1
+}
+
+func isWhitespace(r rune) bool {
+	p, _ := bidi.LookupRune(r)
+	return p.Class() == bidi.WS
+}
+
+func isStrong(c bidi.Class) bool {
+	return c == bidi.L || c == bidi.R || c == bidi.AL
+}
+
+func isNeutral(c bidi.Class) bool {
+	return c == bidi.B || c == bidi.S || c == bidi.WS || c == bidi.ON
+}
+
+func paragraphLevel(classes []bidi.Class) int {
+	for _, c := range classes {
+		switch c {
+		case bidi.L:
+			return 0
+		case bidi.R, bidi.AL:
+			return 1
+		}
+	}
+	return 0
+}
+
+func directionClass(level int) bidi.Class {
+	if level%2 == 1 {
+		return bidi.R
+	}
+	return bidi.L
+}
+
+// resolveWeak applies rules W1-W7
+func resolveWeak(classes []bidi.Class, base int) {
+	sos := directionClass(base)
+
+	// W1
+	prev := sos
+	for i, c := range classes {
+		if c == bidi.NSM {
+			classes[i] = prev
+		}
+		prev = classes[i]
+	}
+
+	// W2, W3
+	last := sos
+	for i, c := range classes {
+		switch {
+		case c == bidi.EN && last == bidi.AL:
+			classes[i] = bidi.AN
+		case isStrong(c):
+			last = c
+		}
+	}
+	for i, c := range classes {
+		if c == bidi.AL {
+			classes[i] = bidi.R
+		}
+	}
+
+	// W4
+	for i := 1; i+1 < len(classes); i++ {
+		p, c, n := classes[i-1], classes[i], classes[i+1]
+		switch {
+		case c == bidi.ES && p == bidi.EN && n == bidi.EN:
+			classes[i] = bidi.EN
+		case c == bidi.CS && p == n && (p == bidi.EN || p == bidi.AN):
+			classes[i] = p
+		}
+	}
+
+	// W5
+	for i := 0; i < len(classes); i++ {
+		if classes[i] != bidi.ET {
+			continue
+		}
+		j := i
+		for j < len(classes) && classes[j] == bidi.ET {
+			j++
+		}
+		if (i > 0 && classes[i-1] == bidi.EN) || (j < len(classes) && classes[j] == bidi.EN) {
+			for k := i; k < j; k++ {
+				classes[k] = bidi.EN
+			}
+		}
+		i = j - 1
+	}
+
+	// W6, W7
+	last = sos
+	for i, c := range classes {
+		switch c {
+		case bidi.ES, bidi.ET, bidi.CS, bidi.BN:
+			classes[i] = bidi.ON
+		case bidi.EN:
+			if last == bidi.L {
+				classes[i] = bidi.L
+			}
+		case bidi.L, bidi.R:
+			last = c
+		}
+	}
+}
+
+// resolveNeutral applies rules N1 and N2, numbers count as right to left
+func resolveNeutral(classes []bidi.Class, base int) {
+	strong := func(c bidi.Class) bidi.Class {
+		if c == bidi.EN || c == bidi.AN {
+			return bidi.R
+		}
+		return c
+	}
+
+	for i := 0; i < len(classes); i++ {
+		if !isNeutral(classes[i]) {
+			continue
+		}
+		j := i
+		for j < len(classes) && isNeutral(classes[j]) {
+			j++
+		}
+		before, after := directionClass(base), directionClass(base)
+		if i > 0 {
+			before = strong(classes[i-1])
+		}
+		if j < len(classes) {
+			after = strong(classes[j])
+		}
+		c := directionClass(base)
+		if before == after {
+			c = before
+		}
+		for k := i; k < j; k++ {
+			classes[k] = c
+		}
+		i = j - 1
+	}
+}
+
+// resolveLevels applies rules I1 and I2
+func resolveLevels(classes []bidi.Class, base int) []int {
+	levels := make([]int, len(classes))
+	for i, c := range classes {
+		levels[i] = base
+		if base%2 == 0 {
+			switch c {
+			case bidi.R:
+				levels[i]++
+			case bidi.AN, bidi.EN:
+				levels[i] += 2
+			}
+		} else if c == bidi.L || c == bidi.EN || c == bidi.AN {
+			levels[i]++
+		}
+	}
+	return levels
+}
+
+// reorder reverses every run at or above each odd level, highest first (rule L2)
+func reorder(runes []rune, levels []int) {
+	var highest, lowestOdd = 0, 1 << 30
+	for _, l := range levels {
+		if l > highest {
+			highest = l
+		}
+		if l%2 == 1 && l < lowestOdd {
+			lowestOdd = l
+		}
+	}
+
+	for level := highest; level >= lowestOdd; level-- {
+		for i := 0; i < len(runes); i++ {
+			if levels[i] < level {
+				continue
+			}
+			j := i
+			for j < len(runes) && levels[j] >= level {
+				j++
+			}
+			for a, b := i, j-1; a < b; a, b = a+1, b-1 {
+				runes[a], runes[b] = runes[b], runes[a]
+				levels[a], levels[b] = levels[b], levels[a]
+			}
+			i = j
+		}
+	}
+}
+
+var mirrored = map[rune]rune{
+	'(': ')', ')': '(',
+	'[': ']', ']': '[',
+	'{': '}', '}': '{',
+	'<': '>', '>': '<',
+	'«': '»', '»': '«',
+}
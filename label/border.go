@@ -0,0 +1,107 @@
+package label
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/ka2n/ptouchgo"
+)
+
+// Border styles
+const (
+	BorderSolid   = "solid"
+	BorderRounded = "rounded"
+	BorderDouble  = "double"
+	BorderDashed  = "dashed"
+)
+
+// defaultBorderThickness in mm, about two dots
+const defaultBorderThickness = 0.3
+
+// Border is a frame drawn along the edges of the label, content is moved inside of it
+type Border struct {
+	Style     string  `json:"style" yaml:"style"`
+	Thickness float64 `json:"thickness" yaml:"thickness"` // mm, 0: 0.3mm
+	Padding   float64 `json:"padding" yaml:"padding"`     // mm between the border and content
+}
+
+func (b *Border) thickness() int {
+	t := b.Thickness
+	if t == 0 {
+		t = defaultBorderThickness
+	}
+	dots := ptouchgo.MMToDots(t)
+	if dots < 1 {
+		dots = 1
+	}
+	return dots
+}
+
+// lineWidth is the space taken by the lines of the border
+func (b *Border) lineWidth() int {
+	if b.Style == BorderDouble {
+		return b.thickness() * 3
+	}
+	return b.thickness()
+}
+
+// inset is the distance from the label edge to the content
+func (b *Border) inset() int {
+	if b == nil {
+		return 0
+	}
+	return b.lineWidth() + ptouchgo.MMToDots(b.Padding)
+}
+
+// render draws the border of a width x height area
+func (b *Border) render(width, height int) (*image.Alpha, error) {
+	t := float64(b.thickness())
+	var radius float64
+	switch b.Style {
+	case "", BorderSolid, BorderDouble, BorderDashed:
+	case BorderRounded:
+		radius = float64(height) / 4
+	default:
+		return nil, fmt.Errorf("unknown border style: %q", b.Style)
+	}
+
+	m := image.NewAlpha(image.Rect(0, 0, width, height))
+	hw, hh := float64(width)/2, float64(height)/2
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			px, py := float64(x)+0.5-hw, float64(y)+0.5-hh
+			d := -roundedRectDistance(px, py, hw, hh, radius)
+
+			var ink bool
+			switch b.Style {
+			case BorderDouble:
+				ink = d <= t || (d > t*2 && d <= t*3)
+			case BorderDashed:
+				// measure along the nearer edge, dash 3t and gap 2t
+				pos := float64(x)
+				if hw-math.Abs(px) < hh-math.Abs(py) {
+					pos = float64(y)
+				}
+				ink = d <= t && math.Mod(pos, t*5) < t*3
+			default:
+				ink = d <= t
+			}
+			if ink {
+				m.SetAlpha(x, y, color.Alpha{A: 0xff})
+			}
+		}
+	}
+	return m, nil
+}
+
+// roundedRectDistance is the signed distance from p to the outline of a rectangle
+// centered at the origin, negative inside
+func roundedRectDistance(px, py, hw, hh, r float64) float64 {
+	qx := math.Abs(px) - hw + r
+	qy := math.Abs(py) - hh + r
+	outside := math.Hypot(math.Max(qx, 0), math.Max(qy, 0))
+	inside := math.Min(math.Max(qx, qy), 0)
+	return outside + inside - r
+}
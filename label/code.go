@@ -0,0 +1,134 @@
+package label
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+
+	"github.com/boombuler/barcode"
+	"github.com/boombuler/barcode/code128"
+	"github.com/boombuler/barcode/code39"
+	"github.com/boombuler/barcode/ean"
+	"github.com/boombuler/barcode/qr"
+)
+
+const (
+	// defaultModuleDots is the width of the narrowest bar when barcode width is not given
+	defaultModuleDots = 2
+	// minCaptionDots is the smallest height of the text under barcodes when its size is not given
+	minCaptionDots = 12
+	// barcodeCaptionGap is the space in dots between bars and the human readable text
+	barcodeCaptionGap = 2
+)
+
+// renderQR draws the largest QR code with whole dot modules fitting in box
+func renderQR(content string, box image.Rectangle) (*image.Alpha, error) {
+	if content == "" {
+		return nil, fmt.Errorf("qr data is empty")
+	}
+	code, err := qr.Encode(content, qr.M, qr.Auto)
+	if err != nil {
+		return nil, err
+	}
+
+	side := box.Dy()
+	if box.Dx() > 0 && box.Dx() < side {
+		side = box.Dx()
+	}
+	modules := code.Bounds().Dx()
+	scale := side / modules
+	if scale < 1 {
+		return nil, fmt.Errorf("qr code needs %d dots, only %d available", modules, side)
+	}
+
+	scaled, err := barcode.Scale(code, modules*scale, modules*scale)
+	if err != nil {
+		return nil, err
+	}
+	return inkMask(scaled), nil
+}
+
+// quietZone returns the blank modules required on the left and right of a symbology
+func quietZone(symbology string) (int, int) {
+	switch symbology {
+	case "ean":
+		return 11, 7
+	default:
+		return 10, 10
+	}
+}
+
+// renderBarcode draws a 1D barcode filling the box height with its quiet zones,
+// when e.ShowText is set e.Text or the encoded string is printed beneath the bars in e.Font
+func renderBarcode(e Element, content string, box image.Rectangle) (*image.Alpha, error) {
+	if content == "" {
+		return nil, fmt.Errorf("barcode data is empty")
+	}
+
+	var code barcode.Barcode
+	var err error
+	switch e.Symbology {
+	case "", "code128":
+		code, err = code128.Encode(content)
+	case "code39":
+		code, err = code39.Encode(content, false, true)
+	case "ean":
+		code, err = ean.Encode(content)
+	default:
+		return nil, fmt.Errorf("unknown barcode symbology: %q", e.Symbology)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	left, right := quietZone(e.Symbology)
+	modules := code.Bounds().Dx()
+	scale := defaultModuleDots
+	if box.Dx() > 0 {
+		scale = box.Dx() / (modules + left + right)
+		if scale < 1 {
+			return nil, fmt.Errorf("barcode needs %d dots, only %d available", modules+left+right, box.Dx())
+		}
+	}
+	barsWidth := modules * scale
+
+	var caption *image.Alpha
+	barsHeight := box.Dy()
+	if e.ShowText {
+		fs, err := loadFonts(e.Font, e.Fallback)
+		if err != nil {
+			return nil, err
+		}
+		textHeight := box.Dy() / 5
+		if textHeight < minCaptionDots {
+			textHeight = minCaptionDots
+		}
+		text := e.Text
+		if text == "" {
+			text = code.Content()
+		}
+		caption, err = renderText(text, fs, e.Size, textHeight, barsWidth, "center")
+		if err != nil {
+			return nil, err
+		}
+		barsHeight -= caption.Bounds().Dy() + barcodeCaptionGap
+		if barsHeight <= 0 {
+			return nil, fmt.Errorf("no space left for bars under the text")
+		}
+	}
+
+	scaled, err := barcode.Scale(code, barsWidth, barsHeight)
+	if err != nil {
+		return nil, err
+	}
+
+	m := image.NewAlpha(image.Rect(0, 0, (left+modules+right)*scale, box.Dy()))
+	bars := image.Rect(left*scale, 0, left*scale+barsWidth, barsHeight)
+	draw.Draw(m, bars, inkMask(scaled), image.Point{}, draw.Src)
+	if caption != nil {
+		size := caption.Bounds().Size()
+		at := image.Pt(bars.Min.X+(barsWidth-size.X)/2, barsHeight+barcodeCaptionGap)
+		draw.Draw(m, image.Rectangle{Min: at, Max: at.Add(size)}, caption, image.Point{}, draw.Src)
+	}
+	return m, nil
+}
@@ -0,0 +1,83 @@
+package label
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Contact QR code payload formats
+const (
+	ContactVCard  = "vcard"
+	ContactMeCard = "mecard" // shorter, fits better on narrow tapes
+)
+
+// Contact is the information encoded in contact QR codes
+type Contact struct {
+	Name         string
+	Organization string
+	Phone        string
+	Email        string
+}
+
+var (
+	vcardEscaper  = strings.NewReplacer(`\`, `\\`, `,`, `\,`, `;`, `\;`, "\n", `\n`)
+	mecardEscaper = strings.NewReplacer(`\`, `\\`, `;`, `\;`, `,`, `\,`, `:`, `\:`, `"`, `\"`)
+)
+
+// VCard returns a vCard 3.0 payload
+func (c Contact) VCard() string {
+	var b strings.Builder
+	b.WriteString("BEGIN:VCARD\r\nVERSION:3.0\r\n")
+	fmt.Fprintf(&b, "N:%s;;;;\r\n", vcardEscaper.Replace(c.Name))
+	fmt.Fprintf(&b, "FN:%s\r\n", vcardEscaper.Replace(c.Name))
+	if c.Organization != "" {
+		fmt.Fprintf(&b, "ORG:%s\r\n", vcardEscaper.Replace(c.Organization))
+	}
+	if c.Phone != "" {
+		fmt.Fprintf(&b, "TEL:%s\r\n", vcardEscaper.Replace(c.Phone))
+	}
+	if c.Email != "" {
+		fmt.Fprintf(&b, "EMAIL:%s\r\n", vcardEscaper.Replace(c.Email))
+	}
+	b.WriteString("END:VCARD\r\n")
+	return b.String()
+}
+
+// MeCard returns a MECARD payload
+func (c Contact) MeCard() string {
+	var b strings.Builder
+	b.WriteString("MECARD:")
+	fmt.Fprintf(&b, "N:%s;", mecardEscaper.Replace(c.Name))
+	if c.Organization != "" {
+		fmt.Fprintf(&b, "ORG:%s;", mecardEscaper.Replace(c.Organization))
+	}
+	if c.Phone != "" {
+		fmt.Fprintf(&b, "TEL:%s;", mecardEscaper.Replace(c.Phone))
+	}
+	if c.Email != "" {
+		fmt.Fprintf(&b, "EMAIL:%s;", mecardEscaper.Replace(c.Email))
+	}
+	b.WriteString(";")
+	return b.String()
+}
+
+// ContactLabel returns a layout with a contact QR code and the contact details next to it
+func ContactLabel(tape int, c Contact, format string) (*Layout, error) {
+	var payload string
+	switch format {
+	case "", ContactVCard:
+		payload = c.VCard()
+	case ContactMeCard:
+		payload = c.MeCard()
+	default:
+		return nil, fmt.Errorf("unknown contact format: %q", format)
+	}
+
+	var lines []string
+	for _, s := range []string{c.Name, c.Organization, c.Phone, c.Email} {
+		if s != "" {
+			lines = append(lines, s)
+		}
+	}
+	return qrWithText(tape, escapeFields(payload), escapeFields(strings.Join(lines, "\n"))), nil
+}
@@ -0,0 +1,133 @@
+package label
+
+import (
+	"image"
+	"os"
+	"strings"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/font/sfnt"
+	"golang.org/x/image/math/fixed"
+)
+
+// DefaultFallbackFonts are tried for glyphs missing in the element font when it has no
+// explicit fallback, files which do not exist are skipped.
+// Color emoji fonts without outlines can not be rasterized, so monochrome outline fonts are listed.
+var DefaultFallbackFonts = []string{
+	"/usr/share/fonts/truetype/noto/NotoEmoji-Regular.ttf",
+	"/usr/share/fonts/noto/NotoEmoji-Regular.ttf",
+	"/usr/share/fonts/google-noto-emoji/NotoEmoji-Regular.ttf",
+	"/usr/share/fonts/truetype/ancient-scripts/Symbola_hint.ttf",
+	"/usr/share/fonts/truetype/dejavu/DejaVuSans.ttf",
+	"/System/Library/Fonts/Apple Symbols.ttf",
+	`C:\Windows\Fonts\seguiemj.ttf`,
+	`C:\Windows\Fonts\seguisym.ttf`,
+}
+
+// fontSet is a primary font followed by its fallbacks
+type fontSet []*opentype.Font
+
+// loadFonts loads the primary font and its fallbacks, which can be file paths or font names
+func loadFonts(primary string, fallbacks []string) (fontSet, error) {
+	path, err := resolveFont(primary)
+	if err != nil {
+		return nil, err
+	}
+	f, err := loadFont(path)
+	if err != nil {
+		return nil, err
+	}
+	fs := fontSet{f}
+
+	optional := fallbacks == nil
+	if optional {
+		fallbacks = DefaultFallbackFonts
+	}
+	for _, path := range fallbacks {
+		if optional {
+			if _, err := os.Stat(path); err != nil {
+				continue
+			}
+		} else {
+			path, err = resolveFont(path)
+			if err != nil {
+				return nil, err
+			}
+		}
+		f, err := loadFont(path)
+		if err != nil {
+			if optional {
+				continue
+			}
+			return nil, err
+		}
+		fs = append(fs, f)
+	}
+	return fs, nil
+}
+
+// stripEmojiModifiers removes variation selectors, joiners and skin tone modifiers
+// which only make sense with color emoji, the base symbols are drawn one by one
+func stripEmojiModifiers(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 0xFE00 && r <= 0xFE0F, r == 0x200D, r >= 0x1F3FB && r <= 0x1F3FF:
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// fallbackFace draws each rune with the first face which has an outline for it
+type fallbackFace struct {
+	fonts fontSet
+	faces []font.Face
+	buf   sfnt.Buffer
+}
+
+func (f *fallbackFace) pick(r rune) font.Face {
+	for i, ft := range f.fonts {
+		x, err := ft.GlyphIndex(&f.buf, r)
+		if err != nil || x == 0 {
+			continue
+		}
+		// bitmap only glyphs (color emoji) have no outline to rasterize
+		if _, err := ft.LoadGlyph(&f.buf, x, fixed.I(12), nil); err != nil {
+			continue
+		}
+		return f.faces[i]
+	}
+	return f.faces[0]
+}
+
+func (f *fallbackFace) Close() error {
+	for _, face := range f.faces {
+		face.Close()
+	}
+	return nil
+}
+
+func (f *fallbackFace) Glyph(dot fixed.Point26_6, r rune) (image.Rectangle, image.Image, image.Point, fixed.Int26_6, bool) {
+	return f.pick(r).Glyph(dot, r)
+}
+
+func (f *fallbackFace) GlyphBounds(r rune) (fixed.Rectangle26_6, fixed.Int26_6, bool) {
+	return f.pick(r).GlyphBounds(r)
+}
+
+func (f *fallbackFace) GlyphAdvance(r rune) (fixed.Int26_6, bool) {
+	return f.pick(r).GlyphAdvance(r)
+}
+
+func (f *fallbackFace) Kern(r0, r1 rune) fixed.Int26_6 {
+	face := f.pick(r0)
+	if face != f.pick(r1) {
+		return 0
+	}
+	return face.Kern(r0, r1)
+}
+
+func (f *fallbackFace) Metrics() font.Metrics {
+	return f.faces[0].Metrics()
+}
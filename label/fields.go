@@ -0,0 +1,228 @@
+package label
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CounterFile is where sequence counters are persisted between runs
+var CounterFile = defaultCounterFile()
+
+// now is replaceable for reproducible output
+var now = time.Now
+
+const defaultCounter = "default"
+
+// fieldFunc resolves a placeholder name with an optional format
+type fieldFunc func(name, format string) (string, error)
+
+func defaultCounterFile() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "ptouchgo-counters.json"
+	}
+	return filepath.Join(dir, "ptouchgo", "counters.json")
+}
+
+func (l *Layout) counterName() string {
+	if l.Counter == "" {
+		return defaultCounter
+	}
+	return l.Counter
+}
+
+func (l *Layout) usesSequence() bool {
+	for _, e := range l.Elements {
+		for _, s := range []string{e.Text, e.Data, e.Source} {
+			if hasField(s, "seq") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// hasField reports whether s has a placeholder of the field name, escaped braces like {{seq}} are not one
+func hasField(s, name string) bool {
+	found := false
+	expand(s, func(field, format string) (string, error) {
+		found = found || field == name
+		return "", nil
+	})
+	return found
+}
+
+// nextSequence returns the value {seq} takes on the next label printed, 0 for layouts without {seq}
+func (l *Layout) nextSequence() (int, error) {
+	if !l.usesSequence() {
+		return 0, nil
+	}
+	counters, err := readCounters()
+	if err != nil {
+		return 0, err
+	}
+	return counters[l.counterName()] + 1, nil
+}
+
+// fields binds data and the built-in fields, data takes precedence. {seq} takes the value seq.
+func (l *Layout) fields(data map[string]string, seq int) fieldFunc {
+	t := now()
+	return func(name, format string) (string, error) {
+		if v, ok := data[name]; ok {
+			return v, nil
+		}
+		switch name {
+		case "date":
+			if format == "" {
+				format = "2006-01-02"
+			}
+			return t.Format(format), nil
+		case "time":
+			if format == "" {
+				format = "15:04"
+			}
+			return t.Format(format), nil
+		case "seq":
+			width, err := seqWidth(format)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("%0*d", width, seq), nil
+		}
+		return "", fmt.Errorf("no value for {%s}", name)
+	}
+}
+
+// maxSeqWidth is the most digits {seq} is padded to
+const maxSeqWidth = 20
+
+// seqWidth returns the digits {seq:format} is zero padded to, format is zeros like 0000 or a width like 4
+func seqWidth(format string) (int, error) {
+	if format == "" {
+		return 0, nil
+	}
+	width := len(format)
+	if strings.Trim(format, "0") != "" {
+		n, err := strconv.Atoi(format)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid format of {seq:%s}, use zeros like {seq:0000} or a width like {seq:4}", format)
+		}
+		width = n
+	}
+	if width > maxSeqWidth {
+		return 0, fmt.Errorf("{seq:%s} is wider than %d digits", format, maxSeqWidth)
+	}
+	return width, nil
+}
+
+// expand replaces "{name}" and "{name:format}" placeholders, "{{" and "}}" are literal braces
+func expand(s string, fields fieldFunc) (string, error) {
+	if !strings.ContainsAny(s, "{}") {
+		return s, nil
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '{' && i+1 < len(s) && s[i+1] == '{':
+			b.WriteByte('{')
+			i++
+		case c == '}' && i+1 < len(s) && s[i+1] == '}':
+			b.WriteByte('}')
+			i++
+		case c == '{':
+			end := strings.IndexByte(s[i:], '}')
+			if end < 0 {
+				return "", fmt.Errorf("unterminated placeholder in %q", s)
+			}
+			name := s[i+1 : i+end]
+			var format string
+			if n := strings.IndexByte(name, ':'); n >= 0 {
+				name, format = name[:n], name[n+1:]
+			}
+			v, err := fields(name, format)
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(v)
+			i += end
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String(), nil
+}
+
+func readCounters() (map[string]int, error) {
+	counters := make(map[string]int)
+	b, err := ioutil.ReadFile(CounterFile)
+	if os.IsNotExist(err) {
+		return counters, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(b, &counters)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", CounterFile, err)
+	}
+	return counters, nil
+}
+
+// ReserveSequence advances the {seq} counter of the layout by n labels and returns the first of the values,
+// labels rendered with them by RenderSequence or RenderRowsSequence do not share a number with labels of
+// other jobs and processes. Values of a job failing to print are skipped. Layouts without {seq} get 0.
+func (l *Layout) ReserveSequence(n int) (int, error) {
+	if !l.usesSequence() {
+		return 0, nil
+	}
+	last, err := advanceCounter(l.counterName(), n)
+	if err != nil {
+		return 0, err
+	}
+	return last - n + 1, nil
+}
+
+// countersMu serializes the updates of CounterFile in the process, lockFile the ones of other processes
+var countersMu sync.Mutex
+
+// advanceCounter adds n to the named counter in CounterFile and returns its new value,
+// the file is read and written under the lock
+func advanceCounter(name string, n int) (int, error) {
+	err := os.MkdirAll(filepath.Dir(CounterFile), 0755)
+	if err != nil {
+		return 0, err
+	}
+	countersMu.Lock()
+	defer countersMu.Unlock()
+	unlock, err := lockFile(CounterFile + ".lock")
+	if err != nil {
+		return 0, fmt.Errorf("%s: lock: %w", CounterFile, err)
+	}
+	defer unlock()
+
+	counters, err := readCounters()
+	if err != nil {
+		return 0, err
+	}
+	counters[name] += n
+
+	b, err := json.MarshalIndent(counters, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+	tmp := CounterFile + ".tmp"
+	err = ioutil.WriteFile(tmp, b, 0644)
+	if err != nil {
+		return 0, err
+	}
+	return counters[name], os.Rename(tmp, CounterFile)
+}
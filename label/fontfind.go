@@ -0,0 +1,243 @@
+package label
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/gobolditalic"
+	"golang.org/x/image/font/gofont/goitalic"
+	"golang.org/x/image/font/gofont/gomedium"
+	"golang.org/x/image/font/gofont/gomono"
+	"golang.org/x/image/font/gofont/gomonobold"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/sfnt"
+)
+
+// bundledPrefix marks Element.Font values referring to the fonts built into the binary
+const bundledPrefix = "bundled:"
+
+var bundledFonts = []struct {
+	family, style string
+	ttf           []byte
+}{
+	{"Go", "Regular", goregular.TTF},
+	{"Go", "Bold", gobold.TTF},
+	{"Go", "Italic", goitalic.TTF},
+	{"Go", "Bold Italic", gobolditalic.TTF},
+	{"Go", "Medium", gomedium.TTF},
+	{"Go Mono", "Regular", gomono.TTF},
+	{"Go Mono", "Bold", gomonobold.TTF},
+}
+
+func bundledFont(name string) ([]byte, bool) {
+	for _, f := range bundledFonts {
+		if f.family+" "+f.style == name {
+			return f.ttf, true
+		}
+	}
+	return nil, false
+}
+
+// FontInfo describes an available font
+type FontInfo struct {
+	Name   string // full name, e.g. "Noto Sans JP Bold"
+	Family string
+	Style  string
+	Path   string // value for Element.Font, "#n" selects a font in a collection
+}
+
+var (
+	fontListOnce sync.Once
+	fontList     []FontInfo
+)
+
+// Fonts lists fonts found in the system font directories followed by the bundled fonts
+func Fonts() []FontInfo {
+	fontListOnce.Do(func() {
+		fontList = scanFonts(fontDirs())
+		for _, f := range bundledFonts {
+			name := f.family + " " + f.style
+			fontList = append(fontList, FontInfo{Name: name, Family: f.family, Style: f.style, Path: bundledPrefix + name})
+		}
+		sort.SliceStable(fontList, func(i, j int) bool { return fontList[i].Name < fontList[j].Name })
+	})
+	return fontList
+}
+
+// FindFont resolves a font name like "Noto Sans JP Bold" into a value for Element.Font,
+// fontconfig is asked first where it is installed, then font directories are searched
+func FindFont(name string) (string, error) {
+	if path, ok := fontconfigMatch(name); ok {
+		return path, nil
+	}
+
+	want := normalizeFontName(name)
+	var familyMatch string
+	for _, f := range Fonts() {
+		if normalizeFontName(f.Name) == want || normalizeFontName(f.Family+f.Style) == want {
+			return f.Path, nil
+		}
+		if normalizeFontName(f.Family) == want && (familyMatch == "" || isRegularStyle(f.Style)) {
+			familyMatch = f.Path
+		}
+	}
+	if familyMatch != "" {
+		return familyMatch, nil
+	}
+	return "", fmt.Errorf("font not found: %q", name)
+}
+
+// resolveFont accepts a font file path or a font name
+func resolveFont(name string) (string, error) {
+	if name == "" || strings.HasPrefix(name, bundledPrefix) {
+		return name, nil
+	}
+	if _, err := os.Stat(fontFilePath(name)); err == nil {
+		return name, nil
+	}
+	if strings.ContainsAny(name, `/\`) || isFontFile(name) {
+		return name, nil
+	}
+	return FindFont(name)
+}
+
+// fontFilePath strips the collection index from a font path
+func fontFilePath(path string) string {
+	if i := strings.LastIndex(path, "#"); i > 0 {
+		if _, err := strconv.Atoi(path[i+1:]); err == nil {
+			return path[:i]
+		}
+	}
+	return path
+}
+
+var fontStyles = map[string]bool{
+	"thin": true, "light": true, "regular": true, "medium": true, "semibold": true,
+	"bold": true, "heavy": true, "black": true, "italic": true, "oblique": true,
+}
+
+func normalizeFontName(s string) string {
+	return strings.ToLower(strings.NewReplacer(" ", "", "-", "", "_", "").Replace(s))
+}
+
+func isRegularStyle(style string) bool {
+	switch strings.ToLower(style) {
+	case "regular", "normal", "book", "roman", "":
+		return true
+	}
+	return false
+}
+
+func isFontFile(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".ttf", ".otf", ".ttc", ".otc":
+		return true
+	}
+	return false
+}
+
+func fontDirs() []string {
+	home, _ := os.UserHomeDir()
+	switch runtime.GOOS {
+	case "windows":
+		dirs := []string{filepath.Join(os.Getenv("WINDIR"), "Fonts")}
+		if local := os.Getenv("LOCALAPPDATA"); local != "" {
+			dirs = append(dirs, filepath.Join(local, "Microsoft", "Windows", "Fonts"))
+		}
+		return dirs
+	case "darwin":
+		return []string{"/System/Library/Fonts", "/Library/Fonts", filepath.Join(home, "Library", "Fonts")}
+	default:
+		return []string{"/usr/share/fonts", "/usr/local/share/fonts", filepath.Join(home, ".local", "share", "fonts"), filepath.Join(home, ".fonts")}
+	}
+}
+
+func scanFonts(dirs []string) []FontInfo {
+	var fonts []FontInfo
+	var buf sfnt.Buffer
+	for _, dir := range dirs {
+		filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil || info.IsDir() || !isFontFile(path) {
+				return nil
+			}
+			f, err := os.Open(path)
+			if err != nil {
+				return nil
+			}
+			defer f.Close()
+
+			c, err := sfnt.ParseCollectionReaderAt(f)
+			if err != nil {
+				return nil
+			}
+			for i := 0; i < c.NumFonts(); i++ {
+				font, err := c.Font(i)
+				if err != nil {
+					continue
+				}
+				info := FontInfo{Path: path}
+				if c.NumFonts() > 1 {
+					info.Path = path + "#" + strconv.Itoa(i)
+				}
+				info.Family = fontName(font, &buf, sfnt.NameIDTypographicFamily, sfnt.NameIDFamily)
+				info.Style = fontName(font, &buf, sfnt.NameIDTypographicSubfamily, sfnt.NameIDSubfamily)
+				info.Name = fontName(font, &buf, sfnt.NameIDFull)
+				if info.Name == "" {
+					info.Name = info.Family + " " + info.Style
+				}
+				fonts = append(fonts, info)
+			}
+			return nil
+		})
+	}
+	return fonts
+}
+
+// fontName returns the first non empty name of ids
+func fontName(f *sfnt.Font, buf *sfnt.Buffer, ids ...sfnt.NameID) string {
+	for _, id := range ids {
+		if s, err := f.Name(buf, id); err == nil && s != "" {
+			return s
+		}
+	}
+	return ""
+}
+
+// fontconfigMatch asks fc-match, it always answers with its closest font
+// so the result is only used when the family is part of the requested name
+func fontconfigMatch(name string) (string, bool) {
+	if runtime.GOOS == "windows" || runtime.GOOS == "darwin" {
+		return "", false
+	}
+	fcMatch, err := exec.LookPath("fc-match")
+	if err != nil {
+		return "", false
+	}
+	// "Family Bold" is a family name for fontconfig, the style has to be given separately
+	pattern := name
+	if i := strings.LastIndex(name, " "); i > 0 && fontStyles[strings.ToLower(name[i+1:])] {
+		pattern = name[:i] + ":style=" + name[i+1:]
+	}
+	out, err := exec.Command(fcMatch, "--format=%{family[0]}\n%{file}\n%{index}", pattern).Output()
+	if err != nil {
+		return "", false
+	}
+	fields := strings.Split(string(out), "\n")
+	if len(fields) < 3 || !strings.HasPrefix(normalizeFontName(name), normalizeFontName(fields[0])) {
+		return "", false
+	}
+
+	path := fields[1]
+	if fields[2] != "" && fields[2] != "0" {
+		path += "#" + fields[2]
+	}
+	return path, true
+}
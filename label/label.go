@@ -0,0 +1,145 @@
+// Package label renders declarative label designs into images for ptouchgo
+package label
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/ka2n/ptouchgo"
+	"gopkg.in/yaml.v2"
+)
+
+// Element types
+const (
+	TypeText    = "text"
+	TypeImage   = "image"
+	TypeQR      = "qr"
+	TypeBarcode = "barcode"
+	TypeRuler   = "ruler"
+)
+
+// Layout is a label design, all positions and sizes are in millimeters
+type Layout struct {
+	Tape     int       `json:"tape" yaml:"tape"`       // tape width in mm, 4 means 3.5mm
+	Length   float64   `json:"length" yaml:"length"`   // printed length, 0: fit to content
+	Align    string    `json:"align" yaml:"align"`     // position of content when Length is longer: center, right
+	Margin   float64   `json:"margin" yaml:"margin"`   // blank space after content when Length is 0
+	Counter  string    `json:"counter" yaml:"counter"` // name of the {seq} counter, empty: "default"
+	Border   *Border   `json:"border" yaml:"border"`
+	Elements []Element `json:"elements" yaml:"elements"`
+
+	dir string
+}
+
+// Element is a single item placed on the label
+// Text, Data and Source may contain "{name}" placeholders bound at render time,
+// see Render for built-in fields
+type Element struct {
+	Type   string  `json:"type" yaml:"type"`
+	X      float64 `json:"x" yaml:"x"`
+	Y      float64 `json:"y" yaml:"y"`
+	Width  float64 `json:"width" yaml:"width"`
+	Height float64 `json:"height" yaml:"height"`
+
+	// Inverse fills the element box and knocks out the content, for white on black header bands
+	Inverse bool `json:"inverse" yaml:"inverse"`
+
+	// text
+	Text     string   `json:"text" yaml:"text"`
+	Font     string   `json:"font" yaml:"font"`         // font file or name, see FindFont. empty: bundled Go font
+	Fallback []string `json:"fallback" yaml:"fallback"` // fonts for glyphs missing in Font, see DefaultFallbackFonts
+	Size     float64  `json:"size" yaml:"size"`         // pt, 0: fit to height
+	Align    string   `json:"align" yaml:"align"`
+
+	// image
+	Source string `json:"source" yaml:"source"`
+
+	// qr, barcode
+	Data      string `json:"data" yaml:"data"`
+	Symbology string `json:"symbology" yaml:"symbology"` // code128, code39, ean
+	ShowText  bool   `json:"show_text" yaml:"show_text"` // print Text or the encoded string under the bars using Font and Size
+
+	// ruler
+	Unit string `json:"unit" yaml:"unit"` // mm or inch
+}
+
+// Load reads a layout from a .json, .yaml or .yml file
+func Load(path string) (*Layout, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var l *Layout
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		l, err = ParseJSON(b)
+	case ".yaml", ".yml":
+		l, err = ParseYAML(b)
+	default:
+		return nil, fmt.Errorf("unknown layout format: %s", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	l.dir = filepath.Dir(path)
+	return l, nil
+}
+
+// ParseJSON decodes a JSON layout, unknown fields are rejected like ParseYAML does
+func ParseJSON(b []byte) (*Layout, error) {
+	var l Layout
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&l); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("unexpected data after the layout")
+	}
+	return &l, nil
+}
+
+// ParseYAML decodes a YAML layout
+func ParseYAML(b []byte) (*Layout, error) {
+	var l Layout
+	err := yaml.UnmarshalStrict(b, &l)
+	if err != nil {
+		return nil, err
+	}
+	return &l, nil
+}
+
+// Print renders the layout with data and prints it
+// Tape width falls back to the connection setting when the layout has none
+func Print(s ptouchgo.Serial, l *Layout, data map[string]string) error {
+	if l.Tape == 0 {
+		c := *l
+		c.Tape = int(s.TapeWidthMM)
+		l = &c
+	}
+	seq, err := l.ReserveSequence(1)
+	if err != nil {
+		return err
+	}
+	img, err := RenderSequence(l, data, seq)
+	if err != nil {
+		return err
+	}
+	return s.PrintImage(img, ptouchgo.DefaultPrintOptions())
+}
+
+// PrintFile loads a layout file and prints it
+func PrintFile(s ptouchgo.Serial, path string, data map[string]string) error {
+	l, err := Load(path)
+	if err != nil {
+		return err
+	}
+	return Print(s, l, data)
+}
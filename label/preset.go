@@ -0,0 +1,102 @@
+package label
+
+import "math"
+
+// presetPadding is the blank space in mm kept around text inside presets
+const presetPadding = 1
+
+// CableFlag returns a flag label for a cable of cableDiameter mm.
+// The middle part wraps around the cable and both flag halves of flagLength mm
+// stick together, so text is printed on each half to be readable from both sides.
+func CableFlag(tape int, text string, cableDiameter, flagLength float64) *Layout {
+	wrap := math.Pi * cableDiameter
+	return &Layout{
+		Tape:   tape,
+		Length: flagLength*2 + wrap,
+		Elements: []Element{
+			flagText(text, 0, flagLength),
+			flagText(text, flagLength+wrap, flagLength),
+		},
+	}
+}
+
+// CableWrap returns a self-laminating wrap label for a cable of cableDiameter mm.
+// Text is repeated around the circumference so it can be read at any angle,
+// followed by laminate long enough to cover the printed part once more.
+func CableWrap(tape int, text string, cableDiameter float64) *Layout {
+	circumference := math.Pi * cableDiameter
+	repeats := int(circumference / 25)
+	if repeats < 1 {
+		repeats = 1
+	}
+	segment := circumference / float64(repeats)
+
+	l := &Layout{
+		Tape:   tape,
+		Length: circumference * 2,
+	}
+	for i := 0; i < repeats; i++ {
+		l.Elements = append(l.Elements, flagText(text, float64(i)*segment, segment))
+	}
+	return l
+}
+
+// FolderTab returns a file folder tab label of length mm with centered text
+func FolderTab(tape int, text string, length float64) *Layout {
+	return &Layout{
+		Tape:     tape,
+		Length:   length,
+		Elements: []Element{flagText(text, 0, length)},
+	}
+}
+
+func flagText(text string, x, width float64) Element {
+	return Element{
+		Type:  TypeText,
+		Text:  text,
+		X:     x + presetPadding,
+		Width: width - presetPadding*2,
+		Align: "center",
+	}
+}
+
+// Text returns a text label, length 0 fits the label to the text
+// otherwise the text is centered on a label of length mm
+func Text(tape int, text string, length float64) *Layout {
+	return &Layout{
+		Tape:     tape,
+		Length:   length,
+		Align:    "center",
+		Margin:   presetPadding,
+		Elements: []Element{{Type: TypeText, Text: text, X: presetPadding}},
+	}
+}
+
+// QR returns a QR code label with optional text next to it, length works like Text
+func QR(tape int, data, text string, length float64) *Layout {
+	var l *Layout
+	if text == "" {
+		l = &Layout{
+			Tape:     tape,
+			Margin:   presetPadding,
+			Elements: []Element{{Type: TypeQR, Data: data}},
+		}
+	} else {
+		l = qrWithText(tape, data, text)
+	}
+	l.Length = length
+	l.Align = "center"
+	return l
+}
+
+// Barcode returns a 1D barcode label of symbology like "code128",
+// text is printed under the bars when given, length works like Text
+func Barcode(tape int, symbology, data, text string, length float64) *Layout {
+	return &Layout{
+		Tape:     tape,
+		Length:   length,
+		Align:    "center",
+		Margin:   presetPadding,
+		Elements: []Element{{Type: TypeBarcode, Symbology: symbology, Data: data, Text: text, ShowText: text != ""}},
+	}
+}
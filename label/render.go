@@ -0,0 +1,300 @@
+package label
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"os"
+	"path/filepath"
+
+	"github.com/disintegration/imaging"
+	"github.com/ka2n/ptouchgo"
+)
+
+// Render draws the layout bound with data into an image covering the whole print head,
+// the result can be passed to ptouchgo.LoadRawImage or Serial.PrintImage.
+//
+// Besides keys of data these fields are available, format is optional:
+//
+//	{date:2006-01-02}  current date, Go time layout
+//	{time:15:04}       current time, Go time layout
+//	{seq:0000}         next value of the layout counter, zero padded to the number of zeros or to a width like {seq:4}
+//
+// Render shows the next counter value without advancing the counter, labels to print take
+// the values of Layout.ReserveSequence with RenderSequence like Print does.
+func Render(l *Layout, data map[string]string) (image.Image, error) {
+	seq, err := l.nextSequence()
+	if err != nil {
+		return nil, err
+	}
+	return l.render(data, seq)
+}
+
+// RenderSequence is Render with {seq} taking the value seq, like one reserved by Layout.ReserveSequence
+func RenderSequence(l *Layout, data map[string]string, seq int) (image.Image, error) {
+	return l.render(data, seq)
+}
+
+// RenderRows renders one label for each of rows, {seq} counts up row by row from the next counter value.
+// The counter is not advanced, see RenderRowsSequence.
+func RenderRows(l *Layout, rows []map[string]string) ([]image.Image, error) {
+	first, err := l.nextSequence()
+	if err != nil {
+		return nil, err
+	}
+	return RenderRowsSequence(l, rows, first)
+}
+
+// RenderRowsSequence is RenderRows with {seq} counting up from first, like the values of Layout.ReserveSequence for the rows
+func RenderRowsSequence(l *Layout, rows []map[string]string, first int) ([]image.Image, error) {
+	imgs := make([]image.Image, len(rows))
+	for i, data := range rows {
+		img, err := l.render(data, first+i)
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %w", i+1, err)
+		}
+		imgs[i] = img
+	}
+	return imgs, nil
+}
+
+// render is Render with {seq} taking the value seq
+func (l *Layout) render(data map[string]string, seq int) (image.Image, error) {
+	tw := ptouchgo.TapeWidth(l.Tape)
+	if !tw.Valid() || tw.PrintableDots() == 0 {
+		return nil, fmt.Errorf("unsupported tape width: %d", l.Tape)
+	}
+	inset := l.Border.inset()
+	height := tw.PrintableDots() - inset*2
+	if height <= 0 {
+		return nil, fmt.Errorf("border does not fit on %s tape", tw)
+	}
+
+	fields := l.fields(data, seq)
+
+	type placed struct {
+		mask *image.Alpha
+		at   image.Point
+	}
+	var items []placed
+	var contentEnd int
+
+	for i, e := range l.Elements {
+		box := elementBox(e, height)
+		var mask *image.Alpha
+		var err error
+		if e.Inverse {
+			mask, err = l.renderInverse(e, fields, box)
+		} else {
+			mask, err = l.renderElement(e, fields, box)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("element %d (%s): %w", i, e.Type, err)
+		}
+
+		size := mask.Bounds().Size()
+		at := box.Min
+		if box.Dy() > size.Y {
+			at.Y += (box.Dy() - size.Y) / 2
+		}
+		if box.Dx() > size.X {
+			switch e.Align {
+			case "center":
+				at.X += (box.Dx() - size.X) / 2
+			case "right":
+				at.X += box.Dx() - size.X
+			}
+		}
+
+		end := at.X + size.X
+		if box.Max.X > end {
+			end = box.Max.X
+		}
+		if end > contentEnd {
+			contentEnd = end
+		}
+		items = append(items, placed{mask: mask, at: at})
+	}
+
+	length := ptouchgo.MMToDots(l.Length)
+	var shift int
+	if length == 0 {
+		length = contentEnd + inset*2 + ptouchgo.MMToDots(l.Margin)
+	} else {
+		free := length - inset*2 - contentEnd
+		if free < 0 {
+			return nil, fmt.Errorf("content needs %.1fmm, label length is %.1fmm", dotsToMM(contentEnd+inset*2), l.Length)
+		}
+		switch l.Align {
+		case "center":
+			shift = free / 2
+		case "right":
+			shift = free
+		}
+	}
+	if length <= 0 {
+		return nil, fmt.Errorf("label has no content")
+	}
+
+	canvas := image.NewGray(image.Rect(0, 0, length, ptouchgo.HeadPins))
+	draw.Draw(canvas, canvas.Bounds(), image.White, image.Point{}, draw.Src)
+
+	// clip everything to the printable area of the tape
+	printable := canvas.SubImage(image.Rect(0, tw.Margin(), length, tw.Margin()+tw.PrintableDots())).(*image.Gray)
+	if l.Border != nil {
+		frame, err := l.Border.render(length, tw.PrintableDots())
+		if err != nil {
+			return nil, err
+		}
+		draw.DrawMask(printable, printable.Bounds(), image.Black, image.Point{}, frame, image.Point{}, draw.Over)
+	}
+	for _, item := range items {
+		r := item.mask.Bounds().Add(item.at).Add(image.Pt(inset+shift, tw.Margin()+inset))
+		draw.DrawMask(printable, r, image.Black, image.Point{}, item.mask, image.Point{}, draw.Over)
+	}
+	return canvas, nil
+}
+
+// elementBox returns the element area in dots inside the printable area,
+// missing height extends to the bottom of the tape
+func elementBox(e Element, height int) image.Rectangle {
+	x := ptouchgo.MMToDots(e.X)
+	y := ptouchgo.MMToDots(e.Y)
+	h := ptouchgo.MMToDots(e.Height)
+	if h == 0 || y+h > height {
+		h = height - y
+	}
+	return image.Rect(x, y, x+ptouchgo.MMToDots(e.Width), y+h)
+}
+
+func (l *Layout) renderElement(e Element, fields fieldFunc, box image.Rectangle) (*image.Alpha, error) {
+	if box.Dy() <= 0 {
+		return nil, fmt.Errorf("element is outside of the tape")
+	}
+
+	switch e.Type {
+	case TypeText:
+		text, err := expand(e.Text, fields)
+		if err != nil {
+			return nil, err
+		}
+		face, err := loadFonts(e.Font, e.Fallback)
+		if err != nil {
+			return nil, err
+		}
+		return renderText(text, face, e.Size, box.Dy(), box.Dx(), e.Align)
+	case TypeImage:
+		src, err := expand(e.Source, fields)
+		if err != nil {
+			return nil, err
+		}
+		return l.renderImage(src, box)
+	case TypeQR:
+		content, err := expand(e.Data, fields)
+		if err != nil {
+			return nil, err
+		}
+		return renderQR(content, box)
+	case TypeBarcode:
+		content, err := expand(e.Data, fields)
+		if err != nil {
+			return nil, err
+		}
+		if e.Text, err = expand(e.Text, fields); err != nil {
+			return nil, err
+		}
+		return renderBarcode(e, content, box)
+	case TypeRuler:
+		return renderRuler(e, box)
+	default:
+		return nil, fmt.Errorf("unknown element type: %q", e.Type)
+	}
+}
+
+// renderInverse draws a filled band over the whole element box with the content knocked out
+func (l *Layout) renderInverse(e Element, fields fieldFunc, box image.Rectangle) (*image.Alpha, error) {
+	vpad := box.Dy() / 10
+	if vpad < 1 {
+		vpad = 1
+	}
+	hpad := box.Dy() / 4
+	inner := image.Rect(box.Min.X+hpad, box.Min.Y+vpad, box.Max.X-hpad, box.Max.Y-vpad)
+	if box.Dx() == 0 {
+		inner.Max.X = inner.Min.X
+	}
+	content, err := l.renderElement(e, fields, inner)
+	if err != nil {
+		return nil, err
+	}
+
+	size := content.Bounds().Size()
+	width := size.X + hpad*2
+	if box.Dx() > width {
+		width = box.Dx()
+	}
+	band := image.NewAlpha(image.Rect(0, 0, width, box.Dy()))
+	draw.Draw(band, band.Bounds(), image.Opaque, image.Point{}, draw.Src)
+
+	at := image.Pt(hpad, (box.Dy()-size.Y)/2)
+	switch e.Align {
+	case "center":
+		at.X = (width - size.X) / 2
+	case "right":
+		at.X = width - hpad - size.X
+	}
+	for y := 0; y < size.Y; y++ {
+		for x := 0; x < size.X; x++ {
+			a := content.AlphaAt(x, y).A
+			band.SetAlpha(at.X+x, at.Y+y, color.Alpha{A: 0xff - a})
+		}
+	}
+	return band, nil
+}
+
+func (l *Layout) renderImage(src string, box image.Rectangle) (*image.Alpha, error) {
+	if src == "" {
+		return nil, fmt.Errorf("image source required")
+	}
+	if !filepath.IsAbs(src) && l.dir != "" {
+		src = filepath.Join(l.dir, src)
+	}
+
+	f, err := os.Open(src)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	img, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("decode %s: %w", src, err)
+	}
+
+	if box.Dx() > 0 {
+		img = imaging.Fit(img, box.Dx(), box.Dy(), imaging.Lanczos)
+	} else {
+		img = imaging.Resize(img, 0, box.Dy(), imaging.Lanczos)
+	}
+	return inkMask(img), nil
+}
+
+// inkMask converts an image into ink coverage, dark and opaque pixels become ink
+func inkMask(img image.Image) *image.Alpha {
+	b := img.Bounds()
+	m := image.NewAlpha(image.Rect(0, 0, b.Dx(), b.Dy()))
+	for y := 0; y < b.Dy(); y++ {
+		for x := 0; x < b.Dx(); x++ {
+			r, g, bl, a := img.At(b.Min.X+x, b.Min.Y+y).RGBA()
+			lum := (55*r + 182*g + 18*bl) / (55 + 182 + 18)
+			if lum > a {
+				lum = a
+			}
+			m.SetAlpha(x, y, color.Alpha{A: uint8((a - lum) >> 8)})
+		}
+	}
+	return m
+}
@@ -0,0 +1,98 @@
+package label
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"math"
+	"strconv"
+
+	"github.com/ka2n/ptouchgo"
+)
+
+// Ruler units
+const (
+	UnitMM   = "mm"
+	UnitInch = "inch"
+)
+
+// Ruler returns a measuring scale of length mm, ticks start at the beginning of the label
+// so the printed distances can be used to check DPI and feed accuracy
+func Ruler(tape int, length float64, unit string) *Layout {
+	return &Layout{
+		Tape:   tape,
+		Length: length,
+		Elements: []Element{
+			{Type: TypeRuler, Width: length, Unit: unit},
+		},
+	}
+}
+
+type rulerTick struct {
+	step   float64 // mm
+	height float64 // ratio of the element height
+	number bool
+}
+
+// renderRuler draws ticks along the top edge of box, every tick position is rounded on its own
+// so errors do not accumulate over the length
+func renderRuler(e Element, box image.Rectangle) (*image.Alpha, error) {
+	if box.Dx() <= 0 {
+		return nil, fmt.Errorf("ruler width required")
+	}
+
+	var ticks []rulerTick
+	var numberStep float64
+	switch e.Unit {
+	case "", UnitMM:
+		ticks = []rulerTick{{1, 0.2, false}, {5, 0.35, false}, {10, 0.5, true}}
+		numberStep = 10
+	case UnitInch:
+		ticks = []rulerTick{{25.4 / 16, 0.15, false}, {25.4 / 8, 0.25, false}, {25.4 / 4, 0.35, false}, {25.4 / 2, 0.45, false}, {25.4, 0.6, true}}
+		numberStep = 25.4
+	default:
+		return nil, fmt.Errorf("unknown ruler unit: %q", e.Unit)
+	}
+
+	fs, err := loadFonts(e.Font, e.Fallback)
+	if err != nil {
+		return nil, err
+	}
+
+	m := image.NewAlpha(image.Rect(0, 0, box.Dx(), box.Dy()))
+	length := float64(box.Dx()) * 25.4 / ptouchgo.DPI
+	finest := ticks[0].step
+	for i := 0; float64(i)*finest <= length+1e-9; i++ {
+		pos := float64(i) * finest
+
+		// the longest matching tick wins
+		var tick rulerTick
+		for _, t := range ticks {
+			if n := pos / t.step; math.Abs(n-math.Round(n)) < 1e-6 {
+				tick = t
+			}
+		}
+
+		x := ptouchgo.MMToDots(pos)
+		if x >= box.Dx() {
+			x = box.Dx() - 1
+		}
+		h := int(float64(box.Dy()) * tick.height)
+		draw.Draw(m, image.Rect(x, 0, x+1, h), image.Opaque, image.Point{}, draw.Src)
+
+		if tick.number && i > 0 {
+			label := strconv.Itoa(int(math.Round(pos / numberStep)))
+			text, err := renderText(label, fs, e.Size, (box.Dy()-h)*2/3, 0, "")
+			if err != nil {
+				return nil, err
+			}
+			size := text.Bounds().Size()
+			at := image.Pt(x-size.X/2, h)
+			if at.X+size.X > box.Dx() {
+				at.X = box.Dx() - size.X
+			}
+			draw.Draw(m, image.Rectangle{Min: at, Max: at.Add(size)}, text, image.Point{}, draw.Over)
+		}
+	}
+	return m, nil
+}
@@ -0,0 +1,179 @@
+package label
+
+import (
+	"fmt"
+	"image"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/ka2n/ptouchgo"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+var (
+	fontsMu sync.Mutex
+	fonts   = make(map[string]*opentype.Font)
+)
+
+// loadFont parses the font file at path once, empty path returns the bundled Go font.
+// A "#n" suffix selects a font of a collection.
+func loadFont(path string) (*opentype.Font, error) {
+	fontsMu.Lock()
+	defer fontsMu.Unlock()
+	if f, ok := fonts[path]; ok {
+		return f, nil
+	}
+
+	var b []byte
+	var index int
+	switch {
+	case path == "":
+		b = goregular.TTF
+	case strings.HasPrefix(path, bundledPrefix):
+		var ok bool
+		b, ok = bundledFont(strings.TrimPrefix(path, bundledPrefix))
+		if !ok {
+			return nil, fmt.Errorf("unknown bundled font: %s", path)
+		}
+	default:
+		file := fontFilePath(path)
+		if file != path {
+			index, _ = strconv.Atoi(path[len(file)+1:])
+		}
+		var err error
+		b, err = ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	c, err := opentype.ParseCollection(b)
+	if err != nil {
+		return nil, fmt.Errorf("parse font %s: %w", path, err)
+	}
+	f, err := c.Font(index)
+	if err != nil {
+		return nil, fmt.Errorf("parse font %s: %w", path, err)
+	}
+	fonts[path] = f
+	return f, nil
+}
+
+func newFace(fs fontSet, size float64) (font.Face, error) {
+	faces := make([]font.Face, len(fs))
+	for i, f := range fs {
+		face, err := opentype.NewFace(f, &opentype.FaceOptions{
+			Size:    size,
+			DPI:     ptouchgo.DPI,
+			Hinting: font.HintingFull,
+		})
+		if err != nil {
+			return nil, err
+		}
+		faces[i] = face
+	}
+	if len(faces) == 1 {
+		return faces[0], nil
+	}
+	return &fallbackFace{fonts: fs, faces: faces}, nil
+}
+
+// fitSize returns the font size which lines of text fit in height dots
+func fitSize(f fontSet, lines, height int) (float64, error) {
+	perLine := float64(height) / float64(lines)
+	size := perLine * 72 / ptouchgo.DPI
+	face, err := newFace(f, size)
+	if err != nil {
+		return 0, err
+	}
+	defer face.Close()
+	lineHeight := face.Metrics().Height.Ceil()
+	if lineHeight == 0 {
+		return size, nil
+	}
+	return size * perLine / float64(lineHeight), nil
+}
+
+func measureLines(face font.Face, lines []string) ([]int, int) {
+	widths := make([]int, len(lines))
+	var width int
+	for i, line := range lines {
+		widths[i] = font.MeasureString(face, line).Ceil()
+		if widths[i] > width {
+			width = widths[i]
+		}
+	}
+	return widths, width
+}
+
+// renderText draws text, lines are separated by "\n" and aligned to each other,
+// right to left lines are reordered for display and right aligned unless align is given
+// When size is 0 the text is scaled to fit in height and, if given, maxWidth dots
+func renderText(text string, f fontSet, size float64, height, maxWidth int, align string) (*image.Alpha, error) {
+	if text == "" {
+		return nil, fmt.Errorf("text is empty")
+	}
+	lines := strings.Split(stripEmojiModifiers(text), "\n")
+	rtl := make([]bool, len(lines))
+	for i, line := range lines {
+		lines[i], rtl[i] = visualLine(line)
+	}
+
+	fit := size == 0
+	if fit {
+		var err error
+		size, err = fitSize(f, len(lines), height)
+		if err != nil {
+			return nil, err
+		}
+	}
+	face, err := newFace(f, size)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { face.Close() }()
+
+	widths, width := measureLines(face, lines)
+	if width == 0 {
+		return nil, fmt.Errorf("text has no visible glyphs")
+	}
+	if fit && maxWidth > 0 && width > maxWidth {
+		face.Close()
+		face, err = newFace(f, size*float64(maxWidth)/float64(width))
+		if err != nil {
+			return nil, err
+		}
+		widths, width = measureLines(face, lines)
+	}
+
+	metrics := face.Metrics()
+	lineHeight := metrics.Height.Ceil()
+
+	dst := image.NewAlpha(image.Rect(0, 0, width, lineHeight*len(lines)))
+	d := font.Drawer{
+		Dst:  dst,
+		Src:  image.Opaque,
+		Face: face,
+	}
+	for i, line := range lines {
+		var x int
+		lineAlign := align
+		if lineAlign == "" && rtl[i] {
+			lineAlign = "right"
+		}
+		switch lineAlign {
+		case "center":
+			x = (width - widths[i]) / 2
+		case "right":
+			x = width - widths[i]
+		}
+		d.Dot = fixed.P(x, i*lineHeight+metrics.Ascent.Ceil())
+		d.DrawString(line)
+	}
+	return dst, nil
+}
@@ -0,0 +1,72 @@
+package label
+
+import (
+	"strings"
+
+	"github.com/ka2n/ptouchgo"
+)
+
+// WiFi security types for WiFiPayload
+const (
+	WiFiWPA    = "WPA"
+	WiFiWEP    = "WEP"
+	WiFiNoPass = "nopass"
+)
+
+var wifiEscaper = strings.NewReplacer(`\`, `\\`, `;`, `\;`, `,`, `\,`, `:`, `\:`, `"`, `\"`)
+
+// WiFiPayload builds the "WIFI:" QR code payload understood by phone cameras,
+// empty security means WPA with a password and no encryption without one
+func WiFiPayload(ssid, password, security string, hidden bool) string {
+	if security == "" {
+		security = WiFiWPA
+		if password == "" {
+			security = WiFiNoPass
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString("WIFI:T:")
+	b.WriteString(security)
+	b.WriteString(";S:")
+	b.WriteString(wifiEscaper.Replace(ssid))
+	if security != WiFiNoPass {
+		b.WriteString(";P:")
+		b.WriteString(wifiEscaper.Replace(password))
+	}
+	if hidden {
+		b.WriteString(";H:true")
+	}
+	b.WriteString(";;")
+	return b.String()
+}
+
+// WiFi returns a layout with a QR code joining the network followed by the SSID
+func WiFi(tape int, ssid, password, security string, hidden bool) *Layout {
+	return qrWithText(tape, escapeFields(WiFiPayload(ssid, password, security, hidden)), escapeFields(ssid))
+}
+
+// qrWithText places a QR code as high as the tape and text next to it,
+// placeholders in payload and text are expanded
+func qrWithText(tape int, payload, text string) *Layout {
+	side := dotsToMM(ptouchgo.TapeWidth(tape).PrintableDots())
+	return &Layout{
+		Tape:   tape,
+		Margin: presetPadding,
+		Elements: []Element{
+			{Type: TypeQR, Data: payload, Width: side},
+			{Type: TypeText, Text: text, X: side + presetPadding},
+		},
+	}
+}
+
+func dotsToMM(dots int) float64 {
+	return float64(dots) * 25.4 / ptouchgo.DPI
+}
+
+var fieldEscaper = strings.NewReplacer("{", "{{", "}", "}}")
+
+// escapeFields protects literal braces from placeholder expansion
+func escapeFields(s string) string {
+	return fieldEscaper.Replace(s)
+}
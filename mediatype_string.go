@@ -6,25 +6,32 @@ import "strconv"
 
 const (
 	_MediaType_name_0 = "No tapeLaminated"
-	_MediaType_name_1 = "Non laminated"
-	_MediaType_name_2 = "Heat shrink tube"
-	_MediaType_name_3 = "Invalid tape type"
+	_MediaType_name_1 = "Non laminatedFabric"
+	_MediaType_name_2 = "Continuous length tapeDie-cut labels"
+	_MediaType_name_3 = "Heat shrink tube"
+	_MediaType_name_4 = "Invalid tape type"
 )
 
 var (
 	_MediaType_index_0 = [...]uint8{0, 7, 16}
+	_MediaType_index_1 = [...]uint8{0, 13, 19}
+	_MediaType_index_2 = [...]uint8{0, 22, 36}
 )
 
 func (i MediaType) String() string {
 	switch {
 	case 0 <= i && i <= 1:
 		return _MediaType_name_0[_MediaType_index_0[i]:_MediaType_index_0[i+1]]
-	case i == 3:
-		return _MediaType_name_1
+	case 3 <= i && i <= 4:
+		i -= 3
+		return _MediaType_name_1[_MediaType_index_1[i]:_MediaType_index_1[i+1]]
+	case 10 <= i && i <= 11:
+		i -= 10
+		return _MediaType_name_2[_MediaType_index_2[i]:_MediaType_index_2[i+1]]
 	case i == 17:
-		return _MediaType_name_2
-	case i == 255:
 		return _MediaType_name_3
+	case i == 255:
+		return _MediaType_name_4
 	default:
 		return "MediaType(" + strconv.FormatInt(int64(i), 10) + ")"
 	}
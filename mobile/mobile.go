@@ -0,0 +1,69 @@
+//go:build linux
+// +build linux
+
+// Package mobile exposes printing for gomobile bindings, build an Android library with
+//
+//	gomobile bind -target android github.com/ka2n/ptouchgo/mobile
+//
+// The app asks UsbManager for permission, opens the printer with UsbManager.openDevice
+// and passes UsbDeviceConnection.getFileDescriptor to OpenUSB. The connection has to
+// stay open on the Java side until the Printer is closed.
+package mobile
+
+import (
+	"bytes"
+	"fmt"
+	"image/png"
+
+	"github.com/ka2n/ptouchgo"
+	"github.com/ka2n/ptouchgo/conn"
+)
+
+// Printer is a printer attached through USB host mode
+type Printer struct {
+	ser ptouchgo.Serial
+}
+
+// OpenUSB uses the usbfs file descriptor fd of an opened printer loaded with tapeWidthMM tape
+func OpenUSB(fd int, tapeWidthMM int) (*Printer, error) {
+	c, err := conn.OpenUSBFD(fd, conn.DefaultTimeouts)
+	if err != nil {
+		return nil, err
+	}
+	ser := ptouchgo.Serial{
+		Conn:         c,
+		TapeWidthMM:  uint(tapeWidthMM),
+		Capabilities: conn.Capabilities{StatusReadback: true, FullDuplex: true},
+	}
+	return &Printer{ser: ser}, nil
+}
+
+// TapeWidth reads the status of the printer and returns the width of the loaded tape in mm,
+// printing adapts to the model read with it
+func (p *Printer) TapeWidth() (int, error) {
+	if err := p.ser.RequestStatus(); err != nil {
+		return 0, err
+	}
+	st, err := p.ser.ReadStatus()
+	if err != nil {
+		return 0, err
+	}
+	if st.Model.Supported() {
+		p.ser.Model = st.Model
+	}
+	return int(st.TapeWidth), nil
+}
+
+// PrintPNG prints a PNG image with one side matching the print head as one label with autocut
+func (p *Printer) PrintPNG(data []byte) error {
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("load image: %w", err)
+	}
+	return p.ser.PrintImage(img, ptouchgo.DefaultPrintOptions())
+}
+
+// Close releases the printer, the file descriptor is closed by the app
+func (p *Printer) Close() error {
+	return p.ser.Close()
+}
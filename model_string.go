@@ -5,16 +5,50 @@ package ptouchgo
 import "strconv"
 
 const (
-	_Model_name_0 = "PT-P750W"
-	_Model_name_1 = "PT-P710BT"
+	_Model_name_0 = "QL-700"
+	_Model_name_1 = "QL-800"
+	_Model_name_2 = "TD-2020TD-2120NTD-2130N"
+	_Model_name_3 = "QL-820NWB"
+	_Model_name_4 = "QL-1100QL-1110NWB"
+	_Model_name_5 = "PT-H500PT-E550W"
+	_Model_name_6 = "PT-P700PT-P750WPT-P900W"
+	_Model_name_7 = "PT-P300BTPT-P950NW"
+	_Model_name_8 = "PT-P710BT"
+)
+
+var (
+	_Model_index_2 = [...]uint8{0, 7, 15, 23}
+	_Model_index_4 = [...]uint8{0, 7, 17}
+	_Model_index_5 = [...]uint8{0, 7, 15}
+	_Model_index_6 = [...]uint8{0, 7, 15, 23}
+	_Model_index_7 = [...]uint8{0, 9, 18}
 )
 
 func (i Model) String() string {
 	switch {
-	case i == 104:
+	case i == 53:
 		return _Model_name_0
-	case i == 118:
+	case i == 56:
 		return _Model_name_1
+	case 58 <= i && i <= 60:
+		i -= 58
+		return _Model_name_2[_Model_index_2[i]:_Model_index_2[i+1]]
+	case i == 65:
+		return _Model_name_3
+	case 67 <= i && i <= 68:
+		i -= 67
+		return _Model_name_4[_Model_index_4[i]:_Model_index_4[i+1]]
+	case 100 <= i && i <= 101:
+		i -= 100
+		return _Model_name_5[_Model_index_5[i]:_Model_index_5[i+1]]
+	case 103 <= i && i <= 105:
+		i -= 103
+		return _Model_name_6[_Model_index_6[i]:_Model_index_6[i+1]]
+	case 111 <= i && i <= 112:
+		i -= 111
+		return _Model_name_7[_Model_index_7[i]:_Model_index_7[i+1]]
+	case i == 118:
+		return _Model_name_8
 	default:
 		return "Model(" + strconv.FormatInt(int64(i), 10) + ")"
 	}
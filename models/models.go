@@ -0,0 +1,213 @@
+// Package models describes the label printers of ptouchgo, what they print on and which
+// settings they take, by the model code of their status and their USB product ID
+package models
+
+import "strings"
+
+// Family is the raster protocol a model speaks
+type Family int
+
+const (
+	// PT are the P-touch tape printers taking compressed raster lines
+	PT Family = iota
+	// QL are the QL label printers taking uncompressed raster lines on QL media
+	QL
+	// TD are the TD portable printers speaking the protocol of QL printers on RD rolls
+	TD
+)
+
+// FeatureHighDPI is the feature name of printing at the doubled resolution along the tape
+const FeatureHighDPI = "high resolution printing"
+
+// Firmware is a feature missing or broken on firmware older than Version
+type Firmware struct {
+	Feature string // like FeatureHighDPI
+	Version string // like "1.04"
+}
+
+// Tape is a tape or media width a model prints on
+type Tape struct {
+	WidthMM int // as reported by the printer, the 3.5mm tape is reported as 4
+	Dots    int // printable dots across the tape
+}
+
+// Spec is what a model prints and which settings it takes
+type Spec struct {
+	Code      byte   // model code of the status
+	Name      string // like "PT-P710BT"
+	ProductID uint16 // USB product ID of the Brother vendor ID, zero without USB
+	Family    Family
+
+	HeadPins   int    // dots of a raster line
+	DPI        int    // across the tape, HighDPI doubles the raster lines along it
+	Tapes      []Tape // narrowest first, the continuous length tape of QL printers
+	Tubes      []Tape // heat shrink tubes by the width they are reported as, nil without them
+	MaxFeed    int    // largest feed amount in raster lines, zero for a fixed margin
+	Margin     int    // raster lines of the fixed margin around labels on continuous length tape
+	Invalidate int    // bytes of the invalidate command clearing a partial command
+
+	// media types of the print information of QL printers
+	ContinuousMedia byte
+	DieCutMedia     byte
+
+	AutoCut       bool // cuts after labels
+	HalfCut       bool // cuts through the tape leaving the backing paper
+	CutEvery      bool // cuts after several labels, not only each one
+	CutAtEnd      bool // has the cut at end setting of QL printers
+	HighDPI       bool // doubles the resolution along the tape
+	TwoColor      bool // black and red media
+	PowerSettings bool // takes the auto power-off and battery level commands
+	WLAN          bool // prints on a wireless LAN, its settings are read over USB
+
+	MinFirmware []Firmware // features needing newer firmware than the first releases
+}
+
+// LineBytes returns the bytes of a raster line of s
+func (s Spec) LineBytes() int {
+	return s.HeadPins / 8
+}
+
+// Tape returns the tape of s widthMM wide
+func (s Spec) Tape(widthMM int) (Tape, bool) {
+	for _, t := range s.Tapes {
+		if t.WidthMM == widthMM {
+			return t, true
+		}
+	}
+	return Tape{}, false
+}
+
+// Tube returns the heat shrink tube of s reported as widthMM wide
+func (s Spec) Tube(widthMM int) (Tape, bool) {
+	for _, t := range s.Tubes {
+		if t.WidthMM == widthMM {
+			return t, true
+		}
+	}
+	return Tape{}, false
+}
+
+// MaxTapeWidth returns the width of the widest tape of s in mm
+func (s Spec) MaxTapeWidth() int {
+	if len(s.Tapes) == 0 {
+		return 0
+	}
+	return s.Tapes[len(s.Tapes)-1].WidthMM
+}
+
+var (
+	// tapes180 are the tapes of the 128 pin 180 dpi head of the PT-P700 series
+	tapes180 = []Tape{{4, 24}, {6, 32}, {9, 50}, {12, 70}, {18, 112}, {24, 128}}
+	// tapes360 are the tapes of the 560 pin 360 dpi head of the PT-P900 series, up to 36mm
+	tapes360 = []Tape{{4, 48}, {6, 64}, {9, 100}, {12, 140}, {18, 224}, {24, 256}, {36, 454}}
+	// tubes180 are the 5.8 to 23.6mm heat shrink tubes of the 128 pin head, reported as 6 to 24mm
+	tubes180 = []Tape{{6, 28}, {9, 48}, {12, 66}, {18, 106}, {24, 128}}
+	// tubes360 are the heat shrink tubes of the 560 pin head
+	tubes360 = []Tape{{6, 56}, {9, 96}, {12, 132}, {18, 212}, {24, 256}}
+	// tapesQL are the continuous length tapes of the 720 pin 300 dpi head of QL printers
+	tapesQL = []Tape{{12, 106}, {29, 306}, {38, 413}, {50, 554}, {54, 590}, {62, 696}}
+	// tapesQLWide are the continuous length tapes of the 1296 pin head of the QL-1100 series
+	tapesQLWide = append(append([]Tape(nil), tapesQL...), Tape{102, 1164})
+	// tapesTD are the receipt paper rolls of the 448 pin 203 dpi head of the TD series
+	tapesTD = []Tape{{40, 296}, {51, 384}, {58, 432}}
+)
+
+// pt returns a PT model with the head of the PT-P700 series
+func pt(code byte, name string, productID uint16) Spec {
+	return Spec{
+		Code: code, Name: name, ProductID: productID, Family: PT,
+		HeadPins: 128, DPI: 180, Tapes: tapes180, MaxFeed: 0xffff, Invalidate: 100,
+		AutoCut: true, CutEvery: true, HighDPI: true,
+	}
+}
+
+// ql returns a QL model
+func ql(code byte, name string, productID uint16) Spec {
+	return Spec{
+		Code: code, Name: name, ProductID: productID, Family: QL,
+		HeadPins: 720, DPI: 300, Tapes: tapesQL, Margin: 35, Invalidate: 200,
+		ContinuousMedia: 0x0a, DieCutMedia: 0x0b,
+		AutoCut: true, CutEvery: true, HighDPI: true,
+	}
+}
+
+// td returns a model of the TD series, it tears labels off without a cutter
+func td(code byte, name string, productID uint16) Spec {
+	return Spec{
+		Code: code, Name: name, ProductID: productID, Family: TD,
+		HeadPins: 448, DPI: 203, Tapes: tapesTD, Margin: 24, Invalidate: 200,
+		ContinuousMedia: 0x0a, DieCutMedia: 0x0b,
+	}
+}
+
+// specs are the known models, USB printers are looked for in this order when no product ID is given
+var specs = func() []Spec {
+	p750w := pt(0x68, "PT-P750W", 0x2062)
+	p750w.HalfCut, p750w.Tubes, p750w.WLAN = true, tubes180, true
+	p710bt := pt(0x76, "PT-P710BT", 0x20af)
+	p710bt.PowerSettings = true
+	p710bt.MinFirmware = []Firmware{{FeatureHighDPI, "1.04"}} // earlier releases print high resolution labels garbled
+	p300bt := pt(0x6f, "PT-P300BT", 0)
+	p300bt.Tapes = tapes180[:4]
+	p300bt.CutEvery, p300bt.HighDPI = false, false
+	e550w := pt(0x65, "PT-E550W", 0x2060)
+	e550w.HalfCut, e550w.Tubes, e550w.WLAN = true, tubes180, true
+	p900w := pt(0x69, "PT-P900W", 0x2085)
+	p900w.HeadPins, p900w.DPI, p900w.Tapes, p900w.Tubes, p900w.HalfCut = 560, 360, tapes360, tubes360, true
+	p900w.WLAN = true
+	p950nw := p900w
+	p950nw.Code, p950nw.Name, p950nw.ProductID = 0x70, "PT-P950NW", 0x2086
+	ql700 := ql(0x35, "QL-700", 0x2042)
+	ql700.CutAtEnd = true
+	ql800 := ql(0x38, "QL-800", 0x209b)
+	ql800.TwoColor = true
+	ql820nwb := ql(0x41, "QL-820NWB", 0x209d)
+	ql820nwb.TwoColor, ql820nwb.CutAtEnd = true, true
+	ql1100 := ql(0x43, "QL-1100", 0x20a7)
+	ql1100.HeadPins, ql1100.Tapes, ql1100.CutAtEnd = 1296, tapesQLWide, true
+	ql1110nwb := ql1100
+	ql1110nwb.Code, ql1110nwb.Name, ql1110nwb.ProductID = 0x44, "QL-1110NWB", 0x20a8
+	td2020 := td(0x3a, "TD-2020", 0x2041)
+	td2120n := td(0x3b, "TD-2120N", 0x2043)
+	td2130n := td(0x3c, "TD-2130N", 0x2044)
+	td2130n.AutoCut = true // with the optional cutter
+	return []Spec{
+		p750w, pt(0x67, "PT-P700", 0x2061), pt(0x64, "PT-H500", 0x205e), p710bt, p300bt, e550w, p900w, p950nw,
+		ql700, ql800, ql820nwb, ql1100, ql1110nwb, td2020, td2120n, td2130n,
+	}
+}()
+
+// All returns the known models
+func All() []Spec {
+	return append([]Spec(nil), specs...)
+}
+
+// Lookup returns the model of the status model code
+func Lookup(code byte) (Spec, bool) {
+	for _, s := range specs {
+		if s.Code == code {
+			return s, true
+		}
+	}
+	return Spec{}, false
+}
+
+// ByProductID returns the model of the USB product ID
+func ByProductID(productID uint16) (Spec, bool) {
+	for _, s := range specs {
+		if productID != 0 && s.ProductID == productID {
+			return s, true
+		}
+	}
+	return Spec{}, false
+}
+
+// ByName returns the model named like "PT-P710BT", case is ignored
+func ByName(name string) (Spec, bool) {
+	for _, s := range specs {
+		if strings.EqualFold(s.Name, name) {
+			return s, true
+		}
+	}
+	return Spec{}, false
+}
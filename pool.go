@@ -0,0 +1,233 @@
+package ptouchgo
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"log"
+	"sync"
+)
+
+// ErrNoPrinter is returned by Pool when no printer satisfies the label requirements
+var ErrNoPrinter = errors.New("no printer matches the label requirements")
+
+// Requirements select the printers of a Pool a label can be printed on, zero values match any printer
+type Requirements struct {
+	TapeWidth TapeWidth
+	Model     Model
+}
+
+// Printer is a printer of a Pool, operations on one printer are serialized
+type Printer struct {
+	Name    string
+	Address string
+
+	sem       chan struct{} // held while the printer is in use
+	ser       Serial        // with the model and media of the last status, guarded by sem
+	unusable  error         // why the media of the last status can not be printed on, guarded by sem
+	tapeWidth uint          // given to Add
+	pending   int           // jobs running or waiting, guarded by Pool.mu
+
+	statusm sync.Mutex
+	status  *Status
+}
+
+// Status returns the last status read from the printer, nil when it never answered
+func (p *Printer) Status() *Status {
+	p.statusm.Lock()
+	defer p.statusm.Unlock()
+	return p.status
+}
+
+// TapeWidth returns the width of the loaded tape, the configured width when the status is unknown
+func (p *Printer) TapeWidth() TapeWidth {
+	if st := p.Status(); st != nil && st.TapeWidth != tapeWidthNone {
+		return st.TapeWidth
+	}
+	return TapeWidth(p.tapeWidth)
+}
+
+func (p *Printer) matches(req Requirements) bool {
+	if req.TapeWidth != tapeWidthNone && req.TapeWidth != p.TapeWidth() {
+		return false
+	}
+	if req.Model != 0 {
+		st := p.Status()
+		if st == nil || st.Model != req.Model {
+			return false
+		}
+	}
+	return true
+}
+
+// Do runs f with exclusive access to the printer
+func (p *Printer) Do(f func(s Serial) error) error {
+	p.sem <- struct{}{}
+	defer func() { <-p.sem }()
+	return f(p.ser)
+}
+
+// Refresh requests the status of the printer, the loaded tape is used for routing and the model and media
+// of the status for printing, see Serial.UseStatus
+func (p *Printer) Refresh() (*Status, error) {
+	var st *Status
+	err := p.Do(func(s Serial) error {
+		if err := s.RequestStatus(); err != nil {
+			return err
+		}
+		var err error
+		if st, err = s.ReadStatus(); err != nil {
+			return err
+		}
+		// the sem is held, p.ser is not in use
+		p.unusable = s.UseStatus(st)
+		p.ser = s
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", p.Name, err)
+	}
+	p.statusm.Lock()
+	p.status = st
+	p.statusm.Unlock()
+	return st, nil
+}
+
+// Pool manages several printers and routes jobs to a printer with matching tape
+type Pool struct {
+	Debug bool
+
+	mu       sync.Mutex
+	printers []*Printer
+}
+
+// NewPool returns an empty Pool
+func NewPool() *Pool {
+	return &Pool{}
+}
+
+// Add opens the printer at address like OpenReconnecting and reads its status when the transport
+// supports it. tapeWidthMM is used when the printer does not report the loaded tape.
+func (pool *Pool) Add(name, address string, tapeWidthMM uint) (*Printer, error) {
+	pool.mu.Lock()
+	for _, p := range pool.printers {
+		if p.Name == name {
+			pool.mu.Unlock()
+			return nil, fmt.Errorf("printer %q already added", name)
+		}
+	}
+	pool.mu.Unlock()
+
+	ser, err := OpenReconnecting(address, tapeWidthMM, pool.Debug)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	p := &Printer{Name: name, Address: address, ser: ser, tapeWidth: tapeWidthMM, sem: make(chan struct{}, 1)}
+	// statuses of transports without readback do not describe the loaded tape
+	if ser.Capabilities.StatusReadback {
+		if _, err := p.Refresh(); err != nil && pool.Debug {
+			log.Printf("%v, using %dmm tape\n", err, tapeWidthMM)
+		}
+	}
+
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	for _, other := range pool.printers {
+		if other.Name == name {
+			ser.Close()
+			return nil, fmt.Errorf("printer %q already added", name)
+		}
+	}
+	pool.printers = append(pool.printers, p)
+	return p, nil
+}
+
+// Remove closes the printer called name once its current job finished
+func (pool *Pool) Remove(name string) error {
+	pool.mu.Lock()
+	var p *Printer
+	for i, candidate := range pool.printers {
+		if candidate.Name == name {
+			p = candidate
+			pool.printers = append(pool.printers[:i], pool.printers[i+1:]...)
+			break
+		}
+	}
+	pool.mu.Unlock()
+	if p == nil {
+		return fmt.Errorf("printer %q not found", name)
+	}
+	return p.Do(func(s Serial) error {
+		return s.Close()
+	})
+}
+
+// Printers returns the printers of the pool in the order they were added
+func (pool *Pool) Printers() []*Printer {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+	return append([]*Printer(nil), pool.printers...)
+}
+
+// Acquire picks the least busy printer satisfying req and waits until it is free,
+// release has to be called when done with the printer
+func (pool *Pool) Acquire(req Requirements) (p *Printer, release func(), err error) {
+	pool.mu.Lock()
+	for _, candidate := range pool.printers {
+		if candidate.matches(req) && (p == nil || candidate.pending < p.pending) {
+			p = candidate
+		}
+	}
+	if p == nil {
+		pool.mu.Unlock()
+		return nil, nil, ErrNoPrinter
+	}
+	p.pending++
+	pool.mu.Unlock()
+
+	p.sem <- struct{}{}
+	release = func() {
+		<-p.sem
+		pool.mu.Lock()
+		p.pending--
+		pool.mu.Unlock()
+	}
+	return p, release, nil
+}
+
+// Print prints img on a printer satisfying req and returns the printer used.
+// Jobs for the same printer are printed one after another.
+func (pool *Pool) Print(req Requirements, img image.Image, opts PrintOptions) (*Printer, error) {
+	p, release, err := pool.Acquire(req)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	if p.unusable != nil {
+		return p, fmt.Errorf("%s: %w", p.Name, p.unusable)
+	}
+	if err := p.ser.PrintImage(img, opts); err != nil {
+		return p, fmt.Errorf("%s: %w", p.Name, err)
+	}
+	return p, nil
+}
+
+// Close closes every printer of the pool
+func (pool *Pool) Close() error {
+	pool.mu.Lock()
+	printers := pool.printers
+	pool.printers = nil
+	pool.mu.Unlock()
+
+	var firstErr error
+	for _, p := range printers {
+		err := p.Do(func(s Serial) error {
+			return s.Close()
+		})
+		if err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("%s: %w", p.Name, err)
+		}
+	}
+	return firstErr
+}
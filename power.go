@@ -0,0 +1,131 @@
+package ptouchgo
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"time"
+)
+
+// Printer setting commands of the PT-P710BT and the WLAN models, a setting is written as
+// ESC i U w 01 <setting> <value> and read as ESC i U r 01 <setting>,
+// answered by the setting and its value
+var (
+	cmdWriteSettingPrefix = []byte{0x1b, 0x69, 0x55, 0x77, 0x01}
+	cmdReadSettingPrefix  = []byte{0x1b, 0x69, 0x55, 0x72, 0x01}
+)
+
+const (
+	settingAutoPowerOffAC      byte = 0x41 // steps of AutoPowerOffStep, 0 never turns off
+	settingAutoPowerOffBattery byte = 0x42
+	settingBatteryLevel        byte = 0x43 // percent, read only
+
+	// batteryLevelAC is the battery level reported while running on the AC adapter without a battery
+	batteryLevelAC = 0xff
+)
+
+const (
+	// AutoPowerOffStep is the resolution of the auto power-off time
+	AutoPowerOffStep = 10 * time.Minute
+	// MaxAutoPowerOff is the longest auto power-off time
+	MaxAutoPowerOff = 60 * time.Minute
+)
+
+// ErrNoBattery is returned by BatteryLevel for printers running on the AC adapter without a battery
+var ErrNoBattery = errors.New("no battery, the printer runs on the AC adapter")
+
+// PowerSettings reports whether m takes the auto power-off and battery level commands
+func (m Model) PowerSettings() bool {
+	spec, _ := m.spec()
+	return spec.PowerSettings
+}
+
+// PowerSettings is the auto power-off configuration of a printer, zero never turns it off
+type PowerSettings struct {
+	AutoPowerOffAC      time.Duration // on the AC adapter
+	AutoPowerOffBattery time.Duration // on battery
+}
+
+// checkPowerSettings returns an error if the model of s is known not to take power setting commands
+func (s Serial) checkPowerSettings() error {
+	return s.unsupported(Model.PowerSettings, "power settings")
+}
+
+// SetAutoPowerOff sets the idle time after which the printer turns off on the AC adapter or on battery,
+// in steps of AutoPowerOffStep up to MaxAutoPowerOff, zero keeps it on
+func (s Serial) SetAutoPowerOff(battery bool, d time.Duration) error {
+	if err := s.checkPowerSettings(); err != nil {
+		return err
+	}
+	if d < 0 || d > MaxAutoPowerOff || d%AutoPowerOffStep != 0 {
+		return fmt.Errorf("auto power-off %s: must be a multiple of %s up to %s", d, AutoPowerOffStep, MaxAutoPowerOff)
+	}
+	setting := settingAutoPowerOffAC
+	if battery {
+		setting = settingAutoPowerOffBattery
+	}
+	payload := append(append([]byte{}, cmdWriteSettingPrefix...), setting, byte(d/AutoPowerOffStep))
+	if s.Debug {
+		log.Println("SetAutoPowerOff", battery, d, hex.EncodeToString(payload))
+	}
+	_, err := s.Conn.Write(payload)
+	return err
+}
+
+// ReadPowerSettings reads the auto power-off times of the printer
+func (s Serial) ReadPowerSettings() (PowerSettings, error) {
+	if err := s.checkPowerSettings(); err != nil {
+		return PowerSettings{}, err
+	}
+	ac, err := s.readSetting(settingAutoPowerOffAC)
+	if err != nil {
+		return PowerSettings{}, err
+	}
+	battery, err := s.readSetting(settingAutoPowerOffBattery)
+	if err != nil {
+		return PowerSettings{}, err
+	}
+	return PowerSettings{
+		AutoPowerOffAC:      time.Duration(ac) * AutoPowerOffStep,
+		AutoPowerOffBattery: time.Duration(battery) * AutoPowerOffStep,
+	}, nil
+}
+
+// BatteryLevel reads the charge of the battery in percent, finer than the Battery of a Status
+func (s Serial) BatteryLevel() (int, error) {
+	if err := s.checkPowerSettings(); err != nil {
+		return 0, err
+	}
+	level, err := s.readSetting(settingBatteryLevel)
+	if err != nil {
+		return 0, err
+	}
+	if level == batteryLevelAC {
+		return 0, ErrNoBattery
+	}
+	if level > 100 {
+		return 0, fmt.Errorf("read battery level: invalid level %d", level)
+	}
+	return int(level), nil
+}
+
+// readSetting requests setting and reads its value from the answer
+func (s Serial) readSetting(setting byte) (byte, error) {
+	payload := append(append([]byte{}, cmdReadSettingPrefix...), setting)
+	if s.Debug {
+		log.Println("ReadSetting", hex.EncodeToString(payload))
+	}
+	if _, err := s.Conn.Write(payload); err != nil {
+		return 0, err
+	}
+	answer := make([]byte, 2)
+	if _, err := io.ReadFull(s.Conn, answer); err != nil {
+		return 0, fmt.Errorf("read setting %#02x: %w", setting, err)
+	}
+	if answer[0] != setting {
+		return 0, fmt.Errorf("read setting %#02x: answer is for %#02x", setting, answer[0])
+	}
+	return answer[1], nil
+}
@@ -0,0 +1,128 @@
+package ptouchgo
+
+import (
+	"image"
+	"image/color"
+)
+
+// tapeColors approximates the colors of tapes, unknown colors are white
+var tapeColors = map[TapeColor]color.RGBA{
+	tapeColorWhite:             {0xff, 0xff, 0xff, 0xff},
+	tapeColorClear:             {0xf4, 0xf4, 0xf0, 0xff},
+	tapeColorRed:               {0xd7, 0x26, 0x2a, 0xff},
+	tapeColorBlue:              {0x1f, 0x5f, 0xb7, 0xff},
+	tapeColorYellow:            {0xfb, 0xdc, 0x2c, 0xff},
+	tapeColorGreen:             {0x2f, 0x9e, 0x4f, 0xff},
+	tapeColorBlack:             {0x1a, 0x1a, 0x1a, 0xff},
+	tapeColorClearWhiteText:    {0xf4, 0xf4, 0xf0, 0xff},
+	tapeColorMatteWhite:        {0xf7, 0xf7, 0xf2, 0xff},
+	tapeColorMatteClear:        {0xee, 0xee, 0xea, 0xff},
+	tapeColorMatteSilver:       {0xb8, 0xbb, 0xbf, 0xff},
+	tapeColorSatinGold:         {0xc9, 0xa8, 0x4c, 0xff},
+	tapeColorSatinSilver:       {0xc4, 0xc6, 0xc8, 0xff},
+	tapeColorDBlue:             {0x13, 0x3d, 0x8a, 0xff},
+	tapeColorDRed:              {0xa8, 0x1c, 0x22, 0xff},
+	tapeColorFluorescentOrange: {0xff, 0x7a, 0x1f, 0xff},
+	tapeColorFluorescentyellow: {0xf0, 0xff, 0x3a, 0xff},
+	tapeColorBerryPink:         {0xe0, 0x4f, 0x8e, 0xff},
+	tapeColorLightGray:         {0xc8, 0xc8, 0xc8, 0xff},
+	tapeColorLimeGreen:         {0x9c, 0xd3, 0x3f, 0xff},
+	tapeColorFYellow:           {0xf2, 0xe0, 0x6a, 0xff},
+	tapeColorFPing:             {0xf0, 0x9c, 0xb8, 0xff},
+	tapeColorFBlue:             {0x7f, 0xb2, 0xe0, 0xff},
+	tapeColorHeatShrinkWhite:   {0xfa, 0xfa, 0xfa, 0xff},
+	tapeColorFlexWhite:         {0xff, 0xff, 0xff, 0xff},
+	tapeColorFlexYellow:        {0xfb, 0xdc, 0x2c, 0xff},
+}
+
+// fontColors approximates the colors of the text printed on tapes, unknown colors are black
+var fontColors = map[FontColor]color.RGBA{
+	fontColorWhite: {0xff, 0xff, 0xff, 0xff},
+	fontColorRed:   {0xd7, 0x26, 0x2a, 0xff},
+	fontColorBlue:  {0x1f, 0x5f, 0xb7, 0xff},
+	fontColorBlack: {0x1a, 0x1a, 0x1a, 0xff},
+	fontColorGold:  {0xc9, 0xa8, 0x4c, 0xff},
+	fontColorFBlue: {0x7f, 0xb2, 0xe0, 0xff},
+}
+
+// Color returns the approximate color of the tape
+func (i TapeColor) Color() color.RGBA {
+	if c, ok := tapeColors[i]; ok {
+		return c
+	}
+	return color.RGBA{0xff, 0xff, 0xff, 0xff}
+}
+
+// Color returns the approximate color of the text
+func (i FontColor) Color() color.RGBA {
+	if c, ok := fontColors[i]; ok {
+		return c
+	}
+	return color.RGBA{0x1a, 0x1a, 0x1a, 0xff}
+}
+
+// RasterImage returns raster data of LoadRawImage as it is sent to the printer, raster lines are
+// columns from left to right and the first pin is the top row. Dots are black on white.
+func RasterImage(data []byte, bytesWidth int) *image.Gray {
+	lines := len(data) / bytesWidth
+	img := image.NewGray(image.Rect(0, 0, lines, bytesWidth*8))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+	for line := 0; line < lines; line++ {
+		row := data[line*bytesWidth : (line+1)*bytesWidth]
+		for pin := 0; pin < bytesWidth*8; pin++ {
+			if row[pin/8]&(0x80>>uint(pin%8)) != 0 {
+				img.Pix[pin*img.Stride+line] = 0
+			}
+		}
+	}
+	return img
+}
+
+// PreviewImage is RasterImage in the colors of the tape and text,
+// cropped to the printable dots of tapeWidth
+func PreviewImage(data []byte, bytesWidth int, tapeWidth TapeWidth, tape TapeColor, text FontColor) *image.RGBA {
+	raster := RasterImage(data, bytesWidth)
+	top, height := tapeWidth.Margin(), tapeWidth.PrintableDots()
+	if height == 0 {
+		top, height = 0, raster.Bounds().Dy()
+	}
+
+	bg, fg := tape.Color(), text.Color()
+	img := image.NewRGBA(image.Rect(0, 0, raster.Bounds().Dx(), height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < img.Bounds().Dx(); x++ {
+			if raster.GrayAt(x, top+y).Y == 0 {
+				img.SetRGBA(x, y, fg)
+			} else {
+				img.SetRGBA(x, y, bg)
+			}
+		}
+	}
+	return img
+}
+
+// QLPreviewImage is PreviewImage of the lines of ConvertQLImage on the white media,
+// cropped to its printable dots. Red dots of TwoColor media are red.
+func QLPreviewImage(black, red []byte, media QLMedia) *image.RGBA {
+	lineBytes := qlDefaultFor(media).LineBytes()
+	lines := len(black) / lineBytes
+	bg, fg, redFg := tapeColorWhite.Color(), fontColorBlack.Color(), fontColorRed.Color()
+	img := image.NewRGBA(image.Rect(0, 0, lines, media.Dots))
+	for x := 0; x < lines; x++ {
+		for y := 0; y < media.Dots; y++ {
+			pin := media.Offset + y
+			i, bit := x*lineBytes+pin/8, byte(0x80>>uint(pin%8))
+			switch {
+			case black[i]&bit != 0:
+				img.SetRGBA(x, y, fg)
+			case len(red) > i && red[i]&bit != 0:
+				img.SetRGBA(x, y, redFg)
+			default:
+				img.SetRGBA(x, y, bg)
+			}
+		}
+	}
+	return img
+}
@@ -0,0 +1,365 @@
+package ptouchgo
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"log"
+	"time"
+
+	"github.com/ka2n/ptouchgo/conn"
+	"github.com/ka2n/ptouchgo/models"
+)
+
+// PrintOptions controls cutting and feed behavior of PrintImage
+type PrintOptions struct {
+	AutoCut    bool
+	Mirror     bool
+	HalfCut    bool // models with Model.HalfCut only
+	ChainPrint bool
+	HighDPI    bool
+	Quality    bool // prefer the print quality over the speed, PT-P900 series only
+	FeedAmount int  // raster lines fed before and after the labels, see LengthDots, QL printers feed a fixed margin
+	FabricAsIs bool // keeps Mirror and AutoCut on Serial.Fabric tape, which is otherwise printed mirrored and not cut
+
+	// ConvertOptions turn the images into raster data
+	ConvertOptions
+
+	// Length pads every page to this many raster lines with blank lines around the image,
+	// zero keeps the length of the image
+	Length int
+
+	// Copies is how many times the pages are printed, in order of the pages, zero prints them once
+	Copies int
+	// CutEvery cuts after every CutEvery labels instead of after each with AutoCut, up to 99.
+	// A value of at least the number of labels cuts after the last label only.
+	CutEvery int
+
+	// Retries is how many times the label is sent again when the connection
+	// was reopened during the job, see OpenReconnecting
+	Retries int
+
+	// WaitPrinted waits after sending the job until the printer reports every page printed,
+	// the connection has to read statuses, see conn.Capabilities.StatusReadback
+	WaitPrinted bool
+	// Progress is called while the raster data is sent and when waiting for the printer starts
+	Progress func(Progress)
+}
+
+// Progress is the state of a job reported to PrintOptions.Progress
+type Progress struct {
+	Sent  int // bytes of raster data sent
+	Total int // bytes of raster data of the job
+	Pages int // pages of the job
+
+	// Waiting is set when all data is sent and the printer is waited for with WaitPrinted
+	Waiting bool
+}
+
+// printedTimeout is how long WaitPrinted waits for the printer
+const printedTimeout = 2 * time.Minute
+
+// DefaultPrintOptions returns options for printing a single label with autocut
+func DefaultPrintOptions() PrintOptions {
+	return PrintOptions{
+		AutoCut:    true,
+		FeedAmount: 10,
+	}
+}
+
+// LengthDots converts mm along the tape into raster lines for Length and FeedAmount at DPI,
+// with HighDPI the lines are twice as many. Serial.LengthDots converts for other models.
+func (o PrintOptions) LengthDots(mm float64) int {
+	if o.HighDPI {
+		return MMToDots(mm * 2)
+	}
+	return MMToDots(mm)
+}
+
+// LengthDots is PrintOptions.LengthDots for the resolution of the model of s
+func (s Serial) LengthDots(opts PrintOptions, mm float64) int {
+	if opts.HighDPI {
+		mm *= 2
+	}
+	return int(mm*float64(s.DPI())/25.4 + 0.5)
+}
+
+// DPI returns the resolution of the model of s
+func (s Serial) DPI() int {
+	return s.Head().DPI
+}
+
+// PrintImage converts img into raster data and prints it as one label.
+// When the job was interrupted by a lost link and all retries are used up the error
+// wraps conn.ErrReconnected, the connection is usable again and the label can be resent.
+func (s Serial) PrintImage(img image.Image, opts PrintOptions) error {
+	return s.PrintImages([]image.Image{img}, opts)
+}
+
+// PrintImages prints imgs as pages of one job, the pages are cut apart when opts.AutoCut is set.
+// The tape is fed in front of the first page only, which saves the tape wasted by printing them one by one.
+func (s Serial) PrintImages(imgs []image.Image, opts PrintOptions) error {
+	if len(imgs) == 0 {
+		return errors.New("no image to print")
+	}
+	if opts.CutEvery > MaxCutEvery {
+		return fmt.Errorf("cut every %d labels, at most %d are supported", opts.CutEvery, MaxCutEvery)
+	}
+	// QL printers feed a fixed margin and take any FeedAmount
+	if max := s.spec().MaxFeed; opts.FeedAmount < 0 || max > 0 && opts.FeedAmount > max {
+		return fmt.Errorf("feed amount %d is out of range, 0-%d are supported", opts.FeedAmount, max)
+	}
+	if s.Fabric && !opts.FabricAsIs {
+		// iron-on tape is ironed on face down, and cut by hand
+		opts.Mirror, opts.AutoCut = true, false
+		if s.Debug {
+			log.Println("Fabric tape, printing mirrored without cutting")
+		}
+	}
+	pages, err := s.protocol().pages(s, imgs, opts)
+	if err != nil {
+		return err
+	}
+
+	for n := 1; n < opts.Copies; n++ {
+		pages = append(pages, pages[:len(imgs)]...)
+	}
+
+	for retry := 0; ; retry++ {
+		err := s.printJob(pages, opts)
+		if retry >= opts.Retries || !errors.Is(err, conn.ErrReconnected) {
+			return err
+		}
+		if s.Debug {
+			log.Printf("Resending label after reconnect (%d/%d): %v\n", retry+1, opts.Retries, err)
+		}
+	}
+}
+
+// tapePages converts imgs into pages of compressed raster data for the tape of s
+func (s Serial) tapePages(imgs []image.Image, opts PrintOptions) ([]page, error) {
+	tw := TapeWidth(s.TapeWidthMM)
+	if m := s.model(); !m.Supported() && tw > tapeWidth24 {
+		return nil, fmt.Errorf("%s tape needs a printer of the PT-P900 series, set Model or read the status first", tw)
+	} else if m.Supported() {
+		switch {
+		case m.QL():
+			return nil, fmt.Errorf("%s prints on QL media, set Media or call UseStatus", m)
+		case !m.tapeWidth(tw):
+			return nil, &UnsupportedError{Model: m, Feature: fmt.Sprintf("%dmm tape", s.TapeWidthMM), Media: true}
+		case opts.HalfCut && !m.HalfCut():
+			return nil, &UnsupportedError{Model: m, Feature: "half cut"}
+		case opts.HighDPI && !m.HighDPI():
+			return nil, &UnsupportedError{Model: m, Feature: models.FeatureHighDPI}
+		case opts.AutoCut && opts.CutEvery > 1 && !m.CutEvery():
+			return nil, &UnsupportedError{Model: m, Feature: fmt.Sprintf("cutting after %d labels, it cuts after every label", opts.CutEvery)}
+		}
+		if opts.HighDPI {
+			if err := s.checkFirmware(m, models.FeatureHighDPI); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if s.HeatShrink {
+		m := s.model()
+		switch {
+		case !m.HeatShrink(tw):
+			return nil, &UnsupportedError{Model: m, Feature: fmt.Sprintf("%s heat shrink tube", tw), Media: true}
+		case opts.HalfCut:
+			return nil, &UnsupportedError{Model: m, Feature: "half cut of heat shrink tube"}
+		}
+	}
+	pages := make([]page, len(imgs))
+	for i, img := range imgs {
+		data, bytesWidth, err := s.Head().ConvertImage(img, tw, opts.ConvertOptions)
+		if err == nil && s.HeatShrink {
+			data = fitTube(data, bytesWidth, headOf(s.spec()).PrintableDots(tw), s.Head().PrintableDots(tw))
+		}
+		if err == nil {
+			data, err = padLength(data, bytesWidth, opts.Length)
+		}
+		if err != nil {
+			if len(imgs) > 1 {
+				return nil, fmt.Errorf("page %d: %w", i+1, err)
+			}
+			return nil, err
+		}
+		pages[i].rasterLines = len(data) / bytesWidth
+		pages[i].packedData, err = CompressImage(data, bytesWidth)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return pages, nil
+}
+
+// padLength centers the raster data in length raster lines, zero keeps it as is
+func padLength(data []byte, bytesWidth, length int) ([]byte, error) {
+	lines := len(data) / bytesWidth
+	if length == 0 || lines == length {
+		return data, nil
+	}
+	if lines > length {
+		return nil, fmt.Errorf("image is %d raster lines long, longer than the length of %d", lines, length)
+	}
+	padded := make([]byte, length*bytesWidth)
+	copy(padded[(length-lines)/2*bytesWidth:], data)
+	return padded, nil
+}
+
+// Feed advances the tape by rasterLines blank lines without cutting,
+// die-cut labels of QL printers are fed by one blank label
+func (s Serial) Feed(rasterLines int) error {
+	return s.printBlank(rasterLines, false)
+}
+
+// Cut cuts the tape, like after the last label of a chain printed job,
+// by ejecting a blank line with the shortest feed
+func (s Serial) Cut() error {
+	return s.printBlank(1, true)
+}
+
+func (s Serial) printBlank(rasterLines int, cut bool) error {
+	if rasterLines < 1 {
+		return errors.New("no raster line to feed")
+	}
+	p, err := s.protocol().blank(s, rasterLines)
+	if err != nil {
+		return err
+	}
+	return s.printJob([]page{p}, PrintOptions{AutoCut: cut})
+}
+
+// page is the raster data of a page with the raster commands
+type page struct {
+	packedData  []byte
+	rasterLines int
+}
+
+// MaxCutEvery is the largest PrintOptions.CutEvery, the label count of the cut setting command
+const MaxCutEvery = 99
+
+// page values of the print information command
+const (
+	pageFirst = 0
+	pageOther = 1
+	pageLast  = 2
+)
+
+func (s Serial) printJob(pages []page, opts PrintOptions) error {
+	progress := Progress{Pages: len(pages)}
+	for _, p := range pages {
+		progress.Total += len(p.packedData)
+	}
+	report := func(n int) {
+		progress.Sent += n
+		if opts.Progress != nil {
+			opts.Progress(progress)
+		}
+	}
+
+	if opts.WaitPrinted && !s.Capabilities.StatusReadback {
+		return errors.New("the connection can not read the printer status to wait for printing")
+	}
+
+	err := s.Reset()
+	if err != nil {
+		return err
+	}
+
+	err = s.SetRasterMode()
+	if err != nil {
+		return err
+	}
+
+	proto := s.protocol()
+	for i, p := range pages {
+		n := pageOther
+		switch {
+		case i == 0:
+			n = pageFirst
+		case i == len(pages)-1:
+			n = pageLast
+		}
+		if err = proto.setPage(s, p, byte(n), opts); err != nil {
+			return err
+		}
+
+		err = s.sendImage(p.packedData, report)
+		if err != nil {
+			return err
+		}
+
+		// every page but the last ends with a form feed
+		if i < len(pages)-1 {
+			err = s.Print()
+		} else {
+			err = s.PrintAndEject()
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if opts.WaitPrinted {
+		progress.Waiting = true
+		report(0)
+		if err := s.waitPrinted(len(pages)); err != nil {
+			return err
+		}
+	}
+	return s.Reset()
+}
+
+// setPage sends the settings of a page of a PT job, quality sets the quality bit of the print information
+func (s Serial) setPage(p page, n byte, opts PrintOptions, quality bool) error {
+	err := s.setPrintProperty(p.rasterLines, n, quality)
+	if err != nil {
+		return err
+	}
+
+	err = s.SetPrintMode(opts.AutoCut, opts.Mirror)
+	if err != nil {
+		return err
+	}
+
+	if opts.AutoCut && opts.CutEvery > 1 {
+		err = s.SetAutocutPerPagesForPTP750W(opts.CutEvery)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = s.SetExtendedMode(opts.HalfCut, !opts.ChainPrint, false, opts.HighDPI, false)
+	if err != nil {
+		return err
+	}
+
+	err = s.SetFeedAmount(opts.FeedAmount)
+	if err != nil {
+		return err
+	}
+
+	return s.SetCompressionModeEnabled(true)
+}
+
+// waitPrinted reads statuses until the printer reported pages printed or an error
+func (s Serial) waitPrinted(pages int) error {
+	deadline := time.Now().Add(printedTimeout)
+	for printed := 0; printed < pages; {
+		st, err := s.ReadStatus()
+		if err != nil {
+			if conn.IsTimeout(err) && time.Now().Before(deadline) {
+				continue
+			}
+			return fmt.Errorf("wait for printing: %w", err)
+		}
+		switch st.StatusType {
+		case statusTypePrintingCompleted:
+			printed++
+		case statusTypeErrorOccured:
+			return &StatusError{Status: st}
+		}
+	}
+	return nil
+}
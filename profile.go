@@ -0,0 +1,78 @@
+package ptouchgo
+
+import (
+	"fmt"
+	"log"
+	"sync"
+)
+
+// UnsupportedError is returned when the model of the printer or its firmware lacks a feature
+type UnsupportedError struct {
+	Model   Model
+	Feature string // like "half cut" or "18mm tape"
+	Media   bool   // Feature is the loaded tape or media rather than a setting
+
+	// Firmware is the version of the printer lacking the feature up to MinFirmware, empty for the model
+	Firmware    string
+	MinFirmware string
+}
+
+func (e *UnsupportedError) Error() string {
+	if e.Firmware != "" {
+		return fmt.Sprintf("%s firmware %s does not support %s, update it to %s or later", e.Model, e.Firmware, e.Feature, e.MinFirmware)
+	}
+	return fmt.Sprintf("%s does not support %s", e.Model, e.Feature)
+}
+
+// detection is the model of the first status read on a connection, shared by the copies of a Serial
+type detection struct {
+	mu       sync.Mutex
+	model    Model
+	firmware string // read by ReadFirmware
+}
+
+// detect keeps the model of st when it is the first status read
+func (s Serial) detect(st *Status) {
+	d := s.detected
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.model == 0 && st.Model.Supported() {
+		d.model = st.Model
+		if s.Debug {
+			log.Println("Detected", st.Model)
+		}
+	}
+}
+
+// model returns the model of the printer of s, Model if it is set and the model
+// of the first status read otherwise, zero while neither is known
+func (s Serial) model() Model {
+	if s.Model != 0 || s.detected == nil {
+		return s.Model
+	}
+	s.detected.mu.Lock()
+	defer s.detected.mu.Unlock()
+	return s.detected.model
+}
+
+// DetectedModel returns the model the printing of s adapts to, see Model
+func (s Serial) DetectedModel() Model {
+	return s.model()
+}
+
+// unsupported returns an UnsupportedError of feature if the model of s is known and has is false,
+// or if the firmware read from the printer is older than the model needs for feature
+func (s Serial) unsupported(has func(Model) bool, feature string, args ...interface{}) error {
+	m := s.model()
+	if !m.Supported() {
+		return nil
+	}
+	feature = fmt.Sprintf(feature, args...)
+	if !has(m) {
+		return &UnsupportedError{Model: m, Feature: feature}
+	}
+	return s.checkFirmware(m, feature)
+}
@@ -0,0 +1,101 @@
+package ptouchgo
+
+import (
+	"image"
+
+	"github.com/ka2n/ptouchgo/models"
+)
+
+// protocol builds the raster commands of a printer family
+type protocol interface {
+	// pages converts imgs into the pages of a job
+	pages(s Serial, imgs []image.Image, opts PrintOptions) ([]page, error)
+	// blank returns a page of rasterLines blank raster lines
+	blank(s Serial, rasterLines int) (page, error)
+	// setPage sends the print information and settings in front of page n of a job
+	setPage(s Serial, p page, n byte, opts PrintOptions) error
+	// notification reports whether the family takes SetNotificationMode
+	notification() bool
+}
+
+// protocol returns the protocol of the printer of s
+func (s Serial) protocol() protocol {
+	spec := s.spec()
+	switch {
+	case spec.Family == models.TD:
+		return tdProtocol{}
+	case s.ql():
+		return qlProtocol{}
+	case spec.DPI > DPI:
+		return ptHiRes{}
+	}
+	return ptClassic{}
+}
+
+// ptClassic is the protocol of the PT-P700 series, compressed G raster lines of the 128 pin head
+type ptClassic struct{}
+
+func (ptClassic) pages(s Serial, imgs []image.Image, opts PrintOptions) ([]page, error) {
+	return s.tapePages(imgs, opts)
+}
+
+func (ptClassic) blank(s Serial, rasterLines int) (page, error) {
+	bytesWidth := s.Head().Pins / 8
+	packed, err := CompressImage(make([]byte, rasterLines*bytesWidth), bytesWidth)
+	return page{packedData: packed, rasterLines: rasterLines}, err
+}
+
+func (ptClassic) setPage(s Serial, p page, n byte, opts PrintOptions) error {
+	return s.setPage(p, n, opts, false)
+}
+
+func (ptClassic) notification() bool {
+	return true
+}
+
+// ptHiRes is the protocol of the PT-P900 series, the print information takes the quality bit
+type ptHiRes struct {
+	ptClassic
+}
+
+func (ptHiRes) setPage(s Serial, p page, n byte, opts PrintOptions) error {
+	return s.setPage(p, n, opts, opts.Quality)
+}
+
+// qlProtocol is the protocol of QL printers, uncompressed g raster lines or w lines of both colors on QLMedia
+type qlProtocol struct{}
+
+func (qlProtocol) pages(s Serial, imgs []image.Image, opts PrintOptions) ([]page, error) {
+	return s.qlPages(imgs, opts)
+}
+
+// blank is a whole label of die-cut labels
+func (qlProtocol) blank(s Serial, rasterLines int) (page, error) {
+	if s.Media.DieCut() {
+		rasterLines = s.Media.LengthDots
+	}
+	lineBytes := s.qlSpec().LineBytes()
+	var red []byte
+	if s.Media.TwoColor {
+		red = make([]byte, rasterLines*lineBytes)
+	}
+	packed := qlRaster(make([]byte, rasterLines*lineBytes), red, lineBytes)
+	return page{packedData: packed, rasterLines: rasterLines}, nil
+}
+
+func (qlProtocol) setPage(s Serial, p page, n byte, opts PrintOptions) error {
+	return s.setQLPage(p, n, opts)
+}
+
+func (qlProtocol) notification() bool {
+	return true
+}
+
+// tdProtocol is the protocol of the TD series, the one of QL printers without status notifications
+type tdProtocol struct {
+	qlProtocol
+}
+
+func (tdProtocol) notification() bool {
+	return false
+}
@@ -1,4 +1,5 @@
-// Package ptouchgo is a driver for PT-710BT/PT700/PT750W
+// Package ptouchgo is a driver for the PT-710BT/PT700/PT750W, PT-H500, PT-E550W, PT-P300BT and PT-P900W/PT-P950NW tape printers,
+// the QL-700/QL-800/QL-820NWB and QL-1100/QL-1110NWB label printers and the TD-2020/TD-2120N/TD-2130N portable printers
 package ptouchgo
 
 import (
@@ -9,13 +10,31 @@ import (
 	"image/png"
 	"io"
 	"log"
-	"net/url"
+	"strings"
+	"time"
 
 	"github.com/disintegration/imaging"
 	"github.com/ka2n/ptouchgo/conn"
+	"github.com/ka2n/ptouchgo/models"
 )
 
 const (
+	statusSize       = 32
+	maxStatusGarbage = 1024 // bytes skipped looking for a status header before giving up
+)
+
+// statusHeader starts every status, the print head mark, size and "B" for Brother,
+// the series byte follows
+var statusHeader = []byte{0x80, 0x20, 0x42}
+
+// series bytes after statusHeader
+const (
+	statusSeriesPT = 0x30
+	statusSeriesQL = 0x34
+)
+
+const (
+	statusOffsetSeries       = 3
 	statusOffsetModel        = 4
 	statusOffsetBattery      = 6
 	statusOffsetErrorInfo1   = 8
@@ -31,6 +50,7 @@ const (
 	statusOffsetTapeColor    = 24
 	statusOffsetFontColor    = 25
 	statusOffsetHardwareConf = 26
+	statusHardwareConfSize   = 4
 )
 
 type Status struct {
@@ -50,21 +70,232 @@ type Status struct {
 	TapeLength int
 	TapeWidth  TapeWidth
 	FontColor  FontColor
+
+	// HardwareSettings are the hardware settings bytes reported by the PT-P900 series
+	HardwareSettings [statusHardwareConfSize]byte
+
+	// Firmware is the version read with Serial.ReadFirmware on the connection, empty before
+	Firmware string
+}
+
+// error1Bits and error2Bits describe the error information bytes of a status
+var (
+	error1Bits = []statusBit{
+		{int(error1NoMedia), "No media"},
+		{int(error1EndOfMedia), "End of media"},
+		{int(error1CutterJam), "Cutter jam"},
+		{int(error1WeakBattery), "Weak battery"},
+		{0x40, "High-voltage adapter"},
+		{0x80, "Fan motor error"},
+	}
+	error2Bits = []statusBit{
+		{int(error2InvalidMedia), error2InvalidMedia.String()},
+		{0x02, "Expansion buffer full"},
+		{0x04, "Transmission error"},
+		{0x08, "Transmission buffer full"},
+		{int(error2CoverOpen), error2CoverOpen.String()},
+		{int(error2Hot), error2Hot.String()},
+		{0x40, "Media feed error"},
+		{0x80, "System error"},
+	}
+)
+
+type statusBit struct {
+	mask int
+	desc string
+}
+
+// Errors describes the errors reported by the status, empty when there are none
+func (s *Status) Errors() []string {
+	var errs []string
+	for _, b := range error1Bits {
+		if int(s.Error1)&b.mask != 0 {
+			errs = append(errs, b.desc)
+		}
+	}
+	for _, b := range error2Bits {
+		if int(s.Error2)&b.mask != 0 {
+			errs = append(errs, b.desc)
+		}
+	}
+	return errs
+}
+
+// NoMedia reports whether the printer has no tape
+func (s *Status) NoMedia() bool {
+	return s.Error1&error1NoMedia != 0
+}
+
+// EndOfMedia reports whether the media ran out, like the die-cut labels of QL printers
+func (s *Status) EndOfMedia() bool {
+	return s.Error1&error1EndOfMedia != 0
+}
+
+// CutterJam reports whether the cutter is jammed
+func (s *Status) CutterJam() bool {
+	return s.Error1&error1CutterJam != 0
+}
+
+// WeakBattery reports whether the battery of the printer is low
+func (s *Status) WeakBattery() bool {
+	return s.Error1&error1WeakBattery != 0
+}
+
+// CoverOpen reports whether the cover of the printer is open
+func (s *Status) CoverOpen() bool {
+	return s.Error2&error2CoverOpen != 0
+}
+
+// InvalidMedia reports whether the loaded tape is not supported
+func (s *Status) InvalidMedia() bool {
+	return s.Error2&error2InvalidMedia != 0
+}
+
+// HeatShrink reports whether the loaded media is heat shrink tube
+func (s *Status) HeatShrink() bool {
+	return s.MediaType == mediaTypeHeatShirink
+}
+
+// Fabric reports whether the loaded media is fabric tape, like the iron-on tape
+func (s *Status) Fabric() bool {
+	return s.MediaType == mediaTypeFabric
+}
+
+// AutoCut reports whether the print mode last set on the printer cuts after each label
+func (s *Status) AutoCut() bool {
+	return s.Mode&printModeAutoCut != 0
+}
+
+// Mirror reports whether the print mode last set on the printer prints mirrored
+func (s *Status) Mirror() bool {
+	return s.Mode&printModeMirror != 0
+}
+
+// StatusError is an error reported by the printer in Status
+type StatusError struct {
+	Status *Status
+}
+
+func (e *StatusError) Error() string {
+	return "printer error: " + strings.Join(e.Status.Errors(), ", ")
 }
 
 //go:generate stringer -linecomment -type Model
 type Model int
 
 const (
-	modelPTP700   Model = 0x67 // PT-P700
-	modelPTP750W  Model = 0x68 // PT-P750W
-	modelPTP710BT Model = 0x76 // PT-P710BT
+	modelPTH500    Model = 0x64 // PT-H500
+	modelPTE550W   Model = 0x65 // PT-E550W
+	modelPTP700    Model = 0x67 // PT-P700
+	modelPTP750W   Model = 0x68 // PT-P750W
+	modelPTP710BT  Model = 0x76 // PT-P710BT
+	modelPTP300BT  Model = 0x6f // PT-P300BT
+	modelPTP900W   Model = 0x69 // PT-P900W
+	modelPTP950NW  Model = 0x70 // PT-P950NW
+	modelQL700     Model = 0x35 // QL-700
+	modelQL800     Model = 0x38 // QL-800
+	modelQL820NWB  Model = 0x41 // QL-820NWB
+	modelQL1100    Model = 0x43 // QL-1100
+	modelQL1110NWB Model = 0x44 // QL-1110NWB
+	modelTD2020    Model = 0x3a // TD-2020
+	modelTD2120N   Model = 0x3b // TD-2120N
+	modelTD2130N   Model = 0x3c // TD-2130N
 )
 
+// spec returns the capabilities of m from the model registry
+func (m Model) spec() (models.Spec, bool) {
+	if m < 0 || m > 0xff {
+		return models.Spec{}, false
+	}
+	return models.Lookup(byte(m))
+}
+
+// Supported reports whether m is a model this package prints on
+func (m Model) Supported() bool {
+	_, ok := m.spec()
+	return ok
+}
+
+// Models lists the models this package prints on
+func Models() []Model {
+	var ms []Model
+	for _, spec := range models.All() {
+		ms = append(ms, Model(spec.Code))
+	}
+	return ms
+}
+
+// ParseModel returns the model named like "PT-P710BT", case is ignored
+func ParseModel(name string) (Model, error) {
+	spec, ok := models.ByName(name)
+	if !ok {
+		return 0, fmt.Errorf("unknown model %q", name)
+	}
+	return Model(spec.Code), nil
+}
+
+// MaxTapeWidth returns the widest tape m prints on, 24mm for unknown models
+func (m Model) MaxTapeWidth() TapeWidth {
+	if spec, ok := m.spec(); ok {
+		return TapeWidth(spec.MaxTapeWidth())
+	}
+	return tapeWidth24
+}
+
+// CutEvery reports whether m cuts after several labels with PrintOptions.CutEvery,
+// the PT-P300BT cuts after each label only
+func (m Model) CutEvery() bool {
+	spec, ok := m.spec()
+	return !ok || spec.CutEvery
+}
+
+// HighDPI reports whether m prints with PrintOptions.HighDPI
+func (m Model) HighDPI() bool {
+	spec, ok := m.spec()
+	return !ok || spec.HighDPI
+}
+
+// TapeWidths lists the tape widths m prints on, narrowest first, the tapes of the head for unknown models
+func (m Model) TapeWidths() []TapeWidth {
+	spec, ok := m.spec()
+	if !ok || spec.Family != models.PT {
+		return TapeWidths()
+	}
+	var tws []TapeWidth
+	for _, t := range spec.Tapes {
+		tws = append(tws, TapeWidth(t.WidthMM))
+	}
+	return tws
+}
+
+// tapeWidth reports whether m prints on tw
+func (m Model) tapeWidth(tw TapeWidth) bool {
+	for _, w := range m.TapeWidths() {
+		if w == tw {
+			return true
+		}
+	}
+	return false
+}
+
+// HeatShrink reports whether m prints on heat shrink tube reported as tw wide
+func (m Model) HeatShrink(tw TapeWidth) bool {
+	spec, _ := m.spec()
+	_, ok := spec.Tube(int(tw))
+	return ok
+}
+
+// HalfCut reports whether m has the half cutter of PrintOptions.HalfCut
+func (m Model) HalfCut() bool {
+	spec, _ := m.spec()
+	return spec.HalfCut
+}
+
 type Error1Type int
 
 const (
 	error1NoMedia          Error1Type = 0x01 // No Media
+	error1EndOfMedia       Error1Type = 0x02 // End of media, die-cut labels of QL printers
 	error1CutterJam        Error1Type = 0x04 // Cutter Jam
 	error1WeakBattery      Error1Type = 0x08 // Weak battery
 	error1TooHighVoltageAC Error1Type = 0x06 // Too high voltage from AC
@@ -91,6 +322,7 @@ const (
 	tapeWidth12   TapeWidth = 12 // 12mm
 	tapeWidth18   TapeWidth = 18 // 18mm
 	tapeWidth24   TapeWidth = 24 // 24mm
+	tapeWidth36   TapeWidth = 36 // 36mm
 )
 
 //go:generate stringer -linecomment -type MediaType
@@ -100,6 +332,9 @@ const (
 	mediaTypeNone         MediaType = 0    // No tape
 	mediaTypeLaminated    MediaType = 0x01 // Laminated
 	mediaTypeNonLaminated MediaType = 0x03 // Non laminated
+	mediaTypeFabric       MediaType = 0x04 // Fabric
+	mediaTypeContinuous   MediaType = 0x0A // Continuous length tape
+	mediaTypeDieCut       MediaType = 0x0B // Die-cut labels
 	mediaTypeHeatShirink  MediaType = 0x11 // Heat shrink tube
 	mediaTypeInvalid      MediaType = 0xFF // Invalid tape type
 )
@@ -229,7 +464,7 @@ const (
 	printPropertyEnableBitMedia           = 0x02
 	printPropertyEnableBitWidth           = 0x04
 	printPropertyEnableBitLength          = 0x08
-	printPropertyEnableBitQuality         = 0x40 // unused
+	printPropertyEnableBitQuality         = 0x40 // PT-P900 series
 	printPropertyEnableBitRecoverOnDevice = 0x80
 )
 
@@ -237,44 +472,88 @@ type Serial struct {
 	Conn        io.ReadWriteCloser
 	TapeWidthMM uint
 	Debug       bool
+
+	// Model is the printer set by UseStatus, while it is zero printing adapts to the model
+	// of the first status read on a connection of Open. Media selects the protocol of QL printers,
+	// which print on it instead of TapeWidthMM, the zero value prints for the PT series.
+	// HeatShrink is set by UseStatus for heat shrink tube, printed on its narrower area of TapeWidthMM,
+	// and Fabric for fabric tape, printed mirrored without cutting unless PrintOptions.FabricAsIs is set.
+	Model      Model
+	Media      QLMedia
+	HeatShrink bool
+	Fabric     bool
+
+	detected *detection
+
+	// Capabilities of the transport, set by Open
+	Capabilities conn.Capabilities
 }
 
-// Open connection, address should be a device path string like "/dev/rfcomm0", "usb" or "usb://0x7c35" or "tcp://192.168.100.1:9100")
+// Open connection, address should be a device path string like "/dev/rfcomm0", "COM3", "serial:/dev/ttyUSB0", "usb" or "usb:" or "usb://0x7c35" or "net:192.168.100.1" or "bt:AA:BB:CC:DD:EE:FF" or "ble:AA:BB:CC:DD:EE:FF" or "tcp://192.168.100.1:9100" or "file:job.prn", see conn.ParseAddress
 func Open(address string, TapeWidthMM uint, debug bool) (Serial, error) {
-	var ser io.ReadWriteCloser
-	var err error
-	if address == "usb" {
+	driver, addr, err := parseAddress(address, debug)
+	if err != nil {
+		return Serial{}, err
+	}
+	caps, err := conn.DriverCapabilities(driver)
+	if err != nil {
+		return Serial{}, err
+	}
+	ser, err := conn.Open(driver, addr)
+	if err != nil {
+		return Serial{}, err
+	}
+	return Serial{Conn: ser, TapeWidthMM: TapeWidthMM, Debug: debug, Capabilities: caps, detected: &detection{}}, nil
+}
+
+// OpenReconnecting is Open with a connection which is reopened and reset when the link is lost,
+// the interrupted operation fails with an error wrapping conn.ErrReconnected and
+// PrintImage sends the label again up to PrintOptions.Retries times
+func OpenReconnecting(address string, TapeWidthMM uint, debug bool) (Serial, error) {
+	driver, addr, err := parseAddress(address, debug)
+	if err != nil {
+		return Serial{}, err
+	}
+	opts := conn.DefaultReconnectOptions
+	opts.Restore = func(rw io.ReadWriter) error {
 		if debug {
-			log.Println("Select USB driver with automatic device selection")
+			log.Printf("Reconnected to %s, resetting printer\n", address)
 		}
-		ser, err = conn.Open("usb", "")
-		if err != nil {
-			return Serial{}, err
-		}
-	} else {
-		var driver string
-		var addr string
-		u, err := url.Parse(address)
-		if err != nil {
-			return Serial{}, err
-		}
-		if u.Scheme == "" {
-			driver = "serial"
-			addr = u.Path
+		return Serial{Conn: readWriteNopCloser{rw}, Debug: debug}.Reset()
+	}
+	caps, err := conn.DriverCapabilities(driver)
+	if err != nil {
+		return Serial{}, err
+	}
+	ser, err := conn.OpenReconnecting(driver, addr, opts)
+	if err != nil {
+		return Serial{}, err
+	}
+	return Serial{Conn: ser, TapeWidthMM: TapeWidthMM, Debug: debug, Capabilities: caps, detected: &detection{}}, nil
+}
+
+type readWriteNopCloser struct {
+	io.ReadWriter
+}
+
+func (readWriteNopCloser) Close() error {
+	return nil
+}
+
+// parseAddress returns the driver and driver address of an address given to Open
+func parseAddress(address string, debug bool) (driver, addr string, err error) {
+	driver, addr, err = conn.ParseAddress(address)
+	if err != nil {
+		return "", "", err
+	}
+	if debug {
+		if driver == "usb" && addr == "" {
+			log.Println("Select USB driver with automatic device selection")
 		} else {
-			driver = u.Scheme
-			addr = u.Host
-		}
-		if debug {
 			log.Printf("Select %s driver, address: %s\n", driver, addr)
 		}
-
-		ser, err = conn.Open(driver, addr)
-		if err != nil {
-			return Serial{}, err
-		}
 	}
-	return Serial{Conn: ser, TapeWidthMM: TapeWidthMM, Debug: debug}, err
+	return driver, addr, nil
 }
 
 // ClearBuffer clears current state
@@ -285,7 +564,8 @@ func (s Serial) ClearBuffer() error {
 	if s.Debug {
 		log.Println("ClearBuffer")
 	}
-	_, err := s.Conn.Write(make([]byte, 100))
+	n := s.spec().Invalidate
+	_, err := s.Conn.Write(make([]byte, n))
 	return err
 }
 
@@ -308,11 +588,60 @@ func (s Serial) RequestStatus() error {
 	return err
 }
 
-// ReadStatus reads current status from buffer
+// ReadStatus reads current status from buffer,
+// conn.IsTimeout reports whether the printer did not answer in time.
+// Short reads are accumulated and bytes preceding the status header are skipped.
 func (s Serial) ReadStatus() (*Status, error) {
-	buf := make([]byte, 32)
-	s.Conn.Read(buf)
-	return parseStatus(buf)
+	buf := make([]byte, 0, statusSize)
+	chunk := make([]byte, statusSize)
+	skipped := 0
+	for empty := 0; len(buf) < statusSize; {
+		// never read beyond this status, the next one may follow
+		n, err := s.Conn.Read(chunk[:statusSize-len(buf)])
+		buf = append(buf, chunk[:n]...)
+		if i := statusHeaderIndex(buf); i > 0 {
+			buf = append(buf[:0], buf[i:]...)
+			skipped += i
+		}
+		if skipped > maxStatusGarbage {
+			return nil, fmt.Errorf("read status: no status header in %d bytes", skipped)
+		}
+		if len(buf) == statusSize {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read status: %w", err)
+		}
+		if n == 0 {
+			if empty++; empty > 100 {
+				return nil, fmt.Errorf("read status: %w", io.ErrNoProgress)
+			}
+		}
+	}
+	if skipped > 0 && s.Debug {
+		log.Printf("ReadStatus skipped %d bytes before the status\n", skipped)
+	}
+	st, err := parseStatus(buf)
+	if err == nil {
+		s.detect(st)
+		st.Firmware = s.Firmware()
+	}
+	return st, err
+}
+
+// statusHeaderIndex returns where a status may start in b, the header or
+// its beginning at the end of b, len(b) when b holds none
+func statusHeaderIndex(b []byte) int {
+	for i := range b {
+		rest := b[i:]
+		if len(rest) > len(statusHeader) {
+			rest = rest[:len(statusHeader)]
+		}
+		if bytes.Equal(rest, statusHeader[:len(rest)]) {
+			return i
+		}
+	}
+	return len(b)
 }
 
 func (s Serial) SetRasterMode() error {
@@ -326,6 +655,9 @@ func (s Serial) SetRasterMode() error {
 // SetNotificationMode set auto status notification mode
 // default: on
 func (s Serial) SetNotificationMode(on bool) error {
+	if !s.protocol().notification() {
+		return &UnsupportedError{Model: s.model(), Feature: "status notifications"}
+	}
 	var b byte
 	if on {
 		b = 0x0
@@ -347,9 +679,18 @@ func (s Serial) Close() error {
 }
 
 func (s Serial) SetPrintProperty(rasterLines int) error {
+	return s.setPrintProperty(rasterLines, pageFirst, false)
+}
+
+// setPrintProperty sends the print information of a page of a job with several pages,
+// quality prefers the print quality over the speed on the PT-P900 series
+func (s Serial) setPrintProperty(rasterLines int, page byte, quality bool) error {
 	var enableFlag int
 
 	enableFlag |= printPropertyEnableBitRecoverOnDevice
+	if quality {
+		enableFlag |= printPropertyEnableBitQuality
+	}
 
 	// Tape
 	tapeWidth := byte(s.TapeWidthMM)
@@ -367,8 +708,6 @@ func (s Serial) SetPrintProperty(rasterLines int) error {
 	// Media type
 	const mediaType = byte(0x00)
 
-	const page = byte(0x00) // firstPage: 0, otherPage: 1
-
 	const eeprom = byte(0x00)
 
 	data := append(cmdSetPrintPropertyPrefix, []byte{
@@ -392,6 +731,12 @@ func (s Serial) SetPrintProperty(rasterLines int) error {
 	return err
 }
 
+// bits of SetPrintMode reported in Status.Mode
+const (
+	printModeAutoCut = 1 << 6
+	printModeMirror  = 1 << 7
+)
+
 func (s Serial) SetPrintMode(autocut, mirror bool) error {
 	var v int
 	if autocut {
@@ -411,6 +756,16 @@ func (s Serial) SetPrintMode(autocut, mirror bool) error {
 }
 
 func (s Serial) SetExtendedMode(pt750halfcut bool, noChainprint bool, specialTapeDisableCut bool, highDPI bool, noClearBuffer bool) error {
+	if pt750halfcut {
+		if err := s.unsupported(Model.HalfCut, "half cut"); err != nil {
+			return err
+		}
+	}
+	if highDPI {
+		if err := s.unsupported(Model.HighDPI, models.FeatureHighDPI); err != nil {
+			return err
+		}
+	}
 	var v int
 	if pt750halfcut {
 		v = setBit(v, 2)
@@ -456,6 +811,11 @@ func (s Serial) SetFeedAmount(amount int) error {
 }
 
 func (s Serial) SetAutocutPerPagesForPTP750W(pages int) error {
+	if pages > 1 {
+		if err := s.unsupported(Model.CutEvery, "cutting after %d labels", pages); err != nil {
+			return err
+		}
+	}
 	if pages == 0 {
 		pages = 1
 	}
@@ -481,12 +841,45 @@ func (s Serial) SetCompressionModeEnabled(enabled bool) error {
 	return err
 }
 
+// SendImage sends raster data in writes of at most Capabilities.MaxWriteChunk bytes
+// separated by Capabilities.WriteInterval
 func (s Serial) SendImage(tiffdata []byte) error {
+	return s.sendImage(tiffdata, nil)
+}
+
+// progressChunk is the write size for reporting progress on connections without MaxWriteChunk
+const progressChunk = 4096
+
+// sendImage is SendImage calling sent with the bytes of each write
+func (s Serial) sendImage(tiffdata []byte, sent func(n int)) error {
 	if s.Debug {
 		log.Println("SendImage", len(tiffdata))
 	}
-	_, err := s.Conn.Write(tiffdata)
-	return err
+	chunk := s.Capabilities.MaxWriteChunk
+	if chunk <= 0 {
+		chunk = len(tiffdata)
+		// smaller writes let sent follow the transfer
+		if sent != nil && chunk > progressChunk {
+			chunk = progressChunk
+		}
+	}
+	for len(tiffdata) > 0 {
+		n := chunk
+		if n > len(tiffdata) {
+			n = len(tiffdata)
+		}
+		if _, err := s.Conn.Write(tiffdata[:n]); err != nil {
+			return err
+		}
+		if sent != nil {
+			sent(n)
+		}
+		tiffdata = tiffdata[n:]
+		if len(tiffdata) > 0 && s.Capabilities.WriteInterval > 0 {
+			time.Sleep(s.Capabilities.WriteInterval)
+		}
+	}
+	return nil
 }
 
 func (s Serial) Print() error {
@@ -522,38 +915,102 @@ func LoadPNGImage(r io.Reader, tapeWidth TapeWidth) ([]byte, int, error) {
 }
 
 func LoadRawImage(p image.Image, tapeWidth TapeWidth) ([]byte, int, error) {
-	ws := 128
-	var canvas image.Image
+	return ConvertImage(p, tapeWidth, ConvertOptions{})
+}
 
-	size := p.Bounds().Size()
-	if size.X == ws {
-		canvas = imaging.FlipH(p)
-	} else if size.Y == ws {
-		canvas = imaging.Transpose(p)
-	} else {
-		return nil, 0, fmt.Errorf("image size must have %dpx width or height for %d tape, got: %dx%d", ws, tapeWidth, size.X, size.Y)
+// ConvertOptions controls how images are turned into raster data
+type ConvertOptions struct {
+	// Rotate turns the images before they are printed
+	Rotate Rotation
+	// Dither selects how gray is printed
+	Dither Dither
+	// Threshold is the lightness from 0 to 1 up to which pixels are printed, zero uses 0.5.
+	// Dithering shifts the gray levels by it.
+	Threshold float64
+}
+
+// Rotation turns images clockwise before printing, the height of the turned image spans the tape
+type Rotation int
+
+const (
+	// RotateAuto prints images as high as the print head as they are and turns images
+	// as wide as it counterclockwise, like Rotate270
+	RotateAuto Rotation = iota
+	Rotate0
+	Rotate90
+	Rotate180
+	Rotate270
+)
+
+func (r Rotation) String() string {
+	switch r {
+	case RotateAuto:
+		return "auto"
+	case Rotate0:
+		return "0"
+	case Rotate90:
+		return "90"
+	case Rotate180:
+		return "180"
+	case Rotate270:
+		return "270"
 	}
+	return fmt.Sprintf("Rotation(%d)", int(r))
+}
 
-	size = canvas.Bounds().Size()
-	bytesWidth := size.X / 8
-	if size.X%8 != 0 {
-		bytesWidth++
+// ParseRotation parses "0", "90", "180", "270" or "auto"
+func ParseRotation(s string) (Rotation, error) {
+	for r := RotateAuto; r <= Rotate270; r++ {
+		if r.String() == s {
+			return r, nil
+		}
 	}
+	return 0, fmt.Errorf("unknown rotation %q, use 0, 90, 180, 270 or auto", s)
+}
 
-	data := make([]byte, bytesWidth*size.Y)
+// ConvertImage turns p into raster data for tapeWidth, LoadRawImage uses the zero options
+func ConvertImage(p image.Image, tapeWidth TapeWidth, opts ConvertOptions) ([]byte, int, error) {
+	return head180.ConvertImage(p, tapeWidth, opts)
+}
 
-	// 1bit
-	for y := 0; y < size.Y; y++ {
-		for x := 0; x < size.X; x++ {
-			r, g, b, _ := canvas.At(x, y).RGBA()
-			lightness := float64(55*r+182*g+18*b) / float64(0xffff*(55+182+18))
-			if lightness <= 0.5 {
-				data[y*bytesWidth+x/8] |= 0x80 >> uint(x%8)
-			}
+// orient turns p into a canvas with a row for each raster line and pins columns, target names the media in errors
+func orient(p image.Image, pins int, target string, rotate Rotation) (image.Image, error) {
+	size := p.Bounds().Size()
+	switch rotate {
+	case RotateAuto:
+		if size.X == pins {
+			return imaging.FlipH(p), nil
+		} else if size.Y == pins {
+			return imaging.Transpose(p), nil
+		}
+		return nil, fmt.Errorf("image size must have %dpx width or height for %s, got: %dx%d", pins, target, size.X, size.Y)
+	case Rotate0, Rotate90, Rotate180, Rotate270:
+		switch rotate {
+		case Rotate90:
+			p = imaging.Rotate270(p) // imaging turns counterclockwise
+		case Rotate180:
+			p = imaging.Rotate180(p)
+		case Rotate270:
+			p = imaging.Rotate90(p)
 		}
+		if size = p.Bounds().Size(); size.Y != pins {
+			return nil, fmt.Errorf("image must be %dpx high for %s after rotating by %s, got: %dx%d", pins, target, rotate, size.X, size.Y)
+		}
+		return imaging.Transpose(p), nil
 	}
+	return nil, fmt.Errorf("unknown rotation %d", int(rotate))
+}
 
-	return data, bytesWidth, nil
+// threshold returns Threshold with the default for zero
+func (o ConvertOptions) threshold() (float64, error) {
+	threshold := o.Threshold
+	if threshold == 0 {
+		threshold = 0.5
+	}
+	if threshold < 0 || threshold > 1 {
+		return 0, fmt.Errorf("threshold %g is out of range 0-1", threshold)
+	}
+	return threshold, nil
 }
 
 func CompressImage(data []byte, bytesWidth int) ([]byte, error) {
@@ -586,11 +1043,21 @@ func CompressImage(data []byte, bytesWidth int) ([]byte, error) {
 }
 
 func parseStatus(in []byte) (*Status, error) {
-	if len(in) != 32 {
-		return nil, fmt.Errorf("status must be 32 bytes, got: %d", len(in))
+	if len(in) != statusSize {
+		return nil, fmt.Errorf("status must be %d bytes, got: %d", statusSize, len(in))
+	}
+	if !bytes.HasPrefix(in, statusHeader) {
+		return nil, fmt.Errorf("invalid status header % x", in[:len(statusHeader)])
+	}
+	series := in[statusOffsetSeries]
+	if series != statusSeriesPT && series != statusSeriesQL {
+		return nil, fmt.Errorf("unknown printer series %#02x in status", series)
+	}
+	if m := Model(in[statusOffsetModel]); m.Supported() && m.QL() != (series == statusSeriesQL) {
+		return nil, fmt.Errorf("status of %s has the series byte %#02x of another series", m, series)
 	}
 
-	return &Status{
+	st := &Status{
 		Type:         StatusType(in[statusOffsetStatusType]),
 		Model:        Model(in[statusOffsetModel]),
 		Battery:      BatteryStatusType(in[statusOffsetBattery]),
@@ -606,7 +1073,9 @@ func parseStatus(in []byte) (*Status, error) {
 		TapeLength:   int(in[statusOffsetTapeLength]),
 		TapeWidth:    TapeWidth(in[statusOffsetMediaWidth]),
 		FontColor:    FontColor(in[statusOffsetFontColor]),
-	}, nil
+	}
+	copy(st.HardwareSettings[:], in[statusOffsetHardwareConf:])
+	return st, nil
 }
 
 func setBit(n int, pos uint) int {
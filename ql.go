@@ -0,0 +1,473 @@
+package ptouchgo
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"log"
+
+	"github.com/ka2n/ptouchgo/models"
+)
+
+// uncompressed raster line commands of QL printers, followed by the bytes of a line and the line
+var (
+	cmdQLRasterTransfer = []byte{0x67, 0x00}
+	cmdQLRasterBlack    = []byte{0x77, 0x01}
+	cmdQLRasterRed      = []byte{0x77, 0x02}
+)
+
+// bits of the extended mode of QL printers
+const (
+	qlExtendedTwoColor = 1 << 0
+	qlExtendedCutAtEnd = 1 << 3
+	qlExtendedHighDPI  = 1 << 6
+)
+
+// qlRednessThreshold is how much red exceeds the other channels of a pixel printed red
+const qlRednessThreshold = 0.25
+
+var (
+	errQLHalfCut      = errors.New("QL printers have no half cutter")
+	errQLDieCutLength = errors.New("die-cut labels have the length of the label, Length can not be set")
+)
+
+// QL reports whether m is a QL label printer, printing on QLMedia with the QL raster protocol.
+// The TD series speaks it too.
+func (m Model) QL() bool {
+	spec, ok := m.spec()
+	return ok && (spec.Family == models.QL || spec.Family == models.TD)
+}
+
+// TD reports whether m is a printer of the TD series, printing on the RD rolls of QLMedia
+func (m Model) TD() bool {
+	spec, ok := m.spec()
+	return ok && spec.Family == models.TD
+}
+
+// qlSpec returns the capabilities of the model of s, printing is not restricted for an unknown model
+func (s Serial) qlSpec() models.Spec {
+	if m := s.model(); m.QL() {
+		spec, _ := m.spec()
+		return spec
+	}
+	return qlDefaultFor(s.Media)
+}
+
+var (
+	// qlDefault is the QL model taking every setting, used for unknown QL models
+	qlDefault, _ = models.Lookup(byte(modelQL820NWB))
+	// qlWide is used for unknown QL models loaded with media of the QL-1100 series
+	qlWide, _ = models.Lookup(byte(modelQL1100))
+	// tdDefault is used for unknown models loaded with RD rolls
+	tdDefault, _ = models.Lookup(byte(modelTD2130N))
+)
+
+// qlDefaultFor returns the model printing on media when the model is unknown
+func qlDefaultFor(media QLMedia) models.Spec {
+	switch {
+	case media.TD:
+		return tdDefault
+	case !qlFits(qlDefault, media):
+		return qlWide
+	}
+	return qlDefault
+}
+
+// qlFits reports whether the head of spec spans media
+func qlFits(spec models.Spec, media QLMedia) bool {
+	return media.Offset+media.Dots <= spec.HeadPins
+}
+
+// ql reports whether s prints with the QL raster protocol
+func (s Serial) ql() bool {
+	return s.Media.Dots != 0
+}
+
+// QLMedia is a roll of QL printers, continuous length tape or die-cut labels
+type QLMedia struct {
+	Name       string // like "62" for 62mm continuous length tape or "62x29" for die-cut labels
+	WidthMM    int    // width reported by the printer
+	LengthMM   int    // length of a die-cut label reported by the printer, zero for continuous length tape
+	Dots       int    // printable dots across the media, images must be as wide or high
+	LengthDots int    // raster lines of a die-cut label
+	Offset     int    // head pins before the first printable dot of a raster line
+	TwoColor   bool   // black and red tape of the QL-800 series
+	TD         bool   // RD rolls of the TD series
+}
+
+// DieCut reports whether m are die-cut labels
+func (m QLMedia) DieCut() bool {
+	return m.LengthMM != 0
+}
+
+func (m QLMedia) String() string {
+	if m.DieCut() {
+		return m.Name + " die-cut labels"
+	}
+	return m.Name + " continuous length tape"
+}
+
+// qlMedia are the rolls of QL printers, continuous length tape first
+var qlMedia = []QLMedia{
+	{Name: "12", WidthMM: 12, Dots: 106, Offset: 29},
+	{Name: "29", WidthMM: 29, Dots: 306, Offset: 6},
+	{Name: "38", WidthMM: 38, Dots: 413, Offset: 12},
+	{Name: "50", WidthMM: 50, Dots: 554, Offset: 12},
+	{Name: "54", WidthMM: 54, Dots: 590, Offset: 0},
+	{Name: "62", WidthMM: 62, Dots: 696, Offset: 12},
+	{Name: "62red", WidthMM: 62, Dots: 696, Offset: 12, TwoColor: true},
+	{Name: "102", WidthMM: 102, Dots: 1164, Offset: 12},
+	{Name: "17x54", WidthMM: 17, LengthMM: 54, Dots: 165, LengthDots: 566, Offset: 0},
+	{Name: "17x87", WidthMM: 17, LengthMM: 87, Dots: 165, LengthDots: 956, Offset: 0},
+	{Name: "23x23", WidthMM: 23, LengthMM: 23, Dots: 202, LengthDots: 202, Offset: 42},
+	{Name: "29x42", WidthMM: 29, LengthMM: 42, Dots: 306, LengthDots: 425, Offset: 6},
+	{Name: "29x90", WidthMM: 29, LengthMM: 90, Dots: 306, LengthDots: 991, Offset: 6},
+	{Name: "39x90", WidthMM: 38, LengthMM: 90, Dots: 413, LengthDots: 991, Offset: 12},
+	{Name: "39x48", WidthMM: 39, LengthMM: 48, Dots: 425, LengthDots: 495, Offset: 6},
+	{Name: "52x29", WidthMM: 52, LengthMM: 29, Dots: 578, LengthDots: 271, Offset: 0},
+	{Name: "62x29", WidthMM: 62, LengthMM: 29, Dots: 696, LengthDots: 271, Offset: 12},
+	{Name: "62x100", WidthMM: 62, LengthMM: 100, Dots: 696, LengthDots: 1109, Offset: 12},
+	{Name: "102x51", WidthMM: 102, LengthMM: 51, Dots: 1164, LengthDots: 526, Offset: 12},
+	{Name: "102x152", WidthMM: 102, LengthMM: 152, Dots: 1164, LengthDots: 1660, Offset: 12},
+	{Name: "d12", WidthMM: 12, LengthMM: 12, Dots: 94, LengthDots: 94, Offset: 113},
+	{Name: "d24", WidthMM: 24, LengthMM: 24, Dots: 236, LengthDots: 236, Offset: 42},
+	{Name: "d58", WidthMM: 58, LengthMM: 58, Dots: 618, LengthDots: 618, Offset: 51},
+	// RD rolls of the TD series at 203 dpi, receipt paper first
+	{Name: "rd40", WidthMM: 40, Dots: 296, Offset: 76, TD: true},
+	{Name: "rd51", WidthMM: 51, Dots: 384, Offset: 32, TD: true},
+	{Name: "rd58", WidthMM: 58, Dots: 432, Offset: 8, TD: true},
+	{Name: "rd51x26", WidthMM: 51, LengthMM: 26, Dots: 384, LengthDots: 208, Offset: 32, TD: true},
+	{Name: "rd40x40", WidthMM: 40, LengthMM: 40, Dots: 296, LengthDots: 320, Offset: 76, TD: true},
+}
+
+// QLMediaList lists the rolls of QL printers, continuous length tape first
+func QLMediaList() []QLMedia {
+	return append([]QLMedia(nil), qlMedia...)
+}
+
+// ParseQLMedia returns the roll named like "62", "62red" or "29x90"
+func ParseQLMedia(name string) (QLMedia, error) {
+	for _, m := range qlMedia {
+		if m.Name == name {
+			return m, nil
+		}
+	}
+	return QLMedia{}, fmt.Errorf("unknown QL media %q", name)
+}
+
+// QLMediaFor returns the roll of the width and length in mm reported by a QL printer,
+// zero length is continuous length tape. Black and red tape is reported like black tape.
+func QLMediaFor(widthMM, lengthMM int) (QLMedia, error) {
+	return qlMediaFor(widthMM, lengthMM, false)
+}
+
+// QLMediaFor returns the roll of m of the width and length in mm it reported, see QLMediaFor
+func (m Model) QLMediaFor(widthMM, lengthMM int) (QLMedia, error) {
+	return qlMediaFor(widthMM, lengthMM, m.TD())
+}
+
+// qlMediaFor is QLMediaFor of the RD rolls with td
+func qlMediaFor(widthMM, lengthMM int, td bool) (QLMedia, error) {
+	for _, m := range qlMedia {
+		if m.WidthMM == widthMM && m.LengthMM == lengthMM && !m.TwoColor && m.TD == td {
+			return m, nil
+		}
+	}
+	if lengthMM != 0 {
+		return QLMedia{}, fmt.Errorf("unsupported QL media: %dx%dmm die-cut labels", widthMM, lengthMM)
+	}
+	return QLMedia{}, fmt.Errorf("unsupported QL media: %dmm continuous length tape", widthMM)
+}
+
+// UseStatus sets the model and the loaded media reported by st, failing when the media can not be printed on
+func (s *Serial) UseStatus(st *Status) error {
+	if st.Model.QL() {
+		length := st.TapeLength
+		switch st.MediaType {
+		case mediaTypeContinuous:
+			length = 0
+		case mediaTypeDieCut:
+			if length == 0 {
+				return fmt.Errorf("unsupported QL media: %dmm die-cut labels of unknown length", st.TapeWidth)
+			}
+		default:
+			return fmt.Errorf("unsupported QL media: %s", st.MediaType)
+		}
+		media, err := st.Model.QLMediaFor(int(st.TapeWidth), length)
+		if err != nil {
+			return err
+		}
+		s.Model, s.Media, s.HeatShrink, s.Fabric = st.Model, media, false, false
+		s.TapeWidthMM = uint(st.TapeWidth)
+		return nil
+	}
+	heatShrink := st.HeatShrink()
+	switch {
+	case heatShrink && !st.Model.HeatShrink(st.TapeWidth),
+		!heatShrink && (!st.TapeWidth.Valid() || st.TapeWidth.PrintableDots() == 0 && !st.Model.tapeWidth(st.TapeWidth)):
+		return fmt.Errorf("unsupported tape loaded: %s, %s", st.TapeWidth, st.MediaType)
+	}
+	s.Model, s.Media, s.HeatShrink, s.Fabric = st.Model, QLMedia{}, heatShrink, st.Fabric()
+	s.TapeWidthMM = uint(st.TapeWidth)
+	return nil
+}
+
+// ConvertQLImage turns p into raster lines of the QL print head for media,
+// red holds the lines of the red dots for TwoColor media and is nil otherwise.
+// The lines span the head of the QL-800 series, or of the QL-1100 series for media only it prints on.
+func ConvertQLImage(p image.Image, media QLMedia, opts ConvertOptions) (black, red []byte, err error) {
+	return convertQLImage(p, media, opts, qlDefaultFor(media).LineBytes())
+}
+
+// convertQLImage is ConvertQLImage for a head with lines of lineBytes
+func convertQLImage(p image.Image, media QLMedia, opts ConvertOptions, lineBytes int) (black, red []byte, err error) {
+	if media.Dots == 0 {
+		return nil, nil, errors.New("no QL media selected")
+	}
+	if media.Offset+media.Dots > lineBytes*8 {
+		return nil, nil, fmt.Errorf("%s is wider than the %d pins of the print head", media, lineBytes*8)
+	}
+	canvas, err := orient(p, media.Dots, media.Name+" media", opts.Rotate)
+	if err != nil {
+		return nil, nil, err
+	}
+	threshold, err := opts.threshold()
+	if err != nil {
+		return nil, nil, err
+	}
+	if !media.TwoColor {
+		data, bytesWidth, err := rasterize(canvas, opts.Dither, threshold)
+		if err != nil {
+			return nil, nil, err
+		}
+		return qlLines(data, bytesWidth, media, lineBytes), nil, nil
+	}
+
+	blackCanvas, redCanvas := splitRed(canvas)
+	data, bytesWidth, err := rasterize(blackCanvas, opts.Dither, threshold)
+	if err != nil {
+		return nil, nil, err
+	}
+	black = qlLines(data, bytesWidth, media, lineBytes)
+	if data, bytesWidth, err = rasterize(redCanvas, opts.Dither, threshold); err != nil {
+		return nil, nil, err
+	}
+	return black, qlLines(data, bytesWidth, media, lineBytes), nil
+}
+
+// splitRed separates the red pixels of canvas from the others, red pixels are as dark as they are red
+func splitRed(canvas image.Image) (black, red *image.RGBA) {
+	b := canvas.Bounds()
+	black = image.NewRGBA(b)
+	red = image.NewRGBA(b)
+	white := color.RGBA{0xff, 0xff, 0xff, 0xff}
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			c := canvas.At(x, y)
+			r, g, bl, _ := c.RGBA()
+			other := g
+			if bl > other {
+				other = bl
+			}
+			redness := 0.0
+			if r > other {
+				redness = float64(r-other) / 0xffff
+			}
+			if redness < qlRednessThreshold {
+				black.Set(x, y, c)
+				red.Set(x, y, white)
+				continue
+			}
+			black.Set(x, y, white)
+			v := uint8((1 - redness) * 0xff)
+			red.Set(x, y, color.RGBA{v, v, v, 0xff})
+		}
+	}
+	return black, red
+}
+
+// qlLines places raster data of media.Dots wide lines on raster lines of lineBytes at media.Offset
+func qlLines(data []byte, bytesWidth int, media QLMedia, lineBytes int) []byte {
+	lines := len(data) / bytesWidth
+	out := make([]byte, lines*lineBytes)
+	for y := 0; y < lines; y++ {
+		for x := 0; x < media.Dots; x++ {
+			if data[y*bytesWidth+x/8]&(0x80>>uint(x%8)) != 0 {
+				pin := media.Offset + x
+				out[y*lineBytes+pin/8] |= 0x80 >> uint(pin%8)
+			}
+		}
+	}
+	return out
+}
+
+// qlPages converts imgs into pages of QL raster commands for s.Media,
+// images shorter than a die-cut label are centered on it
+func (s Serial) qlPages(imgs []image.Image, opts PrintOptions) ([]page, error) {
+	if opts.HalfCut {
+		return nil, errQLHalfCut
+	}
+	spec := s.qlSpec()
+	if m := s.model(); m.QL() {
+		switch {
+		case s.Media.WidthMM > spec.MaxTapeWidth() || s.Media.TD != m.TD():
+			return nil, &UnsupportedError{Model: m, Feature: s.Media.String(), Media: true}
+		case s.Media.TwoColor && !spec.TwoColor:
+			return nil, &UnsupportedError{Model: m, Feature: "black and red printing", Media: true}
+		case opts.HighDPI && !spec.HighDPI:
+			return nil, &UnsupportedError{Model: m, Feature: "high resolution printing"}
+		case opts.AutoCut && opts.ChainPrint && !spec.CutAtEnd:
+			return nil, &UnsupportedError{Model: m, Feature: "chain printing, it cuts after the last label too"}
+		}
+	}
+	length := opts.Length
+	if s.Media.DieCut() {
+		if length != 0 {
+			return nil, errQLDieCutLength
+		}
+		length = s.Media.LengthDots
+		if opts.HighDPI {
+			length *= 2
+		}
+	}
+	lineBytes := spec.LineBytes()
+	pages := make([]page, len(imgs))
+	for i, img := range imgs {
+		black, red, err := convertQLImage(img, s.Media, opts.ConvertOptions, lineBytes)
+		if lines := len(black) / lineBytes; err == nil && s.Media.DieCut() && lines > length {
+			err = fmt.Errorf("image is %d raster lines long, longer than the %d of the %s", lines, length, s.Media)
+		}
+		if err == nil {
+			black, red, err = padQLLength(black, red, lineBytes, length)
+		}
+		if err != nil {
+			if len(imgs) > 1 {
+				return nil, fmt.Errorf("page %d: %w", i+1, err)
+			}
+			return nil, err
+		}
+		// QL printers have no mirror printing, the lines are sent in reverse
+		if opts.Mirror {
+			reverseLines(black, lineBytes)
+			reverseLines(red, lineBytes)
+		}
+		pages[i].rasterLines = len(black) / lineBytes
+		pages[i].packedData = qlRaster(black, red, lineBytes)
+	}
+	return pages, nil
+}
+
+// padQLLength is padLength for both colors
+func padQLLength(black, red []byte, lineBytes, length int) ([]byte, []byte, error) {
+	black, err := padLength(black, lineBytes, length)
+	if err != nil || red == nil {
+		return black, nil, err
+	}
+	red, err = padLength(red, lineBytes, length)
+	return black, red, err
+}
+
+func reverseLines(data []byte, lineBytes int) {
+	lines := len(data) / lineBytes
+	line := make([]byte, lineBytes)
+	for i, j := 0, lines-1; i < j; i, j = i+1, j-1 {
+		a, b := data[i*lineBytes:(i+1)*lineBytes], data[j*lineBytes:(j+1)*lineBytes]
+		copy(line, a)
+		copy(a, b)
+		copy(b, line)
+	}
+}
+
+// qlRaster frames the raster lines as uncompressed raster commands,
+// with red the black and red line of each raster line follow each other
+func qlRaster(black, red []byte, lineBytes int) []byte {
+	lines := len(black) / lineBytes
+	cmd := cmdQLRasterTransfer
+	if red != nil {
+		cmd = cmdQLRasterBlack
+	}
+	out := make([]byte, 0, (len(black)+len(red))*(lineBytes+len(cmd)+1)/lineBytes)
+	for y := 0; y < lines; y++ {
+		out = append(append(out, cmd...), byte(lineBytes))
+		out = append(out, black[y*lineBytes:(y+1)*lineBytes]...)
+		if red != nil {
+			out = append(append(out, cmdQLRasterRed...), byte(lineBytes))
+			out = append(out, red[y*lineBytes:(y+1)*lineBytes]...)
+		}
+	}
+	return out
+}
+
+// setQLPage sends the settings of a page of a QL job
+func (s Serial) setQLPage(p page, n byte, opts PrintOptions) error {
+	if err := s.setQLPrintProperty(p.rasterLines, n); err != nil {
+		return err
+	}
+	spec := s.qlSpec()
+	// the TD series tears labels off, cutting is left out
+	cut := opts.AutoCut && spec.AutoCut
+	if err := s.SetPrintMode(cut, false); err != nil {
+		return err
+	}
+	if cut && opts.CutEvery > 1 {
+		if err := s.SetAutocutPerPagesForPTP750W(opts.CutEvery); err != nil {
+			return err
+		}
+	}
+	cutAtEnd := !opts.ChainPrint && spec.CutAtEnd
+	if err := s.setQLExtendedMode(cutAtEnd, s.Media.TwoColor, opts.HighDPI); err != nil {
+		return err
+	}
+	margin := spec.Margin
+	if s.Media.DieCut() {
+		margin = 0
+	}
+	return s.SetFeedAmount(margin)
+}
+
+// setQLPrintProperty sends the print information of a page for s.Media
+func (s Serial) setQLPrintProperty(rasterLines int, page byte) error {
+	enableFlag := printPropertyEnableBitRecoverOnDevice | printPropertyEnableBitMedia | printPropertyEnableBitWidth | printPropertyEnableBitLength
+	spec := s.qlSpec()
+	mediaType := spec.ContinuousMedia
+	if s.Media.DieCut() {
+		mediaType = spec.DieCutMedia
+	}
+	r := rasterLines
+	data := append(cmdSetPrintPropertyPrefix, []byte{
+		byte(enableFlag),
+		byte(mediaType),
+		byte(s.Media.WidthMM),
+		byte(s.Media.LengthMM),
+		byte(r), byte(r >> 8), byte(r >> 16), byte(r >> 24),
+		page,
+		0x00,
+	}...)
+	if s.Debug {
+		log.Println("SetPrintProperty", hex.EncodeToString(data))
+	}
+	_, err := s.Conn.Write(data)
+	return err
+}
+
+// setQLExtendedMode is SetExtendedMode of QL printers
+func (s Serial) setQLExtendedMode(cutAtEnd, twoColor, highDPI bool) error {
+	var v int
+	if cutAtEnd {
+		v |= qlExtendedCutAtEnd
+	}
+	if twoColor {
+		v |= qlExtendedTwoColor
+	}
+	if highDPI {
+		v |= qlExtendedHighDPI
+	}
+	payload := append(cmdSetExtendedModePrefix, byte(v))
+	if s.Debug {
+		log.Println("SetExtendedMode", hex.EncodeToString(payload))
+	}
+	_, err := s.Conn.Write(payload)
+	return err
+}
@@ -0,0 +1,183 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/ka2n/ptouchgo"
+)
+
+// Client is a submitter of jobs sharing the printers with others, like the user of an API key.
+// Zero fields do not limit it.
+type Client struct {
+	Name string
+	// Priority orders the jobs of the clients, the jobs of higher ones print first
+	Priority int
+	// Rate is the number of jobs it may submit per minute
+	Rate float64
+	// Burst is the number of jobs it may submit at once within Rate, Rate when zero
+	Burst int
+	// DailyMM is the length of tape it may print in a day, a job is submitted while its estimated length fits
+	// into what is left after the tape printed today and the estimates of the queued jobs
+	DailyMM float64
+}
+
+// Usage is what a client printed, the jobs of clients which are not set are accounted as well
+type Usage struct {
+	Client   string  `json:"client"`
+	Jobs     int     `json:"jobs"` // completed
+	Failed   int     `json:"failed"`
+	Labels   int     `json:"labels"`
+	LengthMM float64 `json:"length_mm"` // printed, without the feed margins
+	Day      string  `json:"day"`       // of DayMM like "2021-07-01", in local time
+	DayMM    float64 `json:"day_mm"`
+}
+
+// UsageStore is a Store keeping the usage of the clients as well
+type UsageStore interface {
+	PutUsage(u Usage) error
+	Usages() ([]Usage, error)
+}
+
+// client is the state of a client of a Spooler
+type client struct {
+	Client
+	tokens   float64 // of Rate
+	filled   time.Time
+	usage    Usage
+	queuedMM float64 // estimated length of the jobs which are not finished
+}
+
+// SetClient sets the limits of the client named c.Name, it is called before jobs are submitted
+func (s *Spooler) SetClient(c Client) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cl := s.client(c.Name)
+	cl.Client = c
+	cl.tokens, cl.filled = cl.burst(), time.Now()
+}
+
+// client returns the state of the client of name, s.mu is held
+func (s *Spooler) client(name string) *client {
+	cl, ok := s.clients[name]
+	if !ok {
+		cl = &client{Client: Client{Name: name}, usage: Usage{Client: name}}
+		s.clients[name] = cl
+	}
+	return cl
+}
+
+func (c *client) burst() float64 {
+	if c.Burst > 0 {
+		return float64(c.Burst)
+	}
+	return math.Max(1, c.Rate)
+}
+
+// admit checks the limits of the client of a job of estimateMM being submitted and returns its priority,
+// the estimate is queued until the job is accounted. s.mu is held.
+func (s *Spooler) admit(name string, estimateMM float64) (int, error) {
+	cl, ok := s.clients[name]
+	if !ok {
+		return 0, nil
+	}
+	now := time.Now()
+	if cl.DailyMM > 0 {
+		printed := 0.0
+		if cl.usage.Day == day(now) {
+			printed = cl.usage.DayMM
+		}
+		if printed+cl.queuedMM+estimateMM > cl.DailyMM {
+			return 0, fmt.Errorf("%s printed %.0fmm of %.0fmm and queued %.0fmm, the job needs about %.0fmm: %w",
+				name, printed, cl.DailyMM, cl.queuedMM, estimateMM, ErrQuotaExceeded)
+		}
+	}
+	if cl.Rate > 0 {
+		cl.tokens = math.Min(cl.burst(), cl.tokens+now.Sub(cl.filled).Minutes()*cl.Rate)
+		cl.filled = now
+		if cl.tokens < 1 {
+			wait := time.Duration((1 - cl.tokens) / cl.Rate * float64(time.Minute))
+			return 0, fmt.Errorf("%s: %w, try again in %s", name, ErrRateLimited, wait.Round(time.Second))
+		}
+		cl.tokens--
+	}
+	cl.queuedMM += estimateMM
+	return cl.Priority, nil
+}
+
+// unqueue removes the estimated length of j from the queued length of its client, s.mu is held
+func (s *Spooler) unqueue(j *Job) {
+	cl := s.client(j.Client)
+	cl.queuedMM = math.Max(0, cl.queuedMM-j.estimateMM)
+	j.estimateMM = 0
+}
+
+// estimatedLabelMM is the estimated length of a label of text without LengthMM, about a word on 24mm tape
+const estimatedLabelMM = 25
+
+// estimateMM estimates the length of tape req prints without the feed margins, labels are padded to LengthMM.
+// Images are taken to print along their longer side at the resolution of PT printers.
+func estimateMM(req *PrintRequest) float64 {
+	labelMM := func(mm float64) float64 {
+		return math.Max(mm, req.LengthMM)
+	}
+	var mm float64
+	for _, img := range req.Images {
+		size := img.Bounds().Size()
+		mm += labelMM(math.Max(float64(size.X), float64(size.Y)) * 25.4 / ptouchgo.DPI)
+	}
+	if req.Label() {
+		mm += labelMM(estimatedLabelMM)
+	}
+	copies := req.Copies
+	if copies < 1 {
+		copies = 1
+	}
+	return mm * float64(copies)
+}
+
+// account adds the finished job j to the usage of its client, s.mu is held
+func (s *Spooler) account(j *Job) {
+	s.unqueue(j)
+	u := &s.client(j.Client).usage
+	if today := day(time.Now()); u.Day != today {
+		u.Day, u.DayMM = today, 0
+	}
+	if j.State == JobFailed {
+		u.Failed++
+	} else if r := j.Result; r != nil {
+		u.Jobs++
+		u.Labels += r.Labels
+		u.LengthMM += r.LengthMM
+		u.DayMM += r.LengthMM
+	}
+	if us, ok := s.store.(UsageStore); ok {
+		if err := us.PutUsage(*u); err != nil {
+			log.Printf("usage of %s: store: %v\n", j.Client, err)
+		}
+	}
+}
+
+// Usage returns what the clients printed by their names, the one of jobs without a client is named ""
+func (s *Spooler) Usage() []Usage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	list := make([]Usage, 0, len(s.clients))
+	today := day(time.Now())
+	for _, cl := range s.clients {
+		u := cl.usage
+		if u.Day != today {
+			u.Day, u.DayMM = today, 0
+		}
+		list = append(list, u)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Client < list[j].Client })
+	return list
+}
+
+func day(t time.Time) string {
+	return t.Local().Format("2006-01-02")
+}
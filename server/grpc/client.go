@@ -0,0 +1,107 @@
+package grpcserver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/ka2n/ptouchgo/server/grpc/printpb"
+	"google.golang.org/grpc"
+)
+
+// Client calls the Printing service of a server
+type Client struct {
+	conn   *grpc.ClientConn
+	client printpb.PrintingClient
+}
+
+// Dial connects to the server at address without TLS, give grpc.WithTransportCredentials in opts for it.
+// apiKey is sent with every call when it is not empty.
+func Dial(address, apiKey string, opts ...grpc.DialOption) (*Client, error) {
+	opts = append([]grpc.DialOption{grpc.WithInsecure()}, opts...)
+	if apiKey != "" {
+		opts = append(opts, grpc.WithPerRPCCredentials(apiKeyCredentials(apiKey)))
+	}
+	conn, err := grpc.Dial(address, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("grpc: %w", err)
+	}
+	return &Client{conn: conn, client: printpb.NewPrintingClient(conn)}, nil
+}
+
+// Close closes the connection
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// Submit submits a job and waits until it is finished, progress is called with each update when not nil.
+// The error of a failed job is returned with its last update.
+func (c *Client) Submit(ctx context.Context, req *printpb.SubmitJobRequest, progress func(*printpb.JobUpdate)) (*printpb.JobUpdate, error) {
+	stream, err := c.client.SubmitJob(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	var last *printpb.JobUpdate
+	for {
+		u, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return last, err
+		}
+		last = u
+		if progress != nil {
+			progress(u)
+		}
+	}
+	switch {
+	case last == nil:
+		return nil, errors.New("grpc: the job stream ended without updates")
+	case last.State == printpb.JobState_JOB_STATE_FAILED:
+		return last, fmt.Errorf("job %s on %s: %s", last.Id, last.Printer, last.Error)
+	}
+	return last, nil
+}
+
+// WatchStatus calls f with the status of printer whenever it changes until ctx is done or f returns false,
+// intervalSeconds 0 uses the interval of the server
+func (c *Client) WatchStatus(ctx context.Context, printer string, intervalSeconds int, f func(*printpb.PrinterStatus) bool) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stream, err := c.client.StreamStatus(ctx, &printpb.StreamStatusRequest{Printer: printer, IntervalSeconds: int32(intervalSeconds)})
+	if err != nil {
+		return err
+	}
+	for {
+		st, err := stream.Recv()
+		if err != nil {
+			return err
+		}
+		if !f(st) {
+			return nil
+		}
+	}
+}
+
+// Printers lists the printers of the server
+func (c *Client) Printers(ctx context.Context) ([]*printpb.Printer, error) {
+	res, err := c.client.ListPrinters(ctx, &printpb.ListPrintersRequest{})
+	if err != nil {
+		return nil, err
+	}
+	return res.Printers, nil
+}
+
+// apiKeyCredentials sends an API key in the metadata of every call
+type apiKeyCredentials string
+
+func (k apiKeyCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + string(k)}, nil
+}
+
+// RequireTransportSecurity is false to send keys on connections without TLS inside a trusted network
+func (apiKeyCredentials) RequireTransportSecurity() bool {
+	return false
+}
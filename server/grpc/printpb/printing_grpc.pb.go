@@ -0,0 +1,234 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+
+package printpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// PrintingClient is the client API for Printing service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type PrintingClient interface {
+	// SubmitJob queues a job and streams its state until it is completed or failed.
+	SubmitJob(ctx context.Context, in *SubmitJobRequest, opts ...grpc.CallOption) (Printing_SubmitJobClient, error)
+	// StreamStatus streams the status of a printer, it is read between jobs and sent when it changes.
+	StreamStatus(ctx context.Context, in *StreamStatusRequest, opts ...grpc.CallOption) (Printing_StreamStatusClient, error)
+	// ListPrinters lists the printers of the server.
+	ListPrinters(ctx context.Context, in *ListPrintersRequest, opts ...grpc.CallOption) (*ListPrintersResponse, error)
+}
+
+type printingClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewPrintingClient(cc grpc.ClientConnInterface) PrintingClient {
+	return &printingClient{cc}
+}
+
+func (c *printingClient) SubmitJob(ctx context.Context, in *SubmitJobRequest, opts ...grpc.CallOption) (Printing_SubmitJobClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Printing_ServiceDesc.Streams[0], "/ptouchgo.v1.Printing/SubmitJob", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &printingSubmitJobClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Printing_SubmitJobClient interface {
+	Recv() (*JobUpdate, error)
+	grpc.ClientStream
+}
+
+type printingSubmitJobClient struct {
+	grpc.ClientStream
+}
+
+func (x *printingSubmitJobClient) Recv() (*JobUpdate, error) {
+	m := new(JobUpdate)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *printingClient) StreamStatus(ctx context.Context, in *StreamStatusRequest, opts ...grpc.CallOption) (Printing_StreamStatusClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Printing_ServiceDesc.Streams[1], "/ptouchgo.v1.Printing/StreamStatus", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &printingStreamStatusClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Printing_StreamStatusClient interface {
+	Recv() (*PrinterStatus, error)
+	grpc.ClientStream
+}
+
+type printingStreamStatusClient struct {
+	grpc.ClientStream
+}
+
+func (x *printingStreamStatusClient) Recv() (*PrinterStatus, error) {
+	m := new(PrinterStatus)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *printingClient) ListPrinters(ctx context.Context, in *ListPrintersRequest, opts ...grpc.CallOption) (*ListPrintersResponse, error) {
+	out := new(ListPrintersResponse)
+	err := c.cc.Invoke(ctx, "/ptouchgo.v1.Printing/ListPrinters", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PrintingServer is the server API for Printing service.
+// All implementations must embed UnimplementedPrintingServer
+// for forward compatibility
+type PrintingServer interface {
+	// SubmitJob queues a job and streams its state until it is completed or failed.
+	SubmitJob(*SubmitJobRequest, Printing_SubmitJobServer) error
+	// StreamStatus streams the status of a printer, it is read between jobs and sent when it changes.
+	StreamStatus(*StreamStatusRequest, Printing_StreamStatusServer) error
+	// ListPrinters lists the printers of the server.
+	ListPrinters(context.Context, *ListPrintersRequest) (*ListPrintersResponse, error)
+	mustEmbedUnimplementedPrintingServer()
+}
+
+// UnimplementedPrintingServer must be embedded to have forward compatible implementations.
+type UnimplementedPrintingServer struct {
+}
+
+func (UnimplementedPrintingServer) SubmitJob(*SubmitJobRequest, Printing_SubmitJobServer) error {
+	return status.Errorf(codes.Unimplemented, "method SubmitJob not implemented")
+}
+func (UnimplementedPrintingServer) StreamStatus(*StreamStatusRequest, Printing_StreamStatusServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamStatus not implemented")
+}
+func (UnimplementedPrintingServer) ListPrinters(context.Context, *ListPrintersRequest) (*ListPrintersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListPrinters not implemented")
+}
+func (UnimplementedPrintingServer) mustEmbedUnimplementedPrintingServer() {}
+
+// UnsafePrintingServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to PrintingServer will
+// result in compilation errors.
+type UnsafePrintingServer interface {
+	mustEmbedUnimplementedPrintingServer()
+}
+
+func RegisterPrintingServer(s grpc.ServiceRegistrar, srv PrintingServer) {
+	s.RegisterService(&Printing_ServiceDesc, srv)
+}
+
+func _Printing_SubmitJob_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubmitJobRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(PrintingServer).SubmitJob(m, &printingSubmitJobServer{stream})
+}
+
+type Printing_SubmitJobServer interface {
+	Send(*JobUpdate) error
+	grpc.ServerStream
+}
+
+type printingSubmitJobServer struct {
+	grpc.ServerStream
+}
+
+func (x *printingSubmitJobServer) Send(m *JobUpdate) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Printing_StreamStatus_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamStatusRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(PrintingServer).StreamStatus(m, &printingStreamStatusServer{stream})
+}
+
+type Printing_StreamStatusServer interface {
+	Send(*PrinterStatus) error
+	grpc.ServerStream
+}
+
+type printingStreamStatusServer struct {
+	grpc.ServerStream
+}
+
+func (x *printingStreamStatusServer) Send(m *PrinterStatus) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Printing_ListPrinters_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListPrintersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PrintingServer).ListPrinters(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/ptouchgo.v1.Printing/ListPrinters",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PrintingServer).ListPrinters(ctx, req.(*ListPrintersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Printing_ServiceDesc is the grpc.ServiceDesc for Printing service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Printing_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "ptouchgo.v1.Printing",
+	HandlerType: (*PrintingServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListPrinters",
+			Handler:    _Printing_ListPrinters_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "SubmitJob",
+			Handler:       _Printing_SubmitJob_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "StreamStatus",
+			Handler:       _Printing_StreamStatus_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "printpb/printing.proto",
+}
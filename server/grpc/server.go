@@ -0,0 +1,317 @@
+// Package grpcserver serves the printers of a server.Spooler with the Printing service of printpb/printing.proto
+// and has a client of it. With API keys every call needs one in the "authorization: Bearer KEY" metadata.
+package grpcserver
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative printpb/printing.proto
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"strings"
+	"time"
+
+	"github.com/ka2n/ptouchgo"
+	"github.com/ka2n/ptouchgo/server"
+	"github.com/ka2n/ptouchgo/server/grpc/printpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// defaultStatusInterval is the interval of StreamStatus without interval_seconds
+const defaultStatusInterval = 5 * time.Second
+
+// Server implements the Printing service on its spooler
+type Server struct {
+	printpb.UnimplementedPrintingServer
+
+	// Defaults of the fields of submitted jobs
+	Defaults server.PrintRequest
+	// Clients are the names of the server.Client of API keys
+	Clients map[string]string
+
+	spooler *server.Spooler
+	keys    []string
+}
+
+// New returns a server of the printers of spooler requiring one of apiKeys, no keys serve everyone
+func New(spooler *server.Spooler, apiKeys []string) *Server {
+	return &Server{
+		Defaults: server.PrintRequest{Copies: 1, CutEvery: 1},
+		spooler:  spooler,
+		keys:     apiKeys,
+	}
+}
+
+// GRPCServer returns a gRPC server with the Printing service of s and its API key check
+func (s *Server) GRPCServer(opts ...grpc.ServerOption) *grpc.Server {
+	opts = append(opts, grpc.UnaryInterceptor(s.unary), grpc.StreamInterceptor(s.stream))
+	g := grpc.NewServer(opts...)
+	printpb.RegisterPrintingServer(g, s)
+	return g
+}
+
+func (s *Server) unary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func (s *Server) stream(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := s.authorize(ss.Context()); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
+
+// authorize checks the API key in the metadata of ctx
+func (s *Server) authorize(ctx context.Context) error {
+	if _, ok := s.apiKey(ctx); !ok {
+		return status.Error(codes.Unauthenticated, "API key required")
+	}
+	return nil
+}
+
+// apiKey returns the API key in the metadata of ctx and reports whether it is one of the keys,
+// every call is authorized without keys
+func (s *Server) apiKey(ctx context.Context) (string, bool) {
+	if len(s.keys) == 0 {
+		return "", true
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, auth := range md.Get("authorization") {
+		key := strings.TrimPrefix(auth, "Bearer ")
+		ok := false
+		for _, k := range s.keys {
+			// every key is compared to take the same time
+			if subtle.ConstantTimeCompare([]byte(k), []byte(key)) == 1 {
+				ok = true
+			}
+		}
+		if ok {
+			return key, true
+		}
+	}
+	return "", false
+}
+
+// SubmitJob queues the job and streams its updates until it is finished
+func (s *Server) SubmitJob(in *printpb.SubmitJobRequest, stream printpb.Printing_SubmitJobServer) error {
+	req, err := s.printRequest(in)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	key, _ := s.apiKey(stream.Context())
+	req.Client = s.Clients[key]
+	updates, stop := s.spooler.Watch()
+	defer stop()
+	j, err := s.spooler.Submit(in.Printer, req)
+	switch {
+	case errors.Is(err, server.ErrNoPrinter):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, server.ErrTooManyCopies):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, server.ErrQueueFull), errors.Is(err, server.ErrRateLimited), errors.Is(err, server.ErrQuotaExceeded):
+		return status.Error(codes.ResourceExhausted, err.Error())
+	case errors.Is(err, server.ErrShutdown):
+		return status.Error(codes.Unavailable, err.Error())
+	case err != nil:
+		return status.Error(codes.Internal, err.Error())
+	}
+
+	last := server.JobState("")
+	send := func(j server.Job) error {
+		if j.State == last {
+			return nil
+		}
+		last = j.State
+		return stream.Send(jobUpdate(j))
+	}
+	if err := send(j); err != nil {
+		return err
+	}
+	for !last.Finished() {
+		select {
+		case u := <-updates:
+			if u.ID != j.ID {
+				continue
+			}
+			if err := send(u); err != nil {
+				return err
+			}
+		case <-j.Done():
+			// updates dropped for a slow stream end with the finished job
+			u, _ := s.spooler.Job(j.ID)
+			if err := send(u); err != nil {
+				return err
+			}
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		}
+	}
+	return nil
+}
+
+// printRequest returns the print request of in starting from the defaults of s
+func (s *Server) printRequest(in *printpb.SubmitJobRequest) (*server.PrintRequest, error) {
+	req := s.Defaults
+	req.Images, req.Names = nil, nil
+	for i, im := range in.Images {
+		name := im.Name
+		if name == "" {
+			name = fmt.Sprintf("image %d", i+1)
+		}
+		img, err := server.DecodeImage(bytes.NewReader(im.Data))
+		if err != nil {
+			return nil, fmt.Errorf("%s: load image: %w", name, err)
+		}
+		req.Images = append(req.Images, img)
+		req.Names = append(req.Names, name)
+	}
+	req.Text, req.QR, req.Barcode = in.Text, in.Qr, in.Barcode
+	if in.Font != "" {
+		req.Font = in.Font
+	}
+	if in.Size != 0 {
+		req.Size = in.Size
+	}
+	if in.Copies != 0 {
+		req.Copies = int(in.Copies)
+	}
+	if in.CutEvery != 0 {
+		req.CutEvery = int(in.CutEvery)
+	}
+	if in.LengthMm != 0 {
+		req.LengthMM = in.LengthMm
+	}
+	if in.MarginMm != nil {
+		req.MarginMM = *in.MarginMm
+	}
+	var err error
+	if in.Rotate != "" {
+		if req.Convert.Rotate, err = ptouchgo.ParseRotation(in.Rotate); err != nil {
+			return nil, err
+		}
+	}
+	if in.Dither != "" {
+		if req.Convert.Dither, err = ptouchgo.ParseDither(in.Dither); err != nil {
+			return nil, err
+		}
+	}
+	if in.Threshold != 0 {
+		req.Convert.Threshold = in.Threshold
+	}
+
+	switch {
+	case len(req.Images) == 0 && !req.Label():
+		return nil, errors.New("image or label required")
+	case req.Copies < 1 || req.Copies > server.MaxCopies:
+		return nil, fmt.Errorf("copies must be 1-%d", server.MaxCopies)
+	case req.CutEvery < 1:
+		return nil, errors.New("cut_every must be at least 1")
+	case req.Convert.Threshold < 0 || req.Convert.Threshold > 1:
+		return nil, errors.New("threshold must be between 0 and 1")
+	case req.Size < 0 || req.LengthMM < 0 || req.MarginMM < 0:
+		return nil, errors.New("size, length_mm and margin_mm must not be negative")
+	}
+	return &req, nil
+}
+
+// StreamStatus reads the status of the printer every interval and sends it when it changes
+func (s *Server) StreamStatus(in *printpb.StreamStatusRequest, stream printpb.Printing_StreamStatusServer) error {
+	interval := defaultStatusInterval
+	if in.IntervalSeconds > 0 {
+		interval = time.Duration(in.IntervalSeconds) * time.Second
+	}
+	name := in.Printer
+	if ps := s.spooler.Printers(); name == "" && len(ps) > 0 {
+		name = ps[0].Name
+	}
+	var last *printpb.PrinterStatus
+	for {
+		st, err := s.spooler.Status(name)
+		if errors.Is(err, server.ErrNoPrinter) {
+			return status.Error(codes.NotFound, err.Error())
+		}
+		msg := printerStatus(name, st, err)
+		if last == nil || !proto.Equal(msg, last) {
+			if err := stream.Send(msg); err != nil {
+				return err
+			}
+			last = msg
+		}
+		select {
+		case <-time.After(interval):
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+		}
+	}
+}
+
+// ListPrinters lists the printers of the spooler
+func (s *Server) ListPrinters(ctx context.Context, in *printpb.ListPrintersRequest) (*printpb.ListPrintersResponse, error) {
+	res := &printpb.ListPrintersResponse{}
+	for _, p := range s.spooler.Printers() {
+		res.Printers = append(res.Printers, &printpb.Printer{
+			Name:    p.Name,
+			Device:  p.Device,
+			Default: p.Default,
+			Queued:  int32(p.Queued),
+		})
+	}
+	return res, nil
+}
+
+var jobStates = map[server.JobState]printpb.JobState{
+	server.JobQueued:   printpb.JobState_JOB_STATE_QUEUED,
+	server.JobPrinting: printpb.JobState_JOB_STATE_PRINTING,
+	// the job waits in the queue of the printer, its error tells why
+	server.JobWaiting:   printpb.JobState_JOB_STATE_QUEUED,
+	server.JobCompleted: printpb.JobState_JOB_STATE_COMPLETED,
+	server.JobFailed:    printpb.JobState_JOB_STATE_FAILED,
+}
+
+func jobUpdate(j server.Job) *printpb.JobUpdate {
+	u := &printpb.JobUpdate{Id: j.ID, Printer: j.Printer, State: jobStates[j.State], Error: j.Error}
+	if r := j.Result; r != nil {
+		u.Result = &printpb.JobResult{
+			Device:      r.Device,
+			TapeWidthMm: int32(r.TapeWidthMM),
+			Pages:       int32(r.Pages),
+			Copies:      int32(r.Copies),
+			Labels:      int32(r.Labels),
+			RasterLines: int32(r.RasterLines),
+			LengthMm:    r.LengthMM,
+			Cut:         r.Cut,
+		}
+	}
+	return u
+}
+
+func printerStatus(name string, st *ptouchgo.Status, err error) *printpb.PrinterStatus {
+	if err != nil {
+		return &printpb.PrinterStatus{Printer: name, Error: err.Error()}
+	}
+	return &printpb.PrinterStatus{
+		Printer:      name,
+		Model:        st.Model.String(),
+		Firmware:     st.Firmware,
+		TapeWidthMm:  int32(st.TapeWidth),
+		TapeLengthMm: int32(st.TapeLength),
+		MediaType:    st.MediaType.String(),
+		TapeColor:    st.TapeColor.String(),
+		TextColor:    st.FontColor.String(),
+		Battery:      st.Battery.String(),
+		Errors:       st.Errors(),
+		Phase:        st.Phase.String(),
+	}
+}
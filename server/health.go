@@ -0,0 +1,106 @@
+package server
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	"github.com/ka2n/ptouchgo"
+)
+
+// Health tells whether a printer is ready to print, found by Check and Supervise
+type Health struct {
+	Printer string    `json:"printer"`
+	Ready   bool      `json:"ready"`
+	Error   string    `json:"error,omitempty"` // why it is not ready
+	Checked time.Time `json:"checked"`
+	Since   time.Time `json:"since"` // when it became ready or not ready
+}
+
+// Check reads the status of the printer named name, the first one when name is empty, and returns whether
+// it is connected and has media without errors. A printer printing a job is ready, its status is not read.
+// Nothing is ready after Shutdown.
+func (s *Spooler) Check(name string) (Health, error) {
+	pr, err := s.printer(name)
+	if err != nil {
+		return Health{}, err
+	}
+	s.mu.Lock()
+	closed, busy := s.closed, pr.busy
+	s.mu.Unlock()
+	var reason string
+	switch {
+	case closed:
+		reason = ErrShutdown.Error()
+	case busy:
+	default:
+		pr.mu.Lock()
+		st, err := pr.p.Status()
+		pr.mu.Unlock()
+		if err != nil {
+			reason = err.Error()
+		} else {
+			reason = notReady(st)
+		}
+	}
+	return s.setHealth(pr, reason), nil
+}
+
+// notReady returns why a printer of st can not print, empty when it can
+func notReady(st *ptouchgo.Status) string {
+	if errs := st.Errors(); len(errs) > 0 {
+		return strings.Join(errs, ", ")
+	}
+	if st.TapeWidth == 0 {
+		return "no media"
+	}
+	return ""
+}
+
+// setHealth records a check of pr, logging when its readiness changes
+func (s *Spooler) setHealth(pr *printer, reason string) Health {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	h := pr.health
+	ready := reason == ""
+	if h.Checked.IsZero() || h.Ready != ready {
+		h.Since = now
+		switch {
+		case !ready:
+			log.Printf("printer %s is not ready: %s\n", pr.name, reason)
+		case !h.Checked.IsZero():
+			log.Printf("printer %s is ready again\n", pr.name)
+		}
+	}
+	h.Printer, h.Ready, h.Error, h.Checked = pr.name, ready, reason, now
+	pr.health = h
+	return h
+}
+
+// Health returns the last checks of the printers, the ones never checked are not ready
+func (s *Spooler) Health() []Health {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	list := make([]Health, len(s.printers))
+	for i, pr := range s.printers {
+		list[i] = pr.health
+		list[i].Printer = pr.name
+	}
+	return list
+}
+
+// Supervise checks the printers every interval until stop is closed, so the printers which are disconnected
+// or run out of media are logged and found by Health without waiting for a job to fail
+func (s *Spooler) Supervise(interval time.Duration, stop <-chan struct{}) {
+	for {
+		for _, pr := range s.printers {
+			s.Check(pr.name)
+		}
+		select {
+		case <-stop:
+			return
+		case <-time.After(interval):
+		}
+	}
+}
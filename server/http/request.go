@@ -0,0 +1,145 @@
+package httpserver
+
+import (
+	"errors"
+	"fmt"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ka2n/ptouchgo"
+	"github.com/ka2n/ptouchgo/server"
+)
+
+// MaxRequestSize limits the body of print requests
+const MaxRequestSize = 32 << 20
+
+// ParsePrintRequest reads a print request starting from defaults. The body is an image or a multipart
+// form with "image" files, the fields text, font, size, qr, barcode, copies, cut_every, length_mm,
+// margin_mm, rotate, dither and threshold work like the flags of "ptouchgo print"
+// and may be given in the query as well.
+func ParsePrintRequest(r *http.Request, defaults server.PrintRequest) (*server.PrintRequest, error) {
+	req := defaults
+	req.Images, req.Names = nil, nil
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch {
+	case mediaType == "multipart/form-data":
+		if err := r.ParseMultipartForm(MaxRequestSize); err != nil {
+			return nil, err
+		}
+		for _, fh := range r.MultipartForm.File["image"] {
+			f, err := fh.Open()
+			if err != nil {
+				return nil, err
+			}
+			img, err := server.DecodeImage(f)
+			f.Close()
+			if err != nil {
+				return nil, fmt.Errorf("%s: load image: %w", fh.Filename, err)
+			}
+			req.Images = append(req.Images, img)
+			req.Names = append(req.Names, fh.Filename)
+		}
+	case strings.HasPrefix(mediaType, "image/"):
+		img, err := server.DecodeImage(r.Body)
+		if err != nil {
+			return nil, fmt.Errorf("load image: %w", err)
+		}
+		req.Images = append(req.Images, img)
+		req.Names = append(req.Names, "body")
+	}
+
+	req.Text = r.FormValue("text")
+	if v := r.FormValue("font"); v != "" {
+		req.Font = v
+	}
+	req.QR = r.FormValue("qr")
+	req.Barcode = r.FormValue("barcode")
+	var err error
+	if req.Size, err = formFloat(r, "size", req.Size); err != nil {
+		return nil, err
+	}
+	if req.Copies, err = formInt(r, "copies", req.Copies); err != nil {
+		return nil, err
+	}
+	if req.CutEvery, err = formInt(r, "cut_every", req.CutEvery); err != nil {
+		return nil, err
+	}
+	if req.LengthMM, err = formFloat(r, "length_mm", req.LengthMM); err != nil {
+		return nil, err
+	}
+	if req.MarginMM, err = formFloat(r, "margin_mm", req.MarginMM); err != nil {
+		return nil, err
+	}
+
+	if len(req.Images) == 0 && !req.Label() {
+		return nil, errors.New("image or label required")
+	}
+	if req.Copies < 1 || req.Copies > server.MaxCopies {
+		return nil, fmt.Errorf("copies must be 1-%d", server.MaxCopies)
+	}
+	if req.CutEvery < 1 {
+		return nil, errors.New("cut_every must be at least 1")
+	}
+	if v := r.FormValue("rotate"); v != "" {
+		if req.Convert.Rotate, err = ptouchgo.ParseRotation(v); err != nil {
+			return nil, err
+		}
+	}
+	if v := r.FormValue("dither"); v != "" {
+		if req.Convert.Dither, err = ptouchgo.ParseDither(v); err != nil {
+			return nil, err
+		}
+	}
+	if req.Convert.Threshold, err = formFloat(r, "threshold", req.Convert.Threshold); err != nil {
+		return nil, err
+	}
+	if req.Convert.Threshold < 0 || req.Convert.Threshold > 1 {
+		return nil, errors.New("threshold must be between 0 and 1")
+	}
+	if req.Size < 0 || req.LengthMM < 0 || req.MarginMM < 0 {
+		return nil, errors.New("size, length_mm and margin_mm must not be negative")
+	}
+	return &req, nil
+}
+
+func formInt(r *http.Request, key string, def int) (int, error) {
+	v := r.FormValue(key)
+	if v == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", key, err)
+	}
+	return n, nil
+}
+
+func formFloat(r *http.Request, key string, def float64) (float64, error) {
+	v := r.FormValue(key)
+	if v == "" {
+		return def, nil
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", key, err)
+	}
+	return f, nil
+}
+
+func formBool(r *http.Request, key string) (bool, error) {
+	v := r.FormValue(key)
+	if v == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", key, err)
+	}
+	return b, nil
+}
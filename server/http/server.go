@@ -0,0 +1,304 @@
+// Package httpserver serves the printers of a server.Spooler over HTTP, it is the server of "ptouchgo serve":
+//
+//	POST /print      submits a job, the body is an image or a multipart form, see ParsePrintRequest.
+//	                 It is answered with 202 and the queued job, or the finished job with wait=true
+//	GET  /status     returns the status of a printer
+//	GET  /printers   lists the printers
+//	GET  /jobs       lists the jobs kept, oldest first, of the printer and state fields when given
+//	GET  /jobs/{id}  returns a job
+//	GET  /usage      returns what the clients printed, see server.Usage
+//	GET  /ws         streams the jobs and the status of the printers as JSON messages of Event to a WebSocket
+//	GET  /healthz    answers 200 while the server runs
+//	GET  /readyz     checks the printers, see server.Spooler.Check, and answers 200 when they are ready
+//	                 and 503 when one is not, with their server.Health
+//	POST /preview    returns the PNG a print request would print, the tape field sets the tape width in mm
+//	                 instead of the one of the printer. It is served with Preview only
+//	GET  /           a web UI designing text, QR code and barcode labels with their preview and printing them,
+//	                 served with Preview only
+//
+// POST /print, POST /preview, GET /status, GET /readyz and GET /ws take the printer in the printer field. Without it the first
+// added one is used, GET /readyz checks and GET /ws streams every printer.
+// With API keys every request but the health checks and the page of the web UI needs one in "Authorization: Bearer KEY" or "X-API-Key: KEY",
+// browsers give it to GET /ws in the api_key field. The jobs of a key are submitted for its client in Clients,
+// clients over their limits are answered with 429.
+package httpserver
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ka2n/ptouchgo"
+	"github.com/ka2n/ptouchgo/server"
+)
+
+// Server is an http.Handler submitting jobs to its spooler, see the package documentation
+type Server struct {
+	// Defaults of the fields of print requests
+	Defaults server.PrintRequest
+	// StatusJSON encodes the status of GET /status, nil encodes the status as it is
+	StatusJSON func(*ptouchgo.Status) interface{}
+	// Clients are the names of the server.Client of API keys
+	Clients map[string]string
+	// StatusInterval is the interval the status is read at for GET /ws, DefaultStatusInterval when zero
+	StatusInterval time.Duration
+	// Preview renders the label of a print request on printer for POST /preview, tape is the tape width in mm
+	// or 0 for the one of the printer. Nil serves neither POST /preview nor the web UI
+	Preview func(printer string, tape int, req *server.PrintRequest) (image.Image, error)
+
+	spooler *server.Spooler
+	keys    []string
+
+	mu          sync.Mutex // of GET /ws
+	subscribers map[chan Event]struct{}
+	statuses    map[string]statusEvent
+	polling     bool
+}
+
+// New returns a server of the printers of spooler requiring one of apiKeys, no keys serve everyone
+func New(spooler *server.Spooler, apiKeys []string) *Server {
+	return &Server{
+		Defaults:    server.PrintRequest{Copies: 1, CutEvery: 1},
+		spooler:     spooler,
+		keys:        apiKeys,
+		subscribers: map[chan Event]struct{}{},
+		statuses:    map[string]statusEvent{},
+	}
+}
+
+// ServeHTTP serves the endpoints of the package documentation
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// probes of the health checks have no key
+	switch r.URL.Path {
+	case "/healthz":
+		WriteJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+		return
+	case "/readyz":
+		s.handleReady(w, r)
+		return
+	case "/":
+		// the page asks for the key of its requests
+		if s.Preview != nil {
+			s.handleUI(w, r)
+			return
+		}
+	}
+	key, ok := s.authorized(r)
+	if !ok {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		Error(w, http.StatusUnauthorized, errors.New("API key required"))
+		return
+	}
+	switch path := r.URL.Path; {
+	case path == "/print":
+		s.handlePrint(w, r, s.Clients[key])
+	case path == "/status":
+		s.handleStatus(w, r)
+	case path == "/printers":
+		s.handlePrinters(w, r)
+	case path == "/jobs":
+		s.handleJobs(w, r)
+	case path == "/usage":
+		s.handleUsage(w, r)
+	case path == "/ws":
+		s.handleWS(w, r)
+	case path == "/preview" && s.Preview != nil:
+		s.handlePreview(w, r)
+	case strings.HasPrefix(path, "/jobs/"):
+		s.handleJob(w, r, strings.TrimPrefix(path, "/jobs/"))
+	default:
+		Error(w, http.StatusNotFound, fmt.Errorf("%s: not found", path))
+	}
+}
+
+// authorized reports whether r has one of the API keys and returns it
+func (s *Server) authorized(r *http.Request) (string, bool) {
+	if len(s.keys) == 0 {
+		return "", true
+	}
+	key := r.Header.Get("X-API-Key")
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		key = strings.TrimPrefix(auth, "Bearer ")
+	}
+	if key == "" && r.URL.Path == "/ws" {
+		// browsers can not set the headers of WebSockets
+		key = r.URL.Query().Get("api_key")
+	}
+	if key == "" {
+		return "", false
+	}
+	ok := false
+	for _, k := range s.keys {
+		// every key is compared to take the same time
+		if subtle.ConstantTimeCompare([]byte(k), []byte(key)) == 1 {
+			ok = true
+		}
+	}
+	return key, ok
+}
+
+func (s *Server) handlePrint(w http.ResponseWriter, r *http.Request, client string) {
+	if r.Method != http.MethodPost {
+		Error(w, http.StatusMethodNotAllowed, errors.New("use POST"))
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, MaxRequestSize)
+	req, err := ParsePrintRequest(r, s.Defaults)
+	if err != nil {
+		Error(w, http.StatusBadRequest, err)
+		return
+	}
+	wait, err := formBool(r, "wait")
+	if err != nil {
+		Error(w, http.StatusBadRequest, err)
+		return
+	}
+	req.Client = client
+	j, err := s.spooler.Submit(r.FormValue("printer"), req)
+	switch {
+	case errors.Is(err, server.ErrNoPrinter):
+		Error(w, http.StatusNotFound, err)
+		return
+	case errors.Is(err, server.ErrTooManyCopies):
+		Error(w, http.StatusBadRequest, err)
+		return
+	case errors.Is(err, server.ErrRateLimited), errors.Is(err, server.ErrQuotaExceeded):
+		Error(w, http.StatusTooManyRequests, err)
+		return
+	case err != nil:
+		Error(w, http.StatusServiceUnavailable, err)
+		return
+	}
+	log.Printf("%s: job %s on %s\n", r.RemoteAddr, j.ID, j.Printer)
+	w.Header().Set("Location", "/jobs/"+j.ID)
+	if !wait {
+		WriteJSON(w, http.StatusAccepted, j)
+		return
+	}
+	select {
+	case <-j.Done():
+	case <-r.Context().Done():
+		return
+	}
+	j, _ = s.spooler.Job(j.ID)
+	code := http.StatusOK
+	if j.State == server.JobFailed {
+		code = http.StatusBadGateway
+	}
+	WriteJSON(w, code, j)
+}
+
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		Error(w, http.StatusMethodNotAllowed, errors.New("use GET"))
+		return
+	}
+	st, err := s.spooler.Status(r.FormValue("printer"))
+	switch {
+	case errors.Is(err, server.ErrNoPrinter):
+		Error(w, http.StatusNotFound, err)
+		return
+	case err != nil:
+		Error(w, http.StatusBadGateway, err)
+		return
+	}
+	var v interface{} = st
+	if s.StatusJSON != nil {
+		v = s.StatusJSON(st)
+	}
+	WriteJSON(w, http.StatusOK, v)
+}
+
+func (s *Server) handleReady(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		Error(w, http.StatusMethodNotAllowed, errors.New("use GET"))
+		return
+	}
+	var names []string
+	if name := r.FormValue("printer"); name != "" {
+		names = append(names, name)
+	} else {
+		for _, p := range s.spooler.Printers() {
+			names = append(names, p.Name)
+		}
+	}
+	code := http.StatusOK
+	list := []server.Health{}
+	for _, name := range names {
+		h, err := s.spooler.Check(name)
+		if err != nil {
+			Error(w, http.StatusNotFound, err)
+			return
+		}
+		if !h.Ready {
+			code = http.StatusServiceUnavailable
+		}
+		list = append(list, h)
+	}
+	WriteJSON(w, code, list)
+}
+
+func (s *Server) handlePrinters(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		Error(w, http.StatusMethodNotAllowed, errors.New("use GET"))
+		return
+	}
+	WriteJSON(w, http.StatusOK, s.spooler.Printers())
+}
+
+func (s *Server) handleJobs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		Error(w, http.StatusMethodNotAllowed, errors.New("use GET"))
+		return
+	}
+	list := []server.Job{}
+	state := server.JobState(r.FormValue("state"))
+	for _, j := range s.spooler.Jobs(r.FormValue("printer")) {
+		if state == "" || j.State == state {
+			list = append(list, j)
+		}
+	}
+	WriteJSON(w, http.StatusOK, list)
+}
+
+func (s *Server) handleUsage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		Error(w, http.StatusMethodNotAllowed, errors.New("use GET"))
+		return
+	}
+	WriteJSON(w, http.StatusOK, s.spooler.Usage())
+}
+
+func (s *Server) handleJob(w http.ResponseWriter, r *http.Request, id string) {
+	if r.Method != http.MethodGet {
+		Error(w, http.StatusMethodNotAllowed, errors.New("use GET"))
+		return
+	}
+	j, ok := s.spooler.Job(id)
+	if !ok {
+		Error(w, http.StatusNotFound, fmt.Errorf("job %s: not found", id))
+		return
+	}
+	WriteJSON(w, http.StatusOK, j)
+}
+
+// WriteJSON responds with v as indented JSON
+func WriteJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
+
+// Error responds with {"error": ...} like -json
+func Error(w http.ResponseWriter, code int, err error) {
+	WriteJSON(w, code, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,248 @@
+package httpserver
+
+import (
+	"bytes"
+	"errors"
+	"image/png"
+	"net/http"
+
+	"github.com/ka2n/ptouchgo/server"
+)
+
+func (s *Server) handlePreview(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		Error(w, http.StatusMethodNotAllowed, errors.New("use POST"))
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, MaxRequestSize)
+	req, err := ParsePrintRequest(r, s.Defaults)
+	if err != nil {
+		Error(w, http.StatusBadRequest, err)
+		return
+	}
+	tape, err := formInt(r, "tape", 0)
+	if err != nil {
+		Error(w, http.StatusBadRequest, err)
+		return
+	}
+	img, err := s.Preview(r.FormValue("printer"), tape, req)
+	switch {
+	case errors.Is(err, server.ErrNoPrinter):
+		Error(w, http.StatusNotFound, err)
+		return
+	case err != nil:
+		Error(w, http.StatusBadRequest, err)
+		return
+	}
+	var b bytes.Buffer
+	if err := png.Encode(&b, img); err != nil {
+		Error(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", "image/png")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Write(b.Bytes())
+}
+
+func (s *Server) handleUI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		Error(w, http.StatusMethodNotAllowed, errors.New("use GET"))
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Security-Policy", "default-src 'self'; style-src 'unsafe-inline'; script-src 'unsafe-inline'; img-src 'self' blob:; connect-src 'self' ws: wss:")
+	w.Write([]byte(uiPage))
+}
+
+// uiPage is the web UI of GET /, it uses the other endpoints with the API key the user gives when asked.
+// It has no backquotes to be a raw string.
+const uiPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>ptouchgo</title>
+<style>
+body { font-family: system-ui, sans-serif; margin: 0; background: #f4f4f4; color: #222; }
+header { background: #333; color: #fff; padding: .6em 1em; display: flex; gap: 1em; align-items: center; }
+header h1 { font-size: 1.1em; margin: 0; flex: 1; }
+main { display: grid; grid-template-columns: minmax(16em, 22em) 1fr; gap: 1em; padding: 1em; }
+section { background: #fff; border-radius: 4px; padding: 1em; }
+label { display: block; margin: .6em 0 .2em; font-size: .9em; color: #555; }
+input, select, textarea { width: 100%; box-sizing: border-box; font: inherit; padding: .3em; }
+textarea { height: 5em; }
+button { font: inherit; padding: .5em 1.5em; margin-top: 1em; }
+#preview { display: flex; align-items: center; justify-content: center; min-height: 8em; overflow-x: auto; background: #ddd; }
+#preview img { image-rendering: pixelated; border: 1px solid #999; max-width: none; }
+#error { color: #b00; min-height: 1.2em; margin: .5em 0; }
+table { width: 100%; border-collapse: collapse; font-size: .9em; }
+td, th { text-align: left; padding: .2em .4em; border-bottom: 1px solid #eee; }
+.failed { color: #b00; }
+.completed { color: #070; }
+@media (max-width: 40em) { main { grid-template-columns: 1fr; } }
+</style>
+</head>
+<body>
+<header>
+<h1>ptouchgo</h1>
+<select id="printer" aria-label="Printer"></select>
+<span id="status">&hellip;</span>
+</header>
+<main>
+<section>
+<label for="mode">Label</label>
+<select id="mode">
+<option value="text">Text</option>
+<option value="qr">QR code and caption</option>
+<option value="barcode">Barcode and caption</option>
+</select>
+<label for="text">Text</label>
+<textarea id="text">Hello</textarea>
+<div id="code-field" hidden>
+<label for="code">Code data, barcodes may start with code39:, ean: or code128:</label>
+<input id="code">
+</div>
+<label for="size">Font size in points, 0 fits the tape</label>
+<input id="size" type="number" min="0" step="1" value="0">
+<label for="copies">Copies</label>
+<input id="copies" type="number" min="1" step="1" value="1">
+<button id="print" type="button">Print</button>
+<div id="error" role="alert"></div>
+</section>
+<section>
+<div id="preview"></div>
+<h2>Jobs</h2>
+<table><thead><tr><th>ID</th><th>Printer</th><th>State</th><th>Error</th></tr></thead><tbody id="jobs"></tbody></table>
+</section>
+</main>
+<script>
+"use strict";
+var $ = function (id) { return document.getElementById(id); };
+var key = localStorage.getItem("ptouchgo.apiKey") || "";
+var jobs = {};
+var socket = null;
+
+function api(method, path, body) {
+  var headers = {};
+  if (key) headers["X-API-Key"] = key;
+  return fetch(path, { method: method, headers: headers, body: body }).then(function (resp) {
+    if (resp.status === 401) {
+      var k = prompt("API key");
+      if (k === null) throw new Error("API key required");
+      key = k;
+      localStorage.setItem("ptouchgo.apiKey", k);
+      connect();
+      return api(method, path, body);
+    }
+    if (!resp.ok) {
+      return resp.json().then(function (e) { throw new Error(e.error || resp.statusText); },
+        function () { throw new Error(resp.statusText); });
+    }
+    return resp;
+  });
+}
+
+function form() {
+  var f = new FormData();
+  var mode = $("mode").value;
+  f.append("printer", $("printer").value);
+  f.append("text", $("text").value.replace(/\n/g, "\\n"));
+  if (mode !== "text") f.append(mode, $("code").value);
+  f.append("size", $("size").value || "0");
+  f.append("copies", $("copies").value || "1");
+  return f;
+}
+
+var previewTimer = null;
+function schedulePreview() {
+  clearTimeout(previewTimer);
+  previewTimer = setTimeout(preview, 300);
+}
+
+function preview() {
+  api("POST", "/preview", form()).then(function (resp) { return resp.blob(); }).then(function (blob) {
+    var img = new Image();
+    img.src = URL.createObjectURL(blob);
+    img.onload = function () { URL.revokeObjectURL(img.src); };
+    $("preview").replaceChildren(img);
+    $("error").textContent = "";
+  }).catch(function (e) { $("error").textContent = e.message; });
+}
+
+function print() {
+  $("print").disabled = true;
+  api("POST", "/print", form()).then(function (resp) { return resp.json(); }).then(function (j) {
+    showJob(j);
+    $("error").textContent = "";
+  }).catch(function (e) { $("error").textContent = e.message; })
+    .finally(function () { $("print").disabled = false; });
+}
+
+function showJob(j) {
+  jobs[j.id] = j;
+  var rows = $("jobs");
+  rows.replaceChildren();
+  Object.keys(jobs).sort(function (a, b) { return Number(b) - Number(a); }).slice(0, 20).forEach(function (id) {
+    var job = jobs[id];
+    var tr = document.createElement("tr");
+    [job.id, job.printer, job.state, job.error || ""].forEach(function (v, i) {
+      var td = document.createElement("td");
+      td.textContent = v;
+      if (i === 2) td.className = v;
+      tr.appendChild(td);
+    });
+    rows.appendChild(tr);
+  });
+}
+
+function showStatus(e) {
+  if (e.printer !== $("printer").value) return;
+  if (e.error) {
+    $("status").textContent = e.error;
+    return;
+  }
+  var st = e.status || {};
+  var parts = [];
+  if (st.model) parts.push(st.model);
+  if (st.tape_width) parts.push(st.tape_width);
+  if (st.errors && st.errors.length) parts.push(st.errors.join(", "));
+  $("status").textContent = parts.join(" / ") || "ready";
+}
+
+function connect() {
+  if (socket) socket.close();
+  var url = (location.protocol === "https:" ? "wss://" : "ws://") + location.host + "/ws";
+  if (key) url += "?api_key=" + encodeURIComponent(key);
+  socket = new WebSocket(url);
+  socket.onmessage = function (m) {
+    var e = JSON.parse(m.data);
+    if (e.type === "job") showJob(e.job);
+    else showStatus(e);
+  };
+  socket.onclose = function (ev) {
+    if (ev.target === socket) setTimeout(connect, 5000);
+  };
+}
+
+$("mode").onchange = function () {
+  $("code-field").hidden = $("mode").value === "text";
+  schedulePreview();
+};
+["text", "code", "size", "copies"].forEach(function (id) { $(id).oninput = schedulePreview; });
+$("printer").onchange = function () { $("status").textContent = "…"; schedulePreview(); connect(); };
+$("print").onclick = print;
+
+api("GET", "/printers").then(function (resp) { return resp.json(); }).then(function (printers) {
+  printers.forEach(function (p) {
+    var o = document.createElement("option");
+    o.value = o.textContent = p.name;
+    $("printer").appendChild(o);
+  });
+  api("GET", "/jobs").then(function (resp) { return resp.json(); }).then(function (list) { list.forEach(showJob); });
+  connect();
+  preview();
+}).catch(function (e) { $("error").textContent = e.message; });
+</script>
+</body>
+</html>
+`
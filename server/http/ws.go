@@ -0,0 +1,146 @@
+package httpserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/ka2n/ptouchgo/server"
+	"golang.org/x/net/websocket"
+)
+
+// DefaultStatusInterval is the interval the status of the printers is read at while GET /ws is connected
+const DefaultStatusInterval = 5 * time.Second
+
+// Event is a message of GET /ws
+type Event struct {
+	Type    string      `json:"type"` // "job" or "status"
+	Printer string      `json:"printer"`
+	Job     *server.Job `json:"job,omitempty"`
+	// Status is the status of the printer of status events, Error tells why it can not be read
+	Status interface{} `json:"status,omitempty"`
+	Error  string      `json:"error,omitempty"`
+}
+
+// handleWS streams the jobs as they change and the status of the printers when it changes to a WebSocket,
+// the last status of every printer is sent first
+func (s *Server) handleWS(w http.ResponseWriter, r *http.Request) {
+	printer := r.FormValue("printer")
+	ws := websocket.Server{
+		// clients which are not browsers have no origin, the API key is the access control
+		Handshake: func(c *websocket.Config, r *http.Request) error {
+			c.Origin, _ = websocket.Origin(c, r)
+			return nil
+		},
+		Handler: func(c *websocket.Conn) {
+			defer c.Close()
+			updates, stop := s.spooler.Watch()
+			defer stop()
+			statuses := s.subscribe()
+			defer s.unsubscribe(statuses)
+			closed := make(chan struct{})
+			go func() {
+				// messages of clients are not read, only the end of the connection
+				io.Copy(ioutil.Discard, c)
+				close(closed)
+			}()
+			for {
+				var e Event
+				select {
+				case <-closed:
+					return
+				case j := <-updates:
+					e = Event{Type: "job", Printer: j.Printer, Job: &j}
+				case e = <-statuses:
+				}
+				if printer != "" && e.Printer != printer {
+					continue
+				}
+				c.SetWriteDeadline(time.Now().Add(10 * time.Second))
+				if err := websocket.JSON.Send(c, e); err != nil {
+					return
+				}
+			}
+		},
+	}
+	ws.ServeHTTP(w, r)
+}
+
+// subscribe returns a channel receiving the status events, it receives the last ones at first.
+// The status is read while there are subscribers.
+func (s *Server) subscribe() chan Event {
+	printers := s.spooler.Printers()
+	// room for the last events of every printer, sending them never blocks while holding s.mu
+	c := make(chan Event, len(printers)+16)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, p := range printers {
+		if e, ok := s.statuses[p.Name]; ok {
+			c <- e.Event
+		}
+	}
+	s.subscribers[c] = struct{}{}
+	if !s.polling {
+		s.polling = true
+		go s.pollStatus()
+	}
+	return c
+}
+
+func (s *Server) unsubscribe(c chan Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.subscribers, c)
+}
+
+// statusEvent is the last status event of a printer with its JSON
+type statusEvent struct {
+	Event
+	b []byte
+}
+
+// pollStatus reads the status every StatusInterval and sends the changes to the subscribers until there are none
+func (s *Server) pollStatus() {
+	interval := s.StatusInterval
+	if interval == 0 {
+		interval = DefaultStatusInterval
+	}
+	for {
+		for _, p := range s.spooler.Printers() {
+			e := Event{Type: "status", Printer: p.Name}
+			// waits for the job printing
+			st, err := s.spooler.Status(p.Name)
+			switch {
+			case err != nil:
+				e.Error = err.Error()
+			case s.StatusJSON != nil:
+				e.Status = s.StatusJSON(st)
+			default:
+				e.Status = st
+			}
+			b, _ := json.Marshal(e)
+			s.mu.Lock()
+			if last, ok := s.statuses[p.Name]; !ok || !bytes.Equal(last.b, b) {
+				s.statuses[p.Name] = statusEvent{e, b}
+				for c := range s.subscribers {
+					select {
+					case c <- e:
+					default:
+					}
+				}
+			}
+			s.mu.Unlock()
+		}
+		time.Sleep(interval)
+		s.mu.Lock()
+		if len(s.subscribers) == 0 {
+			s.polling = false
+			s.mu.Unlock()
+			return
+		}
+		s.mu.Unlock()
+	}
+}
@@ -0,0 +1,44 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"io"
+
+	"github.com/ka2n/ptouchgo/models"
+)
+
+// maxLabelLength is the longest label in mm, the longest custom page size of the cups package
+const maxLabelLength = 1000
+
+// MaxImagePixels is the largest image of print requests, a label of the longest length on the widest
+// print head of the models at its highest resolution
+var MaxImagePixels = func() int {
+	largest := 0
+	for _, spec := range models.All() {
+		dpi := spec.DPI
+		if spec.HighDPI {
+			dpi *= 2
+		}
+		if n := spec.HeadPins * int(maxLabelLength/25.4*float64(dpi)); n > largest {
+			largest = n
+		}
+	}
+	return largest
+}()
+
+// DecodeImage decodes an image of a print request, images of more than MaxImagePixels are rejected
+// from their header before they are decoded
+func DecodeImage(r io.Reader) (image.Image, error) {
+	var header bytes.Buffer
+	cfg, _, err := image.DecodeConfig(io.TeeReader(r, &header))
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width > MaxImagePixels/cfg.Height {
+		return nil, fmt.Errorf("image of %dx%d pixels is larger than %d pixels", cfg.Width, cfg.Height, MaxImagePixels)
+	}
+	img, _, err := image.Decode(io.MultiReader(&header, r))
+	return img, err
+}
@@ -0,0 +1,295 @@
+package ippserver
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// service types of printers, IPP Everywhere clients browse the print subtype
+const (
+	ippService   = "_ipp._tcp.local."
+	printSubtype = "_print._sub._ipp._tcp.local."
+	servicesName = "_services._dns-sd._udp.local."
+)
+
+// ttls of the host records and of the other ones, as recommended by RFC 6762
+const (
+	hostTTL  = 120
+	otherTTL = 4500
+)
+
+// maxLabel is the longest label of a DNS name
+const maxLabel = 63
+
+var mdnsGroup = &net.UDPAddr{IP: net.IPv4(224, 0, 0, 251), Port: 5353}
+
+// Service is a printer advertised by a Responder
+type Service struct {
+	Instance string   // like "Brother PT-P710BT on host", dots are replaced and it is cut at 63 bytes
+	Port     int      // of the IPP server
+	TXT      []string // like "rp=ipp/print"
+}
+
+// Responder answers the mDNS queries for its services on the local network
+type Responder struct {
+	conn     *net.UDPConn
+	host     dnsmessage.Name
+	addrs    []net.IP
+	services []Service
+
+	once sync.Once
+	done chan struct{}
+}
+
+// Advertise announces services under the host name of the system and answers queries for them until Close
+func Advertise(services []Service) (*Responder, error) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return nil, fmt.Errorf("mdns: %w", err)
+	}
+	if i := strings.IndexByte(hostname, '.'); i > 0 {
+		hostname = hostname[:i]
+	}
+	host, err := dnsmessage.NewName(hostname + ".local.")
+	if err != nil {
+		return nil, fmt.Errorf("mdns: %w", err)
+	}
+	addrs, err := localAddrs()
+	if err != nil {
+		return nil, err
+	}
+	c, err := net.ListenMulticastUDP("udp4", nil, mdnsGroup)
+	if err != nil {
+		return nil, fmt.Errorf("mdns: %w", err)
+	}
+	r := &Responder{conn: c, host: host, addrs: addrs, done: make(chan struct{})}
+	for _, s := range services {
+		s.Instance = strings.Replace(s.Instance, ".", "-", -1)
+		if len(s.Instance) > maxLabel {
+			s.Instance = s.Instance[:maxLabel]
+		}
+		r.services = append(r.services, s)
+	}
+	go r.serve()
+	go r.announce()
+	return r, nil
+}
+
+// Close sends goodbyes for the services and stops answering
+func (r *Responder) Close() error {
+	r.once.Do(func() {
+		close(r.done)
+		if msg, err := r.response(0, r.allRecords(0)); err == nil {
+			r.conn.WriteToUDP(msg, mdnsGroup)
+		}
+	})
+	return r.conn.Close()
+}
+
+// announce sends the records unasked twice a second apart, as RFC 6762 describes for starting up
+func (r *Responder) announce() {
+	for i := 0; i < 2; i++ {
+		if msg, err := r.response(0, r.allRecords(-1)); err == nil {
+			r.conn.WriteToUDP(msg, mdnsGroup)
+		}
+		select {
+		case <-r.done:
+			return
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+func (r *Responder) serve() {
+	buf := make([]byte, 9000)
+	for {
+		n, from, err := r.conn.ReadFromUDP(buf)
+		if err != nil {
+			select {
+			case <-r.done:
+			default:
+				log.Printf("mdns: %v\n", err)
+			}
+			return
+		}
+		var query dnsmessage.Message
+		if err := query.Unpack(buf[:n]); err != nil || query.Header.Response {
+			continue
+		}
+		var answers []dnsmessage.Resource
+		for _, q := range query.Questions {
+			answers = append(answers, r.answer(q)...)
+		}
+		if len(answers) == 0 {
+			continue
+		}
+		msg, err := r.response(0, answers)
+		to := mdnsGroup
+		if from.Port != mdnsGroup.Port {
+			msg, err = legacyResponse(query, answers)
+			to = from
+		}
+		if err != nil {
+			log.Printf("mdns: %v\n", err)
+			continue
+		}
+		r.conn.WriteToUDP(msg, to)
+	}
+}
+
+// answer returns the records answering q
+func (r *Responder) answer(q dnsmessage.Question) []dnsmessage.Resource {
+	name := strings.ToLower(q.Name.String())
+	var rrs []dnsmessage.Resource
+	switch name {
+	case servicesName:
+		if q.Type == dnsmessage.TypePTR || q.Type == dnsmessage.TypeALL {
+			rrs = append(rrs, ptr(servicesName, ippService, otherTTL), ptr(servicesName, printSubtype, otherTTL))
+		}
+		return rrs
+	case ippService, printSubtype:
+		if q.Type != dnsmessage.TypePTR && q.Type != dnsmessage.TypeALL {
+			return nil
+		}
+		for _, s := range r.services {
+			rrs = append(rrs, ptr(name, s.instanceName(), otherTTL))
+			rrs = append(rrs, r.serviceRecords(s, -1)...)
+		}
+		return append(rrs, r.hostRecords(-1)...)
+	case strings.ToLower(r.host.String()):
+		if q.Type == dnsmessage.TypeA || q.Type == dnsmessage.TypeALL {
+			rrs = r.hostRecords(-1)
+		}
+		return rrs
+	}
+	for _, s := range r.services {
+		if name == strings.ToLower(s.instanceName()) {
+			for _, rr := range r.serviceRecords(s, -1) {
+				if q.Type == dnsmessage.TypeALL || rr.Header.Type == q.Type {
+					rrs = append(rrs, rr)
+				}
+			}
+			return append(rrs, r.hostRecords(-1)...)
+		}
+	}
+	return nil
+}
+
+// allRecords returns the records of every service, ttl -1 keeps the usual ttls and 0 says goodbye
+func (r *Responder) allRecords(ttl int) []dnsmessage.Resource {
+	var rrs []dnsmessage.Resource
+	for _, s := range r.services {
+		rrs = append(rrs, ptr(ippService, s.instanceName(), ttlOr(ttl, otherTTL)), ptr(printSubtype, s.instanceName(), ttlOr(ttl, otherTTL)))
+		rrs = append(rrs, r.serviceRecords(s, ttl)...)
+	}
+	return append(rrs, r.hostRecords(ttl)...)
+}
+
+func (r *Responder) serviceRecords(s Service, ttl int) []dnsmessage.Resource {
+	name := dnsmessage.MustNewName(s.instanceName())
+	return []dnsmessage.Resource{
+		{
+			Header: dnsmessage.ResourceHeader{Name: name, Type: dnsmessage.TypeSRV, Class: dnsmessage.ClassINET | flushClass, TTL: uint32(ttlOr(ttl, hostTTL))},
+			Body:   &dnsmessage.SRVResource{Target: r.host, Port: uint16(s.Port)},
+		},
+		{
+			Header: dnsmessage.ResourceHeader{Name: name, Type: dnsmessage.TypeTXT, Class: dnsmessage.ClassINET | flushClass, TTL: uint32(ttlOr(ttl, otherTTL))},
+			Body:   &dnsmessage.TXTResource{TXT: s.TXT},
+		},
+	}
+}
+
+func (r *Responder) hostRecords(ttl int) []dnsmessage.Resource {
+	var rrs []dnsmessage.Resource
+	for _, ip := range r.addrs {
+		var a [4]byte
+		copy(a[:], ip.To4())
+		rrs = append(rrs, dnsmessage.Resource{
+			Header: dnsmessage.ResourceHeader{Name: r.host, Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET | flushClass, TTL: uint32(ttlOr(ttl, hostTTL))},
+			Body:   &dnsmessage.AResource{A: a},
+		})
+	}
+	return rrs
+}
+
+// response packs the answers of a response
+func (r *Responder) response(id uint16, answers []dnsmessage.Resource) ([]byte, error) {
+	msg := dnsmessage.Message{
+		Header:  dnsmessage.Header{ID: id, Response: true, Authoritative: true},
+		Answers: answers,
+	}
+	return msg.Pack()
+}
+
+// legacyResponse packs the answers to a query from another port than 5353 as unicast DNS does,
+// with the id and questions of the query and ttls of at most 10 seconds, as RFC 6762 describes
+func legacyResponse(query dnsmessage.Message, answers []dnsmessage.Resource) ([]byte, error) {
+	for i := range answers {
+		h := &answers[i].Header
+		h.Class &^= flushClass
+		if h.TTL > 10 {
+			h.TTL = 10
+		}
+	}
+	msg := dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: query.Header.ID, Response: true, Authoritative: true},
+		Questions: query.Questions,
+		Answers:   answers,
+	}
+	return msg.Pack()
+}
+
+// flushClass is the cache flush bit of the class of records only this host answers
+const flushClass = 0x8000
+
+func (s Service) instanceName() string {
+	return s.Instance + "." + ippService
+}
+
+func ptr(name, target string, ttl int) dnsmessage.Resource {
+	return dnsmessage.Resource{
+		Header: dnsmessage.ResourceHeader{Name: dnsmessage.MustNewName(name), Type: dnsmessage.TypePTR, Class: dnsmessage.ClassINET, TTL: uint32(ttl)},
+		Body:   &dnsmessage.PTRResource{PTR: dnsmessage.MustNewName(target)},
+	}
+}
+
+func ttlOr(ttl, def int) int {
+	if ttl < 0 {
+		return def
+	}
+	return ttl
+}
+
+// localAddrs returns the IPv4 addresses of the interfaces which are up, without loopback ones
+func localAddrs() ([]net.IP, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, fmt.Errorf("mdns: %w", err)
+	}
+	var ips []net.IP
+	for _, ifi := range ifaces {
+		if ifi.Flags&net.FlagUp == 0 || ifi.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		addrs, err := ifi.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, a := range addrs {
+			if ipnet, ok := a.(*net.IPNet); ok && ipnet.IP.To4() != nil {
+				ips = append(ips, ipnet.IP.To4())
+			}
+		}
+	}
+	if len(ips) == 0 {
+		return nil, fmt.Errorf("mdns: no IPv4 address to advertise")
+	}
+	return ips, nil
+}
@@ -0,0 +1,325 @@
+package ippserver
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// delimiter tags of attribute groups
+const (
+	tagOperation   = 0x01
+	tagJob         = 0x02
+	tagEnd         = 0x03
+	tagPrinter     = 0x04
+	tagUnsupported = 0x05
+)
+
+// value tags
+const (
+	tagUnsupportedValue = 0x10
+	tagUnknown          = 0x12
+	tagNoValue          = 0x13
+	tagInteger          = 0x21
+	tagBoolean          = 0x22
+	tagEnum             = 0x23
+	tagOctetString      = 0x30
+	tagDateTime         = 0x31
+	tagResolution       = 0x32
+	tagRange            = 0x33
+	tagBeginCollection  = 0x34
+	tagEndCollection    = 0x37
+	tagText             = 0x41
+	tagName             = 0x42
+	tagKeyword          = 0x44
+	tagURI              = 0x45
+	tagCharset          = 0x47
+	tagLanguage         = 0x48
+	tagMIMEType         = 0x49
+	tagMemberName       = 0x4a
+)
+
+// operations
+const (
+	opPrintJob             = 0x0002
+	opValidateJob          = 0x0004
+	opCancelJob            = 0x0008
+	opGetJobAttributes     = 0x0009
+	opGetJobs              = 0x000a
+	opGetPrinterAttributes = 0x000b
+)
+
+// status codes
+const (
+	statusOK                        = 0x0000
+	statusOKIgnored                 = 0x0001
+	statusBadRequest                = 0x0400
+	statusNotAuthenticated          = 0x0402
+	statusNotPossible               = 0x0404
+	statusNotFound                  = 0x0406
+	statusDocumentFormatUnsupported = 0x040a
+	statusAttributesUnsupported     = 0x040b
+	statusDocumentFormatError       = 0x0411
+	statusInternalError             = 0x0500
+	statusOperationUnsupported      = 0x0501
+	statusVersionUnsupported        = 0x0503
+	statusBusy                      = 0x0507
+)
+
+// message is an IPP request or response, code is the operation of requests and the status of responses
+type message struct {
+	major, minor byte
+	code         uint16
+	requestID    uint32
+	groups       []group
+}
+
+// group is an attribute group of a message
+type group struct {
+	tag   byte
+	attrs []attribute
+}
+
+// attribute is an attribute of a group or a member of a collection
+type attribute struct {
+	name   string
+	values []value
+}
+
+// value is a value of an attribute, data holds its encoding and members the ones of collections
+type value struct {
+	tag     byte
+	data    []byte
+	members []attribute
+}
+
+func integer(n int) value {
+	return value{tag: tagInteger, data: int32Bytes(n)}
+}
+
+func enum(n int) value {
+	return value{tag: tagEnum, data: int32Bytes(n)}
+}
+
+func boolean(b bool) value {
+	v := value{tag: tagBoolean, data: []byte{0}}
+	if b {
+		v.data[0] = 1
+	}
+	return v
+}
+
+func str(tag byte, s string) value {
+	return value{tag: tag, data: []byte(s)}
+}
+
+// resolution is a resolution in dots per inch
+func resolution(x, y int) value {
+	return value{tag: tagResolution, data: append(append(int32Bytes(x), int32Bytes(y)...), 3)}
+}
+
+func rangeOf(lower, upper int) value {
+	return value{tag: tagRange, data: append(int32Bytes(lower), int32Bytes(upper)...)}
+}
+
+func collection(members ...attribute) value {
+	return value{tag: tagBeginCollection, members: members}
+}
+
+func noValue() value {
+	return value{tag: tagNoValue}
+}
+
+func int32Bytes(n int) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, uint32(int32(n)))
+	return b
+}
+
+// int returns the value of integers, enums and booleans
+func (v value) int() (int, bool) {
+	switch {
+	case (v.tag == tagInteger || v.tag == tagEnum) && len(v.data) == 4:
+		return int(int32(binary.BigEndian.Uint32(v.data))), true
+	case v.tag == tagBoolean && len(v.data) == 1:
+		return int(v.data[0]), true
+	}
+	return 0, false
+}
+
+// attr returns attr name with values
+func attr(name string, values ...value) attribute {
+	return attribute{name: name, values: values}
+}
+
+// strs is an attribute of strings of tag
+func strs(name string, tag byte, list ...string) attribute {
+	a := attribute{name: name}
+	for _, s := range list {
+		a.values = append(a.values, str(tag, s))
+	}
+	return a
+}
+
+// lookup returns the attribute name of the first group of tag
+func (m *message) lookup(tag byte, name string) (attribute, bool) {
+	for _, g := range m.groups {
+		if g.tag != tag {
+			continue
+		}
+		for _, a := range g.attrs {
+			if a.name == name {
+				return a, true
+			}
+		}
+	}
+	return attribute{}, false
+}
+
+// text returns the first value of the operation attribute name as a string, empty when it is missing
+func (m *message) text(name string) string {
+	a, ok := m.lookup(tagOperation, name)
+	if !ok || len(a.values) == 0 {
+		return ""
+	}
+	return string(a.values[0].data)
+}
+
+// errShortMessage is returned for messages ending inside an attribute
+var errShortMessage = errors.New("ipp: message ends early")
+
+// readMessage reads a message up to its end tag, the document data of a request follows it in r
+func readMessage(r *bufio.Reader) (*message, error) {
+	var head [8]byte
+	if _, err := io.ReadFull(r, head[:]); err != nil {
+		return nil, errShortMessage
+	}
+	m := &message{
+		major:     head[0],
+		minor:     head[1],
+		code:      binary.BigEndian.Uint16(head[2:]),
+		requestID: binary.BigEndian.Uint32(head[4:]),
+	}
+	var g *group
+	// stack of the collections being read, the innermost last
+	var stack []*attribute
+	var last *attribute
+	for {
+		tag, err := r.ReadByte()
+		if err != nil {
+			return nil, errShortMessage
+		}
+		if tag == tagEnd {
+			if len(stack) > 0 {
+				return nil, errors.New("ipp: collection is not closed")
+			}
+			return m, nil
+		}
+		if tag < 0x10 {
+			m.groups = append(m.groups, group{tag: tag})
+			g, last = &m.groups[len(m.groups)-1], nil
+			continue
+		}
+		if g == nil {
+			return nil, errors.New("ipp: attribute outside of a group")
+		}
+		name, err := readField(r)
+		if err != nil {
+			return nil, err
+		}
+		data, err := readField(r)
+		if err != nil {
+			return nil, err
+		}
+
+		switch tag {
+		case tagEndCollection:
+			if len(stack) == 0 {
+				return nil, errors.New("ipp: end of a collection which is not open")
+			}
+			last, stack = stack[len(stack)-1], stack[:len(stack)-1]
+			continue
+		case tagMemberName:
+			if len(stack) == 0 {
+				return nil, errors.New("ipp: member outside of a collection")
+			}
+			c := &stack[len(stack)-1].values[len(stack[len(stack)-1].values)-1]
+			c.members = append(c.members, attribute{name: string(data)})
+			last = &c.members[len(c.members)-1]
+			continue
+		}
+
+		v := value{tag: tag, data: data}
+		switch {
+		case len(name) > 0 && len(stack) > 0:
+			return nil, fmt.Errorf("ipp: attribute %s inside a collection", name)
+		case len(name) > 0:
+			g.attrs = append(g.attrs, attribute{name: string(name)})
+			last = &g.attrs[len(g.attrs)-1]
+		case last == nil:
+			return nil, errors.New("ipp: additional value without an attribute")
+		}
+		last.values = append(last.values, v)
+		if tag == tagBeginCollection {
+			stack = append(stack, last)
+		}
+	}
+}
+
+// readField reads a field of a 2 byte length
+func readField(r *bufio.Reader) ([]byte, error) {
+	var n [2]byte
+	if _, err := io.ReadFull(r, n[:]); err != nil {
+		return nil, errShortMessage
+	}
+	b := make([]byte, binary.BigEndian.Uint16(n[:]))
+	if _, err := io.ReadFull(r, b); err != nil {
+		return nil, errShortMessage
+	}
+	return b, nil
+}
+
+// encode returns the encoding of m
+func (m *message) encode() []byte {
+	var b bytes.Buffer
+	b.Write([]byte{m.major, m.minor})
+	binary.Write(&b, binary.BigEndian, m.code)
+	binary.Write(&b, binary.BigEndian, m.requestID)
+	for _, g := range m.groups {
+		b.WriteByte(g.tag)
+		for _, a := range g.attrs {
+			writeAttribute(&b, a.name, a.values)
+		}
+	}
+	b.WriteByte(tagEnd)
+	return b.Bytes()
+}
+
+// writeAttribute writes the values of an attribute, the name goes with the first one only
+func writeAttribute(b *bytes.Buffer, name string, values []value) {
+	for i, v := range values {
+		if i > 0 {
+			name = ""
+		}
+		writeField(b, v.tag, name, v.data)
+		if v.tag != tagBeginCollection {
+			continue
+		}
+		for _, member := range v.members {
+			writeField(b, tagMemberName, "", []byte(member.name))
+			writeAttribute(b, "", member.values)
+		}
+		writeField(b, tagEndCollection, "", nil)
+	}
+}
+
+func writeField(b *bytes.Buffer, tag byte, name string, data []byte) {
+	b.WriteByte(tag)
+	binary.Write(b, binary.BigEndian, uint16(len(name)))
+	b.WriteString(name)
+	binary.Write(b, binary.BigEndian, uint16(len(data)))
+	b.Write(data)
+}
@@ -0,0 +1,189 @@
+package ippserver
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+// raw returns a request header of Print-Job followed by fields, fields are written by writeField
+func raw(fields ...func(b *bytes.Buffer)) []byte {
+	var b bytes.Buffer
+	b.Write([]byte{2, 0, 0, opPrintJob, 0, 0, 0, 7})
+	for _, f := range fields {
+		f(&b)
+	}
+	return b.Bytes()
+}
+
+func delim(tag byte) func(b *bytes.Buffer) {
+	return func(b *bytes.Buffer) { b.WriteByte(tag) }
+}
+
+func field(tag byte, name, data string) func(b *bytes.Buffer) {
+	return func(b *bytes.Buffer) { writeField(b, tag, name, []byte(data)) }
+}
+
+func bytesOf(p []byte) func(b *bytes.Buffer) {
+	return func(b *bytes.Buffer) { b.Write(p) }
+}
+
+func read(t *testing.T, p []byte) *message {
+	t.Helper()
+	m, err := readMessage(bufio.NewReader(bytes.NewReader(p)))
+	if err != nil {
+		t.Fatalf("readMessage: %v", err)
+	}
+	return m
+}
+
+func TestMessageRoundTrip(t *testing.T) {
+	media := func(x, y int) value {
+		return collection(
+			attr("media-size", collection(
+				attr("x-dimension", integer(x)),
+				attr("y-dimension", integer(y)),
+			)),
+			strs("media-type", tagKeyword, "labels"),
+		)
+	}
+	m := &message{
+		major: 2, minor: 0, code: statusOK, requestID: 42,
+		groups: []group{
+			{tag: tagOperation, attrs: []attribute{
+				strs("attributes-charset", tagCharset, "utf-8"),
+				strs("attributes-natural-language", tagLanguage, "en"),
+			}},
+			{tag: tagPrinter, attrs: []attribute{
+				strs("document-format-supported", tagMIMEType, "image/png", "image/pwg-raster"),
+				attr("printer-state", enum(3)),
+				attr("color-supported", boolean(false)),
+				attr("copies-supported", rangeOf(1, 999)),
+				attr("printer-resolution-default", resolution(180, 360)),
+				attr("media-ready", noValue()),
+				// a 1setOf collections of nested collections
+				attr("media-col-database", media(2400, 0), media(6200, 2900)),
+				attr("queued-job-count", integer(-1)),
+			}},
+			{tag: tagJob},
+		},
+	}
+	b := m.encode()
+	got := read(t, append(b, "document"...))
+
+	if got.major != 2 || got.minor != 0 || got.code != statusOK || got.requestID != 42 || len(got.groups) != 3 {
+		t.Fatalf("header or groups of %+v", got)
+	}
+	if again := got.encode(); !bytes.Equal(again, b) {
+		t.Errorf("encoding of the decoded message differs:\n%x\n%x", again, b)
+	}
+	if a, _ := got.lookup(tagPrinter, "document-format-supported"); len(a.values) != 2 || string(a.values[1].data) != "image/pwg-raster" {
+		t.Errorf("document-format-supported = %+v", a)
+	}
+	if a, _ := got.lookup(tagPrinter, "queued-job-count"); len(a.values) != 1 {
+		t.Errorf("queued-job-count = %+v", a)
+	} else if n, ok := a.values[0].int(); !ok || n != -1 {
+		t.Errorf("queued-job-count = %d, %v", n, ok)
+	}
+
+	a, ok := got.lookup(tagPrinter, "media-col-database")
+	if !ok || len(a.values) != 2 {
+		t.Fatalf("media-col-database = %+v", a)
+	}
+	second := a.values[1]
+	if second.tag != tagBeginCollection || len(second.members) != 2 || second.members[0].name != "media-size" || second.members[1].name != "media-type" {
+		t.Fatalf("second media-col = %+v", second)
+	}
+	size := second.members[0].values[0].members
+	if len(size) != 2 || size[1].name != "y-dimension" {
+		t.Fatalf("media-size = %+v", size)
+	}
+	if y, _ := size[1].values[0].int(); y != 2900 {
+		t.Errorf("y-dimension = %d, want 2900", y)
+	}
+	if got.text("attributes-charset") != "utf-8" {
+		t.Errorf("attributes-charset = %q", got.text("attributes-charset"))
+	}
+}
+
+func TestReadMessageLeavesDocument(t *testing.T) {
+	m := &message{major: 2, code: opPrintJob, requestID: 1, groups: []group{{tag: tagOperation, attrs: []attribute{strs("job-name", tagName, "x")}}}}
+	r := bufio.NewReader(bytes.NewReader(append(m.encode(), "PNG DATA"...)))
+	if _, err := readMessage(r); err != nil {
+		t.Fatalf("readMessage: %v", err)
+	}
+	if doc, _ := ioutil.ReadAll(r); string(doc) != "PNG DATA" {
+		t.Errorf("document = %q", doc)
+	}
+}
+
+func TestReadMessageAdditionalValues(t *testing.T) {
+	m := read(t, raw(
+		delim(tagOperation),
+		field(tagKeyword, "requested-attributes", "printer-state"),
+		field(tagKeyword, "", "media-ready"),
+		field(tagKeyword, "", "copies-supported"),
+		delim(tagEnd),
+	))
+	a, _ := m.lookup(tagOperation, "requested-attributes")
+	if len(a.values) != 3 || string(a.values[2].data) != "copies-supported" {
+		t.Errorf("requested-attributes = %+v", a)
+	}
+}
+
+func TestReadMessageRejects(t *testing.T) {
+	full := raw(delim(tagOperation), field(tagCharset, "attributes-charset", "utf-8"), delim(tagEnd))
+	tests := []struct {
+		name string
+		in   []byte
+		want string
+	}{
+		{"short header", []byte{2, 0, 0, 2}, "message ends early"},
+		{"no end tag", full[:len(full)-1], "message ends early"},
+		{"truncated name", full[:8+1+1+2+5], "message ends early"},
+		{"truncated name length", full[:8+1+1+1], "message ends early"},
+		{"truncated value", full[:len(full)-3], "message ends early"},
+		{"value length beyond the message", raw(delim(tagOperation), bytesOf([]byte{tagText, 0, 1, 'a', 0xff, 0xff, 'x'})), "message ends early"},
+		{"attribute outside of a group", raw(field(tagText, "job-name", "x"), delim(tagEnd)), "attribute outside of a group"},
+		{"additional value first", raw(delim(tagOperation), field(tagText, "", "x"), delim(tagEnd)), "additional value without an attribute"},
+		{"collection is not closed", raw(
+			delim(tagJob),
+			field(tagBeginCollection, "media-col", ""),
+			field(tagMemberName, "", "media-type"),
+			field(tagKeyword, "", "labels"),
+			delim(tagEnd),
+		), "collection is not closed"},
+		{"nested collection is not closed", raw(
+			delim(tagJob),
+			field(tagBeginCollection, "media-col", ""),
+			field(tagMemberName, "", "media-size"),
+			field(tagBeginCollection, "", ""),
+			field(tagEndCollection, "", ""),
+			delim(tagEnd),
+		), "collection is not closed"},
+		{"member outside of a collection", raw(delim(tagJob), field(tagMemberName, "", "media-type"), delim(tagEnd)), "member outside of a collection"},
+		{"member after the collection", raw(
+			delim(tagJob),
+			field(tagBeginCollection, "media-col", ""),
+			field(tagEndCollection, "", ""),
+			field(tagMemberName, "", "media-type"),
+			delim(tagEnd),
+		), "member outside of a collection"},
+		{"end of no collection", raw(delim(tagJob), field(tagEndCollection, "", ""), delim(tagEnd)), "end of a collection which is not open"},
+		{"named attribute in a collection", raw(
+			delim(tagJob),
+			field(tagBeginCollection, "media-col", ""),
+			field(tagKeyword, "media-type", "labels"),
+			field(tagEndCollection, "", ""),
+			delim(tagEnd),
+		), "attribute media-type inside a collection"},
+	}
+	for _, tt := range tests {
+		_, err := readMessage(bufio.NewReader(bytes.NewReader(tt.in)))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: readMessage = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,838 @@
+// Package ippserver serves the printers of a server.Spooler as driverless IPP Everywhere printers, so phones,
+// Chromebooks and CUPS print labels on them without a driver. Each printer is advertised by mDNS and takes
+// jobs of PWG raster pages, laid out on its tape like the CUPS backend does, and of PNG images, printed
+// like the images of "ptouchgo print".
+//
+// It implements the operations Print-Job, Validate-Job, Get-Printer-Attributes, Get-Jobs, Get-Job-Attributes
+// and Cancel-Job, which fails since jobs of the spooler can not be canceled.
+package ippserver
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/png"
+	"io"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ka2n/ptouchgo"
+	"github.com/ka2n/ptouchgo/cups"
+	"github.com/ka2n/ptouchgo/server"
+	httpserver "github.com/ka2n/ptouchgo/server/http"
+)
+
+// document formats of jobs, application/octet-stream is told apart by the start of the document
+const (
+	formatPWG   = "image/pwg-raster"
+	formatPNG   = "image/png"
+	formatAuto  = "application/octet-stream"
+	contentType = "application/ipp"
+)
+
+// PathPrefix is the path of the default printer, the one of the printer named name is PathPrefix + "/" + name
+const PathPrefix = "/ipp/print"
+
+// statusTTL is how long the status read for the printer attributes is used
+const statusTTL = 10 * time.Second
+
+// Server is an IPP server printing on the printers of a spooler
+type Server struct {
+	// Defaults are the settings of jobs, the copies attribute of jobs overrides Copies
+	Defaults server.PrintRequest
+	// Clients are the names of the server.Client of API keys, the passwords of the jobs
+	Clients map[string]string
+
+	spooler *server.Spooler
+	keys    []string
+	started time.Time
+
+	mu       sync.Mutex
+	statuses map[string]printerStatus
+	jobs     map[string]jobInfo
+}
+
+// printerStatus is the status last read from a printer
+type printerStatus struct {
+	st *ptouchgo.Status // nil when it could not be read
+	at time.Time
+}
+
+// jobInfo are the attributes of a job the spooler does not keep
+type jobInfo struct {
+	name string
+	user string
+}
+
+// New returns a server for the printers of spooler, print jobs need HTTP basic authentication with one
+// of apiKeys as the password when it is not empty
+func New(spooler *server.Spooler, apiKeys []string) *Server {
+	return &Server{
+		spooler:  spooler,
+		keys:     apiKeys,
+		started:  time.Now(),
+		statuses: map[string]printerStatus{},
+		jobs:     map[string]jobInfo{},
+	}
+}
+
+// Services returns the mDNS services of the printers served on port, the first printer is the default one
+func (s *Server) Services(port int) []Service {
+	host, _ := os.Hostname()
+	if i := strings.IndexByte(host, '.'); i > 0 {
+		host = host[:i]
+	}
+	var services []Service
+	for i, p := range s.spooler.Printers() {
+		path := PathPrefix
+		if i > 0 {
+			path += "/" + p.Name
+		}
+		m := s.status(p.Name).model()
+		air := "none"
+		if len(s.keys) > 0 {
+			air = "username,password"
+		}
+		txt := []string{
+			"txtvers=1",
+			"qtotal=1",
+			"rp=" + strings.TrimPrefix(path, "/"),
+			"ty=" + makeAndModel(m),
+			"pdl=" + formatPWG + "," + formatPNG,
+			"Color=F",
+			"Duplex=F",
+			"kind=label",
+			"air=" + air,
+			"UUID=" + s.uuid(p.Name),
+		}
+		if m.Supported() {
+			txt = append(txt, "product=("+m.String()+")", "usb_MFG=Brother", "usb_MDL="+m.String())
+		}
+		services = append(services, Service{
+			Instance: fmt.Sprintf("%s (%s) on %s", makeAndModel(m), p.Name, host),
+			Port:     port,
+			TXT:      txt,
+		})
+	}
+	return services
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name, ok := s.printerName(r.URL.Path)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "IPP requests are posted", http.StatusMethodNotAllowed)
+		return
+	}
+	if ct := r.Header.Get("Content-Type"); !strings.HasPrefix(ct, contentType) {
+		http.Error(w, "Content-Type must be "+contentType, http.StatusUnsupportedMediaType)
+		return
+	}
+	body := bufio.NewReader(http.MaxBytesReader(w, r.Body, httpserver.MaxRequestSize))
+	req, err := readMessage(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if (req.code == opPrintJob || req.code == opCancelJob) && !s.authorized(r) {
+		w.Header().Set("WWW-Authenticate", `Basic realm="ptouchgo"`)
+		http.Error(w, "the API key is the password", http.StatusUnauthorized)
+		return
+	}
+
+	res := s.handle(&call{server: s, r: r, printer: name, req: req, document: body})
+	w.Header().Set("Content-Type", contentType)
+	w.Write(res.encode())
+}
+
+// printerName returns the name of the printer of path, empty for the default one
+func (s *Server) printerName(path string) (string, bool) {
+	if path == PathPrefix {
+		return "", true
+	}
+	name := strings.TrimPrefix(path, PathPrefix+"/")
+	if name == path || name == "" || strings.Contains(name, "/") {
+		return "", false
+	}
+	for _, p := range s.spooler.Printers() {
+		if p.Name == name {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// authorized reports whether the password of r is one of the API keys, every request is when there are none
+func (s *Server) authorized(r *http.Request) bool {
+	if len(s.keys) == 0 {
+		return true
+	}
+	_, password, ok := r.BasicAuth()
+	if !ok {
+		return false
+	}
+	for _, k := range s.keys {
+		if subtle.ConstantTimeCompare([]byte(password), []byte(k)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// call is a request to a printer
+type call struct {
+	server   *Server
+	r        *http.Request
+	printer  string // empty for the default one
+	req      *message
+	document io.Reader
+}
+
+// ippError is a request failing with the status code
+type ippError struct {
+	code uint16
+	msg  string
+}
+
+func (e *ippError) Error() string {
+	return e.msg
+}
+
+func errorf(code uint16, format string, args ...interface{}) error {
+	return &ippError{code: code, msg: fmt.Sprintf(format, args...)}
+}
+
+// handle answers a request, the version of the response is the one of the request up to 2.0
+func (s *Server) handle(c *call) *message {
+	res := &message{major: c.req.major, minor: c.req.minor, requestID: c.req.requestID}
+	if res.major > 2 || res.major == 2 && res.minor > 0 {
+		res.major, res.minor = 2, 0
+	}
+	op := group{tag: tagOperation, attrs: []attribute{
+		strs("attributes-charset", tagCharset, "utf-8"),
+		strs("attributes-natural-language", tagLanguage, "en"),
+	}}
+	var groups []group
+	err := c.check()
+	if err == nil {
+		switch c.req.code {
+		case opPrintJob:
+			groups, err = c.printJob()
+		case opValidateJob:
+			_, err = c.jobSettings()
+		case opCancelJob:
+			err = c.cancelJob()
+		case opGetJobAttributes:
+			groups, err = c.getJobAttributes()
+		case opGetJobs:
+			groups, err = c.getJobs()
+		case opGetPrinterAttributes:
+			groups = []group{{tag: tagPrinter, attrs: c.printerAttributes()}}
+		default:
+			err = errorf(statusOperationUnsupported, "operation 0x%04x is not supported", c.req.code)
+		}
+	}
+	if err != nil {
+		res.code = statusInternalError
+		var e *ippError
+		if errors.As(err, &e) {
+			res.code = e.code
+		}
+		op.attrs = append(op.attrs, strs("status-message", tagText, err.Error()))
+		groups = nil
+	}
+	res.groups = append([]group{op}, groups...)
+	return res
+}
+
+// check checks the version and the operation attributes every request starts with
+func (c *call) check() error {
+	if c.req.major < 1 || c.req.major > 2 {
+		return errorf(statusVersionUnsupported, "IPP %d.%d is not supported", c.req.major, c.req.minor)
+	}
+	if len(c.req.groups) == 0 || c.req.groups[0].tag != tagOperation {
+		return errorf(statusBadRequest, "the request has no operation attributes")
+	}
+	attrs := c.req.groups[0].attrs
+	if len(attrs) < 2 || attrs[0].name != "attributes-charset" || attrs[1].name != "attributes-natural-language" {
+		return errorf(statusBadRequest, "the operation attributes must start with attributes-charset and attributes-natural-language")
+	}
+	return nil
+}
+
+// jobSettings returns the print request of the job attributes and the document format of a request
+func (c *call) jobSettings() (*server.PrintRequest, error) {
+	req := c.server.Defaults
+	req.Images, req.Names = nil, nil
+	if a, ok := c.req.lookup(tagJob, "copies"); ok && len(a.values) > 0 {
+		n, ok := a.values[0].int()
+		if !ok || n < 1 || n > server.MaxCopies {
+			return nil, errorf(statusAttributesUnsupported, "copies must be 1-%d", server.MaxCopies)
+		}
+		req.Copies = n
+	}
+	switch format := c.req.text("document-format"); format {
+	case "", formatAuto, formatPWG, formatPNG:
+	default:
+		return nil, errorf(statusDocumentFormatUnsupported, "%s is not supported, send %s or %s", format, formatPWG, formatPNG)
+	}
+	return &req, nil
+}
+
+// printJob submits the document of a Print-Job request
+func (c *call) printJob() ([]group, error) {
+	req, err := c.jobSettings()
+	if err != nil {
+		return nil, err
+	}
+	if req.Images, req.Names, err = c.readDocument(); err != nil {
+		return nil, err
+	}
+	if _, password, ok := c.r.BasicAuth(); ok {
+		req.Client = c.server.Clients[password]
+	}
+	job, err := c.server.spooler.Submit(c.printer, req)
+	switch {
+	case errors.Is(err, server.ErrQueueFull), errors.Is(err, server.ErrRateLimited), errors.Is(err, server.ErrShutdown):
+		return nil, errorf(statusBusy, "%v", err)
+	case errors.Is(err, server.ErrQuotaExceeded):
+		return nil, errorf(statusNotPossible, "%v", err)
+	case errors.Is(err, server.ErrNoPrinter):
+		return nil, errorf(statusNotFound, "%v", err)
+	case err != nil:
+		return nil, err
+	}
+	name := c.req.text("job-name")
+	if name == "" {
+		name = c.req.text("document-name")
+	}
+	c.server.keepJob(job.ID, jobInfo{name: name, user: c.req.text("requesting-user-name")})
+	attrs := c.jobAttributes(job)
+	return []group{{tag: tagJob, attrs: filter(attrs, []string{"job-id", "job-uri", "job-state", "job-state-reasons", "job-state-message"})}}, nil
+}
+
+// readDocument reads the pages of the document following the request
+func (c *call) readDocument() ([]image.Image, []string, error) {
+	br := bufio.NewReader(c.document)
+	format := c.req.text("document-format")
+	if format == "" || format == formatAuto {
+		start, _ := br.Peek(8)
+		switch {
+		case bytes.HasPrefix(start, []byte("RaS2")):
+			format = formatPWG
+		case bytes.HasPrefix(start, []byte("\x89PNG")):
+			format = formatPNG
+		default:
+			return nil, nil, errorf(statusDocumentFormatUnsupported, "the document is neither %s nor %s", formatPWG, formatPNG)
+		}
+	}
+	if format == formatPNG {
+		img, err := server.DecodeImage(br)
+		if err != nil {
+			return nil, nil, errorf(statusDocumentFormatError, "load image: %v", err)
+		}
+		return []image.Image{img}, []string{"document"}, nil
+	}
+
+	rr, err := cups.NewRasterReader(br)
+	if err != nil {
+		return nil, nil, errorf(statusDocumentFormatError, "%v", err)
+	}
+	st := c.server.status(c.printer)
+	rr.Model = st.model()
+	var imgs []image.Image
+	var names []string
+	for {
+		h, page, err := rr.NextPage()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, errorf(statusDocumentFormatError, "%v", err)
+		}
+		imgs = append(imgs, cups.FitPage(st.serial(h), h, page))
+		names = append(names, fmt.Sprintf("page %d", len(imgs)))
+	}
+	if len(imgs) == 0 {
+		return nil, nil, errorf(statusDocumentFormatError, "the document has no pages")
+	}
+	return imgs, names, nil
+}
+
+// serial returns a printer without connection for laying out the pages of h on its tape, the tape of the
+// page size when the status could not be read or is not of a loaded tape
+func (ps printerStatus) serial(h *cups.PageHeader) ptouchgo.Serial {
+	var ser ptouchgo.Serial
+	if ps.st != nil && ser.UseStatus(ps.st) == nil {
+		return ser
+	}
+	ser = ptouchgo.Serial{Model: ps.model(), TapeWidthMM: uint(cups.PageTape(h))}
+	if ser.Model.QL() {
+		// QL media has to be read from the printer, lay out on the media of the page width
+		if media, err := ser.Model.QLMediaFor(int(ser.TapeWidthMM), 0); err == nil {
+			ser.Media = media
+		}
+	}
+	return ser
+}
+
+func (ps printerStatus) model() ptouchgo.Model {
+	if ps.st == nil {
+		return 0
+	}
+	return ps.st.Model
+}
+
+// cancelJob fails for jobs of the printer, which can not be canceled
+func (c *call) cancelJob() error {
+	job, err := c.job()
+	if err != nil {
+		return err
+	}
+	if job.State.Finished() {
+		return errorf(statusNotPossible, "job %s is %s", job.ID, job.State)
+	}
+	return errorf(statusNotPossible, "jobs can not be canceled once they are submitted")
+}
+
+func (c *call) getJobAttributes() ([]group, error) {
+	job, err := c.job()
+	if err != nil {
+		return nil, err
+	}
+	return []group{{tag: tagJob, attrs: filter(c.jobAttributes(job), c.requested())}}, nil
+}
+
+// getJobs lists the jobs of the printer, which-jobs is "not-completed" or "completed"
+func (c *call) getJobs() ([]group, error) {
+	which := c.req.text("which-jobs")
+	if which == "" {
+		which = "not-completed"
+	}
+	if which != "not-completed" && which != "completed" {
+		return nil, errorf(statusAttributesUnsupported, "which-jobs %s is not supported", which)
+	}
+	limit := 0
+	if a, ok := c.req.lookup(tagOperation, "limit"); ok && len(a.values) > 0 {
+		limit, _ = a.values[0].int()
+	}
+	requested := c.requested()
+	if requested == nil {
+		requested = []string{"job-id", "job-uri"}
+	}
+	var groups []group
+	for _, job := range c.server.spooler.Jobs(c.printerFullName()) {
+		if job.State.Finished() != (which == "completed") {
+			continue
+		}
+		if limit > 0 && len(groups) == limit {
+			break
+		}
+		groups = append(groups, group{tag: tagJob, attrs: filter(c.jobAttributes(job), requested)})
+	}
+	return groups, nil
+}
+
+// job returns the job of the job-id or job-uri of a request
+func (c *call) job() (server.Job, error) {
+	id := ""
+	if a, ok := c.req.lookup(tagOperation, "job-id"); ok && len(a.values) > 0 {
+		if n, ok := a.values[0].int(); ok {
+			id = strconv.Itoa(n)
+		}
+	} else if uri := c.req.text("job-uri"); uri != "" {
+		id = uri[strings.LastIndexByte(uri, '/')+1:]
+	}
+	if id == "" {
+		return server.Job{}, errorf(statusBadRequest, "job-id or job-uri is required")
+	}
+	job, ok := c.server.spooler.Job(id)
+	if !ok || job.Printer != c.printerFullName() {
+		return server.Job{}, errorf(statusNotFound, "no job %s", id)
+	}
+	return job, nil
+}
+
+// printerFullName returns the name of the printer of the request in the spooler
+func (c *call) printerFullName() string {
+	if c.printer != "" {
+		return c.printer
+	}
+	return c.server.spooler.Printers()[0].Name
+}
+
+// requested returns the requested-attributes of a request, nil for all of them
+func (c *call) requested() []string {
+	a, ok := c.req.lookup(tagOperation, "requested-attributes")
+	if !ok {
+		return nil
+	}
+	var names []string
+	for _, v := range a.values {
+		switch name := string(v.data); name {
+		case "all", "job-description", "job-template", "printer-description":
+			return nil
+		default:
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// filter returns the attributes of names, all of them for nil
+func filter(attrs []attribute, names []string) []attribute {
+	if names == nil {
+		return attrs
+	}
+	var list []attribute
+	for _, a := range attrs {
+		for _, n := range names {
+			if a.name == n {
+				list = append(list, a)
+				break
+			}
+		}
+	}
+	return list
+}
+
+// printerURI returns the URI of the printer of a request as the client reached it
+func (c *call) printerURI() string {
+	scheme := "ipp"
+	if c.r.TLS != nil {
+		scheme = "ipps"
+	}
+	return scheme + "://" + c.r.Host + c.r.URL.Path
+}
+
+// upTime returns t in seconds of printer-up-time, which starts at 1
+func (s *Server) upTime(t time.Time) int {
+	return int(t.Sub(s.started)/time.Second) + 1
+}
+
+// jobAttributes returns the attributes of job
+func (c *call) jobAttributes(job server.Job) []attribute {
+	s := c.server
+	id, _ := strconv.Atoi(job.ID)
+	info := s.jobInfo(job.ID)
+	state, reason := 3, "job-queued"
+	switch job.State {
+	case server.JobPrinting:
+		state, reason = 5, "job-printing"
+	case server.JobWaiting:
+		state, reason = 6, "printer-stopped"
+	case server.JobCompleted:
+		state, reason = 9, "job-completed-successfully"
+	case server.JobFailed:
+		state, reason = 8, "aborted-by-system"
+	}
+	at := func(name string, t *time.Time) attribute {
+		if t == nil {
+			return attr(name, noValue())
+		}
+		return attr(name, integer(s.upTime(*t)))
+	}
+	attrs := []attribute{
+		attr("job-id", integer(id)),
+		strs("job-uri", tagURI, c.printerURI()+"/"+job.ID),
+		strs("job-printer-uri", tagURI, c.printerURI()),
+		strs("job-name", tagName, info.name),
+		strs("job-originating-user-name", tagName, info.user),
+		attr("job-state", enum(state)),
+		strs("job-state-reasons", tagKeyword, reason),
+		attr("job-printer-up-time", integer(s.upTime(time.Now()))),
+		attr("time-at-creation", integer(s.upTime(job.Created))),
+		at("time-at-processing", job.Started),
+		at("time-at-completed", job.Finished),
+	}
+	if job.Error != "" {
+		attrs = append(attrs, strs("job-state-message", tagText, job.Error))
+	}
+	if job.Result != nil {
+		attrs = append(attrs, attr("job-impressions-completed", integer(job.Result.Labels)))
+	}
+	return attrs
+}
+
+// keepJob keeps the attributes of a job, the ones of jobs the spooler dropped are dropped
+func (s *Server) keepJob(id string, info jobInfo) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs[id] = info
+	if len(s.jobs) <= 2*server.QueueSize {
+		return
+	}
+	for id := range s.jobs {
+		if _, ok := s.spooler.Job(id); !ok {
+			delete(s.jobs, id)
+		}
+	}
+}
+
+func (s *Server) jobInfo(id string) jobInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.jobs[id]
+}
+
+// status returns the status of the printer named name read within statusTTL. While the printer has jobs
+// the last one is returned, reading it would wait until they are printed.
+func (s *Server) status(name string) printerStatus {
+	busy := false
+	for _, p := range s.spooler.Printers() {
+		if p.Name == name || name == "" && p.Default {
+			name, busy = p.Name, p.Queued > 0
+		}
+	}
+	for _, j := range s.spooler.Jobs(name) {
+		busy = busy || j.State == server.JobPrinting
+	}
+	s.mu.Lock()
+	ps, ok := s.statuses[name]
+	s.mu.Unlock()
+	if ok && (busy || time.Since(ps.at) < statusTTL) {
+		return ps
+	}
+	st, err := s.spooler.Status(name)
+	if err != nil {
+		st = nil
+	}
+	ps = printerStatus{st: st, at: time.Now()}
+	s.mu.Lock()
+	s.statuses[name] = ps
+	s.mu.Unlock()
+	return ps
+}
+
+// uuid returns the printer-uuid of the printer named name, the same for the host and name
+func (s *Server) uuid(name string) string {
+	host, _ := os.Hostname()
+	h := sha1.Sum([]byte("ptouchgo/" + host + "/" + name))
+	h[6] = h[6]&0x0f | 0x50 // version 5
+	h[8] = h[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", h[0:4], h[4:6], h[6:8], h[8:10], h[10:16])
+}
+
+// makeAndModel returns the printer-make-and-model of m, zero for a printer whose status is unknown
+func makeAndModel(m ptouchgo.Model) string {
+	if !m.Supported() {
+		return "Brother label printer"
+	}
+	return "Brother " + m.String()
+}
+
+// printerAttributes returns the printer attributes a request asks for
+func (c *call) printerAttributes() []attribute {
+	s := c.server
+	name := c.printerFullName()
+	ps := s.status(c.printer)
+	m := ps.model()
+	head := m.Head()
+
+	state, reasons := 3, []string(nil)
+	if ps.st != nil {
+		reasons = cups.StateReasons(ps.st)
+		if cups.Blocking(reasons) {
+			state = 5
+		}
+	}
+	jobs := s.spooler.Jobs(name)
+	queued := 0
+	for _, j := range jobs {
+		if !j.State.Finished() {
+			queued++
+		}
+	}
+	if queued > 0 && state == 3 {
+		state = 4
+	}
+	if len(reasons) == 0 {
+		reasons = []string{"none"}
+	}
+	auth := "none"
+	if len(s.keys) > 0 {
+		auth = "basic"
+	}
+
+	sizes := cups.PageSizes(m)
+	ready := readySizes(sizes, ps.st)
+	def := sizes[0]
+	for _, size := range sizes {
+		if size.LengthMM == 50 {
+			def = size
+		}
+	}
+	if len(ready) > 0 {
+		def = ready[0]
+		for _, size := range ready {
+			if size.LengthMM == 50 {
+				def = size
+			}
+		}
+	}
+	var supported, readyNames []string
+	var database, readyCols, sizeCols []value
+	margins := map[int]bool{}
+	var sideMargins []value
+	for _, size := range sizes {
+		supported = append(supported, mediaName(size))
+		database = append(database, mediaCol(size))
+		sizeCols = append(sizeCols, collection(mediaSize(size)...))
+		if mm := sideMargin(size); !margins[mm] {
+			margins[mm] = true
+			sideMargins = append(sideMargins, integer(mm))
+		}
+	}
+	for _, size := range ready {
+		readyNames = append(readyNames, mediaName(size))
+		readyCols = append(readyCols, mediaCol(size))
+	}
+
+	ops := []value{enum(opPrintJob), enum(opValidateJob), enum(opCancelJob), enum(opGetJobAttributes), enum(opGetJobs), enum(opGetPrinterAttributes)}
+	info := makeAndModel(m)
+	if m.Supported() {
+		info += " (" + name + ")"
+	}
+	attrs := []attribute{
+		strs("charset-configured", tagCharset, "utf-8"),
+		strs("charset-supported", tagCharset, "utf-8"),
+		strs("natural-language-configured", tagLanguage, "en"),
+		strs("generated-natural-language-supported", tagLanguage, "en"),
+		strs("compression-supported", tagKeyword, "none"),
+		strs("document-format-default", tagMIMEType, formatAuto),
+		strs("document-format-supported", tagMIMEType, formatAuto, formatPWG, formatPNG),
+		strs("ipp-versions-supported", tagKeyword, "1.1", "2.0"),
+		attr("operations-supported", ops...),
+		strs("pdl-override-supported", tagKeyword, "attempted"),
+		attr("printer-is-accepting-jobs", boolean(true)),
+		strs("printer-make-and-model", tagText, makeAndModel(m)),
+		strs("printer-name", tagName, name),
+		strs("printer-info", tagText, info),
+		strs("printer-location", tagText, ""),
+		strs("printer-device-id", tagText, cups.DeviceID(m)+"CMD:PWGRaster,PNG;"),
+		strs("printer-kind", tagKeyword, "labels"),
+		attr("printer-state", enum(state)),
+		strs("printer-state-reasons", tagKeyword, reasons...),
+		attr("printer-up-time", integer(s.upTime(time.Now()))),
+		strs("printer-uri-supported", tagURI, c.printerURI()),
+		strs("printer-uuid", tagURI, "urn:uuid:"+s.uuid(name)),
+		strs("uri-authentication-supported", tagKeyword, auth),
+		strs("uri-security-supported", tagKeyword, "none"),
+		attr("queued-job-count", integer(queued)),
+		attr("color-supported", boolean(false)),
+		attr("copies-default", integer(1)),
+		attr("copies-supported", rangeOf(1, server.MaxCopies)),
+		strs("job-creation-attributes-supported", tagKeyword, "copies", "media", "media-col"),
+		strs("media-default", tagKeyword, mediaName(def)),
+		strs("media-supported", tagKeyword, supported...),
+		attr("media-col-default", mediaCol(def)),
+		attr("media-size-supported", sizeCols...),
+		attr("media-left-margin-supported", sideMargins...),
+		attr("media-right-margin-supported", sideMargins...),
+		attr("media-top-margin-supported", integer(0)),
+		attr("media-bottom-margin-supported", integer(0)),
+		strs("media-col-supported", tagKeyword, "media-size", "media-left-margin", "media-right-margin", "media-top-margin", "media-bottom-margin"),
+		attr("orientation-requested-default", noValue()),
+		attr("orientation-requested-supported", enum(3)),
+		strs("output-bin-default", tagKeyword, "face-up"),
+		strs("output-bin-supported", tagKeyword, "face-up"),
+		strs("print-color-mode-default", tagKeyword, "monochrome"),
+		strs("print-color-mode-supported", tagKeyword, "monochrome"),
+		attr("print-quality-default", enum(4)),
+		attr("print-quality-supported", enum(4)),
+		attr("printer-resolution-default", resolution(head.DPI, head.DPI)),
+		attr("printer-resolution-supported", resolution(head.DPI, head.DPI)),
+		attr("pwg-raster-document-resolution-supported", resolution(head.DPI, head.DPI)),
+		strs("pwg-raster-document-sheet-back", tagKeyword, "normal"),
+		strs("pwg-raster-document-type-supported", tagKeyword, "black_1", "sgray_8"),
+		strs("sides-default", tagKeyword, "one-sided"),
+		strs("sides-supported", tagKeyword, "one-sided"),
+		strs("which-jobs-supported", tagKeyword, "completed", "not-completed"),
+	}
+	if len(ready) > 0 {
+		attrs = append(attrs, strs("media-ready", tagKeyword, readyNames...), attr("media-col-ready", readyCols...))
+	}
+
+	requested := c.requested()
+	for _, name := range requested {
+		// media-col-database is long and only returned on request
+		if name == "media-col-database" {
+			return append(filter(attrs, requested), attr("media-col-database", database...))
+		}
+	}
+	return filter(attrs, requested)
+}
+
+// readySizes returns the page sizes of the tape or media of st, nil when the status is unknown
+func readySizes(sizes []cups.PageSize, st *ptouchgo.Status) []cups.PageSize {
+	if st == nil {
+		return nil
+	}
+	width := float64(st.TapeWidth)
+	if st.TapeWidth == 4 {
+		width = 3.5
+	}
+	var ready []cups.PageSize
+	for _, size := range sizes {
+		if size.WidthMM != width {
+			continue
+		}
+		if st.Model.QL() && size.DieCut != (st.TapeLength != 0) || size.DieCut && size.LengthMM != float64(st.TapeLength) {
+			continue
+		}
+		ready = append(ready, size)
+	}
+	return ready
+}
+
+// mediaName returns the PWG self-describing media name of size like "om_24mm50_24x50mm"
+func mediaName(size cups.PageSize) string {
+	name := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '-'
+	}, strings.ToLower(size.Name))
+	return fmt.Sprintf("om_%s_%sx%smm", name, strconv.FormatFloat(size.WidthMM, 'f', -1, 64), strconv.FormatFloat(size.LengthMM, 'f', -1, 64))
+}
+
+// hundredths converts mm into the hundredths of mm of dimensions
+func hundredths(mm float64) int {
+	return int(math.Round(mm * 100))
+}
+
+// sideMargin returns the margin on each side of size in hundredths of mm
+func sideMargin(size cups.PageSize) int {
+	return hundredths((size.WidthMM - size.PrintedMM) / 2)
+}
+
+func mediaSize(size cups.PageSize) []attribute {
+	return []attribute{
+		attr("x-dimension", integer(hundredths(size.WidthMM))),
+		attr("y-dimension", integer(hundredths(size.LengthMM))),
+	}
+}
+
+// mediaCol returns the media-col of size
+func mediaCol(size cups.PageSize) value {
+	return collection(
+		attr("media-size", collection(mediaSize(size)...)),
+		attr("media-left-margin", integer(sideMargin(size))),
+		attr("media-right-margin", integer(sideMargin(size))),
+		attr("media-top-margin", integer(0)),
+		attr("media-bottom-margin", integer(0)),
+	)
+}
@@ -0,0 +1,90 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+)
+
+// Store keeps the jobs of a Spooler across restarts, see the server/store package
+type Store interface {
+	// Put stores j, req is given when j is submitted and nil for its updates, which keep the stored request.
+	// The request is dropped once j is finished.
+	Put(j Job, req *PrintRequest) error
+	// Delete deletes the job of id
+	Delete(id string) error
+	// Jobs returns the stored jobs oldest first
+	Jobs() ([]StoredJob, error)
+}
+
+// StoredJob is a job of a Store with the request of unfinished jobs
+type StoredJob struct {
+	Job
+	Request *PrintRequest
+}
+
+// Restore adds the jobs of store and keeps the jobs in store from then on, it is called after the printers
+// are added and before jobs are submitted. The unfinished jobs are queued again, so the ones printing when
+// the server stopped print again, and the ones of printers which are gone fail.
+func (s *Spooler) Restore(store Store) error {
+	stored, err := store.Jobs()
+	if err != nil {
+		return err
+	}
+	var usages []Usage
+	if us, ok := store.(UsageStore); ok {
+		if usages, err = us.Usages(); err != nil {
+			return err
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.store = store
+	for _, u := range usages {
+		s.client(u.Client).usage = u
+	}
+	for _, sj := range stored {
+		j := sj.Job
+		j.done = make(chan struct{})
+		if id, err := strconv.Atoi(j.ID); err == nil && id > s.lastID {
+			s.lastID = id
+		}
+		s.jobs[j.ID] = &j
+		s.order = append(s.order, j.ID)
+		if j.State.Finished() {
+			close(j.done)
+			continue
+		}
+		j.State, j.Started, j.req = JobQueued, nil, sj.Request
+		pr, err := s.printer(j.Printer)
+		switch {
+		case err == nil && sj.Request == nil:
+			err = fmt.Errorf("the request of job %s is not stored", j.ID)
+		case err == nil && len(pr.queue) >= QueueSize:
+			err = fmt.Errorf("%s: %w", pr.name, ErrQueueFull)
+		}
+		if err != nil {
+			s.fail(&j, err)
+			continue
+		}
+		j.estimateMM = estimateMM(j.req)
+		s.client(j.Client).queuedMM += j.estimateMM
+		s.enqueue(pr, &j)
+		if err := store.Put(j, nil); err != nil {
+			return err
+		}
+	}
+	s.dropJobs()
+	return nil
+}
+
+// fail finishes the unqueued job j with err, s.mu is held
+func (s *Spooler) fail(j *Job, err error) {
+	now := time.Now()
+	j.State, j.Finished, j.Error, j.req = JobFailed, &now, err.Error(), nil
+	if err := s.store.Put(*j, nil); err != nil {
+		log.Printf("job %s: store: %v\n", j.ID, err)
+	}
+	close(j.done)
+}
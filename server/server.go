@@ -0,0 +1,495 @@
+// Package server spools print jobs for printers, the HTTP and gRPC servers of "ptouchgo serve" submit jobs to it.
+// Each printer prints its queue one job at a time.
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ka2n/ptouchgo"
+)
+
+// Printer prints the jobs of a Spooler, Print and Status are not called at the same time
+type Printer interface {
+	// Print prints req
+	Print(req *PrintRequest) (Result, error)
+	// Status reads the status of the printer
+	Status() (*ptouchgo.Status, error)
+}
+
+// PrintRequest is a job of images followed by an optional label of text, a QR code or a barcode
+type PrintRequest struct {
+	Images []image.Image
+	Names  []string // of Images, for errors
+
+	Text    string // \n starts a new line, the caption with QR or Barcode
+	Font    string
+	Size    float64 // pt, 0 fits the text to the tape
+	QR      string
+	Barcode string // [symbology:]data
+
+	Copies   int
+	CutEvery int
+	LengthMM float64 // every label is padded to, 0 keeps the length of the label
+	MarginMM float64 // fed before and after the labels
+	Convert  ptouchgo.ConvertOptions
+
+	Client string // submitting the job, see Spooler.SetClient
+}
+
+// Label reports whether a label is requested
+func (r *PrintRequest) Label() bool {
+	return r.Text != "" || r.QR != "" || r.Barcode != ""
+}
+
+// Result describes a printed job like "ptouchgo print -json"
+type Result struct {
+	Device      string  `json:"device"`
+	TapeWidthMM int     `json:"tape_width_mm"`
+	Pages       int     `json:"pages"`
+	Copies      int     `json:"copies"`
+	Labels      int     `json:"labels"`
+	RasterLines int     `json:"raster_lines"`
+	LengthMM    float64 `json:"length_mm"` // length of the raster data, without the feed margins
+	Cut         bool    `json:"cut"`
+}
+
+// JobState is the state of a job
+type JobState string
+
+const (
+	JobQueued    JobState = "queued"
+	JobPrinting  JobState = "printing"
+	JobCompleted JobState = "completed"
+	JobFailed    JobState = "failed"
+
+	// JobWaiting is a job which failed for the state of the printer, like an open cover or no tape,
+	// and prints again once the printer is ready, see Spooler.Retry
+	JobWaiting JobState = "waiting"
+)
+
+// Finished reports whether the job is completed or failed
+func (s JobState) Finished() bool {
+	return s == JobCompleted || s == JobFailed
+}
+
+// Job is a print job submitted to a Spooler
+type Job struct {
+	ID       string     `json:"id"`
+	Printer  string     `json:"printer"`
+	State    JobState   `json:"state"`
+	Client   string     `json:"client,omitempty"`
+	Priority int        `json:"priority,omitempty"`
+	Created  time.Time  `json:"created"`
+	Started  *time.Time `json:"started,omitempty"`
+	Finished *time.Time `json:"finished,omitempty"`
+	Result   *Result    `json:"result,omitempty"`
+	Error    string     `json:"error,omitempty"`
+
+	req        *PrintRequest
+	estimateMM float64 // queued for the DailyMM of the client until it is accounted
+	done       chan struct{}
+}
+
+// Done is closed when the job is finished
+func (j Job) Done() <-chan struct{} {
+	return j.done
+}
+
+const (
+	// QueueSize is the number of jobs waiting for a printer, Submit fails with ErrQueueFull beyond it
+	QueueSize = 64
+	// MaxCopies is the most copies of a job, Submit fails with ErrTooManyCopies beyond it
+	MaxCopies = 999
+	// keepJobs is the number of jobs kept for Job, the oldest finished ones are dropped
+	keepJobs = 1000
+	// watchBuffer is the number of job updates buffered for a watcher, more are dropped
+	watchBuffer = 64
+)
+
+var (
+	// ErrNoPrinter is returned for names of printers which are not added
+	ErrNoPrinter = errors.New("no such printer")
+	// ErrQueueFull is returned by Submit when QueueSize jobs are waiting
+	ErrQueueFull = errors.New("too many jobs waiting, try again later")
+	// ErrShutdown is returned by Submit after Shutdown
+	ErrShutdown = errors.New("shutting down, try again later")
+	// ErrRateLimited is returned by Submit for clients submitting more jobs than their Rate
+	ErrRateLimited = errors.New("too many jobs submitted")
+	// ErrQuotaExceeded is returned by Submit for jobs of clients which do not fit into their DailyMM today
+	ErrQuotaExceeded = errors.New("the tape of the day is used up")
+	// ErrTooManyCopies is returned by Submit for jobs of more than MaxCopies copies
+	ErrTooManyCopies = errors.New("too many copies")
+)
+
+// Spooler queues jobs for its printers
+type Spooler struct {
+	// Retry is the time a job failing for the state of the printer waits for the printer to be ready
+	// to print again, zero fails it at once. A job may print partly before it fails.
+	Retry time.Duration
+
+	printers []*printer
+	store    Store // nil keeps the jobs in memory only
+
+	mu       sync.Mutex
+	jobs     map[string]*Job
+	order    []string // of jobs, oldest first
+	lastID   int
+	watchers map[chan Job]struct{}
+	closed   bool // by Shutdown
+	clients  map[string]*client
+}
+
+// printer is a printer of a Spooler printing its queue one job at a time
+type printer struct {
+	name   string
+	device string
+	p      Printer
+	wake   chan struct{} // run waits on it for a job
+	mu     sync.Mutex    // held while printing and reading the status
+
+	// of s.mu
+	queue  []*Job // in the order they are submitted, see pick
+	turns  uint64
+	served map[string]uint64 // turn of the last job of each client
+	busy   bool
+	health Health
+}
+
+// PrinterInfo describes a printer of a Spooler
+type PrinterInfo struct {
+	Name    string `json:"name"`
+	Device  string `json:"device"`
+	Default bool   `json:"default"`
+	Queued  int    `json:"queued"`
+}
+
+// New returns a spooler without printers
+func New() *Spooler {
+	return &Spooler{jobs: map[string]*Job{}, watchers: map[chan Job]struct{}{}, clients: map[string]*client{}}
+}
+
+// Add adds a printer named name, device describes it in Printers
+func (s *Spooler) Add(name, device string, p Printer) {
+	pr := &printer{name: name, device: device, p: p, wake: make(chan struct{}, 1), served: map[string]uint64{}}
+	s.printers = append(s.printers, pr)
+	go s.run(pr)
+}
+
+// run prints the queue of pr
+func (s *Spooler) run(pr *printer) {
+	for {
+		j := s.next(pr)
+		s.print(pr, j)
+		close(j.done)
+	}
+}
+
+// enqueue queues j on pr, s.mu is held
+func (s *Spooler) enqueue(pr *printer, j *Job) {
+	pr.queue = append(pr.queue, j)
+	select {
+	case pr.wake <- struct{}{}:
+	default:
+	}
+}
+
+// next takes the job printing next from the queue of pr, waiting for one
+func (s *Spooler) next(pr *printer) *Job {
+	for {
+		s.mu.Lock()
+		if i := pr.pick(); i >= 0 {
+			j := pr.queue[i]
+			pr.queue = append(pr.queue[:i], pr.queue[i+1:]...)
+			pr.turns++
+			pr.served[j.Client] = pr.turns
+			s.mu.Unlock()
+			return j
+		}
+		s.mu.Unlock()
+		<-pr.wake
+	}
+}
+
+// pick returns the index of the queued job printing next, -1 when there is none. It is the first job of the
+// highest priority of the client printed longest ago, so the batch of a client does not keep the others waiting.
+func (pr *printer) pick() int {
+	best := -1
+	for i, j := range pr.queue {
+		if best < 0 {
+			best = i
+			continue
+		}
+		b := pr.queue[best]
+		if j.Priority > b.Priority || j.Priority == b.Priority && pr.served[j.Client] < pr.served[b.Client] {
+			best = i
+		}
+	}
+	return best
+}
+
+// retryInterval is the interval the printer of a waiting job is checked at
+const retryInterval = 5 * time.Second
+
+// print prints j, waiting for the printer to be ready when it fails for the state of the printer
+func (s *Spooler) print(pr *printer, j *Job) {
+	deadline := time.Now().Add(s.Retry)
+	for {
+		s.update(j, func() {
+			now := time.Now()
+			j.State, j.Started = JobPrinting, &now
+			pr.busy = true
+		})
+		pr.mu.Lock()
+		res, err := pr.p.Print(j.req)
+		pr.mu.Unlock()
+		if err != nil && s.Retry > 0 && !s.waitReady(pr, j, err, deadline) {
+			// printed again
+			continue
+		}
+		s.update(j, func() {
+			now := time.Now()
+			j.State, j.Finished, j.req = JobCompleted, &now, nil
+			pr.busy = false
+			if err != nil {
+				j.State, j.Error = JobFailed, err.Error()
+				log.Printf("job %s on %s: %v\n", j.ID, j.Printer, err)
+			} else {
+				j.Result = &res
+				j.Error = ""
+			}
+			s.account(j)
+		})
+		return
+	}
+}
+
+// waitReady waits until the printer of j failing with err is ready or deadline passes,
+// and reports whether j fails. It fails at once when the printer is ready, the job itself is wrong then.
+func (s *Spooler) waitReady(pr *printer, j *Job, err error, deadline time.Time) bool {
+	s.mu.Lock()
+	pr.busy = false
+	s.mu.Unlock()
+	h, _ := s.Check(pr.name)
+	if h.Ready {
+		return true
+	}
+	s.update(j, func() {
+		j.State, j.Error = JobWaiting, err.Error()
+	})
+	log.Printf("job %s on %s: %v, waiting for the printer: %s\n", j.ID, j.Printer, err, h.Error)
+	for !h.Ready {
+		if time.Now().After(deadline) {
+			return true
+		}
+		time.Sleep(retryInterval)
+		h, _ = s.Check(pr.name)
+	}
+	log.Printf("job %s on %s: the printer is ready, printing again\n", j.ID, j.Printer)
+	return false
+}
+
+// update changes j under the lock of s, stores it and sends it to the watchers
+func (s *Spooler) update(j *Job, f func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f()
+	if s.store != nil {
+		if err := s.store.Put(*j, nil); err != nil {
+			log.Printf("job %s: store: %v\n", j.ID, err)
+		}
+	}
+	s.notify(*j)
+}
+
+// notify sends j to the watchers, s.mu is held
+func (s *Spooler) notify(j Job) {
+	for c := range s.watchers {
+		select {
+		case c <- j:
+		default:
+			log.Printf("job %s: a watcher is behind, update dropped\n", j.ID)
+		}
+	}
+}
+
+// Watch returns a channel receiving every job when it is submitted and changes its state,
+// stop stops and closes it
+func (s *Spooler) Watch() (updates <-chan Job, stop func()) {
+	c := make(chan Job, watchBuffer)
+	s.mu.Lock()
+	s.watchers[c] = struct{}{}
+	s.mu.Unlock()
+	var once sync.Once
+	return c, func() {
+		once.Do(func() {
+			s.mu.Lock()
+			delete(s.watchers, c)
+			s.mu.Unlock()
+			close(c)
+		})
+	}
+}
+
+// Submit queues req on the printer named name, the first one when name is empty
+func (s *Spooler) Submit(name string, req *PrintRequest) (Job, error) {
+	pr, err := s.printer(name)
+	if err != nil {
+		return Job{}, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return Job{}, ErrShutdown
+	}
+	if len(pr.queue) >= QueueSize {
+		return Job{}, fmt.Errorf("%s: %w", pr.name, ErrQueueFull)
+	}
+	if req.Copies > MaxCopies {
+		return Job{}, fmt.Errorf("%d copies, at most %d: %w", req.Copies, MaxCopies, ErrTooManyCopies)
+	}
+	estimate := estimateMM(req)
+	priority, err := s.admit(req.Client, estimate)
+	if err != nil {
+		return Job{}, err
+	}
+	s.lastID++
+	j := &Job{
+		ID:         strconv.Itoa(s.lastID),
+		Printer:    pr.name,
+		Client:     req.Client,
+		Priority:   priority,
+		State:      JobQueued,
+		Created:    time.Now(),
+		req:        req,
+		estimateMM: estimate,
+		done:       make(chan struct{}),
+	}
+	if s.store != nil {
+		if err := s.store.Put(*j, req); err != nil {
+			s.unqueue(j)
+			return Job{}, fmt.Errorf("store job: %w", err)
+		}
+	}
+	s.enqueue(pr, j)
+	s.jobs[j.ID] = j
+	s.order = append(s.order, j.ID)
+	s.dropJobs()
+	s.notify(*j)
+	return *j, nil
+}
+
+// Shutdown stops accepting jobs and waits until the queued and printing ones are finished or ctx is done
+func (s *Spooler) Shutdown(ctx context.Context) error {
+	s.mu.Lock()
+	s.closed = true
+	var waiting []*Job
+	// in the order they print
+	for _, id := range s.order {
+		if j := s.jobs[id]; !j.State.Finished() {
+			waiting = append(waiting, j)
+		}
+	}
+	s.mu.Unlock()
+	for _, j := range waiting {
+		select {
+		case <-j.done:
+		case <-ctx.Done():
+			n := 0
+			for _, j := range waiting {
+				select {
+				case <-j.done:
+				default:
+					n++
+				}
+			}
+			return fmt.Errorf("%d jobs are not finished: %w", n, ctx.Err())
+		}
+	}
+	return nil
+}
+
+// dropJobs drops the oldest finished jobs beyond keepJobs
+func (s *Spooler) dropJobs() {
+	for i := 0; len(s.jobs) > keepJobs && i < len(s.order); {
+		id := s.order[i]
+		if !s.jobs[id].State.Finished() {
+			i++
+			continue
+		}
+		delete(s.jobs, id)
+		s.order = append(s.order[:i], s.order[i+1:]...)
+		if s.store != nil {
+			if err := s.store.Delete(id); err != nil {
+				log.Printf("job %s: store: %v\n", id, err)
+			}
+		}
+	}
+}
+
+// Job returns a copy of the job of id
+func (s *Spooler) Job(id string) (Job, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	j, ok := s.jobs[id]
+	if !ok {
+		return Job{}, false
+	}
+	return *j, true
+}
+
+// Jobs returns copies of the jobs kept for the printer named name, oldest first, of every printer when name is empty
+func (s *Spooler) Jobs(name string) []Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var jobs []Job
+	for _, id := range s.order {
+		if j := s.jobs[id]; name == "" || j.Printer == name {
+			jobs = append(jobs, *j)
+		}
+	}
+	return jobs
+}
+
+// Printers lists the printers, the first one is the default
+func (s *Spooler) Printers() []PrinterInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	list := make([]PrinterInfo, len(s.printers))
+	for i, pr := range s.printers {
+		list[i] = PrinterInfo{Name: pr.name, Device: pr.device, Default: i == 0, Queued: len(pr.queue)}
+	}
+	return list
+}
+
+// Status reads the status of the printer named name between its jobs, the first one when name is empty
+func (s *Spooler) Status(name string) (*ptouchgo.Status, error) {
+	pr, err := s.printer(name)
+	if err != nil {
+		return nil, err
+	}
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+	return pr.p.Status()
+}
+
+func (s *Spooler) printer(name string) (*printer, error) {
+	if len(s.printers) == 0 {
+		return nil, ErrNoPrinter
+	}
+	if name == "" {
+		return s.printers[0], nil
+	}
+	for _, pr := range s.printers {
+		if pr.name == name {
+			return pr, nil
+		}
+	}
+	return nil, fmt.Errorf("%s: %w", name, ErrNoPrinter)
+}
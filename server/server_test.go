@@ -0,0 +1,182 @@
+package server
+
+import (
+	"errors"
+	"image"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ka2n/ptouchgo"
+)
+
+// fakePrinter records the texts of the requests it prints, Print waits until gate is closed
+type fakePrinter struct {
+	gate     chan struct{}
+	lengthMM float64 // printed by each job
+
+	mu      sync.Mutex
+	printed []string
+}
+
+func (p *fakePrinter) Print(req *PrintRequest) (Result, error) {
+	if p.gate != nil {
+		<-p.gate
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.printed = append(p.printed, req.Text)
+	return Result{Copies: req.Copies, Labels: req.Copies, LengthMM: p.lengthMM}, nil
+}
+
+func (p *fakePrinter) Status() (*ptouchgo.Status, error) {
+	return &ptouchgo.Status{}, nil
+}
+
+func submit(t *testing.T, s *Spooler, client, text string, copies int) Job {
+	t.Helper()
+	j, err := s.Submit("", &PrintRequest{Text: text, Copies: copies, CutEvery: 1, Client: client})
+	if err != nil {
+		t.Fatalf("Submit %s of %s: %v", text, client, err)
+	}
+	return j
+}
+
+func wait(t *testing.T, jobs ...Job) {
+	t.Helper()
+	for _, j := range jobs {
+		select {
+		case <-j.Done():
+		case <-time.After(5 * time.Second):
+			t.Fatalf("job %s did not finish", j.ID)
+		}
+	}
+}
+
+func TestSpoolerPickOrder(t *testing.T) {
+	p := &fakePrinter{gate: make(chan struct{})}
+	s := New()
+	s.Add("fake", "fake:", p)
+	s.SetClient(Client{Name: "c", Priority: 1})
+
+	first := submit(t, s, "a", "first", 1)
+	// the first job is taken by the printer before the others are queued
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		if j, _ := s.Job(first.ID); j.State == JobPrinting {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the first job did not start printing")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	jobs := []Job{
+		first,
+		submit(t, s, "a", "a1", 1),
+		submit(t, s, "a", "a2", 1),
+		submit(t, s, "a", "a3", 1),
+		submit(t, s, "b", "b1", 1),
+		submit(t, s, "b", "b2", 1),
+		submit(t, s, "c", "c1", 1),
+	}
+	if jobs[6].Priority != 1 {
+		t.Errorf("job of c has priority %d, want 1", jobs[6].Priority)
+	}
+	close(p.gate)
+	wait(t, jobs...)
+
+	// c has the higher priority, then a and b take turns, a printed first
+	want := "first c1 b1 a1 b2 a2 a3"
+	if got := strings.Join(p.printed, " "); got != want {
+		t.Errorf("printed %s, want %s", got, want)
+	}
+}
+
+func TestSpoolerRateLimit(t *testing.T) {
+	s := New()
+	s.Add("fake", "fake:", &fakePrinter{})
+	s.SetClient(Client{Name: "r", Rate: 60, Burst: 2})
+
+	wait(t, submit(t, s, "r", "1", 1), submit(t, s, "r", "2", 1))
+	_, err := s.Submit("", &PrintRequest{Text: "3", Copies: 1, CutEvery: 1, Client: "r"})
+	if !errors.Is(err, ErrRateLimited) || !strings.Contains(err.Error(), "try again in 1s") {
+		t.Fatalf("third job: %v, want %v waiting 1s", err, ErrRateLimited)
+	}
+
+	// a second later the bucket has a token for one job
+	s.mu.Lock()
+	s.clients["r"].filled = time.Now().Add(-time.Second)
+	s.mu.Unlock()
+	wait(t, submit(t, s, "r", "3", 1))
+	if _, err := s.Submit("", &PrintRequest{Text: "4", Copies: 1, CutEvery: 1, Client: "r"}); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("fourth job: %v, want %v", err, ErrRateLimited)
+	}
+
+	// other clients are not limited
+	for i := 0; i < 5; i++ {
+		wait(t, submit(t, s, "other", "x", 1))
+	}
+}
+
+func TestSpoolerDailyQuota(t *testing.T) {
+	p := &fakePrinter{gate: make(chan struct{}), lengthMM: 90}
+	s := New()
+	s.Add("fake", "fake:", p)
+	s.SetClient(Client{Name: "q", DailyMM: 100})
+
+	// four labels of text are estimated at 100mm, all of the day
+	queued := submit(t, s, "q", "queued", 4)
+	if _, err := s.Submit("", &PrintRequest{Text: "more", Copies: 1, CutEvery: 1, Client: "q"}); !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("job beyond the queued one: %v, want %v", err, ErrQuotaExceeded)
+	}
+	close(p.gate)
+	wait(t, queued)
+
+	// 90mm printed leave 10mm, less than a label
+	_, err := s.Submit("", &PrintRequest{Text: "more", Copies: 1, CutEvery: 1, Client: "q"})
+	if !errors.Is(err, ErrQuotaExceeded) || !strings.Contains(err.Error(), "printed 90mm of 100mm") {
+		t.Fatalf("job after printing: %v, want %v", err, ErrQuotaExceeded)
+	}
+	if _, err := s.Submit("", &PrintRequest{Text: "many", Copies: MaxCopies + 1, CutEvery: 1}); !errors.Is(err, ErrTooManyCopies) {
+		t.Errorf("job of %d copies: %v, want %v", MaxCopies+1, err, ErrTooManyCopies)
+	}
+
+	// the tape printed yesterday does not count today
+	s.mu.Lock()
+	s.clients["q"].usage.Day = "2000-01-01"
+	s.mu.Unlock()
+	for _, u := range s.Usage() {
+		if u.Client == "q" && (u.DayMM != 0 || u.LengthMM != 90 || u.Labels != 4) {
+			t.Errorf("usage on the next day = %+v", u)
+		}
+	}
+	wait(t, submit(t, s, "q", "next day", 4))
+	for _, u := range s.Usage() {
+		if u.Client == "q" && (u.Day == "2000-01-01" || u.DayMM != 90 || u.LengthMM != 180 || u.Jobs != 2) {
+			t.Errorf("usage after printing on the next day = %+v", u)
+		}
+	}
+}
+
+func TestEstimateMM(t *testing.T) {
+	// 360 dots are 50.8mm at 180dpi
+	img := image.NewGray(image.Rect(0, 0, 360, 128))
+	tests := []struct {
+		name string
+		req  PrintRequest
+		want float64
+	}{
+		{"image along its longer side", PrintRequest{Images: []image.Image{img}}, 50.8},
+		{"turned image", PrintRequest{Images: []image.Image{image.NewGray(image.Rect(0, 0, 128, 360))}}, 50.8},
+		{"padded to length_mm", PrintRequest{Images: []image.Image{img}, LengthMM: 80}, 80},
+		{"label of text", PrintRequest{Text: "hello", Copies: 1}, estimatedLabelMM},
+		{"images and a label", PrintRequest{Images: []image.Image{img, img}, QR: "x", Copies: 2}, 2 * (2*50.8 + estimatedLabelMM)},
+		{"copies", PrintRequest{Barcode: "x", Copies: 10, LengthMM: 40}, 400},
+	}
+	for _, tt := range tests {
+		if got := estimateMM(&tt.req); got < tt.want-1e-9 || got > tt.want+1e-9 {
+			t.Errorf("%s: estimateMM = %g, want %g", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,217 @@
+// Package store keeps the jobs of a server.Spooler in a bbolt database file, so the queued jobs of
+// "ptouchgo serve -queue FILE" print after a restart. It keeps the usage of the clients as well.
+package store
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image/png"
+	"strconv"
+	"time"
+
+	"github.com/ka2n/ptouchgo"
+	"github.com/ka2n/ptouchgo/server"
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	jobsBucket  = []byte("jobs")
+	usageBucket = []byte("usage") // by "client:" and the client name
+)
+
+// Store is a server.Store and server.UsageStore of a database file, one process opens it at a time
+type Store struct {
+	db *bolt.DB
+}
+
+// Open opens the database of path, creating it when it does not exist
+func Open(path string) (*Store, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if errors.Is(err, bolt.ErrTimeout) {
+		return nil, fmt.Errorf("%s is used by another process", path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		if _, err := tx.CreateBucketIfNotExists(jobsBucket); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucketIfNotExists(usageBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &Store{db: db}, nil
+}
+
+// Close closes the database
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// record is a stored job
+type record struct {
+	Job     server.Job `json:"job"`
+	Request *request   `json:"request,omitempty"`
+}
+
+// request is a server.PrintRequest with its images encoded as PNG
+type request struct {
+	Images [][]byte `json:"images,omitempty"`
+	Names  []string `json:"names,omitempty"`
+
+	Text    string  `json:"text,omitempty"`
+	Font    string  `json:"font,omitempty"`
+	Size    float64 `json:"size,omitempty"`
+	QR      string  `json:"qr,omitempty"`
+	Barcode string  `json:"barcode,omitempty"`
+
+	Copies   int                     `json:"copies"`
+	CutEvery int                     `json:"cut_every"`
+	LengthMM float64                 `json:"length_mm,omitempty"`
+	MarginMM float64                 `json:"margin_mm,omitempty"`
+	Convert  ptouchgo.ConvertOptions `json:"convert"`
+
+	Client string `json:"client,omitempty"`
+}
+
+func encodeRequest(r *server.PrintRequest) (*request, error) {
+	enc := &request{
+		Names: r.Names,
+		Text:  r.Text, Font: r.Font, Size: r.Size, QR: r.QR, Barcode: r.Barcode,
+		Copies: r.Copies, CutEvery: r.CutEvery, LengthMM: r.LengthMM, MarginMM: r.MarginMM, Convert: r.Convert,
+		Client: r.Client,
+	}
+	for _, img := range r.Images {
+		var b bytes.Buffer
+		if err := png.Encode(&b, img); err != nil {
+			return nil, err
+		}
+		enc.Images = append(enc.Images, b.Bytes())
+	}
+	return enc, nil
+}
+
+func (r *request) decode() (*server.PrintRequest, error) {
+	req := &server.PrintRequest{
+		Names: r.Names,
+		Text:  r.Text, Font: r.Font, Size: r.Size, QR: r.QR, Barcode: r.Barcode,
+		Copies: r.Copies, CutEvery: r.CutEvery, LengthMM: r.LengthMM, MarginMM: r.MarginMM, Convert: r.Convert,
+		Client: r.Client,
+	}
+	for _, b := range r.Images {
+		img, err := png.Decode(bytes.NewReader(b))
+		if err != nil {
+			return nil, err
+		}
+		req.Images = append(req.Images, img)
+	}
+	return req, nil
+}
+
+// key returns the key of the job of id, numeric ids sort in their order
+func key(id string) []byte {
+	n, err := strconv.ParseUint(id, 10, 64)
+	if err != nil {
+		return []byte(id)
+	}
+	k := make([]byte, 8)
+	binary.BigEndian.PutUint64(k, n)
+	return k
+}
+
+// Put stores j, see server.Store
+func (s *Store) Put(j server.Job, req *server.PrintRequest) error {
+	rec := record{Job: j}
+	if req != nil && !j.State.Finished() {
+		enc, err := encodeRequest(req)
+		if err != nil {
+			return err
+		}
+		rec.Request = enc
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		b := tx.Bucket(jobsBucket)
+		k := key(j.ID)
+		if req == nil && !j.State.Finished() {
+			// an update keeps the request
+			if v := b.Get(k); v != nil {
+				var old record
+				if err := json.Unmarshal(v, &old); err != nil {
+					return err
+				}
+				rec.Request = old.Request
+			}
+		}
+		v, err := json.Marshal(rec)
+		if err != nil {
+			return err
+		}
+		return b.Put(k, v)
+	})
+}
+
+// Delete deletes the job of id
+func (s *Store) Delete(id string) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(jobsBucket).Delete(key(id))
+	})
+}
+
+// Jobs returns the stored jobs oldest first
+func (s *Store) Jobs() ([]server.StoredJob, error) {
+	var jobs []server.StoredJob
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(jobsBucket).ForEach(func(k, v []byte) error {
+			var rec record
+			if err := json.Unmarshal(v, &rec); err != nil {
+				return fmt.Errorf("job %x: %w", k, err)
+			}
+			sj := server.StoredJob{Job: rec.Job}
+			if rec.Request != nil {
+				req, err := rec.Request.decode()
+				if err != nil {
+					return fmt.Errorf("job %s: %w", rec.Job.ID, err)
+				}
+				sj.Request = req
+			}
+			jobs = append(jobs, sj)
+			return nil
+		})
+	})
+	return jobs, err
+}
+
+// PutUsage stores the usage of a client
+func (s *Store) PutUsage(u server.Usage) error {
+	v, err := json.Marshal(u)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bolt.Tx) error {
+		// the key of jobs without a client is not empty
+		return tx.Bucket(usageBucket).Put([]byte("client:"+u.Client), v)
+	})
+}
+
+// Usages returns the stored usage of the clients
+func (s *Store) Usages() ([]server.Usage, error) {
+	var list []server.Usage
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(usageBucket).ForEach(func(k, v []byte) error {
+			var u server.Usage
+			if err := json.Unmarshal(v, &u); err != nil {
+				return fmt.Errorf("usage of %s: %w", k, err)
+			}
+			list = append(list, u)
+			return nil
+		})
+	})
+	return list, err
+}
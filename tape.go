@@ -0,0 +1,34 @@
+package ptouchgo
+
+const (
+	// HeadPins is the number of pins on the print head, every raster line has this many dots
+	HeadPins = 128
+	// DPI is the print head resolution in dots per inch
+	DPI = 180
+)
+
+// PrintableDots returns the number of printable dots across the tape on the head of the PT-P700 series
+func (i TapeWidth) PrintableDots() int {
+	t, _ := ptDefault.Tape(int(i))
+	return t.Dots
+}
+
+// TapeWidths lists the tape widths the print head prints on, narrowest first
+func TapeWidths() []TapeWidth {
+	return []TapeWidth{tapeWidth3_5, tapeWidth6, tapeWidth9, tapeWidth12, tapeWidth18, tapeWidth24}
+}
+
+// Margin returns the number of unused pins on each side of the printable area
+func (i TapeWidth) Margin() int {
+	return (HeadPins - i.PrintableDots()) / 2
+}
+
+// MMToDots converts millimeters into dots
+func MMToDots(mm float64) int {
+	return int(mm*DPI/25.4 + 0.5)
+}
+
+// DotsToMM converts dots into millimeters
+func DotsToMM(dots int) float64 {
+	return float64(dots) * 25.4 / DPI
+}
@@ -12,6 +12,7 @@ const (
 	_TapeWidth_name_4 = "12mm"
 	_TapeWidth_name_5 = "18mm"
 	_TapeWidth_name_6 = "24mm"
+	_TapeWidth_name_7 = "36mm"
 )
 
 func (i TapeWidth) String() string {
@@ -30,6 +31,8 @@ func (i TapeWidth) String() string {
 		return _TapeWidth_name_5
 	case i == 24:
 		return _TapeWidth_name_6
+	case i == 36:
+		return _TapeWidth_name_7
 	default:
 		return "TapeWidth(" + strconv.FormatInt(int64(i), 10) + ")"
 	}
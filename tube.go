@@ -0,0 +1,22 @@
+package ptouchgo
+
+// fitTube scales the printable dots of every raster line of data, from dots of the tape
+// the image was laid out for into the to dots of a heat shrink tube, both centered on the head
+func fitTube(data []byte, bytesWidth int, from, to int) []byte {
+	if from == to || from == 0 || to == 0 {
+		return data
+	}
+	pins := bytesWidth * 8
+	fromTop, toTop := (pins-from)/2, (pins-to)/2
+	out := make([]byte, len(data))
+	for line := 0; line+bytesWidth <= len(data); line += bytesWidth {
+		for pin := 0; pin < to; pin++ {
+			src := fromTop + pin*from/to
+			if data[line+src/8]&(0x80>>uint(src%8)) != 0 {
+				dst := toTop + pin
+				out[line+dst/8] |= 0x80 >> uint(dst%8)
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,76 @@
+package ptouchgo
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/ka2n/ptouchgo/conn"
+)
+
+// WLAN setting ids of the printer setting commands, the IPv4 address is read a byte at a time
+const (
+	settingWLANMode byte = 0x60
+	settingWLANIP   byte = 0x61 // to 0x64, the first byte of the address first
+)
+
+//go:generate stringer -linecomment -type WLANMode
+
+// WLANMode is how the wireless LAN interface of a printer is set up
+type WLANMode byte
+
+const (
+	WLANOff            WLANMode = 0 // Off
+	WLANInfrastructure WLANMode = 1 // Infrastructure
+	WLANDirect         WLANMode = 2 // Wireless Direct
+	WLANBoth           WLANMode = 3 // Infrastructure and Wireless Direct
+)
+
+// WLAN reports whether m prints on a wireless LAN, see ReadNetworkSettings
+func (m Model) WLAN() bool {
+	spec, _ := m.spec()
+	return spec.WLAN
+}
+
+// NetworkSettings is the wireless LAN configuration of a printer
+type NetworkSettings struct {
+	Mode WLANMode
+	IP   net.IP // address in the infrastructure network, nil when it is not connected
+}
+
+// Address returns the address to print over the wireless LAN like "wlan:192.168.1.20",
+// the printer's own network on Wireless Direct and empty for WLANOff
+func (n NetworkSettings) Address() string {
+	switch {
+	case n.IP != nil && (n.Mode == WLANInfrastructure || n.Mode == WLANBoth):
+		return "wlan:" + n.IP.String()
+	case n.Mode == WLANDirect || n.Mode == WLANBoth:
+		return "wlan:" + conn.WirelessDirectAddress
+	}
+	return ""
+}
+
+// ReadNetworkSettings reads the wireless LAN configuration of the printer,
+// usually over USB to find the address of a printer before printing over the network
+func (s Serial) ReadNetworkSettings() (NetworkSettings, error) {
+	if err := s.unsupported(Model.WLAN, "wireless LAN"); err != nil {
+		return NetworkSettings{}, err
+	}
+	mode, err := s.readSetting(settingWLANMode)
+	if err != nil {
+		return NetworkSettings{}, err
+	}
+	if mode > byte(WLANBoth) {
+		return NetworkSettings{}, fmt.Errorf("read network settings: invalid WLAN mode %d", mode)
+	}
+	settings := NetworkSettings{Mode: WLANMode(mode)}
+	ip := make(net.IP, net.IPv4len)
+	for i := range ip {
+		if ip[i], err = s.readSetting(settingWLANIP + byte(i)); err != nil {
+			return NetworkSettings{}, err
+		}
+	}
+	if !ip.IsUnspecified() {
+		settings.IP = ip
+	}
+	return settings, nil
+}